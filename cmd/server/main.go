@@ -11,19 +11,28 @@ import (
 	"syscall"
 	"time"
 
+	"scriberr/internal/analytics"
 	"scriberr/internal/api"
 	"scriberr/internal/auth"
+	"scriberr/internal/chapters"
 	"scriberr/internal/config"
 	"scriberr/internal/database"
 	"scriberr/internal/llm"
 	"scriberr/internal/processing"
 	"scriberr/internal/queue"
 	"scriberr/internal/repository"
+	"scriberr/internal/retrieval"
+	"scriberr/internal/review"
 	"scriberr/internal/service"
+	"scriberr/internal/smartanalysis"
 	"scriberr/internal/sse"
+	"scriberr/internal/summarize"
 	"scriberr/internal/transcription"
 	"scriberr/internal/transcription/adapters"
 	"scriberr/internal/transcription/registry"
+	"scriberr/internal/translate"
+	"scriberr/internal/usage"
+	"scriberr/internal/waveform"
 	"scriberr/pkg/logger"
 )
 
@@ -109,6 +118,7 @@ func main() {
 	noteRepo := repository.NewNoteRepository(database.DB)
 	speakerMappingRepo := repository.NewSpeakerMappingRepository(database.DB)
 	refreshTokenRepo := repository.NewRefreshTokenRepository(database.DB)
+	usageRepo := repository.NewUsageRepository(database.DB)
 
 	// Initialize services
 	logger.Startup("service", "Initializing services")
@@ -119,10 +129,24 @@ func main() {
 	logger.Startup("transcription", "Initializing transcription service")
 	unifiedProcessor := transcription.NewUnifiedJobProcessor(jobRepo, cfg.TempDir, cfg.TranscriptsDir)
 	unifiedProcessor.GetUnifiedService().SetBroadcaster(broadcaster)
+	unifiedProcessor.GetUnifiedService().SetSpeakerMappingRepository(speakerMappingRepo)
 
-	// Initialize LLM service for smart analysis
-	groqService := llm.NewGroqService(cfg.GroqAPIKey)
-	unifiedProcessor.GetUnifiedService().SetLLMService(groqService)
+	// Route smart analysis through the LLM configs; a Groq API key from the
+	// environment keeps it on Groq's Llama 3.3 70B unless routed elsewhere
+	if cfg.GroqAPIKey != "" {
+		if err := llm.EnsureGroqSmartAnalysis(context.Background(), llmConfigRepo, cfg.GroqAPIKey, "llama-3.3-70b-versatile"); err != nil {
+			logger.Warn("Failed to register Groq config for smart analysis", "error", err)
+		}
+	}
+	// Every LLM and cloud transcription call is recorded in the usage ledger
+	usageLedger := usage.NewLedger(usageRepo)
+	llmRouter := llm.NewRouter(llmConfigRepo)
+	llmRouter.SetUsageRecorder(usageLedger)
+	unifiedProcessor.GetUnifiedService().SetUsageRecorder(usageLedger)
+
+	// Proofread fresh transcripts, proposing corrections for review
+	smartAnalysisService := smartanalysis.NewService(jobRepo, repository.NewSmartAnalysisRepository(database.DB), summaryRepo, llmRouter)
+	unifiedProcessor.GetUnifiedService().SetSmartAnalyzer(smartAnalysisService)
 
 	// Bootstrap embedded Python environment (for all adapters)
 	logger.Startup("python", "Preparing Python environment")
@@ -148,6 +172,42 @@ func main() {
 	// Initialize multi-track processor
 	multiTrackProcessor := processing.NewMultiTrackProcessor(database.DB, jobRepo)
 
+	waveformService := waveform.NewService(jobRepo, cfg.WaveformDir)
+	multiTrackProcessor.SetWaveformService(waveformService)
+	quickTranscriptionService.SetWaveformService(waveformService)
+
+	// Embed transcripts for chat retrieval once jobs complete
+	retrievalService := retrieval.NewService(jobRepo, repository.NewTranscriptChunkRepository(database.DB), llmRouter)
+	unifiedProcessor.GetUnifiedService().OnJobCompleted(retrievalService.Schedule)
+
+	// Generate summaries in the background, picking up the ones a restart
+	// interrupted, and run the templates attached to jobs once they complete
+	summaryService := summarize.NewService(jobRepo, summaryRepo, speakerMappingRepo, userRepo, llmRouter, broadcaster)
+	if err := summaryService.Resume(context.Background()); err != nil {
+		logger.Warn("Failed to resume unfinished summaries", "error", err)
+	}
+	unifiedProcessor.GetUnifiedService().SetAutoSummarizer(summaryService)
+
+	// Split long recordings into chapters once they complete
+	chapterService := chapters.NewService(jobRepo, repository.NewChapterRepository(database.DB), speakerMappingRepo, summaryRepo, llmRouter)
+	unifiedProcessor.GetUnifiedService().OnJobCompleted(chapterService.Schedule)
+
+	// Compute conversation analytics once jobs complete
+	analyticsService := analytics.NewService(jobRepo, repository.NewAnalyticsRepository(database.DB), speakerMappingRepo)
+	unifiedProcessor.GetUnifiedService().OnJobCompleted(analyticsService.Record)
+
+	// Translate transcripts in the background, picking up the translations a
+	// restart interrupted, and produce the tracks requested for completed jobs
+	translationService := translate.NewService(jobRepo, repository.NewTranslationRepository(database.DB), summaryRepo, llmRouter, cfg.TranslationServiceURL, cfg.TranslationServiceAPIKey)
+	if err := translationService.Resume(context.Background()); err != nil {
+		logger.Warn("Failed to resume unfinished translations", "error", err)
+	}
+	unifiedProcessor.GetUnifiedService().OnJobCompleted(translationService.Schedule)
+
+	// Queue the weak segments of jobs asking for review once they complete
+	reviewService := review.NewService(jobRepo, repository.NewReviewRepository(database.DB))
+	unifiedProcessor.GetUnifiedService().OnJobCompleted(reviewService.Queue)
+
 	// Initialize API handlers
 	handler := api.NewHandler(
 		cfg,
@@ -159,6 +219,7 @@ func main() {
 		profileRepo,
 		userRepo,
 		llmConfigRepo,
+		llmRouter,
 		summaryRepo,
 		chatRepo,
 		noteRepo,
@@ -170,6 +231,16 @@ func main() {
 		multiTrackProcessor,
 		broadcaster,
 	)
+	handler.SetWaveformService(waveformService)
+	handler.SetRetrievalService(retrievalService)
+	handler.SetSummaryService(summaryService)
+	handler.SetActionItemRepository(repository.NewActionItemRepository(database.DB))
+	handler.SetChapterService(chapterService)
+	handler.SetAnalyticsService(analyticsService)
+	handler.SetTranslationService(translationService)
+	handler.SetSmartAnalysisService(smartAnalysisService)
+	handler.SetReviewService(reviewService)
+	handler.SetUsageRepository(usageRepo)
 
 	// Set up router
 	router := api.SetupRoutes(handler, authService)
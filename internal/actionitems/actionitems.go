@@ -0,0 +1,220 @@
+// Package actionitems extracts the commitments made in a transcript as
+// action items, with their assignee, due date and the moment they were made.
+package actionitems
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"scriberr/internal/export"
+	"scriberr/internal/llm"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/internal/summarize"
+	"scriberr/pkg/logger"
+)
+
+const prompt = "Extract the action items of the meeting transcript below: every task someone committed to or was asked to do. " +
+	"Each transcript line starts with its segment number and timestamp. For each action item give a short imperative task, " +
+	"the assignee as named in the transcript (a speaker name or label, or null if nobody was named), " +
+	"the due date as YYYY-MM-DD if one was given, resolving relative dates against the meeting date, else null, " +
+	"and the number of the segment it was said in. Do not invent action items; return an empty list if there are none."
+
+// schema is the structure the model answers with
+const schema = `{
+	"type": "object",
+	"properties": {
+		"action_items": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"task": {"type": "string"},
+					"assignee": {"type": ["string", "null"]},
+					"due_date": {"type": ["string", "null"]},
+					"segment": {"type": ["integer", "null"]}
+				},
+				"required": ["task", "assignee", "due_date", "segment"],
+				"additionalProperties": false
+			}
+		}
+	},
+	"required": ["action_items"],
+	"additionalProperties": false
+}`
+
+type extracted struct {
+	ActionItems []struct {
+		Task     string  `json:"task"`
+		Assignee *string `json:"assignee"`
+		DueDate  *string `json:"due_date"`
+		Segment  *int    `json:"segment"`
+	} `json:"action_items"`
+}
+
+// Extractor extracts action items with the summarization route's model
+type Extractor struct {
+	itemRepo           repository.ActionItemRepository
+	speakerMappingRepo repository.SpeakerMappingRepository
+	router             *llm.Router
+}
+
+// NewExtractor creates an action item extractor
+func NewExtractor(itemRepo repository.ActionItemRepository, speakerMappingRepo repository.SpeakerMappingRepository, router *llm.Router) *Extractor {
+	return &Extractor{itemRepo: itemRepo, speakerMappingRepo: speakerMappingRepo, router: router}
+}
+
+// Extract extracts the action items of a job's transcript with model, or the
+// route's model if empty, and stores them in place of the job's previous
+// ones. Items extracted again keep their ID and status.
+func (e *Extractor) Extract(ctx context.Context, job *models.TranscriptionJob, model string) ([]models.ActionItem, error) {
+	if job.Transcript == nil || *job.Transcript == "" {
+		return nil, fmt.Errorf("transcription has no transcript")
+	}
+	result, err := export.DecodeTranscript(*job.Transcript)
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := e.speakerMappingRepo.ListByJob(ctx, job.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load speaker names: %w", err)
+	}
+	names := export.SpeakerNames(mappings)
+	doc := export.NewDocument(job.ID, "", result, names)
+	var labels []string
+	seen := make(map[string]bool)
+	for _, seg := range result.Segments {
+		if seg.Speaker != nil && *seg.Speaker != "" && !seen[*seg.Speaker] {
+			seen[*seg.Speaker] = true
+			labels = append(labels, *seg.Speaker)
+		}
+	}
+
+	route, err := e.router.Resolve(ctx, models.LLMFeatureSummarization, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+	route = route.WithModel(model).ForJob(job.ID)
+	primary := route.Primary()
+	if primary.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	contextWindow := route.ContextWindow(ctx)
+
+	lines := make([]string, 0, len(doc.Segments))
+	for i, seg := range doc.Segments {
+		speaker := ""
+		if seg.Speaker != "" {
+			speaker = seg.Speaker + ": "
+		}
+		lines = append(lines, fmt.Sprintf("[%d] [%s] %s%s", i, clock(seg.Start), speaker, seg.Text))
+	}
+	instructions := prompt + "\n\nMeeting date: " + job.CreatedAt.Format("Monday 2006-01-02")
+
+	parsed, err := summarize.ParseSchema([]byte(schema))
+	if err != nil {
+		return nil, err
+	}
+	summarizer := summarize.New(route, contextWindow)
+	start := time.Now()
+	// Long transcripts are read in chunks rather than summarized, so every
+	// item keeps the number of the segment it was said in
+	var out extracted
+	seenTasks := make(map[string]bool)
+	for _, chunk := range summarizer.Chunks(instructions, lines) {
+		raw, err := summarizer.Structured(ctx, instructions, parsed, chunk, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract action items: %w", err)
+		}
+		var part extracted
+		if err := json.Unmarshal(raw, &part); err != nil {
+			return nil, fmt.Errorf("failed to parse action items: %w", err)
+		}
+		for _, a := range part.ActionItems {
+			// A task repeated in several chunks is kept where it was first said
+			if key := normalize(a.Task); !seenTasks[key] {
+				seenTasks[key] = true
+				out.ActionItems = append(out.ActionItems, a)
+			}
+		}
+	}
+
+	existing, err := e.itemRepo.ListByJob(ctx, job.ID)
+	if err != nil {
+		return nil, err
+	}
+	previous := make(map[string]models.ActionItem)
+	for _, item := range existing {
+		previous[normalize(item.Title)] = item
+	}
+
+	items := make([]models.ActionItem, 0, len(out.ActionItems))
+	for _, a := range out.ActionItems {
+		title := strings.TrimSpace(a.Task)
+		if title == "" {
+			continue
+		}
+		item := models.ActionItem{
+			TranscriptionID: job.ID,
+			UserID:          job.UserID,
+			Title:           title,
+			Status:          models.ActionItemStatusOpen,
+		}
+		if a.Assignee != nil {
+			item.Assignee, item.Speaker = resolveAssignee(strings.TrimSpace(*a.Assignee), labels, names)
+		}
+		if a.DueDate != nil {
+			if due, err := time.Parse("2006-01-02", strings.TrimSpace(*a.DueDate)); err == nil {
+				date := due.Format("2006-01-02")
+				item.DueDate = &date
+			}
+		}
+		if a.Segment != nil && *a.Segment >= 0 && *a.Segment < len(doc.Segments) {
+			seg := doc.Segments[*a.Segment]
+			item.SourceTime = &seg.Start
+			item.Quote = seg.Text
+		}
+		// Items extracted again keep their identity, e.g. for calendar
+		// exports, and their status
+		if prev, ok := previous[normalize(title)]; ok {
+			delete(previous, normalize(title))
+			item.ID = prev.ID
+			item.CreatedAt = prev.CreatedAt
+			item.Status = prev.Status
+			item.CompletedAt = prev.CompletedAt
+		}
+		items = append(items, item)
+	}
+	if err := e.itemRepo.ReplaceForJob(ctx, job.ID, items); err != nil {
+		return nil, fmt.Errorf("failed to store action items: %w", err)
+	}
+	logger.Info("Extracted action items", "job_id", job.ID, "count", len(items), "model", primary.Model, "duration_ms", time.Since(start).Milliseconds())
+	return items, nil
+}
+
+// resolveAssignee returns the display name and original speaker label of an
+// assignee named by the model, which may use either
+func resolveAssignee(name string, labels []string, names map[string]string) (string, string) {
+	for _, label := range labels {
+		display := label
+		if custom := names[label]; custom != "" {
+			display = custom
+		}
+		if strings.EqualFold(name, label) || strings.EqualFold(name, display) {
+			return display, label
+		}
+	}
+	return name, ""
+}
+
+func normalize(title string) string {
+	return strings.Join(strings.Fields(strings.ToLower(title)), " ")
+}
+
+func clock(seconds float64) string {
+	total := int(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, (total%3600)/60, total%60)
+}
@@ -0,0 +1,292 @@
+// Package analytics computes per-speaker conversation metrics from
+// transcript timings: talk time, monologues, interruptions, overlap, pace,
+// filler words and questions.
+package analytics
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"scriberr/internal/export"
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+)
+
+// UnknownSpeaker labels the segments of transcripts without diarization
+const UnknownSpeaker = "UNKNOWN"
+
+const (
+	// interruptionTolerance ignores overlaps this short, in seconds, which
+	// are usually timing noise at turn changes
+	interruptionTolerance = 0.25
+	// wordGap is the pause, in seconds, between timed words beyond which a
+	// segment's speech is split
+	wordGap = 0.3
+)
+
+// fillers are counted as single words in every language; fillerPhrases as
+// word pairs
+var (
+	fillers = map[string]bool{
+		"um": true, "umm": true, "uh": true, "uhh": true, "uhm": true, "er": true, "erm": true,
+		"ah": true, "hmm": true, "hm": true, "mm": true, "mhm": true,
+	}
+	fillerPhrases = map[string]bool{"you know": true, "i mean": true}
+
+	// languageFillers are the fillers of a language, by primary language
+	// subtag. They are only counted in that language, as some are words
+	// elsewhere (the Vietnamese "à" is the French "to").
+	languageFillers = map[string]map[string]bool{
+		"vi": {"ờ": true, "ừ": true, "à": true, "ừm": true, "ơ": true, "ờm": true},
+		"de": {"äh": true, "ähm": true, "öh": true, "öhm": true},
+		"fr": {"euh": true, "bah": true, "ben": true},
+		"es": {"eh": true, "em": true, "mmm": true},
+		"pt": {"hã": true, "éh": true, "ahn": true},
+		"ko": {"어": true, "음": true},
+	}
+	// unspacedFillers are the fillers of languages written without spaces
+	// between words, counted wherever they occur in the text
+	unspacedFillers = map[string][]string{
+		"ja": {"えーと", "えっと", "あのー"},
+		"zh": {"嗯", "呃"},
+	}
+)
+
+type interval struct {
+	start, end float64
+	speaker    string
+}
+
+// Compute derives the conversation metrics of a transcript. Speech is taken
+// from word timings where present, else from segment timings; speakers are
+// keyed by their diarization label.
+func Compute(result *interfaces.TranscriptResult) *models.ConversationAnalytics {
+	segments := export.NewDocument("", "", result, nil).Segments
+	sort.SliceStable(segments, func(i, j int) bool { return segments[i].Start < segments[j].Start })
+
+	stats := make(map[string]*models.SpeakerAnalytics)
+	speaker := func(label string) *models.SpeakerAnalytics {
+		s, ok := stats[label]
+		if !ok {
+			s = &models.SpeakerAnalytics{Speaker: label}
+			stats[label] = s
+		}
+		return s
+	}
+
+	var speech []interval
+	out := &models.ConversationAnalytics{}
+	for _, seg := range segments {
+		label := seg.Speaker
+		if label == "" {
+			label = UnknownSpeaker
+		}
+		s := speaker(label)
+		words := tokens(seg.Text)
+		s.Words += len(words)
+		s.Fillers += countFillers(seg.Text, words, segmentLanguage(result, seg))
+		s.Questions += countQuestions(seg.Text)
+		speech = append(speech, speechIntervals(seg, label)...)
+		out.Duration = math.Max(out.Duration, seg.End)
+	}
+
+	// Turns are runs of consecutive segments by the same speaker. A turn
+	// starting before another speaker's has ended interrupts it.
+	lastEnd := make(map[string]float64)
+	var turnSpeaker string
+	var turnStart, turnEnd float64
+	closeTurn := func() {
+		if turnSpeaker != "" {
+			s := stats[turnSpeaker]
+			s.LongestMonologue = math.Max(s.LongestMonologue, turnEnd-turnStart)
+		}
+	}
+	for _, seg := range segments {
+		label := seg.Speaker
+		if label == "" {
+			label = UnknownSpeaker
+		}
+		if label == turnSpeaker {
+			turnEnd = math.Max(turnEnd, seg.End)
+			lastEnd[label] = math.Max(lastEnd[label], seg.End)
+			continue
+		}
+		closeTurn()
+		var interrupted string
+		for other, end := range lastEnd {
+			if other != label && end-seg.Start > interruptionTolerance && (interrupted == "" || end > lastEnd[interrupted]) {
+				interrupted = other
+			}
+		}
+		if interrupted != "" {
+			stats[label].Interruptions++
+			stats[interrupted].Interrupted++
+		}
+		stats[label].Turns++
+		out.Turns++
+		turnSpeaker, turnStart, turnEnd = label, seg.Start, seg.End
+		lastEnd[label] = math.Max(lastEnd[label], seg.End)
+	}
+	closeTurn()
+
+	sweep(speech, out, stats)
+
+	var totalTalk float64
+	for _, s := range stats {
+		totalTalk += s.TalkTime
+	}
+	for _, s := range stats {
+		if totalTalk > 0 {
+			s.TalkShare = round(s.TalkTime/totalTalk, 4)
+		}
+		if s.TalkTime > 0 {
+			s.WordsPerMinute = round(float64(s.Words)/(s.TalkTime/60), 1)
+		}
+		if s.Words > 0 {
+			s.FillerRate = round(float64(s.Fillers)/float64(s.Words), 4)
+		}
+		s.TalkTime = round(s.TalkTime, 3)
+		s.OverlapTime = round(s.OverlapTime, 3)
+		s.LongestMonologue = round(s.LongestMonologue, 3)
+		out.Speakers = append(out.Speakers, *s)
+	}
+	sort.Slice(out.Speakers, func(i, j int) bool {
+		if out.Speakers[i].TalkTime != out.Speakers[j].TalkTime {
+			return out.Speakers[i].TalkTime > out.Speakers[j].TalkTime
+		}
+		return out.Speakers[i].Speaker < out.Speakers[j].Speaker
+	})
+
+	if out.Duration > 0 {
+		out.SilenceRatio = round(math.Max(0, 1-out.SpeechTime/out.Duration), 4)
+	}
+	out.Duration = round(out.Duration, 3)
+	out.SpeechTime = round(out.SpeechTime, 3)
+	out.OverlapTime = round(out.OverlapTime, 3)
+	return out
+}
+
+// sweep walks the speech intervals in time order, accumulating each
+// speaker's talk and overlap time and the transcript's speech and overlap
+// time
+func sweep(speech []interval, out *models.ConversationAnalytics, stats map[string]*models.SpeakerAnalytics) {
+	type event struct {
+		at      float64
+		speaker string
+		delta   int
+	}
+	events := make([]event, 0, 2*len(speech))
+	for _, iv := range speech {
+		events = append(events, event{iv.start, iv.speaker, 1}, event{iv.end, iv.speaker, -1})
+	}
+	// Ends before starts at the same instant, so touching intervals do not
+	// overlap
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at != events[j].at {
+			return events[i].at < events[j].at
+		}
+		return events[i].delta < events[j].delta
+	})
+
+	active := make(map[string]int)
+	for i, e := range events {
+		if i > 0 {
+			if dt := e.at - events[i-1].at; dt > 0 && len(active) > 0 {
+				out.SpeechTime += dt
+				if len(active) > 1 {
+					out.OverlapTime += dt
+				}
+				for label := range active {
+					stats[label].TalkTime += dt
+					if len(active) > 1 {
+						stats[label].OverlapTime += dt
+					}
+				}
+			}
+		}
+		active[e.speaker] += e.delta
+		if active[e.speaker] <= 0 {
+			delete(active, e.speaker)
+		}
+	}
+}
+
+// speechIntervals returns the stretches of a segment in which its speaker
+// talks: its timed words joined across short pauses, or the whole segment
+func speechIntervals(seg export.Segment, label string) []interval {
+	if len(seg.Words) == 0 {
+		if seg.End > seg.Start {
+			return []interval{{seg.Start, seg.End, label}}
+		}
+		return nil
+	}
+	var out []interval
+	for _, w := range seg.Words {
+		if w.End <= w.Start {
+			continue
+		}
+		if n := len(out); n > 0 && w.Start-out[n-1].end <= wordGap {
+			out[n-1].end = math.Max(out[n-1].end, w.End)
+			continue
+		}
+		out = append(out, interval{w.Start, w.End, label})
+	}
+	return out
+}
+
+// tokens returns the lowercase words of a text
+func tokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// segmentLanguage returns the primary language subtag of a segment: its own
+// language, else the transcript's
+func segmentLanguage(result *interfaces.TranscriptResult, seg export.Segment) string {
+	language := result.Language
+	if seg.Index < len(result.Segments) && result.Segments[seg.Index].Language != nil {
+		language = *result.Segments[seg.Index].Language
+	}
+	primary, _, _ := strings.Cut(strings.ToLower(language), "-")
+	return primary
+}
+
+func countFillers(text string, words []string, language string) int {
+	n := 0
+	for i, w := range words {
+		if fillers[w] || languageFillers[language][w] {
+			n++
+		} else if i > 0 && fillerPhrases[words[i-1]+" "+w] {
+			n++
+		}
+	}
+	for _, f := range unspacedFillers[language] {
+		n += strings.Count(text, f)
+	}
+	return n
+}
+
+// countQuestions counts the sentences of a text ending with a question mark,
+// ASCII or full-width
+func countQuestions(text string) int {
+	n := 0
+	runes := []rune(text)
+	for i, r := range runes {
+		if isQuestionMark(r) && (i+1 == len(runes) || !isQuestionMark(runes[i+1])) {
+			n++
+		}
+	}
+	return n
+}
+
+func isQuestionMark(r rune) bool {
+	return r == '?' || r == '？'
+}
+
+func round(x float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(x*p) / p
+}
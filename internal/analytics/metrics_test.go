@@ -0,0 +1,86 @@
+package analytics
+
+import (
+	"testing"
+
+	"scriberr/internal/transcription/interfaces"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompute(t *testing.T) {
+	a, b := "SPEAKER_00", "SPEAKER_01"
+	result := &interfaces.TranscriptResult{Segments: []interfaces.TranscriptSegment{
+		{Start: 0, End: 10, Text: "Hi, um, welcome. How are you?", Speaker: &a},
+		{Start: 9, End: 15, Text: "Good thanks, you know, busy.", Speaker: &b},
+		{Start: 16, End: 30, Text: "Let's talk pricing. Any questions??", Speaker: &a},
+		{Start: 30, End: 32, Text: "No.", Speaker: &b},
+	}}
+
+	out := Compute(result)
+	assert.Equal(t, 32.0, out.Duration)
+	assert.Equal(t, 31.0, out.SpeechTime)
+	assert.Equal(t, 0.0313, out.SilenceRatio)
+	assert.Equal(t, 1.0, out.OverlapTime)
+	assert.Equal(t, 4, out.Turns)
+	require.Len(t, out.Speakers, 2)
+
+	host := out.Speakers[0]
+	assert.Equal(t, a, host.Speaker)
+	assert.Equal(t, 24.0, host.TalkTime)
+	assert.Equal(t, 0.75, host.TalkShare)
+	assert.Equal(t, 14.0, host.LongestMonologue)
+	assert.Equal(t, 11, host.Words)
+	assert.Equal(t, 27.5, host.WordsPerMinute)
+	assert.Equal(t, 1, host.Fillers)
+	assert.Equal(t, 2, host.Questions)
+	assert.Equal(t, 0, host.Interruptions)
+	assert.Equal(t, 1, host.Interrupted)
+	assert.Equal(t, 1.0, host.OverlapTime)
+
+	guest := out.Speakers[1]
+	assert.Equal(t, 8.0, guest.TalkTime)
+	assert.Equal(t, 1, guest.Interruptions)
+	assert.Equal(t, 1, guest.Fillers)
+	assert.Equal(t, 0.1667, guest.FillerRate)
+	assert.Equal(t, 0, guest.Questions)
+}
+
+func TestComputeUsesWordTimings(t *testing.T) {
+	result := &interfaces.TranscriptResult{
+		Segments: []interfaces.TranscriptSegment{{Start: 0, End: 10, Text: "one two three"}},
+		WordSegments: []interfaces.TranscriptWord{
+			{Start: 0, End: 1, Word: "one"},
+			{Start: 1.1, End: 2, Word: "two"},
+			{Start: 6, End: 7, Word: "three"},
+		},
+	}
+
+	out := Compute(result)
+	require.Len(t, out.Speakers, 1)
+	assert.Equal(t, UnknownSpeaker, out.Speakers[0].Speaker)
+	// The pause between the second and third word is not talk time
+	assert.Equal(t, 3.0, out.Speakers[0].TalkTime)
+	assert.Equal(t, 60.0, out.Speakers[0].WordsPerMinute)
+	assert.Equal(t, 0.7, out.SilenceRatio)
+}
+
+func TestComputeLanguageFillers(t *testing.T) {
+	result := &interfaces.TranscriptResult{
+		Language: "vi",
+		Segments: []interfaces.TranscriptSegment{
+			{Start: 0, End: 5, Text: "Ờ, ừm, anh đi đâu thế？ À, ừ."},
+			{Start: 5, End: 10, Text: "Euh, il va à Paris ?", Language: strPtr("fr")},
+			{Start: 10, End: 15, Text: "嗯，你好吗？", Language: strPtr("zh-CN")},
+		},
+	}
+
+	out := Compute(result)
+	require.Len(t, out.Speakers, 1)
+	// The French "à" is not a filler
+	assert.Equal(t, 6, out.Speakers[0].Fillers)
+	assert.Equal(t, 3, out.Speakers[0].Questions)
+}
+
+func strPtr(s string) *string { return &s }
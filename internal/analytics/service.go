@@ -0,0 +1,233 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"scriberr/internal/export"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/pkg/logger"
+)
+
+// SpeakerSummary aggregates a named speaker's metrics over transcriptions
+type SpeakerSummary struct {
+	Name             string    `json:"name"`
+	Transcriptions   int       `json:"transcriptions"`
+	TalkTime         float64   `json:"talk_time"`         // In seconds
+	TalkShare        float64   `json:"talk_share"`        // Average over transcriptions
+	LongestMonologue float64   `json:"longest_monologue"` // Longest in any transcription, in seconds
+	Interruptions    int       `json:"interruptions"`
+	Interrupted      int       `json:"interrupted"`
+	OverlapTime      float64   `json:"overlap_time"`
+	Words            int       `json:"words"`
+	WordsPerMinute   float64   `json:"words_per_minute"`
+	Fillers          int       `json:"fillers"`
+	FillerRate       float64   `json:"filler_rate"`
+	Questions        int       `json:"questions"`
+	FirstSeen        time.Time `json:"first_seen"`
+	LastSeen         time.Time `json:"last_seen"`
+}
+
+// SpeakerPoint is a named speaker's metrics in one transcription
+type SpeakerPoint struct {
+	TranscriptionID string    `json:"transcription_id"`
+	Title           string    `json:"title,omitempty"`
+	Date            time.Time `json:"date"`
+	models.SpeakerAnalytics
+}
+
+// Service computes and stores conversation analytics
+type Service struct {
+	jobRepo            repository.JobRepository
+	analyticsRepo      repository.AnalyticsRepository
+	speakerMappingRepo repository.SpeakerMappingRepository
+}
+
+// NewService creates an analytics service
+func NewService(jobRepo repository.JobRepository, analyticsRepo repository.AnalyticsRepository, speakerMappingRepo repository.SpeakerMappingRepository) *Service {
+	return &Service{
+		jobRepo:            jobRepo,
+		analyticsRepo:      analyticsRepo,
+		speakerMappingRepo: speakerMappingRepo,
+	}
+}
+
+// Record computes the analytics of a job when it completes. Failures are
+// logged; analytics are computed again when next read.
+func (s *Service) Record(jobID string) {
+	ctx := context.Background()
+	job, err := s.jobRepo.FindByID(ctx, jobID)
+	if err != nil || job == nil || job.Transcript == nil {
+		return
+	}
+	if _, err := s.Compute(ctx, job); err != nil {
+		logger.Warn("Failed to compute analytics", "job_id", jobID, "error", err)
+	}
+}
+
+// Compute computes and stores the analytics of a job's transcript
+func (s *Service) Compute(ctx context.Context, job *models.TranscriptionJob) (*models.ConversationAnalytics, error) {
+	if job.Transcript == nil || *job.Transcript == "" {
+		return nil, fmt.Errorf("transcription has no transcript")
+	}
+	result, err := export.DecodeTranscript(*job.Transcript)
+	if err != nil {
+		return nil, err
+	}
+	analytics := Compute(result)
+	analytics.TranscriptionID = job.ID
+	if err := s.analyticsRepo.Save(ctx, analytics); err != nil {
+		return nil, fmt.Errorf("failed to store analytics: %w", err)
+	}
+	s.name(ctx, job.ID, analytics)
+	return analytics, nil
+}
+
+// Get returns the analytics of a job, computing them if they are missing or
+// older than the job
+func (s *Service) Get(ctx context.Context, job *models.TranscriptionJob) (*models.ConversationAnalytics, error) {
+	analytics, err := s.analyticsRepo.FindByJob(ctx, job.ID)
+	if err != nil {
+		return nil, err
+	}
+	if analytics == nil || analytics.UpdatedAt.Before(job.UpdatedAt) {
+		return s.Compute(ctx, job)
+	}
+	s.name(ctx, job.ID, analytics)
+	return analytics, nil
+}
+
+// Delete removes the analytics of a job
+func (s *Service) Delete(ctx context.Context, jobID string) error {
+	return s.analyticsRepo.DeleteByTranscriptionID(ctx, jobID)
+}
+
+// Speakers aggregates the metrics of named speakers over the selected
+// transcriptions, most talkative first. Speakers are identified across
+// transcriptions by their custom name; unnamed diarization labels are left
+// out.
+func (s *Service) Speakers(ctx context.Context, filter repository.AnalyticsFilter) ([]SpeakerSummary, error) {
+	points, err := s.points(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var order []string
+	byName := make(map[string][]SpeakerPoint)
+	for _, p := range points {
+		key := strings.ToLower(p.Name)
+		if _, ok := byName[key]; !ok {
+			order = append(order, key)
+		}
+		byName[key] = append(byName[key], p)
+	}
+	summaries := make([]SpeakerSummary, 0, len(order))
+	for _, key := range order {
+		summaries = append(summaries, summarize(byName[key]))
+	}
+	sort.SliceStable(summaries, func(i, j int) bool { return summaries[i].TalkTime > summaries[j].TalkTime })
+	return summaries, nil
+}
+
+// Speaker returns a named speaker's aggregate and per-transcription metrics
+// over the selected transcriptions, oldest first, or nil if the speaker is
+// not found
+func (s *Service) Speaker(ctx context.Context, filter repository.AnalyticsFilter, name string) (*SpeakerSummary, []SpeakerPoint, error) {
+	points, err := s.points(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	var history []SpeakerPoint
+	for _, p := range points {
+		if strings.EqualFold(p.Name, strings.TrimSpace(name)) {
+			history = append(history, p)
+		}
+	}
+	if len(history) == 0 {
+		return nil, nil, nil
+	}
+	summary := summarize(history)
+	return &summary, history, nil
+}
+
+// points lists the metrics of named speakers in the selected transcriptions
+func (s *Service) points(ctx context.Context, filter repository.AnalyticsFilter) ([]SpeakerPoint, error) {
+	items, err := s.analyticsRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var points []SpeakerPoint
+	for i := range items {
+		a := &items[i]
+		s.name(ctx, a.TranscriptionID, a)
+		title := ""
+		if a.Transcription.Title != nil {
+			title = *a.Transcription.Title
+		}
+		for _, sp := range a.Speakers {
+			if sp.Name == "" {
+				continue
+			}
+			points = append(points, SpeakerPoint{
+				TranscriptionID:  a.TranscriptionID,
+				Title:            title,
+				Date:             a.Transcription.CreatedAt,
+				SpeakerAnalytics: sp,
+			})
+		}
+	}
+	return points, nil
+}
+
+// name fills in the custom names of a job's speakers
+func (s *Service) name(ctx context.Context, jobID string, analytics *models.ConversationAnalytics) {
+	mappings, err := s.speakerMappingRepo.ListByJob(ctx, jobID)
+	if err != nil {
+		return
+	}
+	names := export.SpeakerNames(mappings)
+	for i := range analytics.Speakers {
+		analytics.Speakers[i].Name = strings.TrimSpace(names[analytics.Speakers[i].Speaker])
+	}
+}
+
+// summarize aggregates one speaker's points, weighting rates by talk time
+// and words
+func summarize(points []SpeakerPoint) SpeakerSummary {
+	out := SpeakerSummary{Name: points[len(points)-1].Name, FirstSeen: points[0].Date, LastSeen: points[0].Date}
+	transcriptions := make(map[string]bool)
+	var share float64
+	for _, p := range points {
+		transcriptions[p.TranscriptionID] = true
+		out.TalkTime += p.TalkTime
+		share += p.TalkShare
+		out.LongestMonologue = math.Max(out.LongestMonologue, p.LongestMonologue)
+		out.Interruptions += p.Interruptions
+		out.Interrupted += p.Interrupted
+		out.OverlapTime += p.OverlapTime
+		out.Words += p.Words
+		out.Fillers += p.Fillers
+		out.Questions += p.Questions
+		if p.Date.Before(out.FirstSeen) {
+			out.FirstSeen = p.Date
+		}
+		if p.Date.After(out.LastSeen) {
+			out.LastSeen = p.Date
+		}
+	}
+	out.Transcriptions = len(transcriptions)
+	out.TalkShare = round(share/float64(len(points)), 4)
+	if out.TalkTime > 0 {
+		out.WordsPerMinute = round(float64(out.Words)/(out.TalkTime/60), 1)
+	}
+	if out.Words > 0 {
+		out.FillerRate = round(float64(out.Fillers)/float64(out.Words), 4)
+	}
+	out.TalkTime = round(out.TalkTime, 3)
+	out.OverlapTime = round(out.OverlapTime, 3)
+	return out
+}
@@ -0,0 +1,340 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/actionitems"
+	"scriberr/internal/export"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+)
+
+// ExtractActionItemsRequest selects the model that extracts action items
+type ExtractActionItemsRequest struct {
+	Model string `json:"model"` // Defaults to the default summary model
+}
+
+// UpdateTaskRequest changes an action item. Omitted fields are kept; an
+// empty assignee or due date clears it.
+type UpdateTaskRequest struct {
+	Title    *string `json:"title,omitempty"`
+	Assignee *string `json:"assignee,omitempty"`
+	DueDate  *string `json:"due_date,omitempty"` // YYYY-MM-DD
+	Status   *string `json:"status,omitempty" binding:"omitempty,oneof=open done"`
+}
+
+// SetActionItemRepository sets the store of action items behind the tasks API
+func (h *Handler) SetActionItemRepository(repo repository.ActionItemRepository) {
+	h.actionItemRepo = repo
+	h.actionItems = actionitems.NewExtractor(repo, h.speakerMappingRepo, h.llmRouter)
+}
+
+// actionItemsEnabled writes an error response unless action items are set up
+func (h *Handler) actionItemsEnabled(c *gin.Context) bool {
+	if h.actionItemRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Action items are not enabled"})
+		return false
+	}
+	return true
+}
+
+// ExtractActionItems extracts the action items of a transcription
+// @Summary Extract action items
+// @Description Extract the action items of a transcript with the summarization route: each with its assignee (resolved to custom speaker names), due date and the segment it was said in. The transcription's previous action items are replaced; items extracted again keep their ID and status.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Param request body ExtractActionItemsRequest false "Extraction options"
+// @Success 200 {array} models.ActionItem
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/action-items [post]
+func (h *Handler) ExtractActionItems(c *gin.Context) {
+	if !h.actionItemsEnabled(c) {
+		return
+	}
+	var req ExtractActionItemsRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	if job.Transcript == nil || *job.Transcript == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transcription has no transcript"})
+		return
+	}
+	model := req.Model
+	if model == "" {
+		if settings, err := h.summaryRepo.GetSettings(c.Request.Context()); err == nil {
+			model = settings.DefaultModel
+		}
+	}
+
+	items, err := h.actionItems.Extract(c.Request.Context(), job, model)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// ListActionItems lists the action items of a transcription
+// @Summary List action items of a transcription
+// @Description Get the action items extracted from a transcription, in transcript order
+// @Tags tasks
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Success 200 {array} models.ActionItem
+// @Failure 404 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/action-items [get]
+func (h *Handler) ListActionItems(c *gin.Context) {
+	if !h.actionItemsEnabled(c) {
+		return
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	items, err := h.actionItemRepo.ListByJob(c.Request.Context(), job.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch action items"})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// ListTasks lists the user's action items across transcriptions
+// @Summary List tasks
+// @Description Get the action items of all the user's transcriptions, soonest due first
+// @Tags tasks
+// @Produce json
+// @Param status query string false "open or done"
+// @Param assignee query string false "Assignee name"
+// @Param transcription_id query string false "Transcription ID"
+// @Success 200 {array} models.ActionItem
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/tasks [get]
+func (h *Handler) ListTasks(c *gin.Context) {
+	if !h.actionItemsEnabled(c) {
+		return
+	}
+	filter, ok := taskFilter(c)
+	if !ok {
+		return
+	}
+	items, err := h.actionItemRepo.ListTasks(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// ExportTasks renders the user's action items as a file
+// @Summary Export tasks
+// @Description Export the action items of all the user's transcriptions as a Markdown checklist, CSV or iCalendar VTODOs, with the same filters as the task list
+// @Tags tasks
+// @Produce text/markdown
+// @Produce text/csv
+// @Produce text/calendar
+// @Param format query string false "md (default), csv or ics"
+// @Param status query string false "open or done"
+// @Param assignee query string false "Assignee name"
+// @Param transcription_id query string false "Transcription ID"
+// @Success 200 {file} binary
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/tasks/export [get]
+func (h *Handler) ExportTasks(c *gin.Context) {
+	if !h.actionItemsEnabled(c) {
+		return
+	}
+	format, err := export.ParseTaskFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter, ok := taskFilter(c)
+	if !ok {
+		return
+	}
+	items, err := h.actionItemRepo.ListTasks(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
+		return
+	}
+
+	titles := make(map[string]string)
+	tasks := make([]export.Task, len(items))
+	for i, item := range items {
+		title, ok := titles[item.TranscriptionID]
+		if !ok {
+			if job, err := h.jobRepo.FindByID(c.Request.Context(), item.TranscriptionID); err == nil && job != nil && job.Title != nil {
+				title = *job.Title
+			}
+			titles[item.TranscriptionID] = title
+		}
+		tasks[i] = export.Task{ActionItem: item, Source: title}
+	}
+
+	var buf bytes.Buffer
+	if err := export.RenderTasks(&buf, tasks, format); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "tasks"+export.Extension(format)))
+	c.Data(http.StatusOK, export.TaskContentType(format), buf.Bytes())
+}
+
+// UpdateTask changes an action item, e.g. marks it done
+// @Summary Update a task
+// @Description Change the title, assignee, due date or status of an action item
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param task_id path string true "Action item ID"
+// @Param request body UpdateTaskRequest true "Changes"
+// @Success 200 {object} models.ActionItem
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/tasks/{task_id} [patch]
+func (h *Handler) UpdateTask(c *gin.Context) {
+	if !h.actionItemsEnabled(c) {
+		return
+	}
+	var req UpdateTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	item, ok := h.findTask(c)
+	if !ok {
+		return
+	}
+
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "title cannot be empty"})
+			return
+		}
+		item.Title = title
+	}
+	if req.Assignee != nil {
+		// A reassigned item is no longer tied to a speaker
+		item.Assignee = strings.TrimSpace(*req.Assignee)
+		item.Speaker = ""
+	}
+	if req.DueDate != nil {
+		if *req.DueDate == "" {
+			item.DueDate = nil
+		} else if due, err := time.Parse("2006-01-02", *req.DueDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "due_date must be YYYY-MM-DD"})
+			return
+		} else {
+			date := due.Format("2006-01-02")
+			item.DueDate = &date
+		}
+	}
+	if req.Status != nil && *req.Status != item.Status {
+		item.Status = *req.Status
+		item.CompletedAt = nil
+		if item.Status == models.ActionItemStatusDone {
+			now := time.Now()
+			item.CompletedAt = &now
+		}
+	}
+
+	if err := h.actionItemRepo.Update(c.Request.Context(), item); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+// DeleteTask removes an action item
+// @Summary Delete a task
+// @Description Delete an action item
+// @Tags tasks
+// @Param task_id path string true "Action item ID"
+// @Success 204 {string} string "No Content"
+// @Failure 404 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/tasks/{task_id} [delete]
+func (h *Handler) DeleteTask(c *gin.Context) {
+	if !h.actionItemsEnabled(c) {
+		return
+	}
+	item, ok := h.findTask(c)
+	if !ok {
+		return
+	}
+	if err := h.actionItemRepo.Delete(c.Request.Context(), item.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// findTask loads the action item of the request, checking that the user can
+// access its transcription. On failure the error response has been written.
+func (h *Handler) findTask(c *gin.Context) (*models.ActionItem, bool) {
+	item, err := h.actionItemRepo.FindByID(c.Request.Context(), c.Param("task_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task"})
+		return nil, false
+	}
+	if item == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return nil, false
+	}
+	if _, err := h.checkJobOwnership(c, item.TranscriptionID); err != nil {
+		return nil, false
+	}
+	return item, true
+}
+
+// taskFilter reads the status, assignee and transcription_id query
+// parameters, limited to the user's tasks. API keys belong to no user, so
+// the task endpoints take JWT authentication only. On failure the error
+// response has been written.
+func taskFilter(c *gin.Context) (repository.ActionItemFilter, bool) {
+	filter := repository.ActionItemFilter{
+		UserID:          currentUserID(c),
+		TranscriptionID: c.Query("transcription_id"),
+		Status:          c.Query("status"),
+		Assignee:        c.Query("assignee"),
+	}
+	if filter.UserID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return filter, false
+	}
+	if filter.Status != "" && filter.Status != models.ActionItemStatusOpen && filter.Status != models.ActionItemStatusDone {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open or done"})
+		return filter, false
+	}
+	return filter, true
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/analytics"
+	"scriberr/internal/repository"
+)
+
+// SpeakerHistoryResponse is a named speaker's metrics over time
+type SpeakerHistoryResponse struct {
+	Summary analytics.SpeakerSummary `json:"summary"`
+	History []analytics.SpeakerPoint `json:"history"`
+}
+
+// SetAnalyticsService sets the service behind the conversation analytics
+// endpoints
+func (h *Handler) SetAnalyticsService(s *analytics.Service) {
+	h.analytics = s
+}
+
+// analyticsEnabled writes an error response unless analytics are set up
+func (h *Handler) analyticsEnabled(c *gin.Context) bool {
+	if h.analytics == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Conversation analytics are not enabled"})
+		return false
+	}
+	return true
+}
+
+// analyticsFilter reads the from and to query parameters and scopes
+// analytics to the current user. On failure the error response has been
+// written.
+func analyticsFilter(c *gin.Context) (filter repository.AnalyticsFilter, ok bool) {
+	filter.UserID = currentUserID(c)
+	if filter.UserID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return filter, false
+	}
+	filter.From, filter.To, ok = dateRange(c)
+	return filter, ok
+}
+
+// GetConversationAnalytics returns the conversation metrics of a transcription
+// @Summary Get conversation analytics
+// @Description Get per-speaker conversation metrics of a transcription: talk time and share, longest monologue, interruptions, overlap, words per minute, filler words and questions, plus the recording's silence ratio. Metrics are computed from word timings where present, else segment timings.
+// @Tags analytics
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Success 200 {object} models.ConversationAnalytics
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/analytics [get]
+func (h *Handler) GetConversationAnalytics(c *gin.Context) {
+	if !h.analyticsEnabled(c) {
+		return
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	if job.Transcript == nil || *job.Transcript == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transcription has no transcript"})
+		return
+	}
+	result, err := h.analytics.Get(c.Request.Context(), job)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ListSpeakerAnalytics aggregates the metrics of named speakers
+// @Summary List speaker analytics
+// @Description Aggregate the conversation metrics of each named speaker over the current user's transcriptions. Speakers are matched across transcriptions by their custom name; unnamed speakers are left out.
+// @Tags analytics
+// @Produce json
+// @Param from query string false "Start date (RFC3339 or YYYY-MM-DD, inclusive)"
+// @Param to query string false "End date (RFC3339, exclusive, or YYYY-MM-DD, inclusive)"
+// @Success 200 {array} analytics.SpeakerSummary
+// @Failure 400 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/analytics/speakers [get]
+func (h *Handler) ListSpeakerAnalytics(c *gin.Context) {
+	if !h.analyticsEnabled(c) {
+		return
+	}
+	filter, ok := analyticsFilter(c)
+	if !ok {
+		return
+	}
+	summaries, err := h.analytics.Speakers(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate speaker analytics"})
+		return
+	}
+	c.JSON(http.StatusOK, summaries)
+}
+
+// GetSpeakerAnalytics returns a named speaker's metrics over time
+// @Summary Get speaker analytics
+// @Description Get a named speaker's aggregate conversation metrics and their metrics in each transcription, oldest first
+// @Tags analytics
+// @Produce json
+// @Param name path string true "Speaker name (case-insensitive)"
+// @Param from query string false "Start date (RFC3339 or YYYY-MM-DD, inclusive)"
+// @Param to query string false "End date (RFC3339, exclusive, or YYYY-MM-DD, inclusive)"
+// @Success 200 {object} SpeakerHistoryResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/analytics/speakers/{name} [get]
+func (h *Handler) GetSpeakerAnalytics(c *gin.Context) {
+	if !h.analyticsEnabled(c) {
+		return
+	}
+	filter, ok := analyticsFilter(c)
+	if !ok {
+		return
+	}
+	summary, history, err := h.analytics.Speaker(c.Request.Context(), filter, c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate speaker analytics"})
+		return
+	}
+	if summary == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Speaker not found"})
+		return
+	}
+	c.JSON(http.StatusOK, SpeakerHistoryResponse{Summary: *summary, History: history})
+}
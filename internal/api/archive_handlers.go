@@ -0,0 +1,166 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"scriberr/internal/archive"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxArchiveImportSize caps the size of an uploaded archive
+const maxArchiveImportSize = 20 << 30
+
+func (h *Handler) archiveService() *archive.Service {
+	return archive.NewService(h.jobRepo, h.summaryRepo, h.chatRepo, h.noteRepo, h.speakerMappingRepo, h.config.UploadDir)
+}
+
+// ExportArchive streams a zip archive of the user's jobs
+// @Summary Export jobs as an archive
+// @Description Stream a zip containing, for every job, the audio (merged audio for multi-track jobs), transcript, tags, speaker mappings, notes, completed summaries, chat sessions with messages and execution metadata, plus a versioned manifest.json. Action items, chapters, translations, review queues, analytics and smart analyses are not archived and must be regenerated after an import; LLM configs and routes are instance settings and are not archived either.
+// @Tags archive
+// @Produce application/zip
+// @Param job_ids query string false "Comma-separated job IDs (default: all jobs of the user)"
+// @Success 200 {file} binary
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/archive/export [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) ExportArchive(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	userID := userIDVal.(uint)
+
+	var jobs []models.TranscriptionJob
+	if ids := strings.TrimSpace(c.Query("job_ids")); ids != "" {
+		for _, id := range strings.Split(ids, ",") {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				continue
+			}
+			job, err := h.checkJobOwnership(c, id)
+			if err != nil {
+				return
+			}
+			if job == nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+				return
+			}
+			jobs = append(jobs, *job)
+		}
+	} else {
+		all, _, err := h.jobRepo.ListByUser(c.Request.Context(), userID, 0, -1)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list jobs"})
+			return
+		}
+		jobs = all
+	}
+
+	filename := fmt.Sprintf("scriberr-archive-%s.zip", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Status(http.StatusOK)
+
+	// Headers are already sent, so failures can only be logged; the client
+	// sees a truncated zip
+	if err := h.archiveService().Export(c.Request.Context(), c.Writer, jobs); err != nil {
+		logger.Error("Archive export failed", "user_id", userID, "error", err)
+	}
+}
+
+// ImportArchive recreates jobs from an uploaded archive
+// @Summary Import an archive
+// @Description Upload a zip produced by the archive export. Jobs are recreated under the current user (admins may pass user_id) with new IDs; jobs whose audio already exists for that user are skipped.
+// @Tags archive
+// @Accept multipart/form-data
+// @Produce json
+// @Param archive formData file true "Archive zip"
+// @Param user_id formData int false "Target user (admin only)"
+// @Success 200 {object} archive.ImportResult
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /api/v1/archive/import [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) ImportArchive(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	targetUserID := userIDVal.(uint)
+
+	if raw := c.PostForm("user_id"); raw != "" {
+		role, _ := c.Get("role")
+		if role != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can import for another user"})
+			return
+		}
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
+			return
+		}
+		user, err := h.userRepo.FindByID(c.Request.Context(), uint(id))
+		if err != nil || user == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Target user not found"})
+			return
+		}
+		targetUserID = uint(id)
+	}
+
+	header, err := c.FormFile("archive")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Archive file is required"})
+		return
+	}
+	if header.Size > maxArchiveImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Archive is too large"})
+		return
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read archive"})
+		return
+	}
+	defer src.Close()
+
+	// zip needs random access, so spool the upload to a temp file
+	tmp, err := os.CreateTemp(h.config.TempDir, "archive-*.zip")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store archive"})
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, src)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store archive"})
+		return
+	}
+
+	result, err := h.archiveService().Import(c.Request.Context(), tmp, size, targetUserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for _, job := range result.Imported {
+		h.scheduleWaveform(job.NewID)
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/chapters"
+	"scriberr/internal/export"
+)
+
+// GenerateChaptersRequest selects how chapters are found
+type GenerateChaptersRequest struct {
+	Method string `json:"method" binding:"omitempty,oneof=auto llm heuristic"` // Defaults to auto: the model if one is configured, else the heuristic
+	Model  string `json:"model"`                                               // Defaults to the summarization route's model, then the default summary model
+}
+
+// SetChapterService sets the service behind the chapter endpoints
+func (h *Handler) SetChapterService(s *chapters.Service) {
+	h.chapters = s
+}
+
+// chaptersEnabled writes an error response unless chapters are set up
+func (h *Handler) chaptersEnabled(c *gin.Context) bool {
+	if h.chapters == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Chapters are not enabled"})
+		return false
+	}
+	return true
+}
+
+// ListChapters lists the chapters of a transcription
+// @Summary List chapters
+// @Description Get the topical chapters of a transcription, with titles, timestamps and short summaries. Recordings of ten minutes or more are chaptered when their transcription completes.
+// @Tags chapters
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Success 200 {array} models.Chapter
+// @Failure 404 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/chapters [get]
+func (h *Handler) ListChapters(c *gin.Context) {
+	if !h.chaptersEnabled(c) {
+		return
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	items, err := h.chapters.List(c.Request.Context(), job.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch chapters"})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// GenerateChapters splits a transcription into chapters
+// @Summary Generate chapters
+// @Description Split a transcript into topical chapters, replacing its previous ones. The summarization route's model finds the boundaries and writes titles and summaries; without a model, or when it fails in auto mode, boundaries are placed at vocabulary shifts and pauses and titled with keywords.
+// @Tags chapters
+// @Accept json
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Param request body GenerateChaptersRequest false "Options"
+// @Success 200 {array} models.Chapter
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/chapters [post]
+func (h *Handler) GenerateChapters(c *gin.Context) {
+	if !h.chaptersEnabled(c) {
+		return
+	}
+	var req GenerateChaptersRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	if job.Transcript == nil || *job.Transcript == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transcription has no transcript"})
+		return
+	}
+	items, err := h.chapters.Generate(c.Request.Context(), job, chapters.Options{Method: req.Method, Model: req.Model})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// ExportChapters renders the chapters of a transcription as a file
+// @Summary Export chapters
+// @Description Export the chapters of a transcription as Podcasting 2.0 chapters JSON, a WebVTT chapters track or an FFmpeg metadata file for embedding chapters into audio files
+// @Tags chapters
+// @Produce json
+// @Produce text/vtt
+// @Produce plain
+// @Param id path string true "Transcription ID"
+// @Param format query string false "json (default), vtt or ffmetadata"
+// @Success 200 {file} binary
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/chapters/export [get]
+func (h *Handler) ExportChapters(c *gin.Context) {
+	if !h.chaptersEnabled(c) {
+		return
+	}
+	format, err := export.ParseChapterFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	items, err := h.chapters.List(c.Request.Context(), job.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch chapters"})
+		return
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transcription has no chapters"})
+		return
+	}
+
+	title := ""
+	if job.Title != nil {
+		title = *job.Title
+	}
+	var buf bytes.Buffer
+	if err := export.RenderChapters(&buf, title, items, format); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	name := exportBasename(job) + export.ChapterExtension(format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.Data(http.StatusOK, export.ChapterContentType(format), buf.Bytes())
+}
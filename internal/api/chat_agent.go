@@ -0,0 +1,296 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"scriberr/internal/llm"
+	"scriberr/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxAgentSteps bounds the model calls of one agent turn
+const maxAgentSteps = 8
+
+// ChatAgentResponse is the reply to an agent mode message or a tool call
+// confirmation: the messages it added, in order
+type ChatAgentResponse struct {
+	Messages []ChatMessageResponse `json:"messages"`
+	// PendingConfirmation is set when a mutating tool call waits for the user
+	PendingConfirmation bool `json:"pending_confirmation"`
+}
+
+// ChatToolConfirmRequest approves or rejects a pending tool call
+type ChatToolConfirmRequest struct {
+	Approve *bool `json:"approve" binding:"required"`
+}
+
+// runChatAgent lets the model answer turn, running the tools it calls, until
+// it gives an answer or calls a tool that needs confirmation. created holds
+// the messages this request already touched, of which added were new; they
+// are returned along with everything the agent adds.
+func (h *Handler) runChatAgent(c *gin.Context, session *models.ChatSession, turn *chatTurn, created []ChatMessageResponse, added int) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Minute)
+	defer cancel()
+
+	env := &chatToolEnv{session: session, userID: currentUserID(c), sources: turn.sources}
+	// record saves a message and returns its position in created
+	record := func(msg *models.ChatMessage) int {
+		if err := h.saveTurnMessage(context.Background(), session, turn, msg); err != nil {
+			fmt.Printf("Failed to save agent message for session %s: %v\n", session.ID, err)
+			return -1
+		}
+		created = append(created, newChatMessageResponse(msg))
+		added++
+		return len(created) - 1
+	}
+
+	pending, answered := false, false
+	for step := 0; step < maxAgentSteps && !pending && !answered; step++ {
+		tokensUsed := 0
+		stepCtx := llm.WithUsageReporter(ctx, func(u llm.Usage) { tokensUsed += u.PromptTokens + u.CompletionTokens })
+		reply, err := turn.route.ChatCompletionWithTools(stepCtx, turn.messages, chatToolDefinitions(), 0.0)
+		if err != nil {
+			fmt.Printf("Agent step failed for session %s: %v\n", session.ID, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "LLM request failed: " + err.Error()})
+			return
+		}
+
+		if len(reply.ToolCalls) == 0 {
+			record(&models.ChatMessage{
+				Role:       "assistant",
+				Content:    reply.Content,
+				Citations:  extractCitations(reply.Content, turn.sources),
+				TokensUsed: intPtrIfPositive(tokensUsed),
+			})
+			answered = true
+			break
+		}
+
+		// Record every call before any result so history keeps them together
+		turn.messages = append(turn.messages, *reply)
+		calls := make([]*models.ChatMessage, len(reply.ToolCalls))
+		positions := make([]int, len(reply.ToolCalls))
+		for i, tc := range reply.ToolCalls {
+			status := models.ToolStatusExecuted
+			if tool, ok := findChatTool(tc.Function.Name); ok && tool.mutating {
+				status = models.ToolStatusPending
+				pending = true
+			}
+			calls[i] = &models.ChatMessage{
+				Role:          RoleToolCall,
+				Content:       tc.Function.Name + " " + tc.Function.Arguments,
+				ToolCallID:    stringPtr(tc.ID),
+				ToolName:      stringPtr(tc.Function.Name),
+				ToolArguments: stringPtr(tc.Function.Arguments),
+				ToolStatus:    stringPtr(status),
+			}
+			if i == 0 {
+				// The step's tokens are counted once, on its first call
+				calls[i].TokensUsed = intPtrIfPositive(tokensUsed)
+			}
+			positions[i] = record(calls[i])
+		}
+
+		for i, tc := range reply.ToolCalls {
+			if *calls[i].ToolStatus == models.ToolStatusPending {
+				continue
+			}
+			result, status := h.executeChatTool(ctx, env, tc.Function.Name, tc.Function.Arguments)
+			if status != models.ToolStatusExecuted {
+				calls[i].ToolStatus = stringPtr(status)
+				_ = h.chatRepo.UpdateMessage(context.Background(), calls[i])
+				if positions[i] >= 0 {
+					created[positions[i]] = newChatMessageResponse(calls[i])
+				}
+			}
+			record(&models.ChatMessage{Role: RoleToolResult, Content: result, ToolCallID: stringPtr(tc.ID), ToolName: stringPtr(tc.Function.Name), ToolStatus: stringPtr(status)})
+			turn.messages = append(turn.messages, llm.ChatMessage{Role: "tool", ToolCallID: tc.ID, Content: result})
+		}
+	}
+
+	if !pending && !answered {
+		record(&models.ChatMessage{Role: "assistant", Content: fmt.Sprintf("I stopped after %d tool steps without reaching an answer. Please narrow down the request.", maxAgentSteps)})
+	}
+
+	// Update session updated_at, message count, and last activity
+	now := time.Now()
+	session.UpdatedAt = now
+	session.LastActivityAt = &now
+	session.MessageCount += added
+	_ = h.chatRepo.Update(context.Background(), session)
+
+	c.JSON(http.StatusOK, ChatAgentResponse{Messages: created, PendingConfirmation: pending})
+}
+
+// @Summary Confirm a tool call
+// @Description Approve or reject a tool call of agent mode chat that waits for confirmation. Approved calls are run; once no calls are pending, the assistant continues.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param session_id path string true "Chat Session ID"
+// @Param message_id path int true "Tool call message ID"
+// @Param request body ChatToolConfirmRequest true "Decision"
+// @Success 200 {object} ChatAgentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/chat/sessions/{session_id}/tool-calls/{message_id}/confirm [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) ConfirmChatToolCall(c *gin.Context) {
+	sessionID := c.Param("session_id")
+	messageID, err := strconv.ParseUint(c.Param("message_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	var req ChatToolConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.chatRepo.GetSessionWithTranscription(c.Request.Context(), sessionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Chat session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get chat session"})
+		return
+	}
+	anchor, err := h.checkJobOwnership(c, session.TranscriptionID)
+	if err != nil {
+		return
+	}
+	scopeJobs, ok := h.resolveChatScope(c, session.Scope, anchor)
+	if !ok {
+		return
+	}
+
+	call, err := h.chatRepo.GetMessage(c.Request.Context(), sessionID, uint(messageID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tool call not found"})
+		return
+	}
+	if call.Role != RoleToolCall || call.ToolStatus == nil || *call.ToolStatus != models.ToolStatusPending || call.ToolCallID == nil || call.ToolName == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tool call is not awaiting confirmation"})
+		return
+	}
+	history, err := h.chatHistory(c.Request.Context(), session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load chat history"})
+		return
+	}
+	if !slices.ContainsFunc(history, func(m models.ChatMessage) bool { return m.ID == call.ID }) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tool call is not on the active branch"})
+		return
+	}
+
+	route, err := h.llmRoute(c, models.LLMFeatureChat)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	route = route.WithModel(session.Model).ForJob(session.TranscriptionID)
+
+	// Run or decline the call
+	result, status := "The user declined this action.", models.ToolStatusRejected
+	if *req.Approve {
+		sources, err := h.loadChatSources(c.Request.Context(), scopeJobs, session.Language)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse transcript data"})
+			return
+		}
+		arguments := "{}"
+		if call.ToolArguments != nil {
+			arguments = *call.ToolArguments
+		}
+		env := &chatToolEnv{session: session, userID: currentUserID(c), sources: sources}
+		result, status = h.executeChatTool(c.Request.Context(), env, *call.ToolName, arguments)
+	}
+	call.ToolStatus = stringPtr(status)
+	if err := h.chatRepo.UpdateMessage(c.Request.Context(), call); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tool call"})
+		return
+	}
+	resultMessage := &models.ChatMessage{
+		Role:       RoleToolResult,
+		Content:    result,
+		ToolCallID: call.ToolCallID,
+		ToolName:   call.ToolName,
+		ToolStatus: stringPtr(status),
+	}
+	if err := h.chatRepo.AppendMessage(c.Request.Context(), session, resultMessage); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save tool result"})
+		return
+	}
+	created := []ChatMessageResponse{newChatMessageResponse(call), newChatMessageResponse(resultMessage)}
+
+	// The assistant continues once every call of its step is decided
+	history, err = h.chatHistory(c.Request.Context(), session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load chat history"})
+		return
+	}
+	question := ""
+	for _, msg := range history {
+		if msg.Role == RoleToolCall && msg.ToolStatus != nil && *msg.ToolStatus == models.ToolStatusPending {
+			session.MessageCount++
+			_ = h.chatRepo.Update(c.Request.Context(), session)
+			c.JSON(http.StatusOK, ChatAgentResponse{Messages: created, PendingConfirmation: true})
+			return
+		}
+		if msg.Role == RoleUser {
+			question = msg.Content
+		}
+	}
+
+	turn, ok := h.buildChatTurn(c, session, route, scopeJobs, history, question, true)
+	if !ok {
+		return
+	}
+	// Only the result is new; the call was counted when it was made
+	h.runChatAgent(c, session, turn, created, 1)
+}
+
+// cancelPendingToolCalls rejects the tool calls still waiting for
+// confirmation when the user moves on with a new message
+func (h *Handler) cancelPendingToolCalls(ctx context.Context, session *models.ChatSession, history []models.ChatMessage) {
+	for i := range history {
+		call := &history[i]
+		if call.Role != RoleToolCall || call.ToolStatus == nil || *call.ToolStatus != models.ToolStatusPending {
+			continue
+		}
+		call.ToolStatus = stringPtr(models.ToolStatusRejected)
+		if err := h.chatRepo.UpdateMessage(ctx, call); err != nil {
+			continue
+		}
+		_ = h.chatRepo.AppendMessage(ctx, session, &models.ChatMessage{
+			Role:       RoleToolResult,
+			Content:    "Not run: the user sent a new message instead of confirming.",
+			ToolCallID: call.ToolCallID,
+			ToolName:   call.ToolName,
+			ToolStatus: stringPtr(models.ToolStatusRejected),
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func intPtrIfPositive(n int) *int {
+	if n <= 0 {
+		return nil
+	}
+	return &n
+}
@@ -0,0 +1,388 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"scriberr/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ChatRegenerateRequest asks for another reply to the question an assistant
+// message answered
+type ChatRegenerateRequest struct {
+	// Agent lets the assistant call tools; the reply is then a JSON
+	// ChatAgentResponse instead of a text stream
+	Agent bool `json:"agent,omitempty"`
+}
+
+// ChatActiveBranchRequest selects the branch through a message. The branch
+// follows the most recent reply after it.
+type ChatActiveBranchRequest struct {
+	MessageID uint `json:"message_id" binding:"required"`
+}
+
+// ChatBranchResponse describes one branch of a chat session, from its first
+// message to LeafID
+type ChatBranchResponse struct {
+	LeafID uint `json:"leaf_id"`
+	Active bool `json:"active"`
+	// ForkID is the first message of the branch that is not on the active
+	// branch; it is unset for the active branch
+	ForkID       *uint               `json:"fork_id,omitempty"`
+	MessageCount int                 `json:"message_count"`
+	LastMessage  ChatMessageResponse `json:"last_message"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
+
+// chatPath returns the messages of tree from the first one to leafID
+func chatPath(tree []models.ChatMessage, leafID *uint) []models.ChatMessage {
+	byID := make(map[uint]*models.ChatMessage, len(tree))
+	for i := range tree {
+		byID[tree[i].ID] = &tree[i]
+	}
+	var path []models.ChatMessage
+	for id := leafID; id != nil && len(path) < len(tree); {
+		msg, ok := byID[*id]
+		if !ok {
+			break
+		}
+		path = append(path, *msg)
+		id = msg.ParentID
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// chatChildren maps each message of tree to its replies in creation order;
+// first messages are listed under 0
+func chatChildren(tree []models.ChatMessage) map[uint][]uint {
+	children := make(map[uint][]uint)
+	for _, msg := range tree {
+		var parent uint
+		if msg.ParentID != nil {
+			parent = *msg.ParentID
+		}
+		children[parent] = append(children[parent], msg.ID)
+	}
+	return children
+}
+
+// latestLeaf returns the end of the branch through id that follows the most
+// recent reply at every step
+func latestLeaf(tree []models.ChatMessage, id uint) uint {
+	children := chatChildren(tree)
+	for len(children[id]) > 0 {
+		replies := children[id]
+		id = replies[len(replies)-1]
+	}
+	return id
+}
+
+// branchMessageResponses renders the messages of path with the alternative
+// branches at each of them
+func branchMessageResponses(tree, path []models.ChatMessage) []ChatMessageResponse {
+	children := chatChildren(tree)
+	var responses []ChatMessageResponse
+	for i := range path {
+		response := newChatMessageResponse(&path[i])
+		var parent uint
+		if path[i].ParentID != nil {
+			parent = *path[i].ParentID
+		}
+		if siblings := children[parent]; len(siblings) > 1 {
+			response.SiblingIDs = siblings
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+// chatHistory returns the messages of the session's active branch
+func (h *Handler) chatHistory(ctx context.Context, session *models.ChatSession) ([]models.ChatMessage, error) {
+	tree, err := h.chatRepo.GetMessageTree(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	return chatPath(tree, session.ActiveLeafID), nil
+}
+
+// chatSessionAndMessage loads the session and message named in the path and
+// checks access to them. On failure the error response has been written.
+func (h *Handler) chatSessionAndMessage(c *gin.Context) (*models.ChatSession, *models.ChatMessage, []models.TranscriptionJob, bool) {
+	sessionID := c.Param("session_id")
+	messageID, err := strconv.ParseUint(c.Param("message_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return nil, nil, nil, false
+	}
+
+	session, err := h.chatRepo.GetSessionWithTranscription(c.Request.Context(), sessionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Chat session not found"})
+			return nil, nil, nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get chat session"})
+		return nil, nil, nil, false
+	}
+	anchor, err := h.checkJobOwnership(c, session.TranscriptionID)
+	if err != nil {
+		return nil, nil, nil, false
+	}
+	scopeJobs, ok := h.resolveChatScope(c, session.Scope, anchor)
+	if !ok {
+		return nil, nil, nil, false
+	}
+
+	message, err := h.chatRepo.GetMessage(c.Request.Context(), sessionID, uint(messageID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return nil, nil, nil, false
+	}
+	return session, message, scopeJobs, true
+}
+
+// @Summary Regenerate an assistant reply
+// @Description Answer the question of an assistant message again. The new reply is an alternative branch next to the old one and becomes the active branch.
+// @Tags chat
+// @Accept json
+// @Produce text/plain
+// @Param session_id path string true "Chat Session ID"
+// @Param message_id path int true "Assistant message ID"
+// @Param request body ChatRegenerateRequest false "Options"
+// @Success 200 {string} string "Streaming response"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/chat/sessions/{session_id}/messages/{message_id}/regenerate [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) RegenerateChatMessage(c *gin.Context) {
+	var req ChatRegenerateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	session, message, scopeJobs, ok := h.chatSessionAndMessage(c)
+	if !ok {
+		return
+	}
+	if message.Role != "assistant" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only assistant replies can be regenerated"})
+		return
+	}
+
+	route, err := h.llmRoute(c, models.LLMFeatureChat)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	route = route.WithModel(session.Model).ForJob(session.TranscriptionID)
+
+	// The new reply follows the question, after any tool steps of the old one
+	tree, err := h.chatRepo.GetMessageTree(c.Request.Context(), session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load chat history"})
+		return
+	}
+	history := chatPath(tree, &message.ID)
+	for len(history) > 0 && history[len(history)-1].Role != RoleUser {
+		history = history[:len(history)-1]
+	}
+	if len(history) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The reply has no question to answer"})
+		return
+	}
+	// The new reply becomes the active branch once it is saved, so a failed
+	// attempt leaves the old reply in view
+	question := history[len(history)-1]
+	h.replyInChat(c, session, route, scopeJobs, history, question.Content, req.Agent, nil, 0)
+}
+
+// @Summary Edit a user message
+// @Description Ask an edited version of a user message and answer it. The edit is an alternative branch next to the original message, which is kept, and becomes the active branch.
+// @Tags chat
+// @Accept json
+// @Produce text/plain
+// @Param session_id path string true "Chat Session ID"
+// @Param message_id path int true "User message ID"
+// @Param message body ChatMessageRequest true "Edited content"
+// @Success 200 {string} string "Streaming response"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/chat/sessions/{session_id}/messages/{message_id} [put]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) EditChatMessage(c *gin.Context) {
+	var req ChatMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, message, scopeJobs, ok := h.chatSessionAndMessage(c)
+	if !ok {
+		return
+	}
+	if message.Role != RoleUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only user messages can be edited"})
+		return
+	}
+
+	route, err := h.llmRoute(c, models.LLMFeatureChat)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	route = route.WithModel(session.Model).ForJob(session.TranscriptionID)
+
+	edited := &models.ChatMessage{Role: RoleUser, Content: req.Content}
+	if err := h.chatRepo.AddBranchMessage(c.Request.Context(), session, edited, message.ParentID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save message"})
+		return
+	}
+	history, err := h.chatHistory(c.Request.Context(), session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load chat history"})
+		return
+	}
+
+	h.replyInChat(c, session, route, scopeJobs, history, req.Content, req.Agent, []ChatMessageResponse{newChatMessageResponse(edited)}, 1)
+}
+
+// @Summary List chat branches
+// @Description List the branches of a chat session created by regenerating replies and editing messages, oldest first
+// @Tags chat
+// @Produce json
+// @Param session_id path string true "Chat Session ID"
+// @Success 200 {array} ChatBranchResponse
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/chat/sessions/{session_id}/branches [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) ListChatBranches(c *gin.Context) {
+	session, err := h.chatRepo.GetSessionWithTranscription(c.Request.Context(), c.Param("session_id"))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Chat session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get chat session"})
+		return
+	}
+	if _, err := h.checkJobOwnership(c, session.TranscriptionID); err != nil {
+		return
+	}
+
+	tree, err := h.chatRepo.GetMessageTree(c.Request.Context(), session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get chat messages"})
+		return
+	}
+	active := chatPath(tree, session.ActiveLeafID)
+	onActive := make(map[uint]bool, len(active))
+	for _, msg := range active {
+		onActive[msg.ID] = true
+	}
+
+	children := chatChildren(tree)
+	branches := []ChatBranchResponse{}
+	for i := range tree {
+		leaf := &tree[i]
+		if len(children[leaf.ID]) > 0 {
+			continue
+		}
+		path := chatPath(tree, &leaf.ID)
+		branch := ChatBranchResponse{
+			LeafID:       leaf.ID,
+			Active:       session.ActiveLeafID != nil && leaf.ID == *session.ActiveLeafID,
+			MessageCount: len(path),
+			LastMessage:  newChatMessageResponse(leaf),
+			UpdatedAt:    leaf.CreatedAt,
+		}
+		for _, msg := range path {
+			if !onActive[msg.ID] {
+				forkID := msg.ID
+				branch.ForkID = &forkID
+				break
+			}
+		}
+		branches = append(branches, branch)
+	}
+	c.JSON(http.StatusOK, branches)
+}
+
+// @Summary Select the active chat branch
+// @Description Make the branch through a message the one shown and used as context for new messages. The branch follows the most recent reply after the message.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param session_id path string true "Chat Session ID"
+// @Param request body ChatActiveBranchRequest true "Message on the branch"
+// @Success 200 {object} ChatSessionWithMessages
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/chat/sessions/{session_id}/branch [put]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) SetActiveChatBranch(c *gin.Context) {
+	var req ChatActiveBranchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.chatRepo.GetSessionWithTranscription(c.Request.Context(), c.Param("session_id"))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Chat session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get chat session"})
+		return
+	}
+	if _, err := h.checkJobOwnership(c, session.TranscriptionID); err != nil {
+		return
+	}
+
+	tree, err := h.chatRepo.GetMessageTree(c.Request.Context(), session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get chat messages"})
+		return
+	}
+	if !slices.ContainsFunc(tree, func(m models.ChatMessage) bool { return m.ID == req.MessageID }) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return
+	}
+	if err := h.chatRepo.SetActiveLeaf(c.Request.Context(), session, latestLeaf(tree, req.MessageID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to select branch"})
+		return
+	}
+
+	messages := branchMessageResponses(tree, chatPath(tree, session.ActiveLeafID))
+	c.JSON(http.StatusOK, ChatSessionWithMessages{
+		ChatSessionResponse: ChatSessionResponse{
+			ID:              session.ID,
+			TranscriptionID: session.TranscriptionID,
+			Title:           session.Title,
+			Model:           session.Model,
+			Provider:        session.Provider,
+			IsActive:        session.IsActive,
+			CreatedAt:       session.CreatedAt,
+			UpdatedAt:       session.UpdatedAt,
+			MessageCount:    len(messages),
+			LastActivityAt:  session.LastActivityAt,
+			Scope:           scopeResponse(session.Scope),
+			Language:        session.Language,
+		},
+		Messages: messages,
+	})
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"scriberr/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// chatCitationsDelimiter separates the streamed answer from the JSON array of
+// its citations, which is sent once the answer is complete
+const chatCitationsDelimiter = "\x1e"
+
+var (
+	// citationMarker matches bracketed citation lists such as "[#12]",
+	// "[#3-5, #2.7]" or "[@00:01:05-00:01:20]"
+	citationMarker = regexp.MustCompile(`\[([#@][^\[\]\n]{1,80})\]`)
+	// segmentRef is "#12", "#12-14" or, with a source number, "#2.12"
+	segmentRef = regexp.MustCompile(`^#(?:(\d+)\.)?(\d+)(?:\s*-\s*#?(?:\d+\.)?(\d+))?$`)
+	// timeRef is "@00:01:05-00:01:20" or, with a source number, "@2/01:05-01:20"
+	timeRef = regexp.MustCompile(`^@(?:(\d+)/)?(\d{1,2}:\d{2}(?::\d{2})?)\s*-\s*(\d{1,2}:\d{2}(?::\d{2})?)$`)
+)
+
+// citationStopwords are frequent words that do not show a segment supports a claim
+var citationStopwords = map[string]bool{
+	"that": true, "this": true, "with": true, "from": true, "have": true, "were": true,
+	"they": true, "their": true, "there": true, "about": true, "which": true, "what": true,
+	"when": true, "will": true, "would": true, "said": true, "says": true, "also": true,
+	"been": true, "into": true, "than": true, "then": true, "them": true, "these": true,
+	"those": true, "speaker": true, "mentioned": true, "discussed": true, "transcript": true,
+}
+
+// segmentLabel is the label of segment index in rendered chat context; number
+// is the source number in multi-source context and 0 otherwise
+func segmentLabel(number, index int) string {
+	if number > 0 {
+		return fmt.Sprintf("#%d.%d", number, index)
+	}
+	return fmt.Sprintf("#%d", index)
+}
+
+// citationInstructions asks the model to cite the segments behind each claim
+func citationInstructions(multiSource bool) string {
+	if multiSource {
+		return "Each line starts with its label, e.g. [#2.14] for line 14 of source 2. After every claim, cite the lines that support it with their labels, e.g. [#2.14] or [#2.14-16], or with a time range, e.g. [@2/00:01:05-00:01:20]. Only cite lines that are in the transcripts."
+	}
+	return "Each line starts with its label, e.g. [#14]. After every claim, cite the lines that support it with their labels, e.g. [#14] or [#14-16], or with a time range, e.g. [@00:01:05-00:01:20]. Only cite lines that are in the transcript."
+}
+
+// extractCitations resolves the citation markers in an assistant message
+// against the transcripts in context. Markers that do not refer to existing
+// segments are dropped; the others are marked supported when the segments
+// share wording with the sentence they follow.
+func extractCitations(content string, sources []chatSource) []models.ChatCitation {
+	var citations []models.ChatCitation
+	seen := make(map[string]bool)
+	for _, loc := range citationMarker.FindAllStringSubmatchIndex(content, -1) {
+		claim := citationClaim(content[:loc[0]])
+		for _, ref := range strings.FieldsFunc(content[loc[2]:loc[3]], func(r rune) bool { return r == ',' || r == ';' }) {
+			citation, ok := resolveCitation(strings.TrimSpace(ref), sources)
+			if !ok {
+				continue
+			}
+			key := fmt.Sprintf("%s|%d|%d", citation.JobID, citation.StartSegment, citation.EndSegment)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			citation.Supported = sharesWording(claim, citation.Text)
+			citations = append(citations, citation)
+		}
+	}
+	return citations
+}
+
+// resolveCitation maps one reference, e.g. "#2.12" or "@00:01:05-00:01:20",
+// to the segments it cites
+func resolveCitation(ref string, sources []chatSource) (models.ChatCitation, bool) {
+	var source, first, last int
+	var err error
+	switch {
+	case segmentRef.MatchString(ref):
+		m := segmentRef.FindStringSubmatch(ref)
+		source, _ = strconv.Atoi(m[1])
+		first, err = strconv.Atoi(m[2])
+		if err != nil {
+			return models.ChatCitation{}, false
+		}
+		last = first
+		if m[3] != "" {
+			if last, err = strconv.Atoi(m[3]); err != nil || last < first {
+				return models.ChatCitation{}, false
+			}
+		}
+	case timeRef.MatchString(ref):
+		m := timeRef.FindStringSubmatch(ref)
+		source, _ = strconv.Atoi(m[1])
+		src, ok := citedSource(source, sources)
+		if !ok {
+			return models.ChatCitation{}, false
+		}
+		from, to := parseClock(m[2]), parseClock(m[3])
+		if from < 0 || to < from {
+			return models.ChatCitation{}, false
+		}
+		// Context times are rounded to the second
+		first, last = -1, -1
+		for i, seg := range src.Segments {
+			if seg.End > from-0.5 && seg.Start < to+0.5 {
+				if first < 0 {
+					first = i
+				}
+				last = i
+			}
+		}
+		if first < 0 {
+			return models.ChatCitation{}, false
+		}
+	default:
+		return models.ChatCitation{}, false
+	}
+
+	src, ok := citedSource(source, sources)
+	if !ok || first < 0 || last >= len(src.Segments) {
+		return models.ChatCitation{}, false
+	}
+	texts := make([]string, 0, last-first+1)
+	for _, seg := range src.Segments[first : last+1] {
+		texts = append(texts, strings.TrimSpace(seg.Text))
+	}
+	return models.ChatCitation{
+		Marker:       ref,
+		JobID:        src.Job.ID,
+		StartSegment: first,
+		EndSegment:   last,
+		Start:        src.Segments[first].Start,
+		End:          src.Segments[last].End,
+		Text:         strings.Join(texts, " "),
+	}, true
+}
+
+// citedSource returns the source a reference points to. References without a
+// source number are only unambiguous in single-source context.
+func citedSource(number int, sources []chatSource) (chatSource, bool) {
+	if number == 0 && len(sources) == 1 {
+		number = 1
+	}
+	if number < 1 || number > len(sources) {
+		return chatSource{}, false
+	}
+	return sources[number-1], true
+}
+
+// parseClock parses "hh:mm:ss" or "mm:ss" into seconds, or returns -1
+func parseClock(s string) float64 {
+	var total int
+	for _, part := range strings.Split(s, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return -1
+		}
+		total = total*60 + n
+	}
+	return float64(total)
+}
+
+// citationClaim returns the sentence that precedes a marker
+func citationClaim(before string) string {
+	before = strings.TrimRight(citationMarker.ReplaceAllString(before, ""), " \t.!?")
+	if i := strings.LastIndexAny(before, ".!?\n"); i >= 0 {
+		before = before[i+1:]
+	}
+	return before
+}
+
+// sharesWording reports whether claim and text have a content word in common,
+// comparing the first five letters so inflections still match
+func sharesWording(claim, text string) bool {
+	words := make(map[string]bool)
+	for _, w := range contentWords(text) {
+		words[w] = true
+	}
+	for _, w := range contentWords(claim) {
+		if words[w] {
+			return true
+		}
+	}
+	return false
+}
+
+func contentWords(s string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		r := []rune(w)
+		if len(r) < 4 || citationStopwords[w] {
+			continue
+		}
+		if len(r) > 5 {
+			r = r[:5]
+		}
+		words = append(words, string(r))
+	}
+	return words
+}
+
+// writeCitations sends the citations of a streamed answer after the answer
+func writeCitations(c *gin.Context, citations []models.ChatCitation) {
+	if len(citations) == 0 {
+		return
+	}
+	data, err := json.Marshal(citations)
+	if err != nil {
+		return
+	}
+	_, _ = c.Writer.WriteString(chatCitationsDelimiter + string(data))
+	c.Writer.Flush()
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"testing"
+
+	"scriberr/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractCitations(t *testing.T) {
+	planning := chatSource{
+		Job: models.TranscriptionJob{ID: "planning"},
+		Segments: []Segment{
+			{Start: 0, End: 4.5, Text: "Welcome everyone to the planning call"},
+			{Start: 4.5, End: 9, Text: "The migration deadline is next Friday"},
+			{Start: 9, End: 12, Text: "We should migrate the billing service first"},
+		},
+	}
+	retro := chatSource{
+		Job:      models.TranscriptionJob{ID: "retro"},
+		Segments: []Segment{{Start: 0, End: 3, Text: "The launch went smoothly"}},
+	}
+
+	answer := "The migration is due next Friday [#1]. Billing migrates first [#2, #1]. " +
+		"The budget was approved [@00:00:01-00:00:02]. Nothing else [#7]."
+	citations := extractCitations(answer, []chatSource{planning})
+	require.Len(t, citations, 3)
+
+	assert.Equal(t, "#1", citations[0].Marker)
+	assert.Equal(t, "planning", citations[0].JobID)
+	assert.Equal(t, 4.5, citations[0].Start)
+	assert.Equal(t, 9.0, citations[0].End)
+	assert.True(t, citations[0].Supported)
+
+	assert.Equal(t, 2, citations[1].StartSegment)
+	assert.True(t, citations[1].Supported, "migrates and migrate share a stem")
+
+	// Time ranges resolve to the overlapping segments; segment 0 says nothing
+	// about the budget, and #7 does not exist
+	assert.Equal(t, 0, citations[2].StartSegment)
+	assert.Equal(t, 0, citations[2].EndSegment)
+	assert.False(t, citations[2].Supported)
+
+	// Ranges and source numbers in multi-source context
+	citations = extractCitations("Billing is first [#1.1-2] and the launch went fine [#2.0]. Unnumbered [#0].", []chatSource{planning, retro})
+	require.Len(t, citations, 2)
+	assert.Equal(t, 1, citations[0].StartSegment)
+	assert.Equal(t, 2, citations[0].EndSegment)
+	assert.Equal(t, "The migration deadline is next Friday We should migrate the billing service first", citations[0].Text)
+	assert.Equal(t, "retro", citations[1].JobID)
+	assert.True(t, citations[1].Supported)
+}
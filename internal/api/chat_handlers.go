@@ -2,15 +2,16 @@ package api
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"math"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"scriberr/internal/llm"
 	"scriberr/internal/models"
+	"scriberr/internal/translate"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -19,18 +20,31 @@ import (
 const (
 	StylePrompt = "\n\nINSTRUCTIONS: Return your answer as a raw markdown string. \n1. Use LaTeX for equations (e.g., $E=mc^2$). \n2. Do NOT use code block fences (```) around the entire response. \n3. Do NOT include any meta-comments (e.g., \"Here is the markdown...\"). \n4. Just provide the raw content."
 	RoleUser    = "user"
+
+	// RoleToolCall and RoleToolResult are the roles of the tool calls made in
+	// agent mode and their results
+	RoleToolCall   = "tool_call"
+	RoleToolResult = "tool_result"
 )
 
-// ChatCreateRequest represents a request to create a new chat session
+// ChatCreateRequest represents a request to create a new chat session. The
+// session is about transcription_id unless scope selects several jobs, a tag
+// or a saved search; transcription_id is then optional. Model is required
+// unless the chat route sets one.
 type ChatCreateRequest struct {
-	TranscriptionID string `json:"transcription_id" binding:"required"`
-	Model           string `json:"model" binding:"required"`
-	Title           string `json:"title,omitempty"`
+	TranscriptionID string            `json:"transcription_id"`
+	Model           string            `json:"model,omitempty"`
+	Title           string            `json:"title,omitempty"`
+	Scope           *models.ChatScope `json:"scope,omitempty"`
+	Language        string            `json:"language,omitempty"` // Chat about the translation tracks in this language where they exist
 }
 
 // ChatMessageRequest represents a request to send a message
 type ChatMessageRequest struct {
 	Content string `json:"content" binding:"required"`
+	// Agent lets the assistant call tools; the reply is then a JSON
+	// ChatAgentResponse instead of a text stream
+	Agent bool `json:"agent,omitempty"`
 }
 
 // ChatSessionResponse represents a chat session response
@@ -46,14 +60,41 @@ type ChatSessionResponse struct {
 	MessageCount    int                  `json:"message_count"`
 	LastActivityAt  *time.Time           `json:"last_activity_at,omitempty"`
 	LastMessage     *ChatMessageResponse `json:"last_message,omitempty"`
+	Scope           *models.ChatScope    `json:"scope,omitempty"`
+	Language        string               `json:"language,omitempty"`
 }
 
 // ChatMessageResponse represents a chat message response
 type ChatMessageResponse struct {
-	ID        uint      `json:"id"`
-	Role      string    `json:"role"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            uint                  `json:"id"`
+	Role          string                `json:"role"`
+	Content       string                `json:"content"`
+	Citations     []models.ChatCitation `json:"citations,omitempty"`
+	ToolCallID    *string               `json:"tool_call_id,omitempty"`
+	ToolName      *string               `json:"tool_name,omitempty"`
+	ToolArguments *string               `json:"tool_arguments,omitempty"`
+	ToolStatus    *string               `json:"tool_status,omitempty"`
+	CreatedAt     time.Time             `json:"created_at"`
+	// ParentID is the message this one follows. SiblingIDs lists the
+	// alternative branches at this message, itself included, when there are
+	// several.
+	ParentID   *uint  `json:"parent_id,omitempty"`
+	SiblingIDs []uint `json:"sibling_ids,omitempty"`
+}
+
+func newChatMessageResponse(msg *models.ChatMessage) ChatMessageResponse {
+	return ChatMessageResponse{
+		ID:            msg.ID,
+		Role:          msg.Role,
+		Content:       msg.Content,
+		Citations:     msg.Citations,
+		ToolCallID:    msg.ToolCallID,
+		ToolName:      msg.ToolName,
+		ToolArguments: msg.ToolArguments,
+		ToolStatus:    msg.ToolStatus,
+		CreatedAt:     msg.CreatedAt,
+		ParentID:      msg.ParentID,
+	}
 }
 
 // ChatModelsResponse represents the available chat models
@@ -78,29 +119,19 @@ type Segment struct {
 	Speaker string  `json:"speaker"`
 }
 
-// getLLMService returns a provider-agnostic LLM service based on active config
-func (h *Handler) getLLMService(ctx context.Context) (llm.Service, string, error) {
-	cfg, err := h.llmConfigRepo.GetActive(ctx)
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, "", fmt.Errorf("no active LLM configuration found")
-		}
-		return nil, "", fmt.Errorf("failed to get LLM config: %w", err)
-	}
-	switch strings.ToLower(cfg.Provider) {
-	case "openai":
-		if cfg.APIKey == nil || *cfg.APIKey == "" {
-			return nil, cfg.Provider, fmt.Errorf("OpenAI API key not configured")
-		}
-		return llm.NewOpenAIService(*cfg.APIKey, cfg.OpenAIBaseURL), cfg.Provider, nil
-	case "ollama":
-		if cfg.BaseURL == nil || *cfg.BaseURL == "" {
-			return nil, cfg.Provider, fmt.Errorf("Ollama base URL not configured")
+// llmRoute returns the LLM route of feature for the current user
+func (h *Handler) llmRoute(c *gin.Context, feature string) (*llm.Route, error) {
+	return h.llmRouter.Resolve(c.Request.Context(), feature, currentUserID(c))
+}
+
+// currentUserID returns the authenticated user's ID, or nil
+func currentUserID(c *gin.Context) *uint {
+	if v, exists := c.Get("user_id"); exists {
+		if id, ok := v.(uint); ok {
+			return &id
 		}
-		return llm.NewOllamaService(*cfg.BaseURL), cfg.Provider, nil
-	default:
-		return nil, cfg.Provider, fmt.Errorf("unsupported LLM provider: %s", cfg.Provider)
 	}
+	return nil
 }
 
 // @Summary Get available chat models
@@ -114,7 +145,7 @@ func (h *Handler) getLLMService(ctx context.Context) (llm.Service, string, error
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) GetChatModels(c *gin.Context) {
-	svc, _, err := h.getLLMService(c.Request.Context())
+	route, err := h.llmRoute(c, models.LLMFeatureChat)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -123,7 +154,7 @@ func (h *Handler) GetChatModels(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	models, err := svc.GetModels(ctx)
+	models, err := route.Primary().Service.GetModels(ctx)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch models: " + err.Error()})
 		return
@@ -152,6 +183,35 @@ func (h *Handler) CreateChatSession(c *gin.Context) {
 		return
 	}
 
+	scope := models.ChatScope{Type: models.ChatScopeJob}
+	if req.Scope != nil {
+		normalized, err := normalizeChatScope(*req.Scope)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		scope = normalized
+	}
+	if req.TranscriptionID == "" {
+		if scope.Type != models.ChatScopeJobs {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "transcription_id is required"})
+			return
+		}
+		req.TranscriptionID = scope.JobIDs[0]
+	}
+	if scope.Type == models.ChatScopeJobs && !slices.Contains(scope.JobIDs, req.TranscriptionID) {
+		scope.JobIDs = append([]string{req.TranscriptionID}, scope.JobIDs...)
+	}
+
+	if req.Language != "" {
+		language, ok := translate.NormalizeLanguage(req.Language)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language code"})
+			return
+		}
+		req.Language = language
+	}
+
 	// Verify transcription exists and user has access
 	transcription, err := h.checkJobOwnership(c, req.TranscriptionID)
 	if err != nil {
@@ -163,12 +223,35 @@ func (h *Handler) CreateChatSession(c *gin.Context) {
 		return
 	}
 
-	// Verify LLM service is available
-	_, _, err = h.getLLMService(c.Request.Context())
+	// Verify every job in the scope is accessible and that it has something to chat about
+	if scope.IsMultiJob() {
+		jobs, ok := h.resolveChatScope(c, scope, transcription)
+		if !ok {
+			return
+		}
+		completed := 0
+		for _, job := range jobs {
+			if job.Status == models.StatusCompleted && job.Transcript != nil {
+				completed++
+			}
+		}
+		if completed == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No completed transcriptions match the chat scope"})
+			return
+		}
+	}
+
+	// Verify LLM service is available and pick the session's model
+	route, err := h.llmRoute(c, models.LLMFeatureChat)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	primary := route.WithModel(req.Model).Primary()
+	if primary.Model == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "model is required"})
+		return
+	}
 
 	// Create chat session
 	title := req.Title
@@ -181,11 +264,13 @@ func (h *Handler) CreateChatSession(c *gin.Context) {
 		JobID:           req.TranscriptionID, // Use same ID for JobID as TranscriptionID
 		TranscriptionID: req.TranscriptionID,
 		Title:           title,
-		Model:           req.Model,
-		Provider:        "openai",
+		Model:           primary.Model,
+		Provider:        primary.Config.Provider,
 		MessageCount:    0,
 		LastActivityAt:  &now,
 		IsActive:        true,
+		Scope:           scope,
+		Language:        req.Language,
 	}
 
 	if err := h.chatRepo.Create(c.Request.Context(), chatSession); err != nil {
@@ -204,6 +289,8 @@ func (h *Handler) CreateChatSession(c *gin.Context) {
 		UpdatedAt:       chatSession.UpdatedAt,
 		MessageCount:    chatSession.MessageCount,
 		LastActivityAt:  chatSession.LastActivityAt,
+		Scope:           scopeResponse(chatSession.Scope),
+		Language:        chatSession.Language,
 	}
 
 	c.JSON(http.StatusCreated, response)
@@ -252,12 +339,8 @@ func (h *Handler) GetChatSessions(c *gin.Context) {
 	// Create last message response lookup map
 	lastMessageMap := make(map[string]*ChatMessageResponse)
 	for sessionID, msg := range lastMsgsMap {
-		lastMessageMap[sessionID] = &ChatMessageResponse{
-			ID:        msg.ID,
-			Role:      msg.Role,
-			Content:   msg.Content,
-			CreatedAt: msg.CreatedAt,
-		}
+		response := newChatMessageResponse(msg)
+		lastMessageMap[sessionID] = &response
 	}
 
 	var responses []ChatSessionResponse
@@ -274,6 +357,8 @@ func (h *Handler) GetChatSessions(c *gin.Context) {
 			MessageCount:    int(messageCountMap[session.ID]), // Use batch-loaded count
 			LastActivityAt:  session.LastActivityAt,
 			LastMessage:     lastMessageMap[session.ID], // Use batch-loaded last message
+			Scope:           scopeResponse(session.Scope),
+			Language:        session.Language,
 		})
 	}
 
@@ -281,7 +366,7 @@ func (h *Handler) GetChatSessions(c *gin.Context) {
 }
 
 // @Summary Get a chat session with messages
-// @Description Get a specific chat session with all its messages
+// @Description Get a specific chat session with the messages of its active branch
 // @Tags chat
 // @Produce json
 // @Param session_id path string true "Chat Session ID"
@@ -298,7 +383,7 @@ func (h *Handler) GetChatSession(c *gin.Context) {
 		return
 	}
 
-	session, err := h.chatRepo.GetSessionWithMessages(c.Request.Context(), sessionID)
+	session, err := h.chatRepo.GetSessionWithTranscription(c.Request.Context(), sessionID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Chat session not found"})
@@ -313,15 +398,12 @@ func (h *Handler) GetChatSession(c *gin.Context) {
 		return
 	}
 
-	var messageResponses []ChatMessageResponse
-	for _, msg := range session.Messages {
-		messageResponses = append(messageResponses, ChatMessageResponse{
-			ID:        msg.ID,
-			Role:      msg.Role,
-			Content:   msg.Content,
-			CreatedAt: msg.CreatedAt,
-		})
+	tree, err := h.chatRepo.GetMessageTree(c.Request.Context(), session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get chat messages"})
+		return
 	}
+	messageResponses := branchMessageResponses(tree, chatPath(tree, session.ActiveLeafID))
 
 	response := ChatSessionWithMessages{
 		ChatSessionResponse: ChatSessionResponse{
@@ -335,6 +417,8 @@ func (h *Handler) GetChatSession(c *gin.Context) {
 			UpdatedAt:       session.UpdatedAt,
 			MessageCount:    len(messageResponses),
 			LastActivityAt:  session.LastActivityAt,
+			Scope:           scopeResponse(session.Scope),
+			Language:        session.Language,
 		},
 		Messages: messageResponses,
 	}
@@ -354,7 +438,7 @@ func formatTime(seconds float64) string {
 }
 
 // @Summary Send a message to a chat session
-// @Description Send a message to a chat session and get streaming response
+// @Description Send a message to a chat session and get streaming response. Answers cite transcript segments with markers such as [#12]; once the answer is complete, the resolved citations follow as a JSON array after a record separator (\x1e). With agent set, the model may call transcript tools instead and the reply is a ChatAgentResponse in JSON; mutating tool calls wait for confirmation.
 // @Tags chat
 // @Accept json
 // @Produce text/plain
@@ -393,27 +477,37 @@ func (h *Handler) SendChatMessage(c *gin.Context) {
 		return
 	}
 
-	// Ensure user has access to the transcription this chat belongs to
-	if _, err := h.checkJobOwnership(c, session.TranscriptionID); err != nil {
+	// Ensure user has access to the transcription this chat belongs to and to
+	// every other job in its scope
+	anchor, err := h.checkJobOwnership(c, session.TranscriptionID)
+	if err != nil {
+		return
+	}
+	scopeJobs, ok := h.resolveChatScope(c, session.Scope, anchor)
+	if !ok {
 		return
 	}
 
-	// Get LLM service
-	svc, _, err := h.getLLMService(c.Request.Context())
+	// Get LLM route; the session's model serves targets without one
+	route, err := h.llmRoute(c, models.LLMFeatureChat)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	route = route.WithModel(session.Model).ForJob(session.TranscriptionID)
 
-	// Save user message
+	// Tool calls left unconfirmed are declined by moving on
+	if history, err := h.chatHistory(c.Request.Context(), session); err == nil {
+		h.cancelPendingToolCalls(c.Request.Context(), session, history)
+	}
+
+	// Save user message at the end of the active branch
 	userMessage := &models.ChatMessage{
-		SessionID:     sessionID,
-		ChatSessionID: sessionID,
-		Role:          RoleUser,
-		Content:       req.Content,
+		Role:    RoleUser,
+		Content: req.Content,
 	}
 
-	if err := h.chatRepo.AddMessage(c.Request.Context(), userMessage); err != nil {
+	if err := h.chatRepo.AppendMessage(c.Request.Context(), session, userMessage); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save message"})
 		return
 	}
@@ -435,142 +529,27 @@ func (h *Handler) SendChatMessage(c *gin.Context) {
 		}
 	}
 
-	// Get context window
-	contextWindow, err := svc.GetContextWindow(c.Request.Context(), session.Model)
+	history, err := h.chatHistory(c.Request.Context(), session)
 	if err != nil {
-		fmt.Printf("Failed to get context window for model %s: %v. Using default 4096.\n", session.Model, err)
-		contextWindow = 4096
-	}
-
-	// Build OpenAI messages including transcript context
-	var openaiMessages []llm.ChatMessage
-	var currentTokenCount int
-	var transcriptContext string
-
-	// Fallback: If transcript wasn't loaded via Preload, fetch it directly from the job repository
-	if session.Transcription.Transcript == nil || *session.Transcription.Transcript == "" {
-		fmt.Printf("Debug: Transcript not loaded via Preload for session %s (TranscriptionID: %s), fetching directly...\n", sessionID, session.TranscriptionID)
-		job, jobErr := h.jobRepo.FindByID(c.Request.Context(), session.TranscriptionID)
-		if jobErr == nil && job != nil && job.Transcript != nil && *job.Transcript != "" {
-			session.Transcription.Transcript = job.Transcript
-			fmt.Printf("Debug: Direct fetch succeeded, transcript length: %d\n", len(*job.Transcript))
-		} else {
-			fmt.Printf("Debug: Direct fetch failed or transcript empty. Error: %v\n", jobErr)
-		}
-	}
-
-	// Add system message with transcript context
-	if session.Transcription.Transcript != nil && *session.Transcription.Transcript != "" {
-		transcript := *session.Transcription.Transcript
-		fmt.Printf("Debug: Transcript found for session %s. Length: %d\n", sessionID, len(transcript))
-
-		// Parse transcript json segments and build string with format: [SPEAKER_01] [00:00:17 - 00:00:19] Nej, det var tråkigt att höra.
-		var t Transcript
-		if err := json.Unmarshal([]byte(transcript), &t); err != nil {
-			fmt.Printf("Error parsing transcript JSON for session %s: %v\n", sessionID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse transcript data"})
-			return
-		}
-
-		fmt.Printf("Debug: Parsed %d segments from transcript\n", len(t.Segments))
-
-		var sb strings.Builder
-
-		// Get speaker mappings
-		mappings, err := h.speakerMappingRepo.ListByJob(c.Request.Context(), session.TranscriptionID)
-		speakerMap := make(map[string]string)
-		if err == nil {
-			for _, m := range mappings {
-				speakerMap[m.OriginalSpeaker] = m.CustomName
-			}
-		} else {
-			fmt.Printf("Failed to get speaker mappings for job %s: %v\n", session.TranscriptionID, err)
-		}
-
-		for _, seg := range t.Segments {
-			start := formatTime(seg.Start)
-			end := formatTime(seg.End)
-
-			speakerName := seg.Speaker
-			if customName, ok := speakerMap[speakerName]; ok {
-				speakerName = customName
-			}
-
-			fmt.Fprintf(&sb, "[%s] [%s - %s] %s\n",
-				speakerName,
-				start,
-				end,
-				strings.TrimSpace(seg.Text),
-			)
-		}
-
-		cleanTranscript := sb.String()
-		fmt.Printf("Debug: Clean transcript length: %d\n", len(cleanTranscript))
-
-		// Build transcript context - will be prepended to first user message for better model compatibility
-		transcriptContext = fmt.Sprintf("You are analyzing the following transcript. Use this transcript to answer questions:\n\n---TRANSCRIPT START---\n%s\n---TRANSCRIPT END---\n\n", cleanTranscript)
-
-		fmt.Printf("Injecting transcript of length %d into chat context for session %s\n", len(transcriptContext), sessionID)
-
-		// Check if transcript itself exceeds context (leaving some room for response)
-		// Estimate 1 token ~= 4 chars
-		transcriptTokens := len(transcriptContext) / 4
-		if transcriptTokens > contextWindow-500 { // Leave 500 tokens for response/history
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Transcript is too long for this model's context window (estimated %d tokens, limit %d). Please use a model with a larger context window.", transcriptTokens, contextWindow)})
-			return
-		}
-		currentTokenCount += transcriptTokens
-	} else {
-		fmt.Printf("Warning: Transcript is nil or empty for chat session %s. Transcription ID: %s\n", sessionID, session.TranscriptionID)
-		if session.Transcription.ID == "" {
-			fmt.Println("Warning: Session Transcription relation seems missing or empty")
-		}
-	}
-
-	// Add conversation history with transcript context prepended to first user message
-	for i, msg := range messages {
-		msgContent := msg.Content
-		// Prepend transcript context to the first user message for better model compatibility
-		// (Some models like Qwen3 don't properly handle system messages)
-		if i == 0 && msg.Role == RoleUser && transcriptContext != "" {
-			msgContent = transcriptContext + "User question: " + msg.Content
-			fmt.Printf("Debug: Prepended transcript to first user message\n")
-		}
-		msgTokens := len(msgContent) / 4
-
-		// Inject style prompt for user messages (in-memory only, not saved to DB)
-		finalContent := msgContent
-		if msg.Role == RoleUser {
-			finalContent += StylePrompt
-		}
-
-		openaiMessages = append(openaiMessages, llm.ChatMessage{
-			Role:    msg.Role,
-			Content: finalContent,
-		})
-		currentTokenCount += msgTokens
-	}
-
-	// Intelligent context trimming: if context exceeds limit, remove oldest messages
-	// Keep the first message (with transcript context) and trim from the middle
-	trimmedCount := 0
-	for currentTokenCount > contextWindow && len(openaiMessages) > 2 {
-		// Remove the second message (oldest after the context-bearing first message)
-		removed := openaiMessages[1]
-		removedTokens := len(removed.Content) / 4
-		openaiMessages = append(openaiMessages[:1], openaiMessages[2:]...)
-		currentTokenCount -= removedTokens
-		trimmedCount++
-		fmt.Printf("Debug: Trimmed message to fit context. Removed %d tokens, new count: %d/%d\n", removedTokens, currentTokenCount, contextWindow)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load chat history"})
+		return
 	}
+	h.replyInChat(c, session, route, scopeJobs, history, req.Content, req.Agent, []ChatMessageResponse{newChatMessageResponse(userMessage)}, 1)
+}
 
-	if trimmedCount > 0 {
-		fmt.Printf("Debug: Trimmed %d messages to fit context window\n", trimmedCount)
+// replyInChat answers the last message of history: streamed as text, or as a
+// ChatAgentResponse in agent mode. The reply follows that message and its
+// branch becomes the active one once the reply is saved. created are the
+// messages the request already touched, of which added were new; the
+// streamed reply counts one more.
+func (h *Handler) replyInChat(c *gin.Context, session *models.ChatSession, route *llm.Route, scopeJobs []models.TranscriptionJob, history []models.ChatMessage, question string, agent bool, created []ChatMessageResponse, added int) {
+	// Build the conversation for the model, with transcript context
+	turn, ok := h.buildChatTurn(c, session, route, scopeJobs, history, question, agent)
+	if !ok {
+		return
 	}
-
-	// Final check - if still over limit after trimming all possible messages, return error
-	if currentTokenCount > contextWindow {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Transcript alone exceeds model context limit (%d tokens > %d). Please use a model with larger context window.", currentTokenCount, contextWindow)})
+	if agent {
+		h.runChatAgent(c, session, turn, created, added)
 		return
 	}
 
@@ -599,41 +578,53 @@ func (h *Handler) SendChatMessage(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", allowOrigin)
 		c.Header("Access-Control-Allow-Credentials", "true")
 	}
-	c.Header("Access-Control-Expose-Headers", "X-Context-Used, X-Context-Limit, X-Messages-Trimmed")
-	c.Header("X-Context-Used", fmt.Sprintf("%d", currentTokenCount))
-	c.Header("X-Context-Limit", fmt.Sprintf("%d", contextWindow))
-	c.Header("X-Messages-Trimmed", fmt.Sprintf("%d", trimmedCount))
+	c.Header("Access-Control-Expose-Headers", "X-Context-Used, X-Context-Limit, X-Messages-Trimmed, X-Context-Mode")
+	c.Header("X-Context-Used", fmt.Sprintf("%d", turn.tokens))
+	c.Header("X-Context-Limit", fmt.Sprintf("%d", turn.contextWindow))
+	c.Header("X-Messages-Trimmed", fmt.Sprintf("%d", turn.trimmed))
+	c.Header("X-Context-Mode", turn.contextMode)
 	c.Status(http.StatusOK) // Start the response immediately
 
 	// Stream the response
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Minute)
 	defer cancel()
 
-	// Use model defaults: do not set temperature explicitly
-	contentChan, errorChan := svc.ChatCompletionStream(ctx, session.Model, openaiMessages, 0.0)
+	// Use model defaults: do not set temperature explicitly. The usage is
+	// reported before the stream closes.
+	tokensUsed := 0
+	ctx = llm.WithUsageReporter(ctx, func(u llm.Usage) { tokensUsed += u.PromptTokens + u.CompletionTokens })
+	contentChan, errorChan := route.ChatCompletionStream(ctx, turn.messages, 0.0)
 
 	var assistantResponse strings.Builder
+	saveResponse := func() {
+		if assistantResponse.Len() == 0 {
+			return
+		}
+		// Resolve the answer's citations, send them after it and store them with it
+		citations := extractCitations(assistantResponse.String(), turn.sources)
+		writeCitations(c, citations)
+
+		assistantMessage := &models.ChatMessage{
+			Role:       "assistant",
+			Content:    assistantResponse.String(),
+			Citations:  citations,
+			TokensUsed: intPtrIfPositive(tokensUsed),
+		}
+		_ = h.saveTurnMessage(context.Background(), session, turn, assistantMessage)
+
+		// Update session updated_at, message count, and last activity
+		now := time.Now()
+		session.UpdatedAt = now
+		session.LastActivityAt = &now
+		session.MessageCount += added + 1 // +1 for the assistant message
+		_ = h.chatRepo.Update(context.Background(), session)
+	}
 	for {
 		select {
 		case content, ok := <-contentChan:
 			if !ok {
 				// Channel closed, save complete response and return
-				if assistantResponse.Len() > 0 {
-					assistantMessage := &models.ChatMessage{
-						SessionID:     sessionID,
-						ChatSessionID: sessionID,
-						Role:          "assistant",
-						Content:       assistantResponse.String(),
-					}
-					_ = h.chatRepo.AddMessage(context.Background(), assistantMessage)
-
-					// Update session updated_at, message count, and last activity
-					now := time.Now()
-					session.UpdatedAt = now
-					session.LastActivityAt = &now
-					session.MessageCount += 2 // +2 for user + assistant message
-					_ = h.chatRepo.Update(context.Background(), session)
-				}
+				saveResponse()
 				return
 			}
 
@@ -644,40 +635,7 @@ func (h *Handler) SendChatMessage(c *gin.Context) {
 
 		case err := <-errorChan:
 			if err != nil {
-				// If streaming is not supported for this model/org, fall back to non-streaming
-				errStr := err.Error()
-				if strings.Contains(errStr, "\"param\": \"stream\"") || strings.Contains(errStr, "unsupported_value") || strings.Contains(errStr, "must be verified to stream") {
-					resp, err2 := svc.ChatCompletion(ctx, session.Model, openaiMessages, 0.0)
-					if err2 != nil || resp == nil || len(resp.Choices) == 0 {
-						_, _ = c.Writer.WriteString("\nError: " + err2.Error())
-						c.Writer.Flush()
-						return
-					}
-					content := resp.Choices[0].Message.Content
-					_, _ = c.Writer.WriteString(content)
-					c.Writer.Flush()
-					assistantResponse.WriteString(content)
-
-					if assistantResponse.Len() > 0 {
-						assistantMessage := &models.ChatMessage{
-							SessionID:     sessionID,
-							ChatSessionID: sessionID,
-							Role:          "assistant",
-							Content:       assistantResponse.String(),
-						}
-						_ = h.chatRepo.AddMessage(context.Background(), assistantMessage)
-
-						// Update session updated_at, message count, and last activity
-						now := time.Now()
-						session.UpdatedAt = now
-						session.LastActivityAt = &now
-						session.MessageCount += 2 // +2 for user + assistant message
-						_ = h.chatRepo.Update(context.Background(), session)
-					}
-					return
-				}
-
-				// Otherwise, return the error to the client
+				// Every target failed; return the error to the client
 				_, _ = c.Writer.WriteString("\nError: " + err.Error())
 				c.Writer.Flush()
 				return
@@ -691,6 +649,165 @@ func (h *Handler) SendChatMessage(c *gin.Context) {
 	}
 }
 
+// chatTurn is the conversation sent to the model for one chat turn
+type chatTurn struct {
+	route         *llm.Route
+	after         *uint // The message the next message of the reply follows
+	sources       []chatSource
+	messages      []llm.ChatMessage
+	tokens        int
+	contextWindow int
+	contextMode   string // "retrieval" when only relevant excerpts of the transcripts fit
+	trimmed       int
+}
+
+// saveTurnMessage saves a message of the reply to turn after the previous
+// one, or after the question for the first, making its branch the active one
+func (h *Handler) saveTurnMessage(ctx context.Context, session *models.ChatSession, turn *chatTurn, msg *models.ChatMessage) error {
+	if turn.after == nil {
+		return h.chatRepo.AppendMessage(ctx, session, msg)
+	}
+	if err := h.chatRepo.AddBranchMessage(ctx, session, msg, turn.after); err != nil {
+		return err
+	}
+	id := msg.ID
+	turn.after = &id
+	return nil
+}
+
+// buildChatTurn builds the model conversation from the session history with
+// the transcripts of scopeJobs as context, trimmed to the model's context
+// window. Tool calls and results are only included for agent turns. On
+// failure the error response has been written.
+func (h *Handler) buildChatTurn(c *gin.Context, session *models.ChatSession, route *llm.Route, scopeJobs []models.TranscriptionJob, history []models.ChatMessage, question string, agent bool) (*chatTurn, bool) {
+	sessionID := session.ID
+	turn := &chatTurn{route: route, contextMode: "full"}
+	if len(history) > 0 {
+		last := history[len(history)-1].ID
+		turn.after = &last
+	}
+
+	// Get context window of the primary model
+	contextWindow := route.ContextWindow(c.Request.Context())
+	turn.contextWindow = contextWindow
+
+	// Add transcript context from every job in the session's scope
+	var transcriptContext string
+	var err error
+	turn.sources, err = h.loadChatSources(c.Request.Context(), scopeJobs, session.Language)
+	if err != nil {
+		fmt.Printf("Error parsing transcript JSON for session %s: %v\n", sessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse transcript data"})
+		return nil, false
+	}
+	if len(turn.sources) > 0 {
+		transcriptContext, turn.contextMode, err = h.buildChatContext(c.Request.Context(), turn.sources, question, contextWindow)
+		if err != nil {
+			fmt.Printf("Transcript retrieval failed for session %s: %v\n", sessionID, err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		fmt.Printf("Injecting %d transcript(s) of length %d into chat context for session %s (%s)\n", len(turn.sources), len(transcriptContext), sessionID, turn.contextMode)
+		turn.tokens += len(transcriptContext) / 4
+	} else {
+		fmt.Printf("Warning: Transcript is nil or empty for chat session %s. Transcription ID: %s\n", sessionID, session.TranscriptionID)
+	}
+
+	// Prepend transcript context to the first user message for better model compatibility
+	// (Some models like Qwen3 don't properly handle system messages). Retrieved
+	// excerpts belong to the current question, so they go on the latest one.
+	contextIndex := -1
+	for i, msg := range history {
+		if msg.Role == RoleUser && (contextIndex < 0 || turn.contextMode == "retrieval") {
+			contextIndex = i
+		}
+	}
+
+	// Add conversation history with transcript context
+	for i, msg := range history {
+		switch msg.Role {
+		case RoleToolCall:
+			if !agent || msg.ToolCallID == nil || msg.ToolName == nil {
+				continue
+			}
+			call := llm.ToolCall{ID: *msg.ToolCallID, Type: "function", Function: llm.ToolCallFunction{Name: *msg.ToolName, Arguments: "{}"}}
+			if msg.ToolArguments != nil {
+				call.Function.Arguments = *msg.ToolArguments
+			}
+			// Calls made in one step share an assistant message
+			if n := len(turn.messages); n > 0 && len(turn.messages[n-1].ToolCalls) > 0 {
+				turn.messages[n-1].ToolCalls = append(turn.messages[n-1].ToolCalls, call)
+			} else {
+				turn.messages = append(turn.messages, llm.ChatMessage{Role: "assistant", ToolCalls: []llm.ToolCall{call}})
+			}
+			turn.tokens += len(call.Function.Arguments)/4 + 10
+			continue
+		case RoleToolResult:
+			if !agent || msg.ToolCallID == nil {
+				continue
+			}
+			turn.messages = append(turn.messages, llm.ChatMessage{Role: "tool", ToolCallID: *msg.ToolCallID, Content: msg.Content})
+			turn.tokens += len(msg.Content) / 4
+			continue
+		}
+
+		msgContent := msg.Content
+		if i == contextIndex && transcriptContext != "" {
+			msgContent = transcriptContext + "User question: " + msg.Content
+		}
+		msgTokens := len(msgContent) / 4
+
+		// Inject style prompt for user messages (in-memory only, not saved to DB)
+		finalContent := msgContent
+		if msg.Role == RoleUser {
+			finalContent += StylePrompt
+		}
+
+		turn.messages = append(turn.messages, llm.ChatMessage{
+			Role:    msg.Role,
+			Content: finalContent,
+		})
+		turn.tokens += msgTokens
+	}
+
+	// Intelligent context trimming: if context exceeds limit, remove oldest messages
+	// Keep the first message (with transcript context) and trim from the middle.
+	// Retrieved excerpts sit on the latest message, so then trim from the start.
+	for turn.tokens > contextWindow && len(turn.messages) > 2 {
+		// Remove the second message (oldest after the context-bearing first message)
+		drop := 1
+		if turn.contextMode == "retrieval" {
+			drop = 0
+		}
+		// Tool results cannot outlive the call they answer
+		end := drop + 1
+		if len(turn.messages[drop].ToolCalls) > 0 {
+			for end < len(turn.messages)-1 && turn.messages[end].Role == "tool" {
+				end++
+			}
+		}
+		removedTokens := 0
+		for _, removed := range turn.messages[drop:end] {
+			removedTokens += len(removed.Content) / 4
+		}
+		turn.messages = append(turn.messages[:drop], turn.messages[end:]...)
+		turn.tokens -= removedTokens
+		turn.trimmed += end - drop
+		fmt.Printf("Debug: Trimmed message to fit context. Removed %d tokens, new count: %d/%d\n", removedTokens, turn.tokens, contextWindow)
+	}
+
+	if turn.trimmed > 0 {
+		fmt.Printf("Debug: Trimmed %d messages to fit context window\n", turn.trimmed)
+	}
+
+	// Final check - if still over limit after trimming all possible messages, return error
+	if turn.tokens > contextWindow {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Transcript alone exceeds model context limit (%d tokens > %d). Please use a model with larger context window.", turn.tokens, contextWindow)})
+		return nil, false
+	}
+	return turn, true
+}
+
 // @Summary Update chat session title
 // @Description Update the title of a chat session
 // @Tags chat
@@ -870,13 +987,13 @@ func (h *Handler) AutoGenerateChatTitle(c *gin.Context) {
 		return
 	}
 
-	svc, _, err := h.getLLMService(c.Request.Context())
+	route, err := h.llmRoute(c, models.LLMFeatureChatTitle)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	title, err := h.generateTitleFromLLM(c.Request.Context(), svc, session.Model, recentMsgs)
+	title, err := h.generateTitleFromLLM(c.Request.Context(), route.WithModel(session.Model).ForJob(session.TranscriptionID), recentMsgs)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate title"})
 		return
@@ -922,7 +1039,7 @@ func (h *Handler) isDefaultTitle(ctx context.Context, session *models.ChatSessio
 	return false, nil
 }
 
-func (h *Handler) generateTitleFromLLM(ctx context.Context, svc llm.Service, model string, msgs []models.ChatMessage) (string, error) {
+func (h *Handler) generateTitleFromLLM(ctx context.Context, route *llm.Route, msgs []models.ChatMessage) (string, error) {
 	prompt := `You are an expert at creating concise, meaningful titles for conversations. Based on the conversation below, generate a short, descriptive title (3-8 words) that captures the main topic or purpose.
 
 Guidelines:
@@ -963,7 +1080,7 @@ Return only the title, nothing else.`
 	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	resp, err := svc.ChatCompletion(timeoutCtx, model, chatMsgs, 0.0)
+	resp, err := route.ChatCompletion(timeoutCtx, chatMsgs, 0.0)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"scriberr/internal/retrieval"
+)
+
+// retrievalTopK is the number of chunks retrieved per transcript and chat
+// turn before they are cut down to the context budget
+const retrievalTopK = 12
+
+// SetRetrievalService sets the service used to retrieve relevant transcript
+// excerpts when transcripts do not fit the chat model's context
+func (h *Handler) SetRetrievalService(s *retrieval.Service) {
+	h.retriever = s
+}
+
+// retrieveTranscriptContext builds a context block from the excerpts of the
+// sources most relevant to question, within maxTokens (estimated at 4 chars
+// per token). Excerpts are rendered in transcript order with speaker names
+// and timestamps, like the full-transcript context.
+func (h *Handler) retrieveTranscriptContext(ctx context.Context, sources []chatSource, question string, maxTokens int) (string, error) {
+	if h.retriever == nil {
+		return "", fmt.Errorf("transcript retrieval is not available")
+	}
+
+	type sourceHit struct {
+		source int
+		hit    retrieval.Hit
+	}
+	var hits []sourceHit
+	for i := range sources {
+		found, err := h.retriever.Search(ctx, &sources[i].Job, question, retrievalTopK)
+		if err != nil {
+			return "", err
+		}
+		for _, hit := range found {
+			hits = append(hits, sourceHit{source: i, hit: hit})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].hit.Score > hits[j].hit.Score })
+
+	// Take the best chunks that fit, then restore transcript order
+	selected := make([]map[int]bool, len(sources))
+	for i := range selected {
+		selected[i] = make(map[int]bool)
+	}
+	budget := maxTokens * 4
+	found := false
+	for _, sh := range hits {
+		segments, chosen := sources[sh.source].Segments, selected[sh.source]
+		end := min(sh.hit.Chunk.EndSegment, len(segments))
+		size := 0
+		for i := sh.hit.Chunk.StartSegment; i < end; i++ {
+			if !chosen[i] {
+				size += len(segments[i].Text) + 40
+			}
+		}
+		if size > budget {
+			continue
+		}
+		budget -= size
+		for i := sh.hit.Chunk.StartSegment; i < end; i++ {
+			chosen[i] = true
+			found = true
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("no relevant transcript excerpts found")
+	}
+
+	if len(sources) == 1 {
+		return fmt.Sprintf("You are analyzing a transcript that is too long to include in full. These are the excerpts most relevant to the question, in transcript order; \"...\" marks skipped parts. Use them to answer, and say so if they do not contain the answer. %s\n\n---TRANSCRIPT EXCERPTS START---\n%s---TRANSCRIPT EXCERPTS END---\n\n", citationInstructions(false), sources[0].render(0, selected[0])), nil
+	}
+
+	var sections []string
+	for i, src := range sources {
+		if len(selected[i]) > 0 {
+			sections = append(sections, src.section(i+1, src.render(i+1, selected[i])))
+		}
+	}
+	return multiSourcePrompt(len(sources), "These are the excerpts most relevant to the question; \"...\" marks skipped parts, and recordings without relevant excerpts are left out. Say so if they do not contain the answer.", sections), nil
+}
@@ -0,0 +1,248 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultChatScopeLimit is the number of jobs tag and search scopes use
+	// when the session does not set a limit
+	defaultChatScopeLimit = 5
+	// maxChatScopeJobs caps the number of transcripts a session draws from
+	maxChatScopeJobs = 20
+)
+
+// chatSource is a transcript in a chat session's scope
+type chatSource struct {
+	Job      models.TranscriptionJob
+	Segments []Segment
+	Speakers map[string]string
+}
+
+// normalizeChatScope validates a requested scope and fills in defaults
+func normalizeChatScope(scope models.ChatScope) (models.ChatScope, error) {
+	if scope.Type == "" {
+		scope.Type = models.ChatScopeJob
+	}
+	if scope.Limit <= 0 {
+		scope.Limit = defaultChatScopeLimit
+	}
+	scope.Limit = min(scope.Limit, maxChatScopeJobs)
+
+	switch scope.Type {
+	case models.ChatScopeJob:
+		return models.ChatScope{Type: models.ChatScopeJob}, nil
+	case models.ChatScopeJobs:
+		seen := make(map[string]bool)
+		var ids []string
+		for _, id := range scope.JobIDs {
+			id = strings.TrimSpace(id)
+			if id != "" && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 {
+			return scope, fmt.Errorf("job_ids is required for a jobs scope")
+		}
+		if len(ids) > maxChatScopeJobs {
+			return scope, fmt.Errorf("a chat session can include at most %d jobs", maxChatScopeJobs)
+		}
+		return models.ChatScope{Type: scope.Type, JobIDs: ids}, nil
+	case models.ChatScopeTag:
+		scope.Tag = strings.TrimSpace(scope.Tag)
+		if scope.Tag == "" {
+			return scope, fmt.Errorf("tag is required for a tag scope")
+		}
+		return models.ChatScope{Type: scope.Type, Tag: scope.Tag, Limit: scope.Limit}, nil
+	case models.ChatScopeSearch:
+		scope.Query = strings.TrimSpace(scope.Query)
+		if scope.Query == "" {
+			return scope, fmt.Errorf("query is required for a search scope")
+		}
+		return models.ChatScope{Type: scope.Type, Query: scope.Query, Limit: scope.Limit}, nil
+	default:
+		return scope, fmt.Errorf("unsupported scope type: %s", scope.Type)
+	}
+}
+
+// resolveChatScope returns the jobs in a session's scope, oldest first. The
+// anchor job is already access-checked; explicit jobs go through
+// checkJobOwnership, and tag and search scopes only match jobs of the anchor
+// job's owner. On failure the error response has been written.
+func (h *Handler) resolveChatScope(c *gin.Context, scope models.ChatScope, anchor *models.TranscriptionJob) ([]models.TranscriptionJob, bool) {
+	ctx := c.Request.Context()
+	ownerID := c.GetUint("user_id")
+	if anchor.UserID != nil {
+		ownerID = *anchor.UserID
+	}
+	limit := scope.Limit
+	if limit <= 0 {
+		limit = defaultChatScopeLimit
+	}
+
+	var jobs []models.TranscriptionJob
+	switch scope.Type {
+	case models.ChatScopeJobs:
+		for _, id := range scope.JobIDs {
+			job, err := h.checkJobOwnership(c, id)
+			if err != nil {
+				return nil, false
+			}
+			jobs = append(jobs, *job)
+		}
+	case models.ChatScopeTag:
+		found, err := h.jobRepo.ListByTag(ctx, ownerID, scope.Tag, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve chat scope"})
+			return nil, false
+		}
+		jobs = found
+	case models.ChatScopeSearch:
+		ids, err := h.jobRepo.SearchJobIDs(ctx, repository.SegmentSearchParams{UserID: ownerID, Query: scope.Query, Limit: limit})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search query in chat scope"})
+			return nil, false
+		}
+		for _, id := range ids {
+			if job, err := h.jobRepo.FindByID(ctx, id); err == nil && job != nil {
+				jobs = append(jobs, *job)
+			}
+		}
+	default:
+		// Single-job session: the anchor may not have its transcript preloaded
+		job := *anchor
+		if job.Transcript == nil || *job.Transcript == "" {
+			if fresh, err := h.jobRepo.FindByID(ctx, anchor.ID); err == nil && fresh != nil {
+				job = *fresh
+			}
+		}
+		return []models.TranscriptionJob{job}, true
+	}
+
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	return jobs, true
+}
+
+// loadChatSources parses the transcripts and speaker names of jobs, skipping
+// jobs without a transcript
+func (h *Handler) loadChatSources(ctx context.Context, jobs []models.TranscriptionJob, language string) ([]chatSource, error) {
+	var sources []chatSource
+	for _, job := range jobs {
+		if job.Transcript == nil || *job.Transcript == "" {
+			continue
+		}
+		var t Transcript
+		if err := json.Unmarshal([]byte(*job.Transcript), &t); err != nil {
+			return nil, fmt.Errorf("failed to parse transcript of job %s: %w", job.ID, err)
+		}
+
+		speakers := make(map[string]string)
+		if mappings, err := h.speakerMappingRepo.ListByJob(ctx, job.ID); err == nil {
+			for _, m := range mappings {
+				speakers[m.OriginalSpeaker] = m.CustomName
+			}
+		} else {
+			fmt.Printf("Failed to get speaker mappings for job %s: %v\n", job.ID, err)
+		}
+		if language != "" && h.translations != nil {
+			if translation, err := h.translations.Completed(ctx, job.ID, language); err == nil && translation != nil {
+				for i := range t.Segments {
+					if i < len(translation.Segments) {
+						t.Segments[i].Text = translation.Segments[i].Text
+					}
+				}
+			}
+		}
+		sources = append(sources, chatSource{Job: job, Segments: t.Segments, Speakers: speakers})
+	}
+	return sources, nil
+}
+
+// buildChatContext renders the transcripts of sources as chat context. When
+// they do not fit the model's context window, only the excerpts relevant to
+// question are included and the mode is "retrieval".
+func (h *Handler) buildChatContext(ctx context.Context, sources []chatSource, question string, contextWindow int) (string, string, error) {
+	var transcriptContext string
+	if len(sources) == 1 {
+		transcriptContext = fmt.Sprintf("You are analyzing the following transcript. Use this transcript to answer questions. %s\n\n---TRANSCRIPT START---\n%s\n---TRANSCRIPT END---\n\n", citationInstructions(false), sources[0].render(0, nil))
+	} else {
+		sections := make([]string, len(sources))
+		for i, src := range sources {
+			sections[i] = src.section(i+1, src.render(i+1, nil))
+		}
+		transcriptContext = multiSourcePrompt(len(sources), "Use these transcripts to answer questions.", sections)
+	}
+
+	// Estimate 1 token ~= 4 chars and leave 500 tokens for response/history
+	transcriptTokens := len(transcriptContext) / 4
+	if transcriptTokens <= contextWindow-500 {
+		return transcriptContext, "full", nil
+	}
+
+	// Too long to include in full: retrieve the excerpts relevant to this question,
+	// using up to half of the context so history still fits
+	excerpts, err := h.retrieveTranscriptContext(ctx, sources, question, contextWindow/2)
+	if err != nil {
+		return "", "", fmt.Errorf("Transcript is too long for this model's context window (estimated %d tokens, limit %d) and relevant excerpts could not be retrieved: %v. Please use a model with a larger context window or configure an embedding model.", transcriptTokens, contextWindow, err)
+	}
+	return excerpts, "retrieval", nil
+}
+
+// render formats the selected segments (all when selected is nil) as
+// "[#3] [Speaker] [00:00:17 - 00:00:19] text" lines, marking gaps with "...".
+// The label is the segment index, prefixed with the source number in
+// multi-source context ("#2.3"), and is what citations refer to.
+func (s chatSource) render(number int, selected map[int]bool) string {
+	var sb strings.Builder
+	last := -1
+	for i, seg := range s.Segments {
+		if selected != nil && !selected[i] {
+			continue
+		}
+		if selected != nil && last >= 0 && i != last+1 {
+			sb.WriteString("...\n")
+		}
+		last = i
+
+		speakerName := seg.Speaker
+		if customName, ok := s.Speakers[speakerName]; ok {
+			speakerName = customName
+		}
+		fmt.Fprintf(&sb, "[%s] [%s] [%s - %s] %s\n", segmentLabel(number, i), speakerName, formatTime(seg.Start), formatTime(seg.End), strings.TrimSpace(seg.Text))
+	}
+	return sb.String()
+}
+
+// section wraps a rendered transcript with its source attribution header
+func (s chatSource) section(number int, body string) string {
+	title := filepath.Base(s.Job.AudioPath)
+	if s.Job.Title != nil && *s.Job.Title != "" {
+		title = *s.Job.Title
+	}
+	return fmt.Sprintf("---SOURCE [%d]: %s (recorded %s, job %s)---\n%s", number, title, s.Job.CreatedAt.Format("2006-01-02"), s.Job.ID, body)
+}
+
+func multiSourcePrompt(count int, instructions string, sections []string) string {
+	return fmt.Sprintf("You are analyzing transcripts of %d recordings. Each one starts with a header giving its source number, title and recording date. %s Attribute every statement to its source, e.g. \"[2] Weekly sync\", and do not mix up what was said in different recordings. %s\n\n%s---SOURCES END---\n\n", count, instructions, citationInstructions(true), strings.Join(sections, "\n"))
+}
+
+// scopeResponse returns the scope to include in session responses, which is
+// omitted for sessions about a single transcription
+func scopeResponse(scope models.ChatScope) *models.ChatScope {
+	if !scope.IsMultiJob() {
+		return nil
+	}
+	return &scope
+}
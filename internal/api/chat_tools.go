@@ -0,0 +1,486 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"scriberr/internal/llm"
+	"scriberr/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxToolSegments caps the transcript lines a tool returns to the model
+	maxToolSegments = 200
+	// maxToolSummaryChars caps the summary text returned to the model
+	maxToolSummaryChars = 4000
+)
+
+// chatToolEnv is what tools of an agent turn work on
+type chatToolEnv struct {
+	session *models.ChatSession
+	userID  *uint
+	sources []chatSource
+}
+
+// chatTool is a server-side tool the chat assistant can call in agent mode
+type chatTool struct {
+	def llm.Tool
+	// mutating tools change data and only run once the user confirms them
+	mutating bool
+	run      func(h *Handler, ctx context.Context, env *chatToolEnv, args json.RawMessage) (string, error)
+}
+
+var sourceParam = map[string]any{"type": "integer", "description": "Source number of the recording, when the chat covers several recordings"}
+
+var chatTools = []chatTool{
+	{
+		def: llm.NewFunctionTool("search_transcript", "Search the transcript for lines containing the given words. Returns matching lines with their labels, speakers and times.", map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"query":  map[string]any{"type": "string", "description": "Words to look for"},
+				"limit":  map[string]any{"type": "integer", "description": "Maximum number of lines to return (default 10)"},
+				"source": sourceParam,
+			},
+			"required": []string{"query"},
+		}),
+		run: (*Handler).toolSearchTranscript,
+	},
+	{
+		def: llm.NewFunctionTool("get_time_range", "Get the transcript lines between two times.", map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"start":  map[string]any{"type": "string", "description": "Start time as hh:mm:ss or seconds"},
+				"end":    map[string]any{"type": "string", "description": "End time as hh:mm:ss or seconds"},
+				"source": sourceParam,
+			},
+			"required": []string{"start", "end"},
+		}),
+		run: (*Handler).toolGetTimeRange,
+	},
+	{
+		def: llm.NewFunctionTool("create_note", "Create a note attached to the part of the transcript between two times. Needs the user's confirmation.", map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"content": map[string]any{"type": "string", "description": "Text of the note"},
+				"start":   map[string]any{"type": "string", "description": "Start time as hh:mm:ss or seconds"},
+				"end":     map[string]any{"type": "string", "description": "End time as hh:mm:ss or seconds"},
+				"source":  sourceParam,
+			},
+			"required": []string{"content", "start", "end"},
+		}),
+		mutating: true,
+		run:      (*Handler).toolCreateNote,
+	},
+	{
+		def: llm.NewFunctionTool("rename_speaker", "Give a speaker a display name. Needs the user's confirmation.", map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"speaker":  map[string]any{"type": "string", "description": "Speaker label (e.g. SPEAKER_01) or current name"},
+				"new_name": map[string]any{"type": "string", "description": "New display name"},
+				"source":   sourceParam,
+			},
+			"required": []string{"speaker", "new_name"},
+		}),
+		mutating: true,
+		run:      (*Handler).toolRenameSpeaker,
+	},
+	{
+		def: llm.NewFunctionTool("generate_summary", "Generate and save a summary of the transcript with a summary template. Needs the user's confirmation.", map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"template": map[string]any{"type": "string", "description": "Template ID or name"},
+				"source":   sourceParam,
+			},
+			"required": []string{"template"},
+		}),
+		mutating: true,
+		run:      (*Handler).toolGenerateSummary,
+	},
+}
+
+// chatToolDefinitions returns the tools offered to the model in agent mode
+func chatToolDefinitions() []llm.Tool {
+	defs := make([]llm.Tool, len(chatTools))
+	for i, t := range chatTools {
+		defs[i] = t.def
+	}
+	return defs
+}
+
+func findChatTool(name string) (chatTool, bool) {
+	for _, t := range chatTools {
+		if t.def.Function.Name == name {
+			return t, true
+		}
+	}
+	return chatTool{}, false
+}
+
+// executeChatTool runs a tool call and returns the result for the model and
+// the resulting call status
+func (h *Handler) executeChatTool(ctx context.Context, env *chatToolEnv, name, arguments string) (string, string) {
+	tool, ok := findChatTool(name)
+	if !ok {
+		return fmt.Sprintf("Error: unknown tool %q", name), models.ToolStatusFailed
+	}
+	args := json.RawMessage(arguments)
+	if strings.TrimSpace(arguments) == "" {
+		args = json.RawMessage("{}")
+	}
+	result, err := tool.run(h, ctx, env, args)
+	if err != nil {
+		return "Error: " + err.Error(), models.ToolStatusFailed
+	}
+	return result, models.ToolStatusExecuted
+}
+
+// toolSource returns the source a tool call refers to and its index
+func toolSource(env *chatToolEnv, number int) (*chatSource, int, error) {
+	if len(env.sources) == 0 {
+		return nil, 0, fmt.Errorf("the chat has no transcript")
+	}
+	if len(env.sources) == 1 {
+		return &env.sources[0], 0, nil
+	}
+	if number < 1 || number > len(env.sources) {
+		return nil, 0, fmt.Errorf("source must be between 1 and %d", len(env.sources))
+	}
+	return &env.sources[number-1], number - 1, nil
+}
+
+// parseToolTime accepts a time as seconds or as hh:mm:ss / mm:ss
+func parseToolTime(v any) (float64, error) {
+	switch t := v.(type) {
+	case float64:
+		return t, nil
+	case string:
+		t = strings.TrimSpace(t)
+		if strings.Contains(t, ":") {
+			if secs := parseClock(t); secs >= 0 {
+				return secs, nil
+			}
+		} else if secs, err := strconv.ParseFloat(t, 64); err == nil {
+			return secs, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid time %v", v)
+}
+
+// toolRange resolves start and end arguments to the indices of the segments
+// of src overlapping them
+func toolRange(src *chatSource, startArg, endArg any) (float64, float64, []int, error) {
+	start, err := parseToolTime(startArg)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	end, err := parseToolTime(endArg)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if end < start {
+		return 0, 0, nil, fmt.Errorf("end must not be before start")
+	}
+	var indices []int
+	for i, seg := range src.Segments {
+		if seg.End > start-0.5 && seg.Start < end+0.5 {
+			indices = append(indices, i)
+		}
+	}
+	if len(indices) == 0 {
+		return 0, 0, nil, fmt.Errorf("no transcript lines between %s and %s", formatTime(start), formatTime(end))
+	}
+	return start, end, indices, nil
+}
+
+func (h *Handler) toolSearchTranscript(ctx context.Context, env *chatToolEnv, raw json.RawMessage) (string, error) {
+	var args struct {
+		Query  string `json:"query"`
+		Limit  int    `json:"limit"`
+		Source int    `json:"source"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	terms := strings.FieldsFunc(strings.ToLower(args.Query), func(r rune) bool { return r == ' ' || r == ',' || r == '"' })
+	if len(terms) == 0 {
+		return "", fmt.Errorf("query is required")
+	}
+	if args.Limit <= 0 {
+		args.Limit = 10
+	}
+	args.Limit = min(args.Limit, 30)
+
+	type match struct {
+		source, index, score int
+	}
+	var matches []match
+	for s, src := range env.sources {
+		if args.Source > 0 && len(env.sources) > 1 && s != args.Source-1 {
+			continue
+		}
+		for i, seg := range src.Segments {
+			text := strings.ToLower(seg.Text)
+			score := 0
+			for _, term := range terms {
+				if strings.Contains(text, term) {
+					score++
+				}
+			}
+			if score > 0 {
+				matches = append(matches, match{source: s, index: i, score: score})
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return "No matching transcript lines.", nil
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	if len(matches) > args.Limit {
+		matches = matches[:args.Limit]
+	}
+
+	selected := make([]map[int]bool, len(env.sources))
+	for _, m := range matches {
+		if selected[m.source] == nil {
+			selected[m.source] = make(map[int]bool)
+		}
+		selected[m.source][m.index] = true
+	}
+	return renderToolSegments(env, selected), nil
+}
+
+func (h *Handler) toolGetTimeRange(ctx context.Context, env *chatToolEnv, raw json.RawMessage) (string, error) {
+	var args struct {
+		Start  any `json:"start"`
+		End    any `json:"end"`
+		Source int `json:"source"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	src, idx, err := toolSource(env, args.Source)
+	if err != nil {
+		return "", err
+	}
+	_, _, indices, err := toolRange(src, args.Start, args.End)
+	if err != nil {
+		return "", err
+	}
+	truncated := len(indices) > maxToolSegments
+	if truncated {
+		indices = indices[:maxToolSegments]
+	}
+
+	selected := make([]map[int]bool, len(env.sources))
+	selected[idx] = make(map[int]bool)
+	for _, i := range indices {
+		selected[idx][i] = true
+	}
+	result := renderToolSegments(env, selected)
+	if truncated {
+		result += fmt.Sprintf("(only the first %d lines are shown)\n", maxToolSegments)
+	}
+	return result, nil
+}
+
+// renderToolSegments renders the selected segments of each source with the
+// labels used in the chat context
+func renderToolSegments(env *chatToolEnv, selected []map[int]bool) string {
+	if len(env.sources) == 1 {
+		return env.sources[0].render(0, selected[0])
+	}
+	var sections []string
+	for s, src := range env.sources {
+		if len(selected[s]) > 0 {
+			sections = append(sections, src.section(s+1, src.render(s+1, selected[s])))
+		}
+	}
+	return strings.Join(sections, "\n")
+}
+
+func (h *Handler) toolCreateNote(ctx context.Context, env *chatToolEnv, raw json.RawMessage) (string, error) {
+	var args struct {
+		Content string `json:"content"`
+		Start   any    `json:"start"`
+		End     any    `json:"end"`
+		Source  int    `json:"source"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(args.Content) == "" {
+		return "", fmt.Errorf("content is required")
+	}
+	src, _, err := toolSource(env, args.Source)
+	if err != nil {
+		return "", err
+	}
+	start, end, indices, err := toolRange(src, args.Start, args.End)
+	if err != nil {
+		return "", err
+	}
+
+	// Notes select words of the transcript; count the words before the range
+	startWord := 0
+	for _, seg := range src.Segments[:indices[0]] {
+		startWord += len(strings.Fields(seg.Text))
+	}
+	var quote []string
+	words := 0
+	for _, i := range indices {
+		text := strings.TrimSpace(src.Segments[i].Text)
+		quote = append(quote, text)
+		words += len(strings.Fields(text))
+	}
+
+	now := time.Now()
+	note := &models.Note{
+		ID:              uuid.New().String(),
+		TranscriptionID: src.Job.ID,
+		StartWordIndex:  startWord,
+		EndWordIndex:    startWord + max(words-1, 0),
+		StartTime:       start,
+		EndTime:         end,
+		Quote:           strings.Join(quote, " "),
+		Content:         args.Content,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := h.noteRepo.Create(ctx, note); err != nil {
+		return "", fmt.Errorf("failed to create note")
+	}
+	return fmt.Sprintf("Created note %s at %s - %s.", note.ID, formatTime(start), formatTime(end)), nil
+}
+
+func (h *Handler) toolRenameSpeaker(ctx context.Context, env *chatToolEnv, raw json.RawMessage) (string, error) {
+	var args struct {
+		Speaker string `json:"speaker"`
+		NewName string `json:"new_name"`
+		Source  int    `json:"source"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	args.NewName = strings.TrimSpace(args.NewName)
+	if args.NewName == "" || len(args.NewName) > 100 {
+		return "", fmt.Errorf("new_name must be 1 to 100 characters")
+	}
+	src, _, err := toolSource(env, args.Source)
+	if err != nil {
+		return "", err
+	}
+
+	// Match the speaker label or its current name
+	labels := make(map[string]bool)
+	var available []string
+	original := ""
+	for _, seg := range src.Segments {
+		if seg.Speaker == "" || labels[seg.Speaker] {
+			continue
+		}
+		labels[seg.Speaker] = true
+		name := seg.Speaker
+		if custom, ok := src.Speakers[seg.Speaker]; ok {
+			name = custom
+		}
+		available = append(available, name)
+		if strings.EqualFold(seg.Speaker, args.Speaker) || strings.EqualFold(name, args.Speaker) {
+			original = seg.Speaker
+		}
+	}
+	if original == "" {
+		if len(available) == 0 {
+			return "", fmt.Errorf("the transcript has no speaker information")
+		}
+		return "", fmt.Errorf("no speaker %q; speakers are %s", args.Speaker, strings.Join(available, ", "))
+	}
+
+	existing, err := h.speakerMappingRepo.ListByJob(ctx, src.Job.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load speaker names")
+	}
+	mappings := []models.SpeakerMapping{{TranscriptionJobID: src.Job.ID, OriginalSpeaker: original, CustomName: args.NewName}}
+	for _, m := range existing {
+		if m.OriginalSpeaker != original {
+			mappings = append(mappings, models.SpeakerMapping{TranscriptionJobID: src.Job.ID, OriginalSpeaker: m.OriginalSpeaker, CustomName: m.CustomName})
+		}
+	}
+	if err := h.speakerMappingRepo.UpdateMappings(ctx, src.Job.ID, mappings); err != nil {
+		return "", fmt.Errorf("failed to save speaker name")
+	}
+	src.Speakers[original] = args.NewName
+	return fmt.Sprintf("Renamed %s to %s.", original, args.NewName), nil
+}
+
+func (h *Handler) toolGenerateSummary(ctx context.Context, env *chatToolEnv, raw json.RawMessage) (string, error) {
+	var args struct {
+		Template string `json:"template"`
+		Source   int    `json:"source"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	src, _, err := toolSource(env, args.Source)
+	if err != nil {
+		return "", err
+	}
+
+	template, err := h.summaryRepo.FindByID(ctx, args.Template)
+	if err != nil {
+		return "", fmt.Errorf("failed to load summary templates")
+	}
+	if template == nil {
+		templates, _, err := h.summaryRepo.List(ctx, 0, 1000)
+		if err != nil {
+			return "", fmt.Errorf("failed to load summary templates")
+		}
+		var names []string
+		for i := range templates {
+			names = append(names, templates[i].Name)
+			if strings.EqualFold(templates[i].Name, strings.TrimSpace(args.Template)) {
+				template = &templates[i]
+			}
+		}
+		if template == nil {
+			return "", fmt.Errorf("no summary template %q; templates are %s", args.Template, strings.Join(names, ", "))
+		}
+	}
+
+	model := template.Model
+	if model == "" {
+		model = env.session.Model
+	}
+	route, err := h.llmRouter.Resolve(ctx, models.LLMFeatureSummarization, env.userID)
+	if err != nil {
+		return "", err
+	}
+	model = route.WithModel(model).Primary().Model
+	if model == "" {
+		return "", fmt.Errorf("no model is configured for summaries")
+	}
+
+	// Generated like any other summary, so it joins the transcription's history
+	if h.summaries == nil {
+		return "", fmt.Errorf("summaries are not enabled")
+	}
+	queued := &models.Summary{TranscriptionID: src.Job.ID, TemplateID: &template.ID, Model: model}
+	if err := h.summaries.Enqueue(ctx, queued); err != nil {
+		return "", err
+	}
+	summary, err := h.collectSummary(ctx, queued.ID)
+	if err != nil {
+		return "", fmt.Errorf("summary generation failed: %w", err)
+	}
+
+	if r := []rune(summary); len(r) > maxToolSummaryChars {
+		summary = string(r[:maxToolSummaryChars]) + "…"
+	}
+	return fmt.Sprintf("Saved a summary using the %q template:\n\n%s", template.Name, summary), nil
+}
@@ -0,0 +1,401 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"scriberr/internal/audio"
+	"scriberr/internal/export"
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+	"scriberr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxHighlightSpans caps the number of spans in a highlight reel
+const maxHighlightSpans = 200
+
+// HighlightSpan is a time range of the source recording to include in a reel.
+// Search hits can be passed directly as spans.
+type HighlightSpan struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Label string  `json:"label,omitempty"`
+}
+
+// HighlightReelRequest selects the parts of a recording to concatenate
+type HighlightReelRequest struct {
+	Title   string          `json:"title"`
+	NoteIDs []string        `json:"note_ids"`
+	Spans   []HighlightSpan `json:"spans"`
+	Padding float64         `json:"padding"` // Seconds added before and after each span
+	Format  string          `json:"format"`  // mp3 (default), wav, m4a, ogg, flac
+}
+
+// HighlightChapter locates one span inside a highlight reel
+type HighlightChapter struct {
+	Title       string  `json:"title"`
+	Start       float64 `json:"start"`
+	End         float64 `json:"end"`
+	SourceStart float64 `json:"source_start"`
+	SourceEnd   float64 `json:"source_end"`
+}
+
+// HighlightReelResponse is returned when a highlight reel job is created
+type HighlightReelResponse struct {
+	Job      models.TranscriptionJob `json:"job"`
+	Chapters []HighlightChapter      `json:"chapters"`
+}
+
+// GetAudioClip returns a cut of a job's audio
+// @Summary Get an audio clip
+// @Description Cut a time range out of the job's audio with ffmpeg. Use format=mp4 for jobs uploaded as video to get a video clip; captions=true burns the transcript into the picture.
+// @Tags transcription
+// @Produce octet-stream
+// @Param id path string true "Job ID"
+// @Param start query number true "Clip start in seconds"
+// @Param end query number true "Clip end in seconds"
+// @Param format query string false "mp3 (default), wav, m4a, ogg, flac or mp4"
+// @Param captions query bool false "Burn captions into mp4 clips"
+// @Success 200 {file} binary
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/transcription/{id}/audio/clip [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) GetAudioClip(c *gin.Context) {
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+
+	start, err1 := strconv.ParseFloat(c.Query("start"), 64)
+	end, err2 := strconv.ParseFloat(c.Query("end"), 64)
+	if err1 != nil || err2 != nil || start < 0 || end <= start {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start and end must be seconds with start < end"})
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", "mp3"))
+	captions := c.Query("captions") == "true"
+	isVideo := format == "mp4"
+	if _, ok := audio.ClipFormats[format]; !ok && !isVideo {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported clip format"})
+		return
+	}
+	if isVideo && (job.VideoPath == nil || *job.VideoPath == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Job has no video"})
+		return
+	}
+	if captions && !isVideo {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Captions can only be burned into mp4 clips"})
+		return
+	}
+
+	source := job.PlaybackAudioPath()
+	if isVideo {
+		source = *job.VideoPath
+	}
+	if _, err := os.Stat(source); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Media file not found on disk"})
+		return
+	}
+
+	out, err := os.CreateTemp(h.config.TempDir, "clip-*."+format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create clip"})
+		return
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Minute)
+	defer cancel()
+
+	if isVideo {
+		subtitlesPath := ""
+		if captions {
+			subtitlesPath, err = h.writeClipSubtitles(ctx, job, start, end)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			defer os.Remove(subtitlesPath)
+		}
+		err = audio.ExtractVideoClip(ctx, source, out.Name(), start, end, subtitlesPath)
+	} else {
+		err = audio.ExtractClip(ctx, source, out.Name(), start, end, format)
+	}
+	if err != nil {
+		logger.Error("Clip extraction failed", "job_id", job.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create clip"})
+		return
+	}
+
+	filename := audio.ClipFilename(exportBasename(job), start, end, format)
+	c.Header("Content-Type", audio.ClipContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.File(out.Name())
+}
+
+// writeClipSubtitles renders the part of the transcript covering the clip as
+// SRT timed relative to the clip start
+func (h *Handler) writeClipSubtitles(ctx context.Context, job *models.TranscriptionJob, start, end float64) (string, error) {
+	if job.Transcript == nil || *job.Transcript == "" {
+		return "", fmt.Errorf("transcript not available for captions")
+	}
+	result, err := export.DecodeTranscript(*job.Transcript)
+	if err != nil {
+		return "", err
+	}
+	mappings, _ := h.speakerMappingRepo.ListByJob(ctx, job.ID)
+	doc := export.NewDocument(job.ID, "", export.SliceTranscript(result, start, end, 0), export.SpeakerNames(mappings))
+
+	f, err := os.CreateTemp(h.config.TempDir, "clip-*.srt")
+	if err != nil {
+		return "", fmt.Errorf("failed to write captions")
+	}
+	defer f.Close()
+	opts := export.Options{Format: export.FormatSRT, MaxLineWidth: 42, MaxLineCount: 2}
+	if err := export.Render(f, doc, opts); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write captions")
+	}
+	return f.Name(), nil
+}
+
+// CreateHighlightReel concatenates selected parts of a recording into a new job
+// @Summary Create a highlight reel
+// @Description Concatenate the audio of the selected notes and/or explicit spans (e.g. search hits) into a new job. The new job's transcript is the matching sub-transcript re-timed to the reel, with the chapter list stored in its metadata. Audio is rendered in the background; the job completes when it is ready.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param id path string true "Source job ID"
+// @Param request body HighlightReelRequest true "Reel selection"
+// @Success 202 {object} HighlightReelResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/transcription/{id}/highlights [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) CreateHighlightReel(c *gin.Context) {
+	source, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+
+	var req HighlightReelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	format := strings.ToLower(req.Format)
+	if format == "" {
+		format = "mp3"
+	}
+	if _, ok := audio.ClipFormats[format]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported reel format"})
+		return
+	}
+
+	spans, err := h.highlightSpans(c.Request.Context(), source.ID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mediaPath := source.PlaybackAudioPath()
+	if _, err := os.Stat(mediaPath); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Audio file not found on disk"})
+		return
+	}
+
+	// Lay the spans out back to back to get the chapter list and sub-transcript
+	var sourceResult *interfaces.TranscriptResult
+	if source.Transcript != nil && *source.Transcript != "" {
+		sourceResult, _ = export.DecodeTranscript(*source.Transcript)
+	}
+	reelTranscript := &interfaces.TranscriptResult{Segments: []interfaces.TranscriptSegment{}, ModelUsed: models.ExecutionSourceHighlight}
+	chapters := make([]HighlightChapter, 0, len(spans))
+	cuts := make([]audio.Span, 0, len(spans))
+	offset := 0.0
+	for _, span := range spans {
+		chapters = append(chapters, HighlightChapter{
+			Title:       span.Label,
+			Start:       offset,
+			End:         offset + span.End - span.Start,
+			SourceStart: span.Start,
+			SourceEnd:   span.End,
+		})
+		cuts = append(cuts, audio.Span{Start: span.Start, End: span.End})
+		if sourceResult != nil {
+			reelTranscript.Language = sourceResult.Language
+			export.AppendTranscript(reelTranscript, export.SliceTranscript(sourceResult, span.Start, span.End, offset))
+		}
+		offset += span.End - span.Start
+	}
+	chaptersJSON, _ := json.Marshal(chapters)
+	reelTranscript.Metadata = map[string]string{
+		"source_job_id": source.ID,
+		"chapters":      string(chaptersJSON),
+	}
+	transcriptJSON, err := json.Marshal(reelTranscript)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build reel transcript"})
+		return
+	}
+
+	title := req.Title
+	if title == "" {
+		title = "Highlights"
+		if source.Title != nil && *source.Title != "" {
+			title = *source.Title + " (highlights)"
+		}
+	}
+	reelID := uuid.New().String()
+	transcript := string(transcriptJSON)
+	reel := models.TranscriptionJob{
+		ID:          reelID,
+		Title:       &title,
+		Status:      models.StatusProcessing,
+		AudioPath:   filepath.Join(h.config.UploadDir, reelID+"."+format),
+		Transcript:  &transcript,
+		Diarization: source.Diarization,
+		UserID:      source.UserID,
+	}
+	reel.Parameters.ModelFamily = models.ExecutionSourceHighlight
+	reel.Parameters.Language = source.Parameters.Language
+	if err := h.jobRepo.Create(c.Request.Context(), &reel); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job"})
+		return
+	}
+
+	// Keep the source's speaker names on the reel
+	if mappings, err := h.speakerMappingRepo.ListByJob(c.Request.Context(), source.ID); err == nil && len(mappings) > 0 {
+		copied := make([]models.SpeakerMapping, 0, len(mappings))
+		for _, m := range mappings {
+			copied = append(copied, models.SpeakerMapping{TranscriptionJobID: reelID, OriginalSpeaker: m.OriginalSpeaker, CustomName: m.CustomName})
+		}
+		_ = h.speakerMappingRepo.UpdateMappings(c.Request.Context(), reelID, copied)
+	}
+
+	go h.renderHighlightReel(reel, mediaPath, cuts, format)
+
+	c.JSON(http.StatusAccepted, HighlightReelResponse{Job: reel, Chapters: chapters})
+}
+
+// highlightSpans resolves the requested notes and spans into padded ranges.
+// Notes are ordered by time; explicit spans keep their order and follow them.
+func (h *Handler) highlightSpans(ctx context.Context, jobID string, req HighlightReelRequest) ([]HighlightSpan, error) {
+	if req.Padding < 0 || req.Padding > 30 {
+		return nil, fmt.Errorf("padding must be between 0 and 30 seconds")
+	}
+
+	var spans []HighlightSpan
+	if len(req.NoteIDs) > 0 {
+		notes, err := h.noteRepo.ListByJob(ctx, jobID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load notes")
+		}
+		byID := make(map[string]models.Note, len(notes))
+		for _, n := range notes {
+			byID[n.ID] = n
+		}
+		var selected []models.Note
+		for _, id := range req.NoteIDs {
+			n, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("note %s not found on this job", id)
+			}
+			selected = append(selected, n)
+		}
+		sort.SliceStable(selected, func(i, j int) bool { return selected[i].StartTime < selected[j].StartTime })
+		for _, n := range selected {
+			spans = append(spans, HighlightSpan{Start: n.StartTime, End: n.EndTime, Label: noteChapterTitle(n)})
+		}
+	}
+	spans = append(spans, req.Spans...)
+
+	if len(spans) == 0 {
+		return nil, fmt.Errorf("select at least one note or span")
+	}
+	if len(spans) > maxHighlightSpans {
+		return nil, fmt.Errorf("too many spans (max %d)", maxHighlightSpans)
+	}
+	for i := range spans {
+		if spans[i].Start < 0 || spans[i].End <= spans[i].Start {
+			return nil, fmt.Errorf("invalid span %d: start must be before end", i)
+		}
+		spans[i].Start = max(0, spans[i].Start-req.Padding)
+		spans[i].End += req.Padding
+		if spans[i].Label == "" {
+			spans[i].Label = fmt.Sprintf("Clip %d", i+1)
+		}
+	}
+	return spans, nil
+}
+
+// renderHighlightReel concatenates the reel audio and completes the job
+func (h *Handler) renderHighlightReel(reel models.TranscriptionJob, mediaPath string, cuts []audio.Span, format string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	startedAt := time.Now()
+	status := models.StatusCompleted
+	var errMsg *string
+	if err := audio.ConcatSpans(ctx, mediaPath, reel.AudioPath, cuts, format); err != nil {
+		logger.Error("Highlight reel rendering failed", "job_id", reel.ID, "error", err)
+		status = models.StatusFailed
+		msg := err.Error()
+		errMsg = &msg
+		_ = h.jobRepo.UpdateError(ctx, reel.ID, msg)
+	}
+
+	completedAt := time.Now()
+	execution := &models.TranscriptionJobExecution{
+		TranscriptionJobID: reel.ID,
+		StartedAt:          startedAt,
+		CompletedAt:        &completedAt,
+		ActualParameters:   reel.Parameters,
+		Status:             status,
+		ErrorMessage:       errMsg,
+		Source:             models.ExecutionSourceHighlight,
+	}
+	execution.CalculateProcessingDuration()
+	_ = h.jobRepo.CreateExecution(ctx, execution)
+
+	if err := h.jobRepo.UpdateStatus(ctx, reel.ID, status); err != nil {
+		logger.Error("Failed to update highlight reel status", "job_id", reel.ID, "error", err)
+	}
+	if status == models.StatusCompleted {
+		h.scheduleWaveform(reel.ID)
+	}
+	if h.broadcaster != nil {
+		h.broadcaster.Broadcast(reel.ID, "job_update", map[string]interface{}{
+			"job_id": reel.ID,
+			"status": status,
+		})
+	}
+}
+
+func noteChapterTitle(n models.Note) string {
+	title := strings.TrimSpace(n.Content)
+	if title == "" {
+		title = strings.TrimSpace(n.Quote)
+	}
+	if r := []rune(title); len(r) > 80 {
+		title = string(r[:77]) + "..."
+	}
+	return title
+}
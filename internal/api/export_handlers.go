@@ -0,0 +1,184 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"scriberr/internal/export"
+	"scriberr/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// buildExportDocument loads a job's transcript together with speaker names,
+// notes and the latest summary into a renderer-agnostic document
+func (h *Handler) buildExportDocument(ctx context.Context, job *models.TranscriptionJob) (*export.Document, error) {
+	if job.Transcript == nil || *job.Transcript == "" {
+		return nil, fmt.Errorf("transcript not available")
+	}
+
+	result, err := export.DecodeTranscript(*job.Transcript)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings, err := h.speakerMappingRepo.ListByJob(ctx, job.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load speaker mappings: %w", err)
+	}
+
+	title := ""
+	if job.Title != nil {
+		title = *job.Title
+	}
+
+	doc := export.NewDocument(job.ID, title, result, export.SpeakerNames(mappings))
+
+	if notes, err := h.noteRepo.ListByJob(ctx, job.ID); err == nil {
+		doc.AttachNotes(notes)
+	}
+
+	if summary, err := h.summaryRepo.GetLatestSummary(ctx, job.ID); err == nil && summary != nil {
+		doc.Summary = summary.Content
+	} else if job.Summary != nil {
+		doc.Summary = *job.Summary
+	}
+
+	return doc, nil
+}
+
+// parseExportOptions reads export options from the query string, falling back
+// to the job's own line formatting parameters
+func parseExportOptions(c *gin.Context, job *models.TranscriptionJob) (export.Options, error) {
+	format, err := export.ParseFormat(c.DefaultQuery("format", "json"))
+	if err != nil {
+		return export.Options{}, err
+	}
+	granularity, err := export.ParseGranularity(c.Query("granularity"))
+	if err != nil {
+		return export.Options{}, err
+	}
+
+	opts := export.Options{
+		Format:         format,
+		Granularity:    granularity,
+		IncludeNotes:   c.Query("include_notes") == "true",
+		IncludeSummary: c.Query("include_summary") == "true",
+	}
+
+	if job.Parameters.MaxLineWidth != nil {
+		opts.MaxLineWidth = *job.Parameters.MaxLineWidth
+	}
+	if job.Parameters.MaxLineCount != nil {
+		opts.MaxLineCount = *job.Parameters.MaxLineCount
+	}
+	if v := c.Query("max_line_width"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return export.Options{}, fmt.Errorf("invalid max_line_width")
+		}
+		opts.MaxLineWidth = n
+	}
+	if v := c.Query("max_line_count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return export.Options{}, fmt.Errorf("invalid max_line_count")
+		}
+		opts.MaxLineCount = n
+	}
+
+	return opts, nil
+}
+
+// exportFilename builds a safe attachment filename for a job export
+func exportFilename(job *models.TranscriptionJob, format export.Format) string {
+	return exportBasename(job) + export.Extension(format)
+}
+
+// exportBasename is the job title made safe for filenames, or the job ID
+func exportBasename(job *models.TranscriptionJob) string {
+	base := job.ID
+	if job.Title != nil && *job.Title != "" {
+		base = strings.Trim(unsafeFilenameChars.ReplaceAllString(*job.Title, "_"), "_")
+		if base == "" {
+			base = job.ID
+		}
+	}
+	return base
+}
+
+// ExportTranscript renders a transcript in a downloadable format
+// @Summary Export transcript
+// @Description Export a completed transcript as SRT, VTT, TXT, Markdown, DOCX or normalized JSON. Speaker names come from the job's speaker mappings.
+// @Tags transcription
+// @Produce octet-stream
+// @Param id path string true "Job ID"
+// @Param format query string false "Output format (srt, vtt, txt, md, docx, json)" default(json)
+// @Param granularity query string false "Timestamp granularity (segment, word, none)" default(segment)
+// @Param max_line_width query int false "Maximum characters per subtitle line"
+// @Param max_line_count query int false "Maximum lines per subtitle cue"
+// @Param include_notes query bool false "Include notes"
+// @Param include_summary query bool false "Include the latest summary"
+// @Param language query string false "Language code of a completed translation track to export instead of the original"
+// @Param bilingual query bool false "With language, show each translated line below the original one"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/transcription/{id}/export [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) ExportTranscript(c *gin.Context) {
+	jobID := c.Param("id")
+
+	job, err := h.checkJobOwnership(c, jobID)
+	if err != nil {
+		return
+	}
+
+	if job.Status != models.StatusCompleted {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Transcript not ready, current status: %s", job.Status)})
+		return
+	}
+
+	opts, err := parseExportOptions(c, job)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	doc, err := h.buildExportDocument(c.Request.Context(), job)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := exportFilename(job, opts.Format)
+	if c.Query("language") != "" {
+		translation, ok := h.completedTranslation(c, job)
+		if !ok {
+			return
+		}
+		texts := make([]string, len(translation.Segments))
+		for i, seg := range translation.Segments {
+			texts[i] = seg.Text
+		}
+		doc.Translate(translation.Language, texts, c.Query("bilingual") == "true")
+		filename = strings.TrimSuffix(filename, export.Extension(opts.Format)) + "." + translation.Language + export.Extension(opts.Format)
+	}
+
+	var buf bytes.Buffer
+	if err := export.Render(&buf, doc, opts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render export"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, export.ContentType(opts.Format), buf.Bytes())
+}
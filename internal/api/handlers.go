@@ -16,15 +16,26 @@ import (
 	"strings"
 	"time"
 
+	"scriberr/internal/actionitems"
+	"scriberr/internal/analytics"
 	"scriberr/internal/auth"
+	"scriberr/internal/chapters"
 	"scriberr/internal/config"
+	"scriberr/internal/llm"
 	"scriberr/internal/models"
 	"scriberr/internal/processing"
 	"scriberr/internal/queue"
 	"scriberr/internal/repository"
+	"scriberr/internal/retrieval"
+	"scriberr/internal/review"
 	"scriberr/internal/service"
+	"scriberr/internal/smartanalysis"
 	"scriberr/internal/sse"
+	"scriberr/internal/summarize"
 	"scriberr/internal/transcription"
+	"scriberr/internal/transcription/pipeline"
+	"scriberr/internal/translate"
+	"scriberr/internal/waveform"
 	"scriberr/pkg/logger"
 
 	"github.com/gin-gonic/gin"
@@ -43,6 +54,8 @@ type Handler struct {
 	profileRepo         repository.ProfileRepository
 	userRepo            repository.UserRepository
 	llmConfigRepo       repository.LLMConfigRepository
+	llmRouter           *llm.Router
+	usageRepo           repository.UsageRepository
 	summaryRepo         repository.SummaryRepository
 	chatRepo            repository.ChatRepository
 	noteRepo            repository.NoteRepository
@@ -53,6 +66,16 @@ type Handler struct {
 	quickTranscription  *transcription.QuickTranscriptionService
 	multiTrackProcessor *processing.MultiTrackProcessor
 	broadcaster         *sse.Broadcaster
+	waveforms           *waveform.Service
+	retriever           *retrieval.Service
+	summaries           *summarize.Service
+	actionItemRepo      repository.ActionItemRepository
+	actionItems         *actionitems.Extractor
+	chapters            *chapters.Service
+	analytics           *analytics.Service
+	translations        *translate.Service
+	smartAnalysis       *smartanalysis.Service
+	reviews             *review.Service
 }
 
 // NewHandler creates a new handler
@@ -66,6 +89,7 @@ func NewHandler(
 	profileRepo repository.ProfileRepository,
 	userRepo repository.UserRepository,
 	llmConfigRepo repository.LLMConfigRepository,
+	llmRouter *llm.Router,
 	summaryRepo repository.SummaryRepository,
 	chatRepo repository.ChatRepository,
 	noteRepo repository.NoteRepository,
@@ -87,6 +111,7 @@ func NewHandler(
 		profileRepo:         profileRepo,
 		userRepo:            userRepo,
 		llmConfigRepo:       llmConfigRepo,
+		llmRouter:           llmRouter,
 		summaryRepo:         summaryRepo,
 		chatRepo:            chatRepo,
 		noteRepo:            noteRepo,
@@ -183,23 +208,29 @@ type YouTubeDownloadResponse struct {
 
 // LLMConfigRequest represents the LLM configuration request
 type LLMConfigRequest struct {
-	Provider      string  `json:"provider" binding:"required,oneof=ollama openai"`
-	BaseURL       *string `json:"base_url,omitempty"`
-	OpenAIBaseURL *string `json:"openai_base_url,omitempty"`
-	APIKey        *string `json:"api_key,omitempty"`
-	IsActive      bool    `json:"is_active"`
+	Name           string  `json:"name,omitempty"`
+	Provider       string  `json:"provider" binding:"required,oneof=ollama openai groq"`
+	BaseURL        *string `json:"base_url,omitempty"`
+	OpenAIBaseURL  *string `json:"openai_base_url,omitempty"`
+	APIKey         *string `json:"api_key,omitempty"`
+	EmbeddingModel *string `json:"embedding_model,omitempty"` // Model used for transcript retrieval; provider default if empty
+	IsActive       bool    `json:"is_active"`
+	IsDefault      bool    `json:"is_default"` // Used by features without a route
 }
 
 // LLMConfigResponse represents the LLM configuration response
 type LLMConfigResponse struct {
-	ID            uint    `json:"id"`
-	Provider      string  `json:"provider"`
-	BaseURL       *string `json:"base_url,omitempty"`
-	OpenAIBaseURL *string `json:"openai_base_url,omitempty"`
-	HasAPIKey     bool    `json:"has_api_key"` // Don't return actual API key
-	IsActive      bool    `json:"is_active"`
-	CreatedAt     string  `json:"created_at"`
-	UpdatedAt     string  `json:"updated_at"`
+	ID             uint    `json:"id"`
+	Name           string  `json:"name"`
+	Provider       string  `json:"provider"`
+	BaseURL        *string `json:"base_url,omitempty"`
+	OpenAIBaseURL  *string `json:"openai_base_url,omitempty"`
+	HasAPIKey      bool    `json:"has_api_key"` // Don't return actual API key
+	EmbeddingModel *string `json:"embedding_model,omitempty"`
+	IsActive       bool    `json:"is_active"`
+	IsDefault      bool    `json:"is_default"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
 }
 
 // APIKeyListResponse represents an API key in the list (without the actual key)
@@ -374,6 +405,8 @@ func (h *Handler) UploadAudio(c *gin.Context) {
 		}
 	}
 
+	h.scheduleWaveform(job.ID)
+
 	c.JSON(http.StatusOK, job)
 }
 
@@ -423,6 +456,7 @@ func (h *Handler) UploadVideo(c *gin.Context) {
 	job := models.TranscriptionJob{
 		ID:        jobID,
 		AudioPath: audioPath, // Use the extracted audio path
+		VideoPath: &videoPath,
 		Status:    models.StatusUploaded,
 	}
 
@@ -474,6 +508,8 @@ func (h *Handler) UploadVideo(c *gin.Context) {
 		}
 	}
 
+	h.scheduleWaveform(job.ID)
+
 	c.JSON(http.StatusOK, job)
 }
 
@@ -719,6 +755,14 @@ func (h *Handler) GetTrackProgress(c *gin.Context) {
 // @Param vad_offset formData number false "VAD offset" default(0.363)
 // @Param min_speakers formData int false "Minimum speakers for diarization"
 // @Param max_speakers formData int false "Maximum speakers for diarization"
+// @Param translate_to formData string false "Comma-separated language codes to translate the transcript into once it completes"
+// @Param skip_smart_analysis formData boolean false "Skip proposing corrections of the transcript with the smart analysis model"
+// @Param smart_analysis_prompt formData string false "Proofreading instructions replacing the defaults for the transcript language; {{title}} and {{language}} are filled in"
+// @Param quality_check formData string false "What to do with hallucinated and low-quality segments: flag (default), drop or off"
+// @Param review_threshold formData number false "Also queue segments below this confidence for human review once the job completes; segments flagged by the quality check are always queued"
+// @Param language_detection formData boolean false "Identify the language of each speech region and transcribe it in that language, for recordings that switch languages"
+// @Param languages formData string false "Comma-separated language codes expected in the recording, e.g. vi,en; regions identified as another language use the first"
+// @Param language_models formData string false "Comma-separated language=model routes for the language identification pass, e.g. vi=fpt_ai,en=groq_whisper"
 // @Success 200 {object} models.TranscriptionJob
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
@@ -790,6 +834,30 @@ func (h *Handler) SubmitJob(c *gin.Context) {
 		params.HfToken = &hfToken
 	}
 
+	params.SkipSmartAnalysis = getFormBoolWithDefault(c, "skip_smart_analysis", false)
+	if prompt := c.PostForm("smart_analysis_prompt"); prompt != "" {
+		params.SmartAnalysisPrompt = &prompt
+	}
+
+	// Parse and validate the quality check mode
+	qualityCheck := getFormValueWithDefault(c, "quality_check", pipeline.QualityCheckFlag)
+	if qualityCheck != pipeline.QualityCheckFlag && qualityCheck != pipeline.QualityCheckDrop && qualityCheck != pipeline.QualityCheckOff {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quality_check. Must be 'flag', 'drop' or 'off'"})
+		_ = h.fileService.RemoveFile(filePath)
+		return
+	}
+	params.QualityCheck = qualityCheck
+
+	if thresholdStr := c.PostForm("review_threshold"); thresholdStr != "" {
+		threshold, err := strconv.ParseFloat(thresholdStr, 64)
+		if err != nil || threshold < 0 || threshold > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review_threshold. Must be between 0 and 1"})
+			_ = h.fileService.RemoveFile(filePath)
+			return
+		}
+		params.ReviewThreshold = &threshold
+	}
+
 	// Parse and validate diarization model
 	diarizeModel := getFormValueWithDefault(c, "diarize_model", "pyannote")
 	if diarizeModel != "pyannote" && diarizeModel != "nvidia_sortformer" {
@@ -799,6 +867,52 @@ func (h *Handler) SubmitJob(c *gin.Context) {
 	}
 	params.DiarizeModel = diarizeModel
 
+	// Parse the translation tracks to produce on completion
+	var translateTo []string
+	for _, code := range strings.Split(c.PostForm("translate_to"), ",") {
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+		language, ok := translate.NormalizeLanguage(code)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid translate_to language code: " + code})
+			_ = h.fileService.RemoveFile(filePath)
+			return
+		}
+		translateTo = append(translateTo, language)
+	}
+
+	// Parse the language identification pass settings
+	params.LanguageDetection = getFormBoolWithDefault(c, "language_detection", false)
+	var languages []string
+	for _, code := range strings.Split(c.PostForm("languages"), ",") {
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+		language, ok := translate.NormalizeLanguage(code)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language code in languages: " + code})
+			_ = h.fileService.RemoveFile(filePath)
+			return
+		}
+		languages = append(languages, language)
+	}
+	if len(languages) > 0 {
+		joined := strings.Join(languages, ",")
+		params.Languages = &joined
+	}
+	if routes := c.PostForm("language_models"); routes != "" {
+		for _, route := range strings.Split(routes, ",") {
+			code, model, ok := strings.Cut(route, "=")
+			if _, valid := translate.NormalizeLanguage(code); !ok || !valid || strings.TrimSpace(model) == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language_models route: " + route + ". Must be language=model"})
+				_ = h.fileService.RemoveFile(filePath)
+				return
+			}
+		}
+		params.LanguageModels = &routes
+	}
+
 	// Create job
 	job := models.TranscriptionJob{
 		ID:          jobID,
@@ -807,6 +921,7 @@ func (h *Handler) SubmitJob(c *gin.Context) {
 		Diarization: diarize,
 		Parameters:  params,
 		UserID:      &userID,
+		TranslateTo: translateTo,
 	}
 
 	if title := c.PostForm(paramTitle); title != "" {
@@ -855,6 +970,7 @@ func (h *Handler) GetJobStatus(c *gin.Context) {
 // @Tags transcription
 // @Produce json
 // @Param id path string true "Job ID"
+// @Param language query string false "Language code of a completed translation track to return instead of the original"
 // @Success 200 {object} map[string]interface{}
 // @Failure 404 {object} map[string]string
 // @Failure 400 {object} map[string]string
@@ -905,6 +1021,15 @@ func (h *Handler) GetTranscript(c *gin.Context) {
 		return
 	}
 
+	// Serve a translation track in place of the original segments
+	if c.Query("language") != "" {
+		translation, ok := h.completedTranslation(c, job)
+		if !ok {
+			return
+		}
+		transcript = translatedTranscript(transcript, translation)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"job_id":     job.ID,
 		"title":      job.Title,
@@ -925,6 +1050,7 @@ func (h *Handler) GetTranscript(c *gin.Context) {
 // @Param sort_by query string false "Sort By"
 // @Param sort_order query string false "Sort Order (asc/desc)"
 // @Param status query string false "Filter by status"
+// @Param review_status query string false "Filter by review status: pending, in_review, reviewed or none"
 // @Param q query string false "Search in title and audio filename"
 // @Param updated_after query string false "Filter by updated_at > timestamp (RFC3339)"
 // @Success 200 {object} map[string]interface{}
@@ -956,7 +1082,18 @@ func (h *Handler) ListTranscriptionJobs(c *gin.Context) {
 	}
 	userID := userIDVal.(uint)
 
-	jobs, total, err := h.jobRepo.ListWithParams(c.Request.Context(), userID, offset, limit, sortBy, sortOrder, searchQuery, updatedAfter)
+	filter := repository.JobFilter{
+		Status:       models.JobStatus(c.Query("status")),
+		ReviewStatus: c.Query("review_status"),
+	}
+	switch filter.ReviewStatus {
+	case "", "none", models.ReviewStatusPending, models.ReviewStatusInReview, models.ReviewStatusReviewed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "review_status must be pending, in_review, reviewed or none"})
+		return
+	}
+
+	jobs, total, err := h.jobRepo.ListWithParams(c.Request.Context(), userID, offset, limit, sortBy, sortOrder, searchQuery, updatedAfter, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list jobs"})
 		return
@@ -1093,6 +1230,10 @@ func (h *Handler) checkJobOwnership(c *gin.Context, jobID string) (*models.Trans
 		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
 		return nil, err
 	}
+	if job == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return nil, fmt.Errorf("job not found")
+	}
 
 	// ENFORCE DATA ISOLATION: User must own the job OR be an admin
 	if role != "admin" && job.UserID != nil && *job.UserID != userID {
@@ -1343,6 +1484,48 @@ func (h *Handler) DeleteTranscriptionJob(c *gin.Context) {
 		fmt.Printf("Failed to delete summaries for job %s: %v\n", jobID, err)
 	}
 
+	// Delete Action Items
+	if h.actionItemRepo != nil {
+		if err := h.actionItemRepo.DeleteByTranscriptionID(ctx, jobID); err != nil {
+			fmt.Printf("Failed to delete action items for job %s: %v\n", jobID, err)
+		}
+	}
+
+	// Delete Translations
+	if h.translations != nil {
+		if err := h.translations.DeleteByJob(ctx, jobID); err != nil {
+			fmt.Printf("Failed to delete translations for job %s: %v\n", jobID, err)
+		}
+	}
+
+	// Delete Review
+	if h.reviews != nil {
+		if err := h.reviews.Delete(ctx, jobID); err != nil {
+			fmt.Printf("Failed to delete review for job %s: %v\n", jobID, err)
+		}
+	}
+
+	// Delete Smart Analysis
+	if h.smartAnalysis != nil {
+		if err := h.smartAnalysis.Delete(ctx, jobID); err != nil {
+			fmt.Printf("Failed to delete smart analysis for job %s: %v\n", jobID, err)
+		}
+	}
+
+	// Delete Conversation Analytics
+	if h.analytics != nil {
+		if err := h.analytics.Delete(ctx, jobID); err != nil {
+			fmt.Printf("Failed to delete analytics for job %s: %v\n", jobID, err)
+		}
+	}
+
+	// Delete Chapters
+	if h.chapters != nil {
+		if err := h.chapters.Delete(ctx, jobID); err != nil {
+			fmt.Printf("Failed to delete chapters for job %s: %v\n", jobID, err)
+		}
+	}
+
 	// Delete Speaker Mappings
 	if err := h.speakerMappingRepo.DeleteByJobID(ctx, jobID); err != nil {
 		fmt.Printf("Failed to delete speaker mappings for job %s: %v\n", jobID, err)
@@ -1358,6 +1541,13 @@ func (h *Handler) DeleteTranscriptionJob(c *gin.Context) {
 		fmt.Printf("Failed to delete multi-track file records for job %s: %v\n", jobID, err)
 	}
 
+	// Delete transcript embeddings
+	if h.retriever != nil {
+		if err := h.retriever.Delete(ctx, jobID); err != nil {
+			fmt.Printf("Failed to delete transcript embeddings for job %s: %v\n", jobID, err)
+		}
+	}
+
 	// Delete from database
 	if err := h.jobRepo.Delete(c.Request.Context(), jobID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete job: " + err.Error()})
@@ -2160,7 +2350,7 @@ func (h *Handler) DeleteAPIKey(c *gin.Context) {
 }
 
 // @Summary Get LLM configuration
-// @Description Get the current active LLM configuration
+// @Description Get the default LLM configuration, used by features without a route
 // @Tags llm
 // @Produce json
 // @Success 200 {object} LLMConfigResponse
@@ -2178,22 +2368,11 @@ func (h *Handler) GetLLMConfig(c *gin.Context) {
 		return
 	}
 
-	response := LLMConfigResponse{
-		ID:            config.ID,
-		Provider:      config.Provider,
-		BaseURL:       config.BaseURL,
-		OpenAIBaseURL: config.OpenAIBaseURL,
-		HasAPIKey:     config.APIKey != nil && *config.APIKey != "",
-		IsActive:      config.IsActive,
-		CreatedAt:     config.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt:     config.UpdatedAt.Format("2006-01-02 15:04:05"),
-	}
-
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, newLLMConfigResponse(config))
 }
 
 // @Summary Create or update LLM configuration
-// @Description Create or update LLM configuration settings
+// @Description Create or update the default LLM configuration. Use /api/v1/llm/configs to manage several configurations.
 // @Tags llm
 // @Accept json
 // @Produce json
@@ -2209,78 +2388,36 @@ func (h *Handler) SaveLLMConfig(c *gin.Context) {
 		return
 	}
 
-	// Validate provider-specific requirements
-	if req.Provider == "ollama" && (req.BaseURL == nil || *req.BaseURL == "") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Base URL is required for Ollama provider"})
-		return
-	}
-
-	// Check if there's an existing active configuration
-	existingConfig, err := h.llmConfigRepo.GetActive(c.Request.Context())
+	// Only the default configuration is managed here, never one that only
+	// routes point to
+	existingConfig, err := h.llmConfigRepo.GetDefault(c.Request.Context())
 	if err != nil && err != gorm.ErrRecordNotFound {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing configuration"})
 		return
 	}
 
-	// Handle API Key logic for OpenAI
-	var apiKeyToSave *string
-	if req.Provider == "openai" {
-		if req.APIKey != nil && *req.APIKey != "" {
-			// New key provided
-			apiKeyToSave = req.APIKey
-		} else if existingConfig != nil && existingConfig.APIKey != nil && *existingConfig.APIKey != "" {
-			// Reuse existing key
-			apiKeyToSave = existingConfig.APIKey
-		} else {
-			// No key provided and no existing key
-			c.JSON(http.StatusBadRequest, gin.H{"error": "API key is required for OpenAI provider"})
-			return
-		}
+	config := existingConfig
+	if config == nil {
+		config = &models.LLMConfig{}
 	}
+	if err := applyLLMConfigRequest(config, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	config.IsDefault = true
 
-	var config *models.LLMConfig
-
-	if err == gorm.ErrRecordNotFound {
-		// No existing active config, create new one
-		config = &models.LLMConfig{
-			Provider:      req.Provider,
-			BaseURL:       req.BaseURL,
-			OpenAIBaseURL: req.OpenAIBaseURL,
-			APIKey:        apiKeyToSave,
-			IsActive:      req.IsActive,
-		}
-
+	if existingConfig == nil {
+		// No default config yet, create one
 		if err := h.llmConfigRepo.Create(c.Request.Context(), config); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create LLM configuration"})
 			return
 		}
-	} else {
-		// Update existing config
-		existingConfig.Provider = req.Provider
-		existingConfig.BaseURL = req.BaseURL
-		existingConfig.OpenAIBaseURL = req.OpenAIBaseURL
-		existingConfig.APIKey = apiKeyToSave
-		existingConfig.IsActive = req.IsActive
-
-		if err := h.llmConfigRepo.Update(c.Request.Context(), existingConfig); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update LLM configuration"})
-			return
-		}
-		config = existingConfig
-	}
-
-	response := LLMConfigResponse{
-		ID:            config.ID,
-		Provider:      config.Provider,
-		BaseURL:       config.BaseURL,
-		OpenAIBaseURL: config.OpenAIBaseURL,
-		HasAPIKey:     config.APIKey != nil && *config.APIKey != "",
-		IsActive:      config.IsActive,
-		CreatedAt:     config.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt:     config.UpdatedAt.Format("2006-01-02 15:04:05"),
+	} else if err := h.llmConfigRepo.Update(c.Request.Context(), config); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update LLM configuration"})
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, newLLMConfigResponse(config))
 }
 
 // generateSecureAPIKey generates a cryptographically secure API key
@@ -2891,6 +3028,8 @@ func (h *Handler) DownloadFromYouTube(c *gin.Context) {
 		return
 	}
 
+	h.scheduleWaveform(job.ID)
+
 	c.JSON(http.StatusOK, job)
 }
 
@@ -3006,17 +3145,19 @@ func (h *Handler) SetUserDefaultProfile(c *gin.Context) {
 
 // UserSettingsResponse represents the user's settings
 type UserSettingsResponse struct {
-	AutoTranscriptionEnabled bool    `json:"auto_transcription_enabled"`
-	DefaultProfileID         *string `json:"default_profile_id,omitempty"`
+	AutoTranscriptionEnabled bool     `json:"auto_transcription_enabled"`
+	DefaultProfileID         *string  `json:"default_profile_id,omitempty"`
+	AutoSummaryTemplateIDs   []string `json:"auto_summary_template_ids"` // Summary templates run on each completed job
 }
 
 // UpdateUserSettingsRequest represents the request to update user settings
 type UpdateUserSettingsRequest struct {
-	AutoTranscriptionEnabled *bool `json:"auto_transcription_enabled,omitempty"`
+	AutoTranscriptionEnabled *bool     `json:"auto_transcription_enabled,omitempty"`
+	AutoSummaryTemplateIDs   *[]string `json:"auto_summary_template_ids,omitempty"`
 }
 
 // @Summary Get user settings
-// @Description Get the current user's settings including auto-transcription preference
+// @Description Get the current user's settings including auto-transcription preference and the summary templates run on completed jobs
 // @Tags user
 // @Produce json
 // @Success 200 {object} UserSettingsResponse
@@ -3040,6 +3181,7 @@ func (h *Handler) GetUserSettings(c *gin.Context) {
 	response := UserSettingsResponse{
 		AutoTranscriptionEnabled: user.AutoTranscriptionEnabled,
 		DefaultProfileID:         user.DefaultProfileID,
+		AutoSummaryTemplateIDs:   summarize.SplitTemplateIDs(user.AutoSummaryTemplates),
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -3080,6 +3222,20 @@ func (h *Handler) UpdateUserSettings(c *gin.Context) {
 	if req.AutoTranscriptionEnabled != nil {
 		user.AutoTranscriptionEnabled = *req.AutoTranscriptionEnabled
 	}
+	if req.AutoSummaryTemplateIDs != nil {
+		for _, id := range *req.AutoSummaryTemplateIDs {
+			template, err := h.summaryRepo.FindByID(c.Request.Context(), id)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get summary template"})
+				return
+			}
+			if template == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Summary template not found: " + id})
+				return
+			}
+		}
+		user.AutoSummaryTemplates = summarize.JoinTemplateIDs(*req.AutoSummaryTemplateIDs)
+	}
 
 	// Save updated user
 	if err := h.userRepo.Update(c.Request.Context(), user); err != nil {
@@ -3090,6 +3246,7 @@ func (h *Handler) UpdateUserSettings(c *gin.Context) {
 	response := UserSettingsResponse{
 		AutoTranscriptionEnabled: user.AutoTranscriptionEnabled,
 		DefaultProfileID:         user.DefaultProfileID,
+		AutoSummaryTemplateIDs:   summarize.SplitTemplateIDs(user.AutoSummaryTemplates),
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTranscriptImportSize caps the size of an imported transcript file
+const maxTranscriptImportSize = 50 << 20
+
+// ImportTranscription creates a completed job from audio plus an existing transcript
+// @Summary Import an existing transcript
+// @Description Upload audio together with an existing transcript (SRT, VTT, JSON, Otter/Descript TXT or plain text). The transcript is parsed and a completed job is created without re-transcribing. Optionally runs forced alignment to add word timings.
+// @Tags transcription
+// @Accept multipart/form-data
+// @Produce json
+// @Param audio formData file true "Audio file"
+// @Param transcript formData file true "Transcript file"
+// @Param title formData string false "Job title"
+// @Param language formData string false "Transcript language (required for alignment when the file does not specify it)"
+// @Param align formData bool false "Run forced alignment to get word timings"
+// @Success 200 {object} models.TranscriptionJob
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/transcription/import [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) ImportTranscription(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	userID := userIDVal.(uint)
+
+	audioHeader, err := c.FormFile(paramAudio)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Audio file is required"})
+		return
+	}
+	transcriptHeader, err := c.FormFile("transcript")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transcript file is required"})
+		return
+	}
+	if transcriptHeader.Size > maxTranscriptImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transcript file is too large"})
+		return
+	}
+
+	transcriptFile, err := transcriptHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read transcript file"})
+		return
+	}
+	transcriptData, err := io.ReadAll(io.LimitReader(transcriptFile, maxTranscriptImportSize))
+	transcriptFile.Close()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read transcript file"})
+		return
+	}
+
+	filePath, err := h.fileService.SaveUpload(audioHeader, h.config.UploadDir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		return
+	}
+
+	jobID := filepath.Base(filePath)
+	jobID = jobID[:len(jobID)-len(filepath.Ext(jobID))]
+
+	job := models.TranscriptionJob{
+		ID:        jobID,
+		AudioPath: filePath,
+		Status:    models.StatusProcessing,
+		UserID:    &userID,
+	}
+	if title := c.PostForm(paramTitle); title != "" {
+		job.Title = &title
+	} else {
+		title := strings.TrimSuffix(audioHeader.Filename, filepath.Ext(audioHeader.Filename))
+		job.Title = &title
+	}
+	if language := c.PostForm("language"); language != "" {
+		job.Parameters.Language = &language
+	}
+
+	if err := h.jobRepo.Create(c.Request.Context(), &job); err != nil {
+		_ = h.fileService.RemoveFile(filePath)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job"})
+		return
+	}
+
+	align := c.PostForm("align") == "true"
+	service := h.unifiedProcessor.GetUnifiedService()
+	if _, err := service.ImportTranscript(c.Request.Context(), &job, transcriptHeader.Filename, transcriptData, align); err != nil {
+		logger.Warn("Transcript import failed", "job_id", jobID, "error", err)
+		_ = h.jobRepo.Delete(c.Request.Context(), jobID)
+		_ = h.fileService.RemoveFile(filePath)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.scheduleWaveform(jobID)
+
+	updated, err := h.jobRepo.FindByID(c.Request.Context(), jobID)
+	if err != nil || updated == nil {
+		c.JSON(http.StatusOK, job)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
@@ -0,0 +1,339 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"scriberr/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LLMRouteRequest sets the route of a feature for the current user or, for
+// admins, globally. Targets are tried in order until one succeeds.
+type LLMRouteRequest struct {
+	Scope   string                  `json:"scope" binding:"omitempty,oneof=user global"` // Defaults to "user"
+	Targets []models.LLMRouteTarget `json:"targets" binding:"required,min=1"`
+}
+
+// LLMFeatureRoutes shows the routes of one feature
+type LLMFeatureRoutes struct {
+	Feature string                  `json:"feature"`
+	Global  []models.LLMRouteTarget `json:"global,omitempty"`
+	User    []models.LLMRouteTarget `json:"user,omitempty"` // Overrides the global route for the current user
+}
+
+func newLLMConfigResponse(config *models.LLMConfig) LLMConfigResponse {
+	return LLMConfigResponse{
+		ID:             config.ID,
+		Name:           config.Name,
+		Provider:       config.Provider,
+		BaseURL:        config.BaseURL,
+		OpenAIBaseURL:  config.OpenAIBaseURL,
+		HasAPIKey:      config.APIKey != nil && *config.APIKey != "",
+		EmbeddingModel: config.EmbeddingModel,
+		IsActive:       config.IsActive,
+		IsDefault:      config.IsDefault,
+		CreatedAt:      config.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      config.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// applyLLMConfigRequest validates req and copies it onto config. The stored
+// API key is kept when req does not provide a new one.
+func applyLLMConfigRequest(config *models.LLMConfig, req LLMConfigRequest) error {
+	// Validate provider-specific requirements
+	if req.Provider == "ollama" && (req.BaseURL == nil || *req.BaseURL == "") {
+		return fmt.Errorf("Base URL is required for Ollama provider")
+	}
+
+	// Handle API Key logic for OpenAI and Groq
+	var apiKeyToSave *string
+	if req.Provider != "ollama" {
+		if req.APIKey != nil && *req.APIKey != "" {
+			// New key provided
+			apiKeyToSave = req.APIKey
+		} else if config.APIKey != nil && *config.APIKey != "" {
+			// Reuse existing key
+			apiKeyToSave = config.APIKey
+		} else if req.Provider == "groq" {
+			return fmt.Errorf("API key is required for Groq provider")
+		} else {
+			return fmt.Errorf("API key is required for OpenAI provider")
+		}
+	}
+
+	if req.Name != "" {
+		config.Name = req.Name
+	}
+	config.Provider = req.Provider
+	config.BaseURL = req.BaseURL
+	config.OpenAIBaseURL = req.OpenAIBaseURL
+	config.APIKey = apiKeyToSave
+	config.EmbeddingModel = req.EmbeddingModel
+	config.IsActive = req.IsActive
+	config.IsDefault = req.IsDefault && req.IsActive
+	return nil
+}
+
+// isAdmin tells whether the current user is an admin
+func (h *Handler) isAdmin(c *gin.Context) bool {
+	if role, exists := c.Get("role"); exists {
+		return role == "admin"
+	}
+	userID := currentUserID(c)
+	if userID == nil {
+		return false
+	}
+	user, err := h.userRepo.FindByID(c.Request.Context(), *userID)
+	return err == nil && user != nil && user.Role == "admin"
+}
+
+// @Summary List LLM configurations
+// @Description List every LLM configuration that features can be routed to
+// @Tags llm
+// @Produce json
+// @Success 200 {array} LLMConfigResponse
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/llm/configs [get]
+func (h *Handler) ListLLMConfigs(c *gin.Context) {
+	configs, _, err := h.llmConfigRepo.List(c.Request.Context(), 0, 1000)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch LLM configurations"})
+		return
+	}
+	response := make([]LLMConfigResponse, len(configs))
+	for i := range configs {
+		response[i] = newLLMConfigResponse(&configs[i])
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Create LLM configuration
+// @Description Add a named LLM configuration (admin only)
+// @Tags llm
+// @Accept json
+// @Produce json
+// @Param request body LLMConfigRequest true "LLM configuration details"
+// @Success 201 {object} LLMConfigResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/llm/configs [post]
+func (h *Handler) CreateLLMConfig(c *gin.Context) {
+	var req LLMConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	config := &models.LLMConfig{}
+	if err := applyLLMConfigRequest(config, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.llmConfigRepo.Create(c.Request.Context(), config); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create LLM configuration"})
+		return
+	}
+	c.JSON(http.StatusCreated, newLLMConfigResponse(config))
+}
+
+// @Summary Update LLM configuration
+// @Description Update a named LLM configuration (admin only). The stored API key is kept unless a new one is given.
+// @Tags llm
+// @Accept json
+// @Produce json
+// @Param id path int true "LLM configuration ID"
+// @Param request body LLMConfigRequest true "LLM configuration details"
+// @Success 200 {object} LLMConfigResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/llm/configs/{id} [put]
+func (h *Handler) UpdateLLMConfig(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid configuration ID"})
+		return
+	}
+	var req LLMConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	config, err := h.llmConfigRepo.FindByID(c.Request.Context(), uint(id))
+	if err != nil || config == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "LLM configuration not found"})
+		return
+	}
+	if err := applyLLMConfigRequest(config, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.llmConfigRepo.Update(c.Request.Context(), config); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update LLM configuration"})
+		return
+	}
+	c.JSON(http.StatusOK, newLLMConfigResponse(config))
+}
+
+// @Summary Delete LLM configuration
+// @Description Delete a named LLM configuration (admin only). Routes skip targets whose configuration is gone.
+// @Tags llm
+// @Param id path int true "LLM configuration ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/llm/configs/{id} [delete]
+func (h *Handler) DeleteLLMConfig(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid configuration ID"})
+		return
+	}
+	config, err := h.llmConfigRepo.FindByID(c.Request.Context(), uint(id))
+	if err != nil || config == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "LLM configuration not found"})
+		return
+	}
+	if err := h.llmConfigRepo.Delete(c.Request.Context(), config.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete LLM configuration"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary List LLM routes
+// @Description List the global routes of every routable feature and the current user's overrides
+// @Tags llm
+// @Produce json
+// @Success 200 {array} LLMFeatureRoutes
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/llm/routes [get]
+func (h *Handler) ListLLMRoutes(c *gin.Context) {
+	routes, err := h.llmConfigRepo.ListRoutes(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch LLM routes"})
+		return
+	}
+
+	response := make([]LLMFeatureRoutes, len(models.LLMFeatures))
+	for i, feature := range models.LLMFeatures {
+		response[i].Feature = feature
+		for _, route := range routes {
+			if route.Feature != feature {
+				continue
+			}
+			if route.UserID == nil {
+				response[i].Global = route.Targets
+			} else {
+				response[i].User = route.Targets
+			}
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Set LLM route
+// @Description Route a feature (chat, chat_title, summarization, smart_analysis, translation, embeddings) to LLM configurations and models, tried in order. User routes override the global route; only admins can set global routes.
+// @Tags llm
+// @Accept json
+// @Produce json
+// @Param feature path string true "Feature"
+// @Param request body LLMRouteRequest true "Route"
+// @Success 200 {object} models.LLMRoute
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/llm/routes/{feature} [put]
+func (h *Handler) SaveLLMRoute(c *gin.Context) {
+	feature := c.Param("feature")
+	if !slices.Contains(models.LLMFeatures, feature) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown feature: " + feature})
+		return
+	}
+	var req LLMRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	userID, ok := h.routeScope(c, req.Scope)
+	if !ok {
+		return
+	}
+
+	for _, target := range req.Targets {
+		config, err := h.llmConfigRepo.FindByID(c.Request.Context(), target.ConfigID)
+		if err != nil || config == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("LLM configuration %d not found", target.ConfigID)})
+			return
+		}
+	}
+
+	route := &models.LLMRoute{UserID: userID, Feature: feature, Targets: req.Targets}
+	if err := h.llmConfigRepo.SaveRoute(c.Request.Context(), route); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save LLM route"})
+		return
+	}
+	c.JSON(http.StatusOK, route)
+}
+
+// @Summary Delete LLM route
+// @Description Remove the route of a feature. Without a user route the global one applies; without either, the default configuration.
+// @Tags llm
+// @Param feature path string true "Feature"
+// @Param scope query string false "user (default) or global"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/llm/routes/{feature} [delete]
+func (h *Handler) DeleteLLMRoute(c *gin.Context) {
+	feature := c.Param("feature")
+	if !slices.Contains(models.LLMFeatures, feature) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown feature: " + feature})
+		return
+	}
+	userID, ok := h.routeScope(c, c.Query("scope"))
+	if !ok {
+		return
+	}
+	if err := h.llmConfigRepo.DeleteRoute(c.Request.Context(), feature, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete LLM route"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// routeScope returns the user ID routes of scope belong to, nil for global
+// routes, which only admins may change. On failure the error response has
+// been written.
+func (h *Handler) routeScope(c *gin.Context, scope string) (*uint, bool) {
+	switch scope {
+	case "", "user":
+		userID := currentUserID(c)
+		if userID == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return nil, false
+		}
+		return userID, true
+	case "global":
+		if !h.isAdmin(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin privileges required"})
+			return nil, false
+		}
+		return nil, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scope must be user or global"})
+		return nil, false
+	}
+}
@@ -0,0 +1,404 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/internal/review"
+)
+
+// ReviewCreateRequest queues a transcript's weak segments for review
+type ReviewCreateRequest struct {
+	Threshold *float64 `json:"threshold" binding:"omitempty,gte=0,lte=1"` // Defaults to the job's review_threshold, then 0.6
+}
+
+// ReviewAssignRequest hands a review to a reviewer; a null user_id puts it
+// back in the queue
+type ReviewAssignRequest struct {
+	UserID *uint `json:"user_id"`
+}
+
+// ReviewDecisionRequest records decisions on queued segments
+type ReviewDecisionRequest struct {
+	Decisions []review.Decision `json:"decisions" binding:"required,min=1"`
+}
+
+// SetReviewService sets the service behind the review endpoints
+func (h *Handler) SetReviewService(s *review.Service) {
+	h.reviews = s
+}
+
+// reviewsEnabled writes an error response unless reviews are set up
+func (h *Handler) reviewsEnabled(c *gin.Context) bool {
+	if h.reviews == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Reviews are not enabled"})
+		return false
+	}
+	return true
+}
+
+// ListReviews lists the review queue across transcriptions
+// @Summary List reviews
+// @Description Get the reviews of the user's transcriptions and the reviews assigned to the user, oldest first, with their progress. Admins see every review.
+// @Tags review
+// @Produce json
+// @Param status query string false "pending, in_review or reviewed"
+// @Param assignee query string false "me, none or a user ID"
+// @Success 200 {array} models.Review
+// @Failure 400 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/reviews [get]
+func (h *Handler) ListReviews(c *gin.Context) {
+	if !h.reviewsEnabled(c) {
+		return
+	}
+	userID := currentUserID(c)
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	filter := repository.ReviewFilter{Status: c.Query("status")}
+	if !h.isAdmin(c) {
+		filter.UserID = userID
+	}
+	switch filter.Status {
+	case "", models.ReviewStatusPending, models.ReviewStatusInReview, models.ReviewStatusReviewed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, in_review or reviewed"})
+		return
+	}
+	switch assignee := c.Query("assignee"); assignee {
+	case "":
+	case "me":
+		filter.AssigneeID = userID
+	case "none":
+		filter.Unassigned = true
+	default:
+		id, err := strconv.ParseUint(assignee, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "assignee must be me, none or a user ID"})
+			return
+		}
+		assigneeID := uint(id)
+		filter.AssigneeID = &assigneeID
+	}
+	reviews, err := h.reviews.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
+	}
+	c.JSON(http.StatusOK, reviews)
+}
+
+// GetReview returns the review queue of a transcript
+// @Summary Get the review
+// @Description Get the segments of a transcript queued for review, with their confidence, quality flags and decisions, and the review's progress. Available to the owner and the assigned reviewer.
+// @Tags review
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Success 200 {object} models.Review
+// @Failure 404 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/review [get]
+func (h *Handler) GetReview(c *gin.Context) {
+	if !h.reviewsEnabled(c) {
+		return
+	}
+	_, r, ok := h.findReview(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// CreateReview queues a transcript's weak segments for review
+// @Summary Queue a transcript for review
+// @Description Queue the segments of a transcript whose confidence is below the threshold, or that the quality check flagged, for a person to check. The previous review of the transcript and its decisions are replaced.
+// @Tags review
+// @Accept json
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Param request body ReviewCreateRequest false "Options"
+// @Success 200 {object} models.Review
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/review [post]
+func (h *Handler) CreateReview(c *gin.Context) {
+	if !h.reviewsEnabled(c) {
+		return
+	}
+	var req ReviewCreateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	if job.Transcript == nil || *job.Transcript == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transcription has no transcript"})
+		return
+	}
+	threshold := review.DefaultThreshold
+	if req.Threshold != nil {
+		threshold = *req.Threshold
+	} else if job.Parameters.ReviewThreshold != nil {
+		threshold = *job.Parameters.ReviewThreshold
+	}
+	r, err := h.reviews.Create(c.Request.Context(), job, threshold)
+	if err != nil {
+		if errors.Is(err, review.ErrNothingToReview) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No segment needs review"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// ClaimReview assigns a review to the current user
+// @Summary Claim a review
+// @Description Assign an unassigned review to the current user
+// @Tags review
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Success 200 {object} models.Review
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/review/claim [post]
+func (h *Handler) ClaimReview(c *gin.Context) {
+	if !h.reviewsEnabled(c) {
+		return
+	}
+	_, r, ok := h.findReview(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if err := h.reviews.Claim(c.Request.Context(), r, *currentUserID(c)); err != nil {
+		h.reviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// AssignReview hands a review to a reviewer
+// @Summary Assign a review
+// @Description Assign the review of a transcript to a user, who can then see the transcript's review and work it, or put it back in the queue with a null user_id. Only the owner of the transcript and admins can assign.
+// @Tags review
+// @Accept json
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Param request body ReviewAssignRequest true "Reviewer"
+// @Success 200 {object} models.Review
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/review/assignee [put]
+func (h *Handler) AssignReview(c *gin.Context) {
+	if !h.reviewsEnabled(c) {
+		return
+	}
+	var req ReviewAssignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	_, r, ok := h.findReview(c, job.ID)
+	if !ok {
+		return
+	}
+	if req.UserID != nil {
+		user, err := h.userRepo.FindByID(c.Request.Context(), *req.UserID)
+		if err != nil || user == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+			return
+		}
+	}
+	if err := h.reviews.Assign(c.Request.Context(), r, req.UserID); err != nil {
+		h.reviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// DecideReview accepts or corrects queued segments
+// @Summary Review segments
+// @Description Accept queued segments as they are, or correct them by giving their text. Corrections are applied to the transcript; nothing is applied if a corrected segment changed since it was queued. Deciding on an unassigned review claims it.
+// @Tags review
+// @Accept json
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Param request body ReviewDecisionRequest true "Decisions"
+// @Success 200 {object} models.Review
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/review/decisions [post]
+func (h *Handler) DecideReview(c *gin.Context) {
+	if !h.reviewsEnabled(c) {
+		return
+	}
+	var req ReviewDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	job, r, ok := h.findReview(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	queued := make(map[int]bool, len(r.Items))
+	for _, item := range r.Items {
+		queued[item.Segment] = true
+	}
+	decided := make(map[int]bool, len(req.Decisions))
+	for _, d := range req.Decisions {
+		if !queued[d.Segment] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Segment %d is not queued for review", d.Segment)})
+			return
+		}
+		if decided[d.Segment] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Segment %d is decided twice", d.Segment)})
+			return
+		}
+		if d.Text != nil && *d.Text == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("The correction of segment %d is empty", d.Segment)})
+			return
+		}
+		decided[d.Segment] = true
+	}
+
+	transcript := job.Transcript
+	if err := h.reviews.Decide(c.Request.Context(), job, r, *currentUserID(c), req.Decisions); err != nil {
+		h.reviewError(c, err)
+		return
+	}
+	if job.Transcript != transcript && h.retriever != nil {
+		h.retriever.Schedule(job.ID)
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// CompleteReview closes a review
+// @Summary Complete a review
+// @Description Close the review of a transcript once every queued segment is accepted or corrected. The transcription's review_status becomes reviewed.
+// @Tags review
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Success 200 {object} models.Review
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/review/complete [post]
+func (h *Handler) CompleteReview(c *gin.Context) {
+	if !h.reviewsEnabled(c) {
+		return
+	}
+	_, r, ok := h.findReview(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if err := h.reviews.Complete(c.Request.Context(), r, *currentUserID(c)); err != nil {
+		h.reviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// DeleteReview discards the review of a transcript
+// @Summary Delete the review
+// @Description Discard the review of a transcript and clear its review_status. Corrections already applied stay in the transcript.
+// @Tags review
+// @Param id path string true "Transcription ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/review [delete]
+func (h *Handler) DeleteReview(c *gin.Context) {
+	if !h.reviewsEnabled(c) {
+		return
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	if _, _, ok := h.findReview(c, job.ID); !ok {
+		return
+	}
+	if err := h.reviews.Delete(c.Request.Context(), job.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete review"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// findReview loads a job and its review for the current user, who must own
+// the job, be an admin or be the assigned reviewer. On failure the error
+// response has been written.
+func (h *Handler) findReview(c *gin.Context, jobID string) (*models.TranscriptionJob, *models.Review, bool) {
+	userID := currentUserID(c)
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return nil, nil, false
+	}
+	ctx := c.Request.Context()
+	job, err := h.jobRepo.FindByID(ctx, jobID)
+	if err != nil || job == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return nil, nil, false
+	}
+	r, err := h.reviews.Get(ctx, job.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch review"})
+		return nil, nil, false
+	}
+	owner := job.UserID == nil || *job.UserID == *userID
+	assignee := r != nil && r.AssigneeID != nil && *r.AssigneeID == *userID
+	if !owner && !assignee && !h.isAdmin(c) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return nil, nil, false
+	}
+	if r == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transcription has no review"})
+		return nil, nil, false
+	}
+	return job, r, true
+}
+
+// reviewError writes the response of a failed review operation
+func (h *Handler) reviewError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, review.ErrClaimed), errors.Is(err, review.ErrCompleted), errors.Is(err, review.ErrIncomplete):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, review.ErrTranscriptChanged):
+		c.JSON(http.StatusConflict, gin.H{"error": "The transcript changed since it was queued; queue it for review again"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -136,7 +136,10 @@ func SetupRoutes(handler *Handler, authService *auth.AuthService) *gin.Engine {
 				uploadRoutes.POST("/upload", handler.UploadAudio)
 				uploadRoutes.POST("/upload-video", handler.UploadVideo)
 				uploadRoutes.POST("/upload-multitrack", handler.UploadMultiTrack)
+				uploadRoutes.POST("/import", handler.ImportTranscription)
 				uploadRoutes.GET("/:id/audio", handler.GetAudioFile) // Audio streaming shouldn't be compressed
+				uploadRoutes.GET("/:id/audio/clip", handler.GetAudioClip)
+				uploadRoutes.GET("/:id/waveform", handler.GetWaveform)
 			}
 
 			// Regular API routes with compression
@@ -147,11 +150,39 @@ func SetupRoutes(handler *Handler, authService *auth.AuthService) *gin.Engine {
 			transcription.GET("/:id/logs", handler.GetJobLogs)
 			transcription.GET("/:id/status", handler.GetJobStatus)
 			transcription.GET("/:id/transcript", handler.GetTranscript)
+			transcription.GET("/:id/export", handler.ExportTranscript)
+			transcription.POST("/:id/highlights", handler.CreateHighlightReel)
 			transcription.GET("/:id/execution", handler.GetJobExecutionData)
 			transcription.GET("/:id/merge-status", handler.GetMergeStatus)
 			transcription.GET("/:id/track-progress", handler.GetTrackProgress)
 			transcription.PUT("/:id/title", handler.UpdateTranscriptionTitle)
+			transcription.PUT("/:id/tags", handler.UpdateTranscriptionTags)
 			transcription.GET("/:id/summary", handler.GetSummaryForTranscription)
+			transcription.GET("/:id/summaries", handler.ListSummaries)
+			transcription.POST("/:id/summaries", handler.QueueSummary)
+			transcription.GET("/:id/summaries/:summaryId", handler.GetSummary)
+			transcription.DELETE("/:id/summaries/:summaryId", handler.DeleteSummary)
+			transcription.GET("/:id/action-items", handler.ListActionItems)
+			transcription.POST("/:id/action-items", handler.ExtractActionItems)
+			transcription.GET("/:id/chapters", handler.ListChapters)
+			transcription.POST("/:id/chapters", handler.GenerateChapters)
+			transcription.GET("/:id/chapters/export", handler.ExportChapters)
+			transcription.GET("/:id/analytics", handler.GetConversationAnalytics)
+			transcription.GET("/:id/translations", handler.ListTranslations)
+			transcription.POST("/:id/translations", handler.CreateTranslation)
+			transcription.GET("/:id/translations/:language", handler.GetTranslation)
+			transcription.DELETE("/:id/translations/:language", handler.DeleteTranslation)
+			transcription.GET("/:id/smart-analysis", handler.GetSmartAnalysis)
+			transcription.POST("/:id/smart-analysis", handler.RunSmartAnalysis)
+			transcription.POST("/:id/smart-analysis/review", handler.ReviewSmartAnalysis)
+			transcription.DELETE("/:id/smart-analysis", handler.DeleteSmartAnalysis)
+			transcription.GET("/:id/review", handler.GetReview)
+			transcription.POST("/:id/review", handler.CreateReview)
+			transcription.DELETE("/:id/review", handler.DeleteReview)
+			transcription.POST("/:id/review/claim", handler.ClaimReview)
+			transcription.PUT("/:id/review/assignee", handler.AssignReview)
+			transcription.POST("/:id/review/decisions", handler.DecideReview)
+			transcription.POST("/:id/review/complete", handler.CompleteReview)
 			transcription.GET("/:id", handler.GetTranscriptionJob)
 			transcription.DELETE("/:id", handler.DeleteTranscriptionJob)
 			transcription.GET("/list", handler.ListTranscriptionJobs)
@@ -199,6 +230,22 @@ func SetupRoutes(handler *Handler, authService *auth.AuthService) *gin.Engine {
 			user.PUT("/settings", handler.UpdateUserSettings)
 		}
 
+		// Search routes (require authentication)
+		search := v1.Group("/search")
+		search.Use(middleware.AuthMiddleware(authService))
+		{
+			search.GET("", handler.SearchTranscripts)
+		}
+
+		// Archive routes (require authentication)
+		archive := v1.Group("/archive")
+		archive.Use(middleware.AuthMiddleware(authService))
+		archive.Use(middleware.NoCompressionMiddleware())
+		{
+			archive.GET("/export", handler.ExportArchive)
+			archive.POST("/import", handler.ImportArchive)
+		}
+
 		// Admin routes (require authentication)
 		admin := v1.Group("/admin")
 		admin.Use(middleware.AuthMiddleware(authService))
@@ -216,6 +263,17 @@ func SetupRoutes(handler *Handler, authService *auth.AuthService) *gin.Engine {
 				users.PUT("/:id/role", handler.UpdateUserRole)
 				users.DELETE("/:id", handler.DeleteUser)
 			}
+
+			// Usage and cost accounting (Admin ONLY)
+			usage := admin.Group("/usage")
+			usage.Use(middleware.AdminMiddleware(authService))
+			{
+				usage.GET("/report", handler.GetUsageReport)
+				usage.GET("/entries", handler.ListUsageEntries)
+				usage.GET("/prices", handler.ListModelPrices)
+				usage.PUT("/prices", handler.SaveModelPrice)
+				usage.DELETE("/prices/:id", handler.DeleteModelPrice)
+			}
 		}
 
 		// LLM configuration routes (require authentication)
@@ -224,6 +282,19 @@ func SetupRoutes(handler *Handler, authService *auth.AuthService) *gin.Engine {
 		{
 			llm.GET("/config", handler.GetLLMConfig)
 			llm.POST("/config", handler.SaveLLMConfig)
+			llm.GET("/configs", handler.ListLLMConfigs)
+			llm.GET("/routes", handler.ListLLMRoutes)
+			llm.PUT("/routes/:feature", handler.SaveLLMRoute)
+			llm.DELETE("/routes/:feature", handler.DeleteLLMRoute)
+
+			// Managing configurations (Admin ONLY)
+			configs := llm.Group("/configs")
+			configs.Use(middleware.AdminMiddleware(authService))
+			{
+				configs.POST("", handler.CreateLLMConfig)
+				configs.PUT("/:id", handler.UpdateLLMConfig)
+				configs.DELETE("/:id", handler.DeleteLLMConfig)
+			}
 		}
 
 		// Summarization templates routes (require authentication)
@@ -248,6 +319,11 @@ func SetupRoutes(handler *Handler, authService *auth.AuthService) *gin.Engine {
 			chat.GET("/transcriptions/:transcription_id/sessions", handler.GetChatSessions)
 			chat.GET("/sessions/:session_id", handler.GetChatSession)
 			chat.POST("/sessions/:session_id/messages", handler.SendChatMessage)
+			chat.POST("/sessions/:session_id/tool-calls/:message_id/confirm", handler.ConfirmChatToolCall)
+			chat.PUT("/sessions/:session_id/messages/:message_id", handler.EditChatMessage)
+			chat.POST("/sessions/:session_id/messages/:message_id/regenerate", handler.RegenerateChatMessage)
+			chat.GET("/sessions/:session_id/branches", handler.ListChatBranches)
+			chat.PUT("/sessions/:session_id/branch", handler.SetActiveChatBranch)
 			chat.PUT("/sessions/:session_id/title", handler.UpdateChatSessionTitle)
 			chat.POST("/sessions/:session_id/title/auto", handler.AutoGenerateChatTitle)
 			chat.DELETE("/sessions/:session_id", handler.DeleteChatSession)
@@ -262,6 +338,31 @@ func SetupRoutes(handler *Handler, authService *auth.AuthService) *gin.Engine {
 			notes.DELETE("/:note_id", handler.DeleteNote)
 		}
 
+		// Speaker analytics across transcriptions (require authentication)
+		analytics := v1.Group("/analytics")
+		analytics.Use(middleware.AuthMiddleware(authService))
+		{
+			analytics.GET("/speakers", handler.ListSpeakerAnalytics)
+			analytics.GET("/speakers/:name", handler.GetSpeakerAnalytics)
+		}
+
+		// Tasks: action items across transcriptions (require authentication)
+		tasks := v1.Group("/tasks")
+		tasks.Use(middleware.AuthMiddleware(authService))
+		{
+			tasks.GET("", handler.ListTasks)
+			tasks.GET("/export", handler.ExportTasks)
+			tasks.PATCH("/:task_id", handler.UpdateTask)
+			tasks.DELETE("/:task_id", handler.DeleteTask)
+		}
+
+		// Review queue across transcriptions (require authentication)
+		reviews := v1.Group("/reviews")
+		reviews.Use(middleware.AuthMiddleware(authService))
+		{
+			reviews.GET("", handler.ListReviews)
+		}
+
 		// Summarization route (require authentication)
 		summarize := v1.Group("/summarize")
 		summarize.Use(middleware.AuthMiddleware(authService))
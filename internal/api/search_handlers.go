@@ -0,0 +1,169 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSearchLimit caps the page size of transcript searches
+const maxSearchLimit = 100
+
+// SearchTranscripts runs a full-text search over the user's transcript segments
+// @Summary Search transcripts
+// @Description Full-text search over transcript segments of the current user's jobs. The query uses SQLite FTS5 syntax: "quoted phrases", prefix* terms and AND/OR/NOT. Results are ranked by relevance and include the segment timestamps, speaker and a snippet with matches wrapped in <mark></mark>.
+// @Tags search
+// @Produce json
+// @Param q query string true "Search query"
+// @Param speaker query string false "Speaker label or mapped speaker name"
+// @Param tag query string false "Job tag"
+// @Param status query string false "Job status"
+// @Param from query string false "Jobs created at or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Jobs created before (RFC3339, or YYYY-MM-DD for the whole day)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Results per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/search [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) SearchTranscripts(c *gin.Context) {
+	userIDVal, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter q is required"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > maxSearchLimit {
+		limit = 20
+	}
+
+	params := repository.SegmentSearchParams{
+		UserID:  userIDVal.(uint),
+		Query:   query,
+		Speaker: strings.TrimSpace(c.Query("speaker")),
+		Tag:     strings.TrimSpace(c.Query("tag")),
+		Status:  models.JobStatus(c.Query("status")),
+		Offset:  (page - 1) * limit,
+		Limit:   limit,
+	}
+
+	var ok bool
+	if params.From, ok = parseSearchDate(c.Query("from"), false); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date"})
+		return
+	}
+	if params.To, ok = parseSearchDate(c.Query("to"), true); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date"})
+		return
+	}
+
+	hits, total, err := h.jobRepo.SearchSegments(c.Request.Context(), params)
+	if err != nil {
+		// Malformed FTS5 expressions surface as query errors
+		logger.Warn("Transcript search failed", "query", query, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search query"})
+		return
+	}
+	if hits == nil {
+		hits = []repository.SegmentSearchHit{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":   query,
+		"results": hits,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// parseSearchDate accepts RFC3339 timestamps or plain dates. A plain date used
+// as an upper bound covers the whole day.
+func parseSearchDate(value string, endOfDay bool) (*time.Time, bool) {
+	if value == "" {
+		return nil, true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, true
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, false
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, true
+}
+
+// UpdateTranscriptionTags replaces the tags of a transcription job
+// @Summary Update transcription tags
+// @Description Replace the tags of a transcription job. Tags can be used to filter transcript searches.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param id path string true "Job ID"
+// @Param request body map[string][]string true "Tags update request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/transcription/{id}/tags [put]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) UpdateTranscriptionTags(c *gin.Context) {
+	var body struct {
+		Tags []string `json:"tags" binding:"max=50,dive,max=64"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+
+	tags := make([]string, 0, len(body.Tags))
+	seen := make(map[string]bool)
+	for _, tag := range body.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	if err := h.jobRepo.UpdateTags(c.Request.Context(), job.ID, tags); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":   job.ID,
+		"tags": tags,
+	})
+}
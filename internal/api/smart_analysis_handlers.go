@@ -0,0 +1,229 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/models"
+	"scriberr/internal/smartanalysis"
+)
+
+// SmartAnalysisRequest runs the smart analysis of a transcript on demand
+type SmartAnalysisRequest struct {
+	Model string `json:"model"` // Defaults to the smart analysis route's model, then the default summary model
+}
+
+// SmartAnalysisReviewRequest accepts and rejects proposed changes by segment
+// index. All decides every change still pending.
+type SmartAnalysisReviewRequest struct {
+	Accept []int  `json:"accept"`
+	Reject []int  `json:"reject"`
+	All    string `json:"all" binding:"omitempty,oneof=accept reject"`
+}
+
+// SetSmartAnalysisService sets the service behind the smart analysis
+// endpoints
+func (h *Handler) SetSmartAnalysisService(s *smartanalysis.Service) {
+	h.smartAnalysis = s
+}
+
+// smartAnalysisEnabled writes an error response unless smart analysis is set
+// up
+func (h *Handler) smartAnalysisEnabled(c *gin.Context) bool {
+	if h.smartAnalysis == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Smart analysis is not enabled"})
+		return false
+	}
+	return true
+}
+
+// GetSmartAnalysis returns the corrections proposed for a transcript
+// @Summary Get the smart analysis
+// @Description Get the corrections the smart analysis model proposed for a transcript, segment by segment with the original and proposed text and speaker, and their review state. Transcripts are analyzed when their transcription completes, unless the job sets skip_smart_analysis or no model is routed for smart_analysis.
+// @Tags smart-analysis
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Success 200 {object} models.SmartAnalysis
+// @Failure 404 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/smart-analysis [get]
+func (h *Handler) GetSmartAnalysis(c *gin.Context) {
+	if !h.smartAnalysisEnabled(c) {
+		return
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	analysis, ok := h.findSmartAnalysis(c, job)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, analysis)
+}
+
+// RunSmartAnalysis analyzes a transcript on demand
+// @Summary Run the smart analysis
+// @Description Proofread a transcript with the smart analysis model, in chunks covering the whole transcript, and propose corrections for review. The job's smart_analysis_prompt replaces the default instructions for the transcript language. The previous analysis and its review state are replaced; the transcript is not changed until changes are accepted.
+// @Tags smart-analysis
+// @Accept json
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Param request body SmartAnalysisRequest false "Options"
+// @Success 200 {object} models.SmartAnalysis
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/smart-analysis [post]
+func (h *Handler) RunSmartAnalysis(c *gin.Context) {
+	if !h.smartAnalysisEnabled(c) {
+		return
+	}
+	var req SmartAnalysisRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	if job.Transcript == nil || *job.Transcript == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transcription has no transcript"})
+		return
+	}
+	analysis, err := h.smartAnalysis.Run(c.Request.Context(), job, req.Model)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, analysis)
+}
+
+// ReviewSmartAnalysis accepts and rejects proposed corrections
+// @Summary Review the smart analysis
+// @Description Accept or reject the proposed corrections of a transcript by segment index. Accepted corrections are applied to the transcript; rejected ones can still be accepted later. Nothing is applied if a segment to correct changed since the analysis.
+// @Tags smart-analysis
+// @Accept json
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Param request body SmartAnalysisReviewRequest true "Decisions"
+// @Success 200 {object} models.SmartAnalysis
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/smart-analysis/review [post]
+func (h *Handler) ReviewSmartAnalysis(c *gin.Context) {
+	if !h.smartAnalysisEnabled(c) {
+		return
+	}
+	var req SmartAnalysisReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	analysis, ok := h.findSmartAnalysis(c, job)
+	if !ok {
+		return
+	}
+
+	changes := make(map[int]string, len(analysis.Changes))
+	for _, change := range analysis.Changes {
+		changes[change.Segment] = change.Status
+		if change.Status == models.ChangeStatusPending {
+			switch req.All {
+			case "accept":
+				req.Accept = append(req.Accept, change.Segment)
+			case "reject":
+				req.Reject = append(req.Reject, change.Segment)
+			}
+		}
+	}
+	decided := make(map[int]bool, len(req.Accept)+len(req.Reject))
+	for _, segment := range append(append([]int{}, req.Accept...), req.Reject...) {
+		status, ok := changes[segment]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("No change proposed for segment %d", segment)})
+			return
+		}
+		if decided[segment] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Segment %d is both accepted and rejected", segment)})
+			return
+		}
+		if status == models.ChangeStatusAccepted {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("The change of segment %d is already applied", segment)})
+			return
+		}
+		decided[segment] = true
+	}
+
+	if err := h.smartAnalysis.Review(c.Request.Context(), job, analysis, req.Accept, req.Reject); err != nil {
+		if errors.Is(err, smartanalysis.ErrTranscriptChanged) {
+			c.JSON(http.StatusConflict, gin.H{"error": "The transcript changed since it was analyzed; run the analysis again"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Accept) > 0 && h.retriever != nil {
+		h.retriever.Schedule(job.ID)
+	}
+	c.JSON(http.StatusOK, analysis)
+}
+
+// DeleteSmartAnalysis discards the corrections proposed for a transcript
+// @Summary Delete the smart analysis
+// @Description Discard the smart analysis of a transcript. Corrections already accepted stay in the transcript.
+// @Tags smart-analysis
+// @Param id path string true "Transcription ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/smart-analysis [delete]
+func (h *Handler) DeleteSmartAnalysis(c *gin.Context) {
+	if !h.smartAnalysisEnabled(c) {
+		return
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	if _, ok := h.findSmartAnalysis(c, job); !ok {
+		return
+	}
+	if err := h.smartAnalysis.Delete(c.Request.Context(), job.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete smart analysis"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// findSmartAnalysis loads the analysis of job. On failure the error response
+// has been written.
+func (h *Handler) findSmartAnalysis(c *gin.Context, job *models.TranscriptionJob) (*models.SmartAnalysis, bool) {
+	analysis, err := h.smartAnalysis.Get(c.Request.Context(), job.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch smart analysis"})
+		return nil, false
+	}
+	if analysis == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transcription has no smart analysis"})
+		return nil, false
+	}
+	return analysis, true
+}
@@ -3,34 +3,74 @@ package api
 import (
 	"bufio"
 	"context"
-	"log"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
-	"scriberr/internal/llm"
 	"scriberr/internal/models"
+	"scriberr/internal/summarize"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// SummarizeRequest asks for a summary of a transcription. Content, if set, is
+// summarized as is in one call. Otherwise the server summarizes the
+// transcript with the template's prompt, splitting transcripts longer than
+// the model's context window into chunks. Model is required unless the
+// template or the summarization route sets one.
 type SummarizeRequest struct {
-	Model           string  `json:"model" binding:"required"`
-	Content         string  `json:"content" binding:"required"`
+	Model           string  `json:"model,omitempty"`
+	Content         string  `json:"content,omitempty"`
 	TranscriptionID string  `json:"transcription_id" binding:"required"`
 	TemplateID      *string `json:"template_id,omitempty"`
+	Mode            string  `json:"mode,omitempty" binding:"omitempty,oneof=auto single map_reduce"` // Defaults to "auto"
 }
 
-// Summarize streams LLM output for a given content prompt
+// QueueSummaryRequest asks for a summary of a transcription's transcript to
+// be generated in the background
+type QueueSummaryRequest struct {
+	Model      string  `json:"model,omitempty"`
+	TemplateID *string `json:"template_id,omitempty"`
+	Mode       string  `json:"mode,omitempty" binding:"omitempty,oneof=auto single map_reduce"` // Defaults to "auto"
+}
+
+// SummarizeEvent is one event of a summary streamed as server-sent events
+type SummarizeEvent struct {
+	Type  string `json:"type"`            // "content", "progress", "done" or "error"
+	Text  string `json:"text,omitempty"`  // Summary text, for content events
+	Stage string `json:"stage,omitempty"` // "map" or "reduce", for progress events
+	Done  int    `json:"done,omitempty"`  // Finished steps of the stage
+	Total int    `json:"total,omitempty"` // Steps of the stage
+	Error string `json:"error,omitempty"`
+}
+
+// SetSummaryService sets the service behind the summary endpoints
+func (h *Handler) SetSummaryService(s *summarize.Service) {
+	h.summaries = s
+}
+
+// summariesEnabled writes an error response when no summary service is set
+func (h *Handler) summariesEnabled(c *gin.Context) bool {
+	if h.summaries == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Summaries are not enabled"})
+		return false
+	}
+	return true
+}
+
+// Summarize queues a summary of a transcription and streams it
 // @Summary Summarize content
-// @Description Stream an LLM-generated summary for provided content; persists latest summary for the transcription
+// @Description Queue an LLM-generated summary of a transcription and stream it as it is generated. The summary is generated in the background and stored in the transcription's summary history even if the client disconnects; its ID is in the X-Summary-ID header. Without content, the server summarizes the transcript with the template; transcripts longer than the model's context window are summarized in chunks in parallel, then combined. Send "Accept: text/event-stream" to receive SummarizeEvent server-sent events with per-chunk progress instead of plain text.
 // @Tags summarize
 // @Accept json
+// @Produce text/plain
 // @Produce text/event-stream
 // @Param request body SummarizeRequest true "Summarize request"
-// @Success 200 {string} string "Event stream"
+// @Success 200 {string} string "Summary stream"
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Security ApiKeyAuth
 // @Security BearerAuth
@@ -42,150 +82,265 @@ func (h *Handler) Summarize(c *gin.Context) {
 		return
 	}
 
-	if _, err := h.checkJobOwnership(c, req.TranscriptionID); err != nil {
+	job, err := h.checkJobOwnership(c, req.TranscriptionID)
+	if err != nil {
 		return
 	}
-
-	svc, provider, err := h.getLLMService(c.Request.Context())
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	summary := &models.Summary{
+		TranscriptionID: req.TranscriptionID,
+		TemplateID:      req.TemplateID,
+		Model:           req.Model,
+		Mode:            req.Mode,
+		Input:           req.Content,
+	}
+	if !h.queueSummary(c, job, summary) {
+		return
+	}
+	updates, ok := h.summaries.Watch(c.Request.Context(), summary.ID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Summary is not being generated"})
 		return
 	}
-
-	// Prepare chat messages: simple single-user message with full content
-	messages := []llm.ChatMessage{{Role: "user", Content: req.Content}}
-
-	start := time.Now()
-	log.Printf("[summarize] start transcription_id=%s provider=%s model=%s content_len=%d", req.TranscriptionID, provider, req.Model, len(req.Content))
 
 	// Stream response with proper headers for real-time delivery
-	c.Header("Content-Type", "text/plain; charset=utf-8")
+	events := strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+	if events {
+		c.Header("Content-Type", "text/event-stream")
+	} else {
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+	}
+	c.Header("X-Summary-ID", summary.ID)
 	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
 	c.Header("Connection", "keep-alive")
 	c.Header("Transfer-Encoding", "chunked")
 	c.Header("X-Accel-Buffering", "no") // Disable nginx buffering
 	c.Status(http.StatusOK)             // Start response immediately
 
-	h.processSummarization(c, req, svc, messages, start)
-}
-
-func (h *Handler) processSummarization(c *gin.Context, req SummarizeRequest, svc llm.Service, messages []llm.ChatMessage, start time.Time) {
-	// Allow longer generation time for large transcripts and smaller models
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Minute)
-	defer cancel()
-
-	contentChan, errChan := svc.ChatCompletionStream(ctx, req.Model, messages, 0.0)
 	flusher, _ := c.Writer.(http.Flusher)
 	writer := bufio.NewWriter(c.Writer)
+	write := func(event SummarizeEvent) {
+		if events {
+			data, _ := json.Marshal(event)
+			_, _ = fmt.Fprintf(writer, "data: %s\n\n", data)
+		} else if event.Type == "content" {
+			_, _ = writer.WriteString(event.Text)
+		} else if event.Type == "error" {
+			// Best-effort error signal for plain text clients
+			_, _ = writer.WriteString("\n")
+		}
+		writer.Flush()
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
 
-	finalText := ""
-	gotFirstChunk := false
-
-	// Loop handles one chunk/error at a time
-	for {
-		select {
-		case chunk, ok := <-contentChan:
-			if !ok {
-				writer.Flush()
-				if flusher != nil {
-					flusher.Flush()
-				}
-				// Persist summary once streaming completes
-				h.persistSummary(req, finalText)
-				log.Printf("[summarize] complete transcription_id=%s model=%s bytes=%d duration_ms=%d", req.TranscriptionID, req.Model, len(finalText), time.Since(start).Milliseconds())
-				return
-			}
-			finalText += chunk
-			_, _ = writer.WriteString(chunk)
-			writer.Flush()
-			if flusher != nil {
-				flusher.Flush()
-			}
-			if !gotFirstChunk && len(chunk) > 0 {
-				gotFirstChunk = true
-				log.Printf("[summarize] first_chunk transcription_id=%s model=%s at_ms=%d", req.TranscriptionID, req.Model, time.Since(start).Milliseconds())
-			}
-		case err := <-errChan:
-			if err != nil {
-				h.handleSummarizeError(c, req, svc, messages, err, finalText, start)
-			}
-			// Persist any partial content on error
-			h.persistSummary(req, finalText)
-			return
-		case <-ctx.Done():
-			// Persist any partial content on timeout/cancel
-			h.persistSummary(req, finalText)
-			log.Printf("[summarize] timeout/cancel transcription_id=%s model=%s bytes=%d duration_ms=%d", req.TranscriptionID, req.Model, len(finalText), time.Since(start).Milliseconds())
-			return
+	// A client that disconnects stops following the summary, not generating it
+	for u := range updates {
+		switch {
+		case u.Text != "":
+			write(SummarizeEvent{Type: "content", Text: u.Text})
+		case u.Progress != nil:
+			write(SummarizeEvent{Type: "progress", Stage: u.Progress.Stage, Done: u.Progress.Done, Total: u.Progress.Total})
+		case u.Status == models.SummaryStatusFailed:
+			write(SummarizeEvent{Type: "error", Error: u.Error})
+		case u.Status == models.SummaryStatusCompleted:
+			write(SummarizeEvent{Type: "done"})
 		}
 	}
 }
 
-func (h *Handler) handleSummarizeError(c *gin.Context, req SummarizeRequest, svc llm.Service, messages []llm.ChatMessage, err error, partialText string, start time.Time) {
-	flusher, _ := c.Writer.(http.Flusher)
-	writer := bufio.NewWriter(c.Writer)
+// QueueSummary queues a summary of a transcription
+// @Summary Queue a summary
+// @Description Queue an LLM-generated summary of a transcription's transcript. The summary is generated in the background; its status changes and per-chunk progress are broadcast as "summary_update" events on the transcription's event stream, and it is stored in the transcription's summary history.
+// @Tags summarize
+// @Accept json
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Param request body QueueSummaryRequest true "Summary request"
+// @Success 202 {object} models.Summary
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/summaries [post]
+func (h *Handler) QueueSummary(c *gin.Context) {
+	var req QueueSummaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	summary := &models.Summary{
+		TranscriptionID: job.ID,
+		TemplateID:      req.TemplateID,
+		Model:           req.Model,
+		Mode:            req.Mode,
+	}
+	if !h.queueSummary(c, job, summary) {
+		return
+	}
+	c.JSON(http.StatusAccepted, summary)
+}
 
-	// Best-effort error signal
-	// If streaming is unsupported for this model/org, fall back to non-streaming
-	errStr := err.Error()
-	if strings.Contains(errStr, "\"param\": \"stream\"") || strings.Contains(errStr, "unsupported_value") || strings.Contains(errStr, "must be verified to stream") {
-		log.Printf("[summarize] falling back to non-streaming transcription_id=%s model=%s due to: %v", req.TranscriptionID, req.Model, err)
-		resp, err2 := svc.ChatCompletion(c.Request.Context(), req.Model, messages, 0.0)
-		if err2 != nil || resp == nil || len(resp.Choices) == 0 {
-			log.Printf("[summarize] fallback failed transcription_id=%s model=%s err=%v", req.TranscriptionID, req.Model, err2)
-			_, _ = c.Writer.Write([]byte("\n"))
-			writer.Flush()
-			if flusher != nil {
-				flusher.Flush()
-			}
-			return
+// queueSummary checks that summary can be generated and queues it, or writes
+// the error response
+func (h *Handler) queueSummary(c *gin.Context, job *models.TranscriptionJob, summary *models.Summary) bool {
+	if !h.summariesEnabled(c) {
+		return false
+	}
+	if summary.TemplateID != nil {
+		template, err := h.summaryRepo.FindByID(c.Request.Context(), *summary.TemplateID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load summary template"})
+			return false
 		}
-		content := resp.Choices[0].Message.Content
-		// Write content (appended to partial if any, though likely partial is empty if stream failed immediately)
-		_, _ = writer.WriteString(content)
-		writer.Flush()
-		if flusher != nil {
-			flusher.Flush()
+		if template == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Summary template not found"})
+			return false
+		}
+		if summary.Model == "" {
+			summary.Model = template.Model
 		}
-		// We should persist the FULL text (partial + fallback), but partialText is passed by value.
-		// However, handleSumarizeError doesn't update partialText in caller.
-		// The caller calls persistSummary(req, finalText) after this function returns.
-		// So we actually need to persist here if we succeed?
-		// Or return the new text?
-		// Since we can't easily update finalText in caller without pointer, let's persist here if success.
-		h.persistSummary(req, partialText+content)
-		log.Printf("[summarize] fallback complete transcription_id=%s model=%s bytes=%d duration_ms=%d", req.TranscriptionID, req.Model, len(partialText+content), time.Since(start).Milliseconds())
-
-		// To avoid double persistence in caller (which uses stale finalText), we need a way to signal "done".
-		// But caller persists anyway.
-		// It's acceptable to double-persist (idempotent updates usually) or just accept that caller persists partial and we persist full.
-		return
-	}
-	_, _ = c.Writer.Write([]byte("\n"))
-	writer.Flush()
-	if flusher != nil {
-		flusher.Flush()
-	}
-	log.Printf("[summarize] error transcription_id=%s model=%s err=%v duration_ms=%d", req.TranscriptionID, req.Model, err, time.Since(start).Milliseconds())
+	}
+	if summary.Input == "" && (job.Transcript == nil || *job.Transcript == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transcription has no transcript"})
+		return false
+	}
+
+	route, err := h.llmRoute(c, models.LLMFeatureSummarization)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	summary.Model = route.WithModel(summary.Model).Primary().Model
+	if summary.Model == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "model is required"})
+		return false
+	}
+
+	if err := h.summaries.Enqueue(c.Request.Context(), summary); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue summary"})
+		return false
+	}
+	return true
 }
 
-func (h *Handler) persistSummary(req SummarizeRequest, content string) {
-	if req.TranscriptionID == "" || content == "" {
+// ListSummaries returns the summary history of a transcription
+// @Summary List summaries of a transcription
+// @Description List every summary generated for a transcription, newest first, including queued, running and failed ones
+// @Tags summarize
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Success 200 {array} models.Summary
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/summaries [get]
+func (h *Handler) ListSummaries(c *gin.Context) {
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
 		return
 	}
-	sum := &models.Summary{
-		TranscriptionID: req.TranscriptionID,
-		TemplateID:      req.TemplateID,
-		Model:           req.Model,
-		Content:         content,
+	summaries, err := h.summaryRepo.ListByTranscriptionID(c.Request.Context(), job.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list summaries"})
+		return
 	}
-	if err := h.summaryRepo.SaveSummary(context.Background(), sum); err != nil {
-		// Fallback: store on the transcription job record
-		_ = h.jobRepo.UpdateSummary(context.Background(), req.TranscriptionID, content)
-	} else {
-		// Also cache on the transcription job for quick access
-		_ = h.jobRepo.UpdateSummary(context.Background(), req.TranscriptionID, content)
+	c.JSON(http.StatusOK, summaries)
+}
+
+// GetSummary returns one summary of a transcription
+// @Summary Get a summary
+// @Description Get a summary of a transcription with its status
+// @Tags summarize
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Param summaryId path string true "Summary ID"
+// @Success 200 {object} models.Summary
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/summaries/{summaryId} [get]
+func (h *Handler) GetSummary(c *gin.Context) {
+	summary, ok := h.transcriptionSummary(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// DeleteSummary deletes a summary of a transcription
+// @Summary Delete a summary
+// @Description Delete a summary from a transcription's history, stopping it if it is still being generated
+// @Tags summarize
+// @Param id path string true "Transcription ID"
+// @Param summaryId path string true "Summary ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/summaries/{summaryId} [delete]
+func (h *Handler) DeleteSummary(c *gin.Context) {
+	if !h.summariesEnabled(c) {
+		return
+	}
+	summary, ok := h.transcriptionSummary(c)
+	if !ok {
+		return
+	}
+	if err := h.summaries.Delete(c.Request.Context(), summary); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete summary"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// transcriptionSummary loads the summary of the request's path, or writes the
+// error response
+func (h *Handler) transcriptionSummary(c *gin.Context) (*models.Summary, bool) {
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return nil, false
+	}
+	summary, err := h.summaryRepo.FindSummary(c.Request.Context(), c.Param("summaryId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get summary"})
+		return nil, false
+	}
+	if summary == nil || summary.TranscriptionID != job.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Summary not found"})
+		return nil, false
+	}
+	return summary, true
+}
+
+// collectSummary waits for a queued summary and returns its text
+func (h *Handler) collectSummary(ctx context.Context, summaryID string) (string, error) {
+	updates, ok := h.summaries.Watch(ctx, summaryID)
+	if !ok {
+		return "", fmt.Errorf("summary is not being generated")
+	}
+	var text strings.Builder
+	for u := range updates {
+		text.WriteString(u.Text)
+		switch u.Status {
+		case models.SummaryStatusFailed:
+			return "", fmt.Errorf("%s", u.Error)
+		case models.SummaryStatusCompleted:
+			return text.String(), nil
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
 	}
+	return "", fmt.Errorf("summary was deleted")
 }
 
 // GetSummaryForTranscription returns the latest summary for a transcription
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"gorm.io/gorm"
 
 	"scriberr/internal/models"
+	"scriberr/internal/summarize"
 )
 
 type SummaryTemplateRequest struct {
@@ -15,6 +17,21 @@ type SummaryTemplateRequest struct {
 	Description *string `json:"description"`
 	Model       string  `json:"model" binding:"required,min=1"`
 	Prompt      string  `json:"prompt" binding:"required,min=1"`
+	// Schema makes summaries of the template structured: JSON matching the
+	// schema, stored with a Markdown rendering
+	Schema json.RawMessage `json:"schema,omitempty" swaggertype:"object"`
+}
+
+// templateSchema validates the schema of a template request, nil when the
+// template has none
+func templateSchema(req *SummaryTemplateRequest) (json.RawMessage, error) {
+	if len(req.Schema) == 0 || string(req.Schema) == "null" {
+		return nil, nil
+	}
+	if _, err := summarize.ParseSchema(req.Schema); err != nil {
+		return nil, err
+	}
+	return req.Schema, nil
 }
 
 type SummarySettingsRequest struct {
@@ -65,11 +82,17 @@ func (h *Handler) CreateSummaryTemplate(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	schema, err := templateSchema(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	item := &models.SummaryTemplate{
 		Name:        req.Name,
 		Description: req.Description,
 		Model:       req.Model,
 		Prompt:      req.Prompt,
+		Schema:      schema,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -126,6 +149,11 @@ func (h *Handler) UpdateSummaryTemplate(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	schema, err := templateSchema(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	item, err := h.summaryRepo.FindByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
@@ -135,6 +163,7 @@ func (h *Handler) UpdateSummaryTemplate(c *gin.Context) {
 	item.Description = req.Description
 	item.Model = req.Model
 	item.Prompt = req.Prompt
+	item.Schema = schema
 	item.UpdatedAt = time.Now()
 	if err := h.summaryRepo.Update(c.Request.Context(), item); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update template"})
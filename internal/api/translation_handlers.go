@@ -0,0 +1,232 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/models"
+	"scriberr/internal/translate"
+)
+
+// TranslationRequest requests a translation track of a transcript
+type TranslationRequest struct {
+	Language string `json:"language" binding:"required"`                         // Target language code, e.g. "vi", "ja" or "en"
+	Provider string `json:"provider" binding:"omitempty,oneof=auto llm service"` // Defaults to auto: the translation service if configured, else the translation LLM route
+	Model    string `json:"model"`                                               // For the llm provider; defaults to the route's model, then the default summary model
+}
+
+// SetTranslationService sets the service behind translation tracks
+func (h *Handler) SetTranslationService(s *translate.Service) {
+	h.translations = s
+}
+
+// translationsEnabled writes an error response unless translations are set
+// up
+func (h *Handler) translationsEnabled(c *gin.Context) bool {
+	if h.translations == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Translations are not enabled"})
+		return false
+	}
+	return true
+}
+
+// completedTranslation returns the completed translation of job selected by
+// the language query parameter. On failure the error response has been
+// written.
+func (h *Handler) completedTranslation(c *gin.Context, job *models.TranscriptionJob) (*models.TranscriptTranslation, bool) {
+	if !h.translationsEnabled(c) {
+		return nil, false
+	}
+	language := c.Query("language")
+	if _, ok := translate.NormalizeLanguage(language); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language code"})
+		return nil, false
+	}
+	translation, err := h.translations.Completed(c.Request.Context(), job.ID, language)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch translation"})
+		return nil, false
+	}
+	if translation == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No completed translation into " + language})
+		return nil, false
+	}
+	return translation, true
+}
+
+// translatedTranscript returns a parsed transcript with the segments of a
+// translation track in place of its own. Word timings belong to the original
+// language and are left out.
+func translatedTranscript(transcript interface{}, translation *models.TranscriptTranslation) interface{} {
+	m, ok := transcript.(map[string]interface{})
+	if !ok {
+		m = make(map[string]interface{})
+	}
+	texts := make([]string, 0, len(translation.Segments))
+	for _, seg := range translation.Segments {
+		if seg.Text != "" {
+			texts = append(texts, seg.Text)
+		}
+	}
+	m["segments"] = translation.Segments
+	m["text"] = strings.Join(texts, " ")
+	m["language"] = translation.Language
+	m["source_language"] = translation.SourceLanguage
+	delete(m, "word_segments")
+	return m
+}
+
+// ListTranslations lists the translation tracks of a transcription
+// @Summary List translations
+// @Description List the translation tracks of a transcription with their status and progress, without their segments
+// @Tags translations
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Success 200 {array} models.TranscriptTranslation
+// @Failure 404 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/translations [get]
+func (h *Handler) ListTranslations(c *gin.Context) {
+	if !h.translationsEnabled(c) {
+		return
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	translations, err := h.translations.List(c.Request.Context(), job.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch translations"})
+		return
+	}
+	c.JSON(http.StatusOK, translations)
+}
+
+// CreateTranslation queues a translation track of a transcription
+// @Summary Translate a transcript
+// @Description Queue the translation of a transcript into another language, segment by segment so the track keeps the original timings. An earlier finished track in that language is replaced; one still being translated is returned as it is. Poll the translation for its status and progress.
+// @Tags translations
+// @Accept json
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Param request body TranslationRequest true "Translation request"
+// @Success 202 {object} models.TranscriptTranslation
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/translations [post]
+func (h *Handler) CreateTranslation(c *gin.Context) {
+	if !h.translationsEnabled(c) {
+		return
+	}
+	var req TranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, ok := translate.NormalizeLanguage(req.Language); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language code"})
+		return
+	}
+	if req.Provider == models.TranslationProviderService && !h.translations.HasTranslationService() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No translation service is configured"})
+		return
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	if job.Status != models.StatusCompleted || job.Transcript == nil || *job.Transcript == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transcription has no completed transcript"})
+		return
+	}
+	translation, err := h.translations.Enqueue(c.Request.Context(), job, translate.Options{Language: req.Language, Provider: req.Provider, Model: req.Model})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, translation)
+}
+
+// GetTranslation returns a translation track of a transcription
+// @Summary Get a translation
+// @Description Get a translation track of a transcription with its segments once completed
+// @Tags translations
+// @Produce json
+// @Param id path string true "Transcription ID"
+// @Param language path string true "Language code"
+// @Success 200 {object} models.TranscriptTranslation
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/translations/{language} [get]
+func (h *Handler) GetTranslation(c *gin.Context) {
+	if !h.translationsEnabled(c) {
+		return
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	translation, ok := h.findTranslation(c, job)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, translation)
+}
+
+// DeleteTranslation removes a translation track, stopping it if it is running
+// @Summary Delete a translation
+// @Description Delete a translation track of a transcription, stopping it first if it is still being translated
+// @Tags translations
+// @Param id path string true "Transcription ID"
+// @Param language path string true "Language code"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/{id}/translations/{language} [delete]
+func (h *Handler) DeleteTranslation(c *gin.Context) {
+	if !h.translationsEnabled(c) {
+		return
+	}
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	translation, ok := h.findTranslation(c, job)
+	if !ok {
+		return
+	}
+	if err := h.translations.Delete(c.Request.Context(), translation); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete translation"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// findTranslation loads the translation of job selected by the language path
+// parameter. On failure the error response has been written.
+func (h *Handler) findTranslation(c *gin.Context, job *models.TranscriptionJob) (*models.TranscriptTranslation, bool) {
+	language := c.Param("language")
+	if _, ok := translate.NormalizeLanguage(language); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language code"})
+		return nil, false
+	}
+	translation, err := h.translations.Get(c.Request.Context(), job.ID, language)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch translation"})
+		return nil, false
+	}
+	if translation == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Translation not found"})
+		return nil, false
+	}
+	return translation, true
+}
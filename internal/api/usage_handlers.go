@@ -0,0 +1,287 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetUsageRepository sets the usage ledger behind the admin usage reports
+func (h *Handler) SetUsageRepository(repo repository.UsageRepository) {
+	h.usageRepo = repo
+}
+
+// ModelPriceRequest sets the price of a provider's model in USD. An empty
+// model sets the provider's default price.
+type ModelPriceRequest struct {
+	Provider         string  `json:"provider" binding:"required"`
+	Model            string  `json:"model"`
+	InputPerMillion  float64 `json:"input_per_million" binding:"min=0"`
+	OutputPerMillion float64 `json:"output_per_million" binding:"min=0"`
+	AudioPerMinute   float64 `json:"audio_per_minute" binding:"min=0"`
+}
+
+// UsageReportResponse totals usage by the requested groups and period
+type UsageReportResponse struct {
+	GroupBy []string                    `json:"group_by"`
+	Period  string                      `json:"period,omitempty"`
+	Rows    []repository.UsageReportRow `json:"rows"`
+	Total   repository.UsageReportRow   `json:"total"`
+}
+
+// usageEnabled writes an error response unless the usage ledger is set
+func (h *Handler) usageEnabled(c *gin.Context) bool {
+	if h.usageRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Usage accounting is not enabled"})
+		return false
+	}
+	return true
+}
+
+// usageFilter reads the user_id, job_id, feature, from and to query
+// parameters. Dates are RFC3339 or YYYY-MM-DD; a bare to date is inclusive.
+// On failure the error response has been written.
+func usageFilter(c *gin.Context) (filter repository.UsageFilter, ok bool) {
+	filter = repository.UsageFilter{JobID: c.Query("job_id"), Feature: c.Query("feature")}
+	if s := c.Query("user_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
+			return filter, false
+		}
+		userID := uint(id)
+		filter.UserID = &userID
+	}
+	if filter.From, filter.To, ok = dateRange(c); !ok {
+		return filter, false
+	}
+	return filter, true
+}
+
+// dateRange reads the from and to query parameters as RFC3339 or YYYY-MM-DD
+// dates; a bare to date is inclusive. On failure the error response has
+// been written.
+func dateRange(c *gin.Context) (from, to *time.Time, ok bool) {
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &from}, {"to", &to}} {
+		s := c.Query(p.name)
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t, err = time.Parse("2006-01-02", s)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + p.name + " date"})
+				return nil, nil, false
+			}
+			if p.name == "to" {
+				t = t.AddDate(0, 0, 1)
+			}
+		}
+		*p.dst = &t
+	}
+	return from, to, true
+}
+
+// @Summary Usage report
+// @Description Total LLM and cloud transcription usage and cost, grouped by user, feature, provider and/or model and by period (admin only)
+// @Tags admin
+// @Produce json
+// @Param user_id query int false "Only this user's usage"
+// @Param job_id query string false "Only this job's usage"
+// @Param feature query string false "Only this feature's usage"
+// @Param from query string false "Start date (RFC3339 or YYYY-MM-DD, inclusive)"
+// @Param to query string false "End date (RFC3339, exclusive, or YYYY-MM-DD, inclusive)"
+// @Param group_by query string false "Comma-separated groups: user, feature, provider, model" default(user)
+// @Param period query string false "Period: day, month or year"
+// @Success 200 {object} UsageReportResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/usage/report [get]
+func (h *Handler) GetUsageReport(c *gin.Context) {
+	if !h.usageEnabled(c) {
+		return
+	}
+	filter, ok := usageFilter(c)
+	if !ok {
+		return
+	}
+
+	var groupBy []string
+	for _, g := range strings.Split(c.DefaultQuery("group_by", "user"), ",") {
+		g = strings.TrimSpace(g)
+		if g == "" || slices.Contains(groupBy, g) {
+			continue
+		}
+		if !slices.Contains(repository.UsageGroupings, g) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group_by " + g + "; use " + strings.Join(repository.UsageGroupings, ", ")})
+			return
+		}
+		groupBy = append(groupBy, g)
+	}
+	period := c.Query("period")
+	if _, ok := repository.UsagePeriods[period]; period != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period; use day, month or year"})
+		return
+	}
+
+	rows, err := h.usageRepo.Report(c.Request.Context(), filter, groupBy, period)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build usage report"})
+		return
+	}
+	response := UsageReportResponse{GroupBy: groupBy, Period: period, Rows: rows}
+	if response.GroupBy == nil {
+		response.GroupBy = []string{}
+	}
+	if response.Rows == nil {
+		response.Rows = []repository.UsageReportRow{}
+	}
+	for _, row := range rows {
+		response.Total.Calls += row.Calls
+		response.Total.InputTokens += row.InputTokens
+		response.Total.OutputTokens += row.OutputTokens
+		response.Total.AudioSeconds += row.AudioSeconds
+		response.Total.Cost += row.Cost
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary List usage entries
+// @Description List recorded LLM and cloud transcription calls, newest first (admin only)
+// @Tags admin
+// @Produce json
+// @Param user_id query int false "Only this user's usage"
+// @Param job_id query string false "Only this job's usage"
+// @Param feature query string false "Only this feature's usage"
+// @Param from query string false "Start date (RFC3339 or YYYY-MM-DD, inclusive)"
+// @Param to query string false "End date (RFC3339, exclusive, or YYYY-MM-DD, inclusive)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/usage/entries [get]
+func (h *Handler) ListUsageEntries(c *gin.Context) {
+	if !h.usageEnabled(c) {
+		return
+	}
+	filter, ok := usageFilter(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	entries, total, err := h.usageRepo.ListEntries(c.Request.Context(), filter, (page-1)*limit, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list usage"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// @Summary List model prices
+// @Description List the prices used to cost usage (admin only)
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.ModelPrice
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/usage/prices [get]
+func (h *Handler) ListModelPrices(c *gin.Context) {
+	if !h.usageEnabled(c) {
+		return
+	}
+	prices, err := h.usageRepo.ListPrices(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list prices"})
+		return
+	}
+	c.JSON(http.StatusOK, prices)
+}
+
+// @Summary Set model price
+// @Description Create or replace the price of a provider's model. Prices apply to usage recorded afterwards (admin only).
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body ModelPriceRequest true "Price in USD"
+// @Success 200 {object} models.ModelPrice
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/usage/prices [put]
+func (h *Handler) SaveModelPrice(c *gin.Context) {
+	if !h.usageEnabled(c) {
+		return
+	}
+	var req ModelPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	price := &models.ModelPrice{
+		Provider:         req.Provider,
+		Model:            req.Model,
+		InputPerMillion:  req.InputPerMillion,
+		OutputPerMillion: req.OutputPerMillion,
+		AudioPerMinute:   req.AudioPerMinute,
+	}
+	if err := h.usageRepo.SavePrice(c.Request.Context(), price); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save price"})
+		return
+	}
+	c.JSON(http.StatusOK, price)
+}
+
+// @Summary Delete model price
+// @Description Delete a model price; later usage of the model is costed at the provider's default price, if any (admin only)
+// @Tags admin
+// @Param id path int true "Price ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/usage/prices/{id} [delete]
+func (h *Handler) DeleteModelPrice(c *gin.Context) {
+	if !h.usageEnabled(c) {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price ID"})
+		return
+	}
+	if err := h.usageRepo.DeletePrice(c.Request.Context(), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete price"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"scriberr/internal/audio"
+	"scriberr/internal/waveform"
+	"scriberr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetWaveformService sets the service used to precompute and serve waveform peaks
+func (h *Handler) SetWaveformService(s *waveform.Service) {
+	h.waveforms = s
+}
+
+// scheduleWaveform starts background peak generation for a job's audio
+func (h *Handler) scheduleWaveform(jobID string) {
+	if h.waveforms != nil {
+		h.waveforms.Schedule(jobID)
+	}
+}
+
+// GetWaveform returns precomputed waveform peaks for a job's audio
+// @Summary Get waveform peaks
+// @Description Get min/max peak data for drawing the job's waveform, in audiowaveform JSON or binary (.dat) format. Zoom levels go from 0 (256 samples per pixel at 16 kHz) to 3 (16384 samples per pixel). Peaks are generated after upload and cached by the audio file's hash; if they are not ready yet they are generated on demand.
+// @Tags transcription
+// @Produce json
+// @Produce octet-stream
+// @Param id path string true "Job ID"
+// @Param zoom query int false "Zoom level (0 = most detailed)" default(3)
+// @Param format query string false "json or dat" default(json)
+// @Success 200 {object} map[string]interface{}
+// @Success 304
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/v1/transcription/{id}/waveform [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) GetWaveform(c *gin.Context) {
+	job, err := h.checkJobOwnership(c, c.Param("id"))
+	if err != nil {
+		return
+	}
+	if h.waveforms == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Waveform generation is not available"})
+		return
+	}
+
+	zoom, err := strconv.Atoi(c.DefaultQuery("zoom", strconv.Itoa(len(audio.WaveformLevels)-1)))
+	if err != nil || zoom < 0 || zoom >= len(audio.WaveformLevels) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("zoom must be between 0 and %d", len(audio.WaveformLevels)-1)})
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "dat" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or dat"})
+		return
+	}
+
+	// A known hash lets us answer revalidations without touching the cache
+	if job.AudioHash != nil && job.AudioPath == job.PlaybackAudioPath() {
+		etag := waveformETag(*job.AudioHash, zoom, format)
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	w, hash, err := h.waveforms.Get(c.Request.Context(), job, zoom)
+	if err != nil {
+		logger.Warn("Failed to get waveform", "job_id", job.ID, "error", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Waveform not available"})
+		return
+	}
+
+	c.Header("ETag", waveformETag(hash, zoom, format))
+	c.Header("Cache-Control", "private, max-age=86400")
+	if format == "dat" {
+		c.Header("Content-Type", "application/octet-stream")
+		c.Status(http.StatusOK)
+		if err := w.WriteDat(c.Writer); err != nil {
+			logger.Warn("Failed to write waveform", "job_id", job.ID, "error", err)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+func waveformETag(hash string, zoom int, format string) string {
+	return fmt.Sprintf("\"%s-%d-%s\"", hash, zoom, format)
+}
@@ -0,0 +1,208 @@
+// Package archive exports a user's jobs with their transcripts, notes,
+// summaries and chats to a portable zip file and imports such archives into
+// another instance. Derived per-job records (action items, chapters,
+// translations, review queues, analytics and smart analyses) and instance
+// settings such as LLM configs and routes are not archived.
+package archive
+
+import (
+	"encoding/json"
+	"time"
+
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+)
+
+// Format and Version identify the archive layout written to manifest.json.
+// Version is bumped whenever the layout changes incompatibly.
+const (
+	Format  = "scriberr-archive"
+	Version = 1
+)
+
+// Archive entry names
+const (
+	manifestName        = "manifest.json"
+	jobFileName         = "job.json"
+	transcriptFileName  = "transcript.json"
+	speakersFileName    = "speaker_mappings.json"
+	notesFileName       = "notes.json"
+	summariesFileName   = "summaries.json"
+	chatFileName        = "chat_sessions.json"
+	executionsFileName  = "executions.json"
+	audioDirName        = "audio"
+	maxRecordsEntrySize = 256 << 20
+)
+
+// Manifest describes the contents of an archive
+type Manifest struct {
+	Format     string        `json:"format"`
+	Version    int           `json:"version"`
+	ExportedAt time.Time     `json:"exported_at"`
+	Jobs       []ManifestJob `json:"jobs"`
+}
+
+// ManifestJob lists a single exported job
+type ManifestJob struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title,omitempty"`
+	Audio       string    `json:"audio,omitempty"` // Entry name of the audio file, empty if missing
+	AudioSHA256 string    `json:"audio_sha256,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Job is the archived form of a transcription job. Secrets stored in the
+// job parameters (API keys, HF tokens) are never exported.
+type Job struct {
+	ID                    string                `json:"id"`
+	Title                 *string               `json:"title,omitempty"`
+	Tags                  []string              `json:"tags,omitempty"`
+	Status                models.JobStatus      `json:"status"`
+	Diarization           bool                  `json:"diarization"`
+	Summary               *string               `json:"summary,omitempty"`
+	ErrorMessage          *string               `json:"error_message,omitempty"`
+	WasMultiTrack         bool                  `json:"was_multi_track"`
+	IndividualTranscripts *string               `json:"individual_transcripts,omitempty"`
+	Hidden                bool                  `json:"hidden"`
+	Parameters            models.WhisperXParams `json:"parameters"`
+	CreatedAt             time.Time             `json:"created_at"`
+	UpdatedAt             time.Time             `json:"updated_at"`
+}
+
+// SpeakerMapping is the archived form of a custom speaker name
+type SpeakerMapping struct {
+	OriginalSpeaker string `json:"original_speaker"`
+	CustomName      string `json:"custom_name"`
+}
+
+// Note is the archived form of a transcript annotation
+type Note struct {
+	StartWordIndex int       `json:"start_word_index"`
+	EndWordIndex   int       `json:"end_word_index"`
+	StartTime      float64   `json:"start_time"`
+	EndTime        float64   `json:"end_time"`
+	Quote          string    `json:"quote"`
+	Content        string    `json:"content"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Summary is the archived form of a completed summary of the summary history
+type Summary struct {
+	TemplateID  *string         `json:"template_id,omitempty"`
+	Model       string          `json:"model"`
+	Mode        string          `json:"mode,omitempty"`
+	Content     string          `json:"content"`
+	Structured  json.RawMessage `json:"structured,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// ChatSession is the archived form of a chat session with its messages
+type ChatSession struct {
+	Title          string        `json:"title"`
+	Model          string        `json:"model"`
+	Provider       string        `json:"provider"`
+	SystemContext  *string       `json:"system_context,omitempty"`
+	LastActivityAt *time.Time    `json:"last_activity_at,omitempty"`
+	IsActive       bool          `json:"is_active"`
+	CreatedAt      time.Time     `json:"created_at"`
+	Messages       []ChatMessage `json:"messages"`
+	// Scope of multi-job sessions. Explicit job lists refer to IDs of the
+	// exporting instance and are not restored on import.
+	Scope *models.ChatScope `json:"scope,omitempty"`
+	// ActiveLeafID is the archived ID of the last message of the active
+	// branch. Archives without message IDs hold a single branch.
+	ActiveLeafID *uint `json:"active_leaf_id,omitempty"`
+}
+
+// ChatMessage is the archived form of a chat message
+type ChatMessage struct {
+	// ID and ParentID link branched messages; they are IDs of the exporting
+	// instance and are remapped on import
+	ID         uint      `json:"id,omitempty"`
+	ParentID   *uint     `json:"parent_id,omitempty"`
+	Role       string    `json:"role"`
+	Content    string    `json:"content"`
+	TokensUsed *int      `json:"tokens_used,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Citations of the archived job have an empty job ID, which import
+	// replaces with the ID of the imported job
+	Citations []models.ChatCitation `json:"citations,omitempty"`
+}
+
+// Execution is the archived form of a job execution record
+type Execution struct {
+	StartedAt          time.Time             `json:"started_at"`
+	CompletedAt        *time.Time            `json:"completed_at,omitempty"`
+	ProcessingDuration *int64                `json:"processing_duration,omitempty"`
+	MultiTrackTimings  *string               `json:"multi_track_timings,omitempty"`
+	MergeStartTime     *time.Time            `json:"merge_start_time,omitempty"`
+	MergeEndTime       *time.Time            `json:"merge_end_time,omitempty"`
+	MergeDuration      *int64                `json:"merge_duration,omitempty"`
+	ActualParameters   models.WhisperXParams `json:"actual_parameters"`
+	Status             models.JobStatus      `json:"status"`
+	ErrorMessage       *string               `json:"error_message,omitempty"`
+	Source             string                `json:"source"`
+}
+
+// ImportedJob maps an archived job ID to the ID it received on import
+type ImportedJob struct {
+	OldID string `json:"old_id"`
+	NewID string `json:"new_id"`
+	Title string `json:"title,omitempty"`
+}
+
+// SkippedJob records a job that was not imported
+type SkippedJob struct {
+	OldID      string `json:"old_id"`
+	Title      string `json:"title,omitempty"`
+	Reason     string `json:"reason"`
+	ExistingID string `json:"existing_id,omitempty"`
+}
+
+// ImportResult summarizes an archive import
+type ImportResult struct {
+	Imported []ImportedJob `json:"imported"`
+	Skipped  []SkippedJob  `json:"skipped"`
+}
+
+// Service reads and writes archives using the application repositories
+type Service struct {
+	jobRepo            repository.JobRepository
+	summaryRepo        repository.SummaryRepository
+	chatRepo           repository.ChatRepository
+	noteRepo           repository.NoteRepository
+	speakerMappingRepo repository.SpeakerMappingRepository
+	uploadDir          string
+}
+
+// NewService creates an archive service. Imported audio is written to uploadDir.
+func NewService(
+	jobRepo repository.JobRepository,
+	summaryRepo repository.SummaryRepository,
+	chatRepo repository.ChatRepository,
+	noteRepo repository.NoteRepository,
+	speakerMappingRepo repository.SpeakerMappingRepository,
+	uploadDir string,
+) *Service {
+	return &Service{
+		jobRepo:            jobRepo,
+		summaryRepo:        summaryRepo,
+		chatRepo:           chatRepo,
+		noteRepo:           noteRepo,
+		speakerMappingRepo: speakerMappingRepo,
+		uploadDir:          uploadDir,
+	}
+}
+
+// jobDir returns the archive directory holding a job's records
+func jobDir(jobID string) string {
+	return "jobs/" + jobID + "/"
+}
+
+// sanitizeParameters strips secrets before parameters leave the instance
+func sanitizeParameters(p models.WhisperXParams) models.WhisperXParams {
+	p.HfToken = nil
+	p.APIKey = nil
+	return p
+}
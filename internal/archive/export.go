@@ -0,0 +1,280 @@
+package archive
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"scriberr/internal/models"
+)
+
+// Export streams a zip archive of the given jobs to w. Each job is written
+// under jobs/<id>/ with its audio (merged audio for multi-track jobs),
+// transcript and related records; manifest.json is written last so that the
+// audio hashes computed while streaming can be included.
+func (s *Service) Export(ctx context.Context, w io.Writer, jobs []models.TranscriptionJob) error {
+	zw := zip.NewWriter(w)
+	manifest := Manifest{
+		Format:     Format,
+		Version:    Version,
+		ExportedAt: time.Now().UTC(),
+		Jobs:       make([]ManifestJob, 0, len(jobs)),
+	}
+
+	for i := range jobs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entry, err := s.exportJob(ctx, zw, &jobs[i])
+		if err != nil {
+			return fmt.Errorf("failed to export job %s: %w", jobs[i].ID, err)
+		}
+		manifest.Jobs = append(manifest.Jobs, entry)
+	}
+
+	if err := writeJSON(zw, manifestName, manifest); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func (s *Service) exportJob(ctx context.Context, zw *zip.Writer, job *models.TranscriptionJob) (ManifestJob, error) {
+	dir := jobDir(job.ID)
+	entry := ManifestJob{ID: job.ID, CreatedAt: job.CreatedAt}
+	if job.Title != nil {
+		entry.Title = *job.Title
+	}
+
+	record := Job{
+		ID:                    job.ID,
+		Title:                 job.Title,
+		Tags:                  job.Tags,
+		Status:                job.Status,
+		Diarization:           job.Diarization,
+		Summary:               job.Summary,
+		ErrorMessage:          job.ErrorMessage,
+		WasMultiTrack:         job.IsMultiTrack,
+		IndividualTranscripts: job.IndividualTranscripts,
+		Hidden:                job.Hidden,
+		Parameters:            sanitizeParameters(job.Parameters),
+		CreatedAt:             job.CreatedAt,
+		UpdatedAt:             job.UpdatedAt,
+	}
+	if err := writeJSON(zw, dir+jobFileName, record); err != nil {
+		return entry, err
+	}
+
+	if job.Transcript != nil && *job.Transcript != "" {
+		if err := writeRaw(zw, dir+transcriptFileName, []byte(*job.Transcript)); err != nil {
+			return entry, err
+		}
+	}
+
+	if audioPath := exportAudioPath(job); audioPath != "" {
+		name := dir + audioDirName + "/" + filepath.Base(audioPath)
+		hash, err := writeFile(zw, name, audioPath)
+		if err != nil {
+			return entry, err
+		}
+		entry.Audio = name
+		entry.AudioSHA256 = hash
+		if job.AudioHash == nil && audioPath == job.AudioPath {
+			_ = s.jobRepo.UpdateAudioHash(ctx, job.ID, hash)
+		}
+	}
+
+	mappings, err := s.speakerMappingRepo.ListByJob(ctx, job.ID)
+	if err != nil {
+		return entry, fmt.Errorf("failed to load speaker mappings: %w", err)
+	}
+	speakers := make([]SpeakerMapping, 0, len(mappings))
+	for _, m := range mappings {
+		speakers = append(speakers, SpeakerMapping{OriginalSpeaker: m.OriginalSpeaker, CustomName: m.CustomName})
+	}
+	if err := writeJSON(zw, dir+speakersFileName, speakers); err != nil {
+		return entry, err
+	}
+
+	notes, err := s.noteRepo.ListByJob(ctx, job.ID)
+	if err != nil {
+		return entry, fmt.Errorf("failed to load notes: %w", err)
+	}
+	archivedNotes := make([]Note, 0, len(notes))
+	for _, n := range notes {
+		archivedNotes = append(archivedNotes, Note{
+			StartWordIndex: n.StartWordIndex,
+			EndWordIndex:   n.EndWordIndex,
+			StartTime:      n.StartTime,
+			EndTime:        n.EndTime,
+			Quote:          n.Quote,
+			Content:        n.Content,
+			CreatedAt:      n.CreatedAt,
+		})
+	}
+	if err := writeJSON(zw, dir+notesFileName, archivedNotes); err != nil {
+		return entry, err
+	}
+
+	summaries, err := s.summaryRepo.ListByTranscriptionID(ctx, job.ID)
+	if err != nil {
+		return entry, fmt.Errorf("failed to load summaries: %w", err)
+	}
+	archivedSummaries := make([]Summary, 0, len(summaries))
+	for _, sm := range summaries {
+		if sm.Status != models.SummaryStatusCompleted {
+			continue // Queued, running and failed summaries are not archived
+		}
+		archivedSummaries = append(archivedSummaries, Summary{
+			TemplateID:  sm.TemplateID,
+			Model:       sm.Model,
+			Mode:        sm.Mode,
+			Content:     sm.Content,
+			Structured:  sm.Structured,
+			CompletedAt: sm.CompletedAt,
+			CreatedAt:   sm.CreatedAt,
+		})
+	}
+	if err := writeJSON(zw, dir+summariesFileName, archivedSummaries); err != nil {
+		return entry, err
+	}
+
+	sessions, err := s.chatRepo.ListByJob(ctx, job.ID)
+	if err != nil {
+		return entry, fmt.Errorf("failed to load chat sessions: %w", err)
+	}
+	archivedSessions := make([]ChatSession, 0, len(sessions))
+	for _, cs := range sessions {
+		messages, err := s.chatRepo.GetMessageTree(ctx, &cs)
+		if err != nil {
+			return entry, fmt.Errorf("failed to load chat messages: %w", err)
+		}
+		session := ChatSession{
+			Title:          cs.Title,
+			Model:          cs.Model,
+			Provider:       cs.Provider,
+			SystemContext:  cs.SystemContext,
+			LastActivityAt: cs.LastActivityAt,
+			IsActive:       cs.IsActive,
+			CreatedAt:      cs.CreatedAt,
+			Messages:       make([]ChatMessage, 0, len(messages)),
+			ActiveLeafID:   cs.ActiveLeafID,
+		}
+		if cs.Scope.IsMultiJob() {
+			scope := cs.Scope
+			session.Scope = &scope
+		}
+		for _, m := range messages {
+			for i := range m.Citations {
+				if m.Citations[i].JobID == job.ID {
+					m.Citations[i].JobID = ""
+				}
+			}
+			session.Messages = append(session.Messages, ChatMessage{
+				ID:         m.ID,
+				ParentID:   m.ParentID,
+				Role:       m.Role,
+				Content:    m.Content,
+				TokensUsed: m.TokensUsed,
+				CreatedAt:  m.CreatedAt,
+				Citations:  m.Citations,
+			})
+		}
+		archivedSessions = append(archivedSessions, session)
+	}
+	if err := writeJSON(zw, dir+chatFileName, archivedSessions); err != nil {
+		return entry, err
+	}
+
+	executions, err := s.jobRepo.ListExecutionsByJobID(ctx, job.ID)
+	if err != nil {
+		return entry, fmt.Errorf("failed to load executions: %w", err)
+	}
+	archivedExecutions := make([]Execution, 0, len(executions))
+	for _, e := range executions {
+		archivedExecutions = append(archivedExecutions, Execution{
+			StartedAt:          e.StartedAt,
+			CompletedAt:        e.CompletedAt,
+			ProcessingDuration: e.ProcessingDuration,
+			MultiTrackTimings:  e.MultiTrackTimings,
+			MergeStartTime:     e.MergeStartTime,
+			MergeEndTime:       e.MergeEndTime,
+			MergeDuration:      e.MergeDuration,
+			ActualParameters:   sanitizeParameters(e.ActualParameters),
+			Status:             e.Status,
+			ErrorMessage:       e.ErrorMessage,
+			Source:             e.Source,
+		})
+	}
+	if err := writeJSON(zw, dir+executionsFileName, archivedExecutions); err != nil {
+		return entry, err
+	}
+
+	return entry, nil
+}
+
+// exportAudioPath picks the audio file to archive: the merged mix for
+// multi-track jobs when available, otherwise the original upload.
+func exportAudioPath(job *models.TranscriptionJob) string {
+	if job.MergedAudioPath != nil && *job.MergedAudioPath != "" {
+		if _, err := os.Stat(*job.MergedAudioPath); err == nil {
+			return *job.MergedAudioPath
+		}
+	}
+	if job.AudioPath != "" {
+		if _, err := os.Stat(job.AudioPath); err == nil {
+			return job.AudioPath
+		}
+	}
+	return ""
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return writeRaw(zw, name, data)
+}
+
+func writeRaw(zw *zip.Writer, name string, data []byte) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// writeFile copies a file into the archive without compression (audio is
+// already compressed) and returns its SHA-256
+func writeFile(zw *zip.Writer, name, path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer src.Close()
+
+	header := &zip.FileHeader{Name: name, Method: zip.Store}
+	if info, err := src.Stat(); err == nil {
+		header.Modified = info.ModTime()
+	}
+	dst, err := zw.CreateHeader(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", name, err)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(dst, h), src); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,480 @@
+package archive
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"scriberr/internal/audio"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// Import recreates the jobs in an archive under userID. Every record gets a
+// fresh ID; jobs whose audio matches an existing job of the user (by
+// SHA-256, or by title and creation time when the archive has no audio) are
+// skipped. Multi-track jobs are imported as single-track jobs using the
+// archived merged audio.
+func (s *Service) Import(ctx context.Context, r io.ReaderAt, size int64, userID uint) (*ImportResult, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var manifest Manifest
+	if err := readJSON(files, manifestName, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Format != Format {
+		return nil, fmt.Errorf("not a scriberr archive")
+	}
+	if manifest.Version < 1 || manifest.Version > Version {
+		return nil, fmt.Errorf("unsupported archive version %d", manifest.Version)
+	}
+
+	existing, err := s.existingJobs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Imported: []ImportedJob{}, Skipped: []SkippedJob{}}
+	for _, entry := range manifest.Jobs {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		if entry.AudioSHA256 != "" {
+			if dup := s.findByAudio(ctx, userID, existing, entry, files); dup != "" {
+				result.Skipped = append(result.Skipped, SkippedJob{OldID: entry.ID, Title: entry.Title, Reason: "duplicate audio", ExistingID: dup})
+				continue
+			}
+		} else if dup, ok := existing.byTitle[titleKey(entry.Title, entry.CreatedAt)]; ok {
+			result.Skipped = append(result.Skipped, SkippedJob{OldID: entry.ID, Title: entry.Title, Reason: "duplicate title and creation time", ExistingID: dup})
+			continue
+		}
+
+		newID, err := s.importJob(ctx, files, entry, userID)
+		if err != nil {
+			logger.Warn("Archive job import failed", "job_id", entry.ID, "error", err)
+			result.Skipped = append(result.Skipped, SkippedJob{OldID: entry.ID, Title: entry.Title, Reason: err.Error()})
+			continue
+		}
+		existing.byTitle[titleKey(entry.Title, entry.CreatedAt)] = newID
+		result.Imported = append(result.Imported, ImportedJob{OldID: entry.ID, NewID: newID, Title: entry.Title})
+	}
+
+	logger.Info("Archive imported", "user_id", userID, "imported", len(result.Imported), "skipped", len(result.Skipped))
+	return result, nil
+}
+
+type existingIndex struct {
+	byTitle map[string]string
+	// unhashed holds the audio paths of jobs without a stored hash, by file
+	// size, so only audio that could match an archived file gets hashed
+	unhashed map[int64][]unhashedJob
+}
+
+type unhashedJob struct {
+	id        string
+	audioPath string
+}
+
+// existingJobs indexes the user's jobs for deduplication. Jobs with a stored
+// audio hash are matched in the database; the others are indexed by audio
+// file size.
+func (s *Service) existingJobs(ctx context.Context, userID uint) (*existingIndex, error) {
+	jobs, _, err := s.jobRepo.ListByUser(ctx, userID, 0, -1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list existing jobs: %w", err)
+	}
+
+	idx := &existingIndex{byTitle: map[string]string{}, unhashed: map[int64][]unhashedJob{}}
+	for _, job := range jobs {
+		if job.AudioHash == nil && job.AudioPath != "" {
+			if info, err := os.Stat(job.AudioPath); err == nil && info.Mode().IsRegular() {
+				idx.unhashed[info.Size()] = append(idx.unhashed[info.Size()], unhashedJob{id: job.ID, audioPath: job.AudioPath})
+			}
+		}
+		title := ""
+		if job.Title != nil {
+			title = *job.Title
+		}
+		idx.byTitle[titleKey(title, job.CreatedAt)] = job.ID
+	}
+	return idx, nil
+}
+
+// findByAudio returns the ID of the user's job with the same audio as an
+// archived job, or "" if there is none. Unhashed jobs whose audio is the size
+// of the archived file are hashed, once, storing the hash.
+func (s *Service) findByAudio(ctx context.Context, userID uint, idx *existingIndex, entry ManifestJob, files map[string]*zip.File) string {
+	if job, err := s.jobRepo.FindByAudioHash(ctx, userID, entry.AudioSHA256); err == nil {
+		return job.ID
+	}
+	f, ok := files[entry.Audio]
+	if !ok {
+		return ""
+	}
+	size := int64(f.UncompressedSize64)
+	candidates := idx.unhashed[size]
+	delete(idx.unhashed, size)
+	dup := ""
+	for _, job := range candidates {
+		h, err := audio.FileHash(job.audioPath)
+		if err != nil {
+			continue
+		}
+		_ = s.jobRepo.UpdateAudioHash(ctx, job.id, h)
+		if h == entry.AudioSHA256 && dup == "" {
+			dup = job.id
+		}
+	}
+	return dup
+}
+
+func titleKey(title string, createdAt time.Time) string {
+	return title + "\x00" + createdAt.UTC().Format("2006-01-02T15:04:05")
+}
+
+// importJob recreates a single job and its records, removing everything it
+// created if any step fails. It returns the new job ID.
+func (s *Service) importJob(ctx context.Context, files map[string]*zip.File, entry ManifestJob, userID uint) (string, error) {
+	dir := jobDir(entry.ID)
+
+	var record Job
+	if err := readJSON(files, dir+jobFileName, &record); err != nil {
+		return "", err
+	}
+
+	newID := uuid.New().String()
+	job := models.TranscriptionJob{
+		ID:                    newID,
+		Title:                 record.Title,
+		Tags:                  record.Tags,
+		Status:                importedStatus(record.Status),
+		Diarization:           record.Diarization,
+		Summary:               record.Summary,
+		ErrorMessage:          record.ErrorMessage,
+		IndividualTranscripts: record.IndividualTranscripts,
+		Hidden:                record.Hidden,
+		Parameters:            sanitizeParameters(record.Parameters),
+		CreatedAt:             record.CreatedAt,
+		UserID:                &userID,
+	}
+
+	if f, ok := files[dir+transcriptFileName]; ok {
+		data, err := readEntry(f)
+		if err != nil {
+			return "", err
+		}
+		transcript := string(data)
+		job.Transcript = &transcript
+	}
+
+	hash := ""
+	if entry.Audio != "" {
+		f, ok := files[entry.Audio]
+		if !ok {
+			return "", fmt.Errorf("audio %s missing from archive", entry.Audio)
+		}
+		audioPath, h, err := s.extractAudio(f, newID)
+		if err != nil {
+			return "", err
+		}
+		if entry.AudioSHA256 != "" && h != entry.AudioSHA256 {
+			_ = os.Remove(audioPath)
+			return "", fmt.Errorf("audio checksum mismatch")
+		}
+		job.AudioPath = audioPath
+		job.AudioHash = &h
+		hash = h
+	}
+	if job.AudioPath == "" {
+		job.AudioPath = filepath.Join(s.uploadDir, newID)
+	}
+
+	if err := s.jobRepo.Create(ctx, &job); err != nil {
+		removeExtracted(hash, job.AudioPath)
+		return "", fmt.Errorf("failed to create job: %w", err)
+	}
+
+	if err := s.importRecords(ctx, files, dir, newID); err != nil {
+		s.rollback(newID)
+		removeExtracted(hash, job.AudioPath)
+		return "", err
+	}
+	return newID, nil
+}
+
+// importRecords recreates the records that hang off a job
+func (s *Service) importRecords(ctx context.Context, files map[string]*zip.File, dir, jobID string) error {
+	var speakers []SpeakerMapping
+	if err := readOptionalJSON(files, dir+speakersFileName, &speakers); err != nil {
+		return err
+	}
+	if len(speakers) > 0 {
+		mappings := make([]models.SpeakerMapping, 0, len(speakers))
+		for _, sp := range speakers {
+			mappings = append(mappings, models.SpeakerMapping{
+				TranscriptionJobID: jobID,
+				OriginalSpeaker:    sp.OriginalSpeaker,
+				CustomName:         sp.CustomName,
+			})
+		}
+		if err := s.speakerMappingRepo.UpdateMappings(ctx, jobID, mappings); err != nil {
+			return fmt.Errorf("failed to import speaker mappings: %w", err)
+		}
+	}
+
+	var notes []Note
+	if err := readOptionalJSON(files, dir+notesFileName, &notes); err != nil {
+		return err
+	}
+	for _, n := range notes {
+		note := models.Note{
+			ID:              uuid.New().String(),
+			TranscriptionID: jobID,
+			StartWordIndex:  n.StartWordIndex,
+			EndWordIndex:    n.EndWordIndex,
+			StartTime:       n.StartTime,
+			EndTime:         n.EndTime,
+			Quote:           n.Quote,
+			Content:         n.Content,
+			CreatedAt:       n.CreatedAt,
+		}
+		if err := s.noteRepo.Create(ctx, &note); err != nil {
+			return fmt.Errorf("failed to import note: %w", err)
+		}
+	}
+
+	var summaries []Summary
+	if err := readOptionalJSON(files, dir+summariesFileName, &summaries); err != nil {
+		return err
+	}
+	for _, sm := range summaries {
+		summary := models.Summary{
+			TranscriptionID: jobID,
+			TemplateID:      sm.TemplateID,
+			Model:           sm.Model,
+			Mode:            sm.Mode,
+			Status:          models.SummaryStatusCompleted,
+			Content:         sm.Content,
+			Structured:      sm.Structured,
+			CompletedAt:     sm.CompletedAt,
+			CreatedAt:       sm.CreatedAt,
+		}
+		if err := s.summaryRepo.SaveSummary(ctx, &summary); err != nil {
+			return fmt.Errorf("failed to import summary: %w", err)
+		}
+	}
+
+	var sessions []ChatSession
+	if err := readOptionalJSON(files, dir+chatFileName, &sessions); err != nil {
+		return err
+	}
+	for _, cs := range sessions {
+		session := models.ChatSession{
+			JobID:           jobID,
+			TranscriptionID: jobID,
+			Title:           cs.Title,
+			Model:           cs.Model,
+			Provider:        cs.Provider,
+			SystemContext:   cs.SystemContext,
+			MessageCount:    len(cs.Messages),
+			LastActivityAt:  cs.LastActivityAt,
+			IsActive:        cs.IsActive,
+			CreatedAt:       cs.CreatedAt,
+		}
+		if cs.Scope != nil && cs.Scope.Type != models.ChatScopeJobs {
+			session.Scope = *cs.Scope
+		}
+		if err := s.chatRepo.Create(ctx, &session); err != nil {
+			return fmt.Errorf("failed to import chat session: %w", err)
+		}
+		// Messages come parents first; archives without IDs are linked in
+		// order when the session is first read
+		imported := make(map[uint]uint, len(cs.Messages))
+		for _, m := range cs.Messages {
+			for i := range m.Citations {
+				if m.Citations[i].JobID == "" {
+					m.Citations[i].JobID = jobID
+				}
+			}
+			message := models.ChatMessage{
+				ChatSessionID: session.ID,
+				Role:          m.Role,
+				Content:       m.Content,
+				TokensUsed:    m.TokensUsed,
+				CreatedAt:     m.CreatedAt,
+				Citations:     m.Citations,
+			}
+			if m.ParentID != nil {
+				if parentID, ok := imported[*m.ParentID]; ok {
+					message.ParentID = &parentID
+				}
+			}
+			if err := s.chatRepo.AddMessage(ctx, &message); err != nil {
+				return fmt.Errorf("failed to import chat message: %w", err)
+			}
+			if m.ID != 0 {
+				imported[m.ID] = message.ID
+			}
+		}
+		if cs.ActiveLeafID != nil {
+			if leafID, ok := imported[*cs.ActiveLeafID]; ok {
+				if err := s.chatRepo.SetActiveLeaf(ctx, &session, leafID); err != nil {
+					return fmt.Errorf("failed to import chat session: %w", err)
+				}
+			}
+		}
+	}
+
+	var executions []Execution
+	if err := readOptionalJSON(files, dir+executionsFileName, &executions); err != nil {
+		return err
+	}
+	for _, e := range executions {
+		execution := models.TranscriptionJobExecution{
+			TranscriptionJobID: jobID,
+			StartedAt:          e.StartedAt,
+			CompletedAt:        e.CompletedAt,
+			ProcessingDuration: e.ProcessingDuration,
+			MultiTrackTimings:  e.MultiTrackTimings,
+			MergeStartTime:     e.MergeStartTime,
+			MergeEndTime:       e.MergeEndTime,
+			MergeDuration:      e.MergeDuration,
+			ActualParameters:   sanitizeParameters(e.ActualParameters),
+			Status:             e.Status,
+			ErrorMessage:       e.ErrorMessage,
+			Source:             e.Source,
+		}
+		if execution.Source == "" {
+			execution.Source = models.ExecutionSourceTranscribed
+		}
+		if err := s.jobRepo.CreateExecution(ctx, &execution); err != nil {
+			return fmt.Errorf("failed to import execution: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// rollback removes a partially imported job and its records
+func (s *Service) rollback(jobID string) {
+	ctx := context.Background()
+	_ = s.chatRepo.DeleteByJobID(ctx, jobID)
+	_ = s.noteRepo.DeleteByTranscriptionID(ctx, jobID)
+	_ = s.summaryRepo.DeleteByTranscriptionID(ctx, jobID)
+	_ = s.speakerMappingRepo.DeleteByJobID(ctx, jobID)
+	_ = s.jobRepo.DeleteExecutionsByJobID(ctx, jobID)
+	_ = s.jobRepo.Delete(ctx, jobID)
+}
+
+// removeExtracted deletes audio written by extractAudio; hash is only set
+// when audio was extracted
+func removeExtracted(hash, audioPath string) {
+	if hash != "" {
+		_ = os.Remove(audioPath)
+	}
+}
+
+// extractAudio writes an archived audio file to the upload directory as
+// <jobID><ext> and returns its path and SHA-256
+func (s *Service) extractAudio(f *zip.File, jobID string) (string, string, error) {
+	if err := os.MkdirAll(s.uploadDir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	ext := strings.ToLower(path.Ext(f.Name))
+	dstPath := filepath.Join(s.uploadDir, jobID+ext)
+
+	src, err := f.Open()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create audio file: %w", err)
+	}
+	h := sha256.New()
+	_, copyErr := io.Copy(io.MultiWriter(dst, h), src)
+	closeErr := dst.Close()
+	if copyErr != nil || closeErr != nil {
+		_ = os.Remove(dstPath)
+		return "", "", fmt.Errorf("failed to extract audio: %v", firstErr(copyErr, closeErr))
+	}
+	return dstPath, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// importedStatus maps in-flight states to uploaded so the job can be re-run
+func importedStatus(status models.JobStatus) models.JobStatus {
+	switch status {
+	case models.StatusCompleted, models.StatusFailed, models.StatusUploaded:
+		return status
+	default:
+		return models.StatusUploaded
+	}
+}
+
+func readEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxRecordsEntrySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	if len(data) > maxRecordsEntrySize {
+		return nil, fmt.Errorf("%s is too large", f.Name)
+	}
+	return data, nil
+}
+
+func readJSON(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%s missing from archive", name)
+	}
+	data, err := readEntry(f)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+func readOptionalJSON(files map[string]*zip.File, name string, v interface{}) error {
+	if _, ok := files[name]; !ok {
+		return nil
+	}
+	return readJSON(files, name, v)
+}
+
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
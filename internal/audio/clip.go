@@ -0,0 +1,136 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Span is a time range in seconds within a source recording
+type Span struct {
+	Start float64
+	End   float64
+}
+
+// Duration returns the length of the span in seconds
+func (s Span) Duration() float64 {
+	return s.End - s.Start
+}
+
+// ClipFormats lists the audio formats clips can be encoded to
+var ClipFormats = map[string][]string{
+	"mp3":  {"-c:a", "libmp3lame", "-b:a", "192k"},
+	"wav":  {"-c:a", "pcm_s16le"},
+	"m4a":  {"-c:a", "aac", "-b:a", "192k"},
+	"ogg":  {"-c:a", "libopus", "-b:a", "128k"},
+	"flac": {"-c:a", "flac"},
+}
+
+// ClipContentType returns the MIME type for a clip format
+func ClipContentType(format string) string {
+	switch format {
+	case "wav":
+		return "audio/wav"
+	case "m4a":
+		return "audio/mp4"
+	case "ogg":
+		return "audio/ogg"
+	case "flac":
+		return "audio/flac"
+	case "mp4":
+		return "video/mp4"
+	default:
+		return "audio/mpeg"
+	}
+}
+
+// ClipFilename names the clip of base cut from [start, end) in format, e.g.
+// "interview_000130-000245.mp3"
+func ClipFilename(base string, start, end float64, format string) string {
+	return fmt.Sprintf("%s_%s-%s.%s", base, clipTimestamp(start), clipTimestamp(end), format)
+}
+
+func clipTimestamp(seconds float64) string {
+	total := int(seconds)
+	return fmt.Sprintf("%02d%02d%02d", total/3600, (total%3600)/60, total%60)
+}
+
+// ExtractClip cuts [start, end) out of an audio or video file and encodes
+// the audio track in the given format
+func ExtractClip(ctx context.Context, inputPath, outputPath string, start, end float64, format string) error {
+	codec, ok := ClipFormats[format]
+	if !ok {
+		return fmt.Errorf("unsupported clip format: %s", format)
+	}
+	args := []string{"-y", "-ss", formatSeconds(start), "-to", formatSeconds(end), "-i", inputPath, "-vn"}
+	args = append(args, codec...)
+	args = append(args, outputPath)
+	return runFFmpeg(ctx, args)
+}
+
+// ExtractVideoClip cuts [start, end) out of a video as MP4. When
+// subtitlesPath is set, the subtitles (timed relative to the clip start) are
+// burned into the picture.
+func ExtractVideoClip(ctx context.Context, videoPath, outputPath string, start, end float64, subtitlesPath string) error {
+	args := []string{"-y", "-ss", formatSeconds(start), "-to", formatSeconds(end), "-i", videoPath}
+	if subtitlesPath != "" {
+		args = append(args, "-vf", "subtitles="+escapeFilterValue(subtitlesPath))
+	}
+	args = append(args, "-c:v", "libx264", "-preset", "veryfast", "-c:a", "aac", "-b:a", "192k", "-movflags", "+faststart", outputPath)
+	return runFFmpeg(ctx, args)
+}
+
+// ConcatSpans joins the given spans of a recording, in order, into a single
+// audio file encoded in the given format
+func ConcatSpans(ctx context.Context, inputPath, outputPath string, spans []Span, format string) error {
+	if len(spans) == 0 {
+		return fmt.Errorf("no spans to concatenate")
+	}
+	codec, ok := ClipFormats[format]
+	if !ok {
+		return fmt.Errorf("unsupported clip format: %s", format)
+	}
+
+	var filter strings.Builder
+	for i, span := range spans {
+		fmt.Fprintf(&filter, "[0:a]atrim=start=%s:end=%s,asetpts=PTS-STARTPTS[s%d];", formatSeconds(span.Start), formatSeconds(span.End), i)
+	}
+	for i := range spans {
+		fmt.Fprintf(&filter, "[s%d]", i)
+	}
+	fmt.Fprintf(&filter, "concat=n=%d:v=0:a=1[out]", len(spans))
+
+	args := []string{"-y", "-i", inputPath, "-filter_complex", filter.String(), "-map", "[out]"}
+	args = append(args, codec...)
+	args = append(args, outputPath)
+	return runFFmpeg(ctx, args)
+}
+
+func runFFmpeg(ctx context.Context, args []string) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		tail := string(output)
+		if len(tail) > 500 {
+			tail = tail[len(tail)-500:]
+		}
+		return fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(tail))
+	}
+	return nil
+}
+
+func formatSeconds(s float64) string {
+	if s < 0 {
+		s = 0
+	}
+	return fmt.Sprintf("%.3f", s)
+}
+
+// escapeFilterValue escapes a path for use as a filter option value inside
+// a filtergraph: first for the option parser, then for the graph parser
+func escapeFilterValue(s string) string {
+	option := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`)
+	graph := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `;`, `\;`)
+	return graph.Replace(option.Replace(s))
+}
@@ -0,0 +1,24 @@
+package audio
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// FileHash returns the hex-encoded SHA-256 of an audio file's contents
+func FileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open audio file: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash audio file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
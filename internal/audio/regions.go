@@ -0,0 +1,102 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	silenceStart = regexp.MustCompile(`silence_start: (-?[0-9.]+)`)
+	silenceEnd   = regexp.MustCompile(`silence_end: (-?[0-9.]+)`)
+)
+
+// DetectSilences finds the silences of at least minSilence seconds in a
+// recording of duration seconds, with ffmpeg's silencedetect filter
+func DetectSilences(ctx context.Context, inputPath string, duration, minSilence float64) ([]Span, error) {
+	filter := fmt.Sprintf("silencedetect=noise=-35dB:d=%s", formatSeconds(minSilence))
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-hide_banner", "-nostats", "-i", inputPath, "-af", filter, "-f", "null", "-")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		tail := string(output)
+		if len(tail) > 500 {
+			tail = tail[len(tail)-500:]
+		}
+		return nil, fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(tail))
+	}
+	return parseSilences(string(output), duration), nil
+}
+
+// parseSilences reads the silences silencedetect logged. A silence still
+// running at the end of the recording lasts until duration.
+func parseSilences(output string, duration float64) []Span {
+	var silences []Span
+	start := -1.0
+	for _, line := range strings.Split(output, "\n") {
+		if m := silenceStart.FindStringSubmatch(line); m != nil {
+			if s, err := strconv.ParseFloat(m[1], 64); err == nil {
+				start = math.Max(s, 0)
+			}
+		} else if m := silenceEnd.FindStringSubmatch(line); m != nil && start >= 0 {
+			if end, err := strconv.ParseFloat(m[1], 64); err == nil {
+				silences = append(silences, Span{Start: start, End: math.Min(end, duration)})
+			}
+			start = -1
+		}
+	}
+	if start >= 0 && start < duration {
+		silences = append(silences, Span{Start: start, End: duration})
+	}
+	return silences
+}
+
+// SpeechRegions splits a recording of duration seconds into the regions of
+// speech between silences, so a region holds few language switches. Speech
+// runs are joined while a region is shorter than minLength seconds, without
+// exceeding maxLength; runs longer than maxLength are cut evenly.
+func SpeechRegions(silences []Span, duration, minLength, maxLength float64) []Span {
+	var speech []Span
+	at := 0.0
+	for _, silence := range silences {
+		if silence.Start > at {
+			speech = append(speech, Span{Start: at, End: math.Min(silence.Start, duration)})
+		}
+		at = math.Max(at, silence.End)
+	}
+	if at < duration {
+		speech = append(speech, Span{Start: at, End: duration})
+	}
+
+	var regions []Span
+	var current *Span
+	for _, run := range speech {
+		if run.Duration() <= 0 {
+			continue
+		}
+		if current != nil && current.Duration() < minLength && run.End-current.Start <= maxLength {
+			current.End = run.End
+			continue
+		}
+		if current != nil {
+			regions = append(regions, *current)
+		}
+		if run.Duration() > maxLength {
+			pieces := math.Ceil(run.Duration() / maxLength)
+			length := run.Duration() / pieces
+			for i := 0; i < int(pieces)-1; i++ {
+				regions = append(regions, Span{Start: run.Start + float64(i)*length, End: run.Start + float64(i+1)*length})
+			}
+			run.Start += (pieces - 1) * length
+		}
+		next := run
+		current = &next
+	}
+	if current != nil {
+		regions = append(regions, *current)
+	}
+	return regions
+}
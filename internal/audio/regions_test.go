@@ -0,0 +1,34 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSilences(t *testing.T) {
+	output := `Input #0, wav, from 'meeting.wav':
+[silencedetect @ 0x5581] silence_start: -0.01
+[silencedetect @ 0x5581] silence_end: 1.5 | silence_duration: 1.51
+[silencedetect @ 0x5581] silence_start: 12.25
+[silencedetect @ 0x5581] silence_end: 13 | silence_duration: 0.75
+[silencedetect @ 0x5581] silence_start: 58.5
+`
+	assert.Equal(t, []Span{{0, 1.5}, {12.25, 13}, {58.5, 60}}, parseSilences(output, 60))
+	assert.Empty(t, parseSilences("no silence here", 60))
+}
+
+func TestSpeechRegions(t *testing.T) {
+	// Short runs are joined up to minLength, never past maxLength
+	silences := []Span{{0, 1}, {3, 3.5}, {5, 6}, {12, 13}, {14, 14.5}}
+	assert.Equal(t, []Span{{1, 5}, {6, 12}, {13, 20}}, SpeechRegions(silences, 20, 4, 30))
+	regions := SpeechRegions(silences, 20, 4, 2.5)
+	assert.Len(t, regions, 9)
+	for _, r := range regions {
+		assert.LessOrEqual(t, r.Duration(), 2.5)
+	}
+
+	// Runs longer than maxLength are cut evenly
+	assert.Equal(t, []Span{{0, 25}, {25, 50}, {50, 75}}, SpeechRegions(nil, 75, 4, 30))
+	assert.Empty(t, SpeechRegions([]Span{{0, 10}}, 10, 4, 30))
+}
@@ -0,0 +1,165 @@
+package audio
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// Waveform holds min/max peak pairs for one zoom level, compatible with the
+// audiowaveform data format (version 2, one channel, 8-bit samples)
+type Waveform struct {
+	SampleRate      int
+	SamplesPerPixel int
+	Data            []int8 // Interleaved min, max per pixel
+}
+
+// WaveformSampleRate is the rate audio is decoded at for peak generation
+const WaveformSampleRate = 16000
+
+// WaveformLevels are the samples-per-pixel of each zoom level, from most to
+// least detailed. At 16 kHz they cover 16 ms, 64 ms, 256 ms and ~1 s per pixel.
+var WaveformLevels = []int{256, 1024, 4096, 16384}
+
+const (
+	datVersion  = 2
+	datFlag8Bit = 1
+	datChannels = 1
+)
+
+// Length returns the number of pixels in the waveform
+func (w *Waveform) Length() int {
+	return len(w.Data) / 2
+}
+
+// MarshalJSON encodes the waveform in audiowaveform's JSON layout
+func (w *Waveform) MarshalJSON() ([]byte, error) {
+	data := make([]int, len(w.Data))
+	for i, v := range w.Data {
+		data[i] = int(v)
+	}
+	return json.Marshal(struct {
+		Version         int   `json:"version"`
+		Channels        int   `json:"channels"`
+		SampleRate      int   `json:"sample_rate"`
+		SamplesPerPixel int   `json:"samples_per_pixel"`
+		Bits            int   `json:"bits"`
+		Length          int   `json:"length"`
+		Data            []int `json:"data"`
+	}{datVersion, datChannels, w.SampleRate, w.SamplesPerPixel, 8, w.Length(), data})
+}
+
+// WriteDat writes the waveform in audiowaveform's binary .dat format
+func (w *Waveform) WriteDat(out io.Writer) error {
+	header := []int32{datVersion, datFlag8Bit, int32(w.SampleRate), int32(w.SamplesPerPixel), int32(w.Length()), datChannels}
+	if err := binary.Write(out, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	return binary.Write(out, binary.LittleEndian, w.Data)
+}
+
+// ReadDat reads a waveform written by WriteDat
+func ReadDat(in io.Reader) (*Waveform, error) {
+	var header [6]int32
+	if err := binary.Read(in, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("failed to read waveform header: %w", err)
+	}
+	if header[0] != datVersion || header[1] != datFlag8Bit || header[5] != datChannels {
+		return nil, fmt.Errorf("unsupported waveform data")
+	}
+	w := &Waveform{SampleRate: int(header[2]), SamplesPerPixel: int(header[3]), Data: make([]int8, int(header[4])*2)}
+	if err := binary.Read(in, binary.LittleEndian, w.Data); err != nil {
+		return nil, fmt.Errorf("failed to read waveform data: %w", err)
+	}
+	return w, nil
+}
+
+// GenerateWaveforms decodes an audio file once and returns peaks for every
+// zoom level in levels. Each level must be a multiple of the first.
+func GenerateWaveforms(ctx context.Context, path string, levels []int) ([]*Waveform, error) {
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("no waveform levels requested")
+	}
+	for _, l := range levels {
+		if l <= 0 || l%levels[0] != 0 {
+			return nil, fmt.Errorf("invalid waveform level %d", l)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-v", "error", "-i", path, "-ac", "1", "-ar", fmt.Sprint(WaveformSampleRate), "-f", "s16le", "-")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	base, readErr := computePeaks(bufio.NewReaderSize(stdout, 64*1024), levels[0])
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	result := []*Waveform{base}
+	for _, l := range levels[1:] {
+		result = append(result, base.Downsample(l/levels[0]))
+	}
+	return result, nil
+}
+
+// computePeaks reads mono s16le samples and returns 8-bit min/max pairs per
+// samplesPerPixel samples
+func computePeaks(r io.Reader, samplesPerPixel int) (*Waveform, error) {
+	w := &Waveform{SampleRate: WaveformSampleRate, SamplesPerPixel: samplesPerPixel}
+	buf := make([]byte, 2*samplesPerPixel)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n >= 2 {
+			lo, hi := int16(32767), int16(-32768)
+			for i := 0; i+1 < n; i += 2 {
+				s := int16(binary.LittleEndian.Uint16(buf[i:]))
+				if s < lo {
+					lo = s
+				}
+				if s > hi {
+					hi = s
+				}
+			}
+			w.Data = append(w.Data, int8(lo>>8), int8(hi>>8))
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return w, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read decoded audio: %w", err)
+		}
+	}
+}
+
+// Downsample merges every factor pixels into one
+func (w *Waveform) Downsample(factor int) *Waveform {
+	out := &Waveform{SampleRate: w.SampleRate, SamplesPerPixel: w.SamplesPerPixel * factor}
+	for i := 0; i < w.Length(); i += factor {
+		lo, hi := int8(127), int8(-128)
+		for j := i; j < i+factor && j < w.Length(); j++ {
+			if w.Data[2*j] < lo {
+				lo = w.Data[2*j]
+			}
+			if w.Data[2*j+1] > hi {
+				hi = w.Data[2*j+1]
+			}
+		}
+		out.Data = append(out.Data, lo, hi)
+	}
+	return out
+}
@@ -0,0 +1,44 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pcm(samples ...int16) []byte {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, samples)
+	return buf.Bytes()
+}
+
+func TestComputePeaksAndDownsample(t *testing.T) {
+	// Two full pixels of 2 samples plus a trailing partial pixel
+	w, err := computePeaks(bytes.NewReader(pcm(-256, 512, 1024, -32768, 32767)), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int8{-1, 2, -128, 4, 127, 127}, w.Data)
+
+	down := w.Downsample(2)
+	assert.Equal(t, 4, down.SamplesPerPixel)
+	assert.Equal(t, []int8{-128, 4, 127, 127}, down.Data)
+}
+
+func TestWaveformDatRoundTripAndJSON(t *testing.T) {
+	w := &Waveform{SampleRate: WaveformSampleRate, SamplesPerPixel: 256, Data: []int8{-3, 5, -7, 9}}
+
+	var buf bytes.Buffer
+	require.NoError(t, w.WriteDat(&buf))
+	assert.Equal(t, 24+4, buf.Len())
+
+	back, err := ReadDat(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, w, back)
+
+	data, err := json.Marshal(w)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"version":2,"channels":1,"sample_rate":16000,"samples_per_pixel":256,"bits":8,"length":2,"data":[-3,5,-7,9]}`, string(data))
+}
@@ -0,0 +1,309 @@
+// Package chapters splits transcripts into topical chapters with titles,
+// timestamps and short summaries. Chapters are written by the summarization
+// route's model, or found heuristically when no model is configured.
+package chapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"scriberr/internal/export"
+	"scriberr/internal/llm"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/internal/summarize"
+	"scriberr/pkg/logger"
+)
+
+// Methods of finding chapters
+const (
+	MethodAuto      = "auto"      // The model if one is configured, else the heuristic
+	MethodLLM       = "llm"       // The model only
+	MethodHeuristic = "heuristic" // Topic shifts and pauses, without a model
+)
+
+// minAutoSeconds is the shortest recording chaptered when its job completes;
+// shorter ones rarely need navigation
+const minAutoSeconds = 10 * 60
+
+// Options selects how chapters are found
+type Options struct {
+	Method string // Defaults to MethodAuto
+	Model  string // Defaults to the route's model, then the default summary model
+}
+
+// Service finds and stores the chapters of transcripts
+type Service struct {
+	jobRepo            repository.JobRepository
+	chapterRepo        repository.ChapterRepository
+	speakerMappingRepo repository.SpeakerMappingRepository
+	summaryRepo        repository.SummaryRepository
+	router             *llm.Router
+}
+
+// NewService creates a chapter service
+func NewService(jobRepo repository.JobRepository, chapterRepo repository.ChapterRepository, speakerMappingRepo repository.SpeakerMappingRepository, summaryRepo repository.SummaryRepository, router *llm.Router) *Service {
+	return &Service{
+		jobRepo:            jobRepo,
+		chapterRepo:        chapterRepo,
+		speakerMappingRepo: speakerMappingRepo,
+		summaryRepo:        summaryRepo,
+		router:             router,
+	}
+}
+
+// List returns the stored chapters of a job
+func (s *Service) List(ctx context.Context, jobID string) ([]models.Chapter, error) {
+	return s.chapterRepo.ListByJob(ctx, jobID)
+}
+
+// Delete removes the chapters of a job
+func (s *Service) Delete(ctx context.Context, jobID string) error {
+	return s.chapterRepo.DeleteByTranscriptionID(ctx, jobID)
+}
+
+// Schedule chapters a long job's transcript in the background. It is called
+// when a job completes; failures are logged and chapters can be generated on
+// demand later.
+func (s *Service) Schedule(jobID string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+
+		job, err := s.jobRepo.FindByID(ctx, jobID)
+		if err != nil || job == nil || job.Transcript == nil {
+			return
+		}
+		result, err := export.DecodeTranscript(*job.Transcript)
+		if err != nil || len(result.Segments) == 0 {
+			return
+		}
+		if last := result.Segments[len(result.Segments)-1]; last.End < minAutoSeconds {
+			return
+		}
+		if _, err := s.Generate(ctx, job, Options{}); err != nil {
+			logger.Warn("Automatic chaptering failed", "job_id", jobID, "error", err)
+		}
+	}()
+}
+
+// Generate finds the chapters of a job's transcript and stores them in place
+// of its previous ones
+func (s *Service) Generate(ctx context.Context, job *models.TranscriptionJob, opts Options) ([]models.Chapter, error) {
+	if job.Transcript == nil || *job.Transcript == "" {
+		return nil, fmt.Errorf("transcription has no transcript")
+	}
+	result, err := export.DecodeTranscript(*job.Transcript)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Segments) == 0 {
+		return nil, fmt.Errorf("transcript has no segments")
+	}
+	var names map[string]string
+	if mappings, err := s.speakerMappingRepo.ListByJob(ctx, job.ID); err == nil {
+		names = export.SpeakerNames(mappings)
+	}
+	segments := export.NewDocument(job.ID, "", result, names).Segments
+
+	method := opts.Method
+	if method == "" {
+		method = MethodAuto
+	}
+	start := time.Now()
+	var spans []span
+	source, model := models.ChapterSourceHeuristic, ""
+	if method != MethodHeuristic {
+		route, err := s.route(ctx, job, opts.Model)
+		if err == nil {
+			model = route.Primary().Model
+			spans, err = detect(ctx, route, segments)
+		}
+		switch {
+		case err == nil:
+			source = models.ChapterSourceLLM
+		case method == MethodLLM:
+			return nil, err
+		default:
+			logger.Warn("Chaptering with the model failed, falling back to the heuristic", "job_id", job.ID, "error", err)
+			model = ""
+		}
+	}
+	if source == models.ChapterSourceHeuristic {
+		spans = heuristic(segments)
+	}
+
+	chapters := make([]models.Chapter, len(spans))
+	for i, sp := range spans {
+		end := segments[len(segments)-1].End
+		if i+1 < len(spans) {
+			end = segments[spans[i+1].start].Start
+		}
+		title := sp.title
+		if title == "" {
+			title = fmt.Sprintf("Chapter %d", i+1)
+		}
+		chapters[i] = models.Chapter{
+			TranscriptionID: job.ID,
+			Position:        i,
+			Title:           title,
+			Summary:         sp.summary,
+			Start:           segments[sp.start].Start,
+			End:             end,
+			StartSegment:    sp.start,
+			Source:          source,
+			Model:           model,
+		}
+	}
+	// The first chapter opens the recording
+	chapters[0].Start = 0
+
+	if err := s.chapterRepo.ReplaceForJob(ctx, job.ID, chapters); err != nil {
+		return nil, fmt.Errorf("failed to store chapters: %w", err)
+	}
+	logger.Info("Found chapters", "job_id", job.ID, "count", len(chapters), "source", source, "model", model, "duration_ms", time.Since(start).Milliseconds())
+	return chapters, nil
+}
+
+// route resolves the model that writes chapters
+func (s *Service) route(ctx context.Context, job *models.TranscriptionJob, model string) (*llm.Route, error) {
+	if s.router == nil {
+		return nil, fmt.Errorf("no LLM configured")
+	}
+	route, err := s.router.Resolve(ctx, models.LLMFeatureSummarization, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if model == "" && route.Primary().Model == "" {
+		if settings, err := s.summaryRepo.GetSettings(ctx); err == nil {
+			model = settings.DefaultModel
+		}
+	}
+	route = route.WithModel(model).ForJob(job.ID)
+	if route.Primary().Model == "" {
+		return nil, fmt.Errorf("no model configured for chapters")
+	}
+	return route, nil
+}
+
+const prompt = "Split the transcript below into topical chapters for navigating the recording. " +
+	"Each transcript line starts with its segment number and timestamp. " +
+	"A chapter starts where the conversation moves to a new topic; prefer a few substantial chapters over many short ones. " +
+	"For each chapter, in order, give the number of its first segment, a short title of at most eight words " +
+	"and a one or two sentence summary."
+
+const continuation = "This is part %d of %d of a longer transcript. The previous part ended in the chapter %q: " +
+	"set continues_previous to true on your first chapter if it is still that chapter, else false."
+
+// schema is the structure the model answers with
+const schema = `{
+	"type": "object",
+	"properties": {
+		"chapters": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"start_segment": {"type": "integer"},
+					"title": {"type": "string"},
+					"summary": {"type": "string"},
+					"continues_previous": {"type": "boolean"}
+				},
+				"required": ["start_segment", "title", "summary", "continues_previous"],
+				"additionalProperties": false
+			}
+		}
+	},
+	"required": ["chapters"],
+	"additionalProperties": false
+}`
+
+type detected struct {
+	Chapters []struct {
+		StartSegment      int    `json:"start_segment"`
+		Title             string `json:"title"`
+		Summary           string `json:"summary"`
+		ContinuesPrevious bool   `json:"continues_previous"`
+	} `json:"chapters"`
+}
+
+// detect asks the model for chapter boundaries. Transcripts longer than the
+// context window are chaptered part by part, each part told the chapter the
+// previous one ended in.
+func detect(ctx context.Context, route *llm.Route, segments []export.Segment) ([]span, error) {
+	contextWindow := route.ContextWindow(ctx)
+	parsed, err := summarize.ParseSchema([]byte(schema))
+	if err != nil {
+		return nil, err
+	}
+	summarizer := summarize.New(route, contextWindow)
+
+	lines := make([]string, len(segments))
+	for i, seg := range segments {
+		speaker := ""
+		if seg.Speaker != "" {
+			speaker = seg.Speaker + ": "
+		}
+		lines[i] = fmt.Sprintf("[%d] [%s] %s%s", i, clock(seg.Start), speaker, seg.Text)
+	}
+	parts := summarizer.Chunks(prompt+continuation, lines)
+
+	var spans []span
+	first := 0
+	for p, part := range parts {
+		last := first + len(part) // Exclusive
+		instructions := prompt
+		if len(parts) > 1 && p > 0 {
+			instructions += "\n\n" + fmt.Sprintf(continuation, p+1, len(parts), spans[len(spans)-1].title)
+		}
+		raw, err := summarizer.Structured(ctx, instructions, parsed, part, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect chapters: %w", err)
+		}
+		var out detected
+		if err := json.Unmarshal(raw, &out); err != nil {
+			return nil, fmt.Errorf("failed to parse chapters: %w", err)
+		}
+
+		next := first
+		for i, c := range out.Chapters {
+			title := strings.TrimSpace(c.Title)
+			if i == 0 && p > 0 && c.ContinuesPrevious {
+				continue
+			}
+			// Keep boundaries in order and inside the part; the first
+			// chapter of a part starts with it
+			at := c.StartSegment
+			if len(spans) == 0 || (i == 0 && p > 0) {
+				at = first
+			}
+			if at < next || at >= last || title == "" {
+				continue
+			}
+			if len(spans) > 0 && spans[len(spans)-1].start == at {
+				continue
+			}
+			spans = append(spans, span{start: at, title: title, summary: strings.TrimSpace(c.Summary)})
+			next = at + 1
+		}
+		if len(spans) == 0 {
+			return nil, fmt.Errorf("model returned no chapters")
+		}
+		first = last
+	}
+	for i := range spans {
+		spans[i].end = len(segments)
+		if i+1 < len(spans) {
+			spans[i].end = spans[i+1].start
+		}
+	}
+	return spans, nil
+}
+
+func clock(seconds float64) string {
+	total := int(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, (total%3600)/60, total%60)
+}
@@ -0,0 +1,219 @@
+package chapters
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"scriberr/internal/export"
+)
+
+const (
+	// minChapterSeconds keeps heuristic chapters long enough to navigate by
+	minChapterSeconds = 120.0
+	// targetChapterSeconds sets how many chapters the heuristic aims for
+	targetChapterSeconds = 420.0
+	// maxChapters bounds the heuristic chapters of very long recordings
+	maxChapters = 20
+	// cohesionWindow is the number of segments compared on each side of a
+	// candidate boundary
+	cohesionWindow = 8
+	// maxSummaryChars bounds heuristic summaries
+	maxSummaryChars = 240
+)
+
+// span is a chapter as a range of segments
+type span struct {
+	start, end int // Segment indexes, end exclusive
+	title      string
+	summary    string
+}
+
+// heuristic splits segments where the vocabulary shifts, favoring long
+// pauses, into chapters of at least minChapterSeconds. Titles are the
+// chapter's most distinctive words and summaries its opening sentences.
+func heuristic(segments []export.Segment) []span {
+	if len(segments) == 0 {
+		return nil
+	}
+	duration := segments[len(segments)-1].End - segments[0].Start
+	count := int(math.Round(duration / targetChapterSeconds))
+	count = min(max(count, 1), maxChapters)
+
+	tokens := make([][]string, len(segments))
+	for i, seg := range segments {
+		tokens[i] = words(seg.Text)
+	}
+
+	// Score each boundary between segments i-1 and i
+	type candidate struct {
+		index int
+		score float64
+	}
+	var candidates []candidate
+	for i := 1; i < len(segments) && count > 1; i++ {
+		before := bag(tokens[max(0, i-cohesionWindow):i])
+		after := bag(tokens[i:min(len(tokens), i+cohesionWindow)])
+		score := 1 - cosine(before, after)
+		if pause := segments[i].Start - segments[i-1].End; pause > 0 {
+			score += min(pause/5, 1) * 0.5
+		}
+		candidates = append(candidates, candidate{index: i, score: score})
+	}
+	sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].score > candidates[b].score })
+
+	// Take the strongest boundaries that keep every chapter long enough
+	boundaries := []int{0}
+	start, end := segments[0].Start, segments[len(segments)-1].End
+	for _, c := range candidates {
+		if len(boundaries) >= count {
+			break
+		}
+		at := segments[c.index].Start
+		if at-start < minChapterSeconds || end-at < minChapterSeconds {
+			continue
+		}
+		ok := true
+		for _, b := range boundaries[1:] {
+			if math.Abs(segments[b].Start-at) < minChapterSeconds {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			boundaries = append(boundaries, c.index)
+		}
+	}
+	sort.Ints(boundaries)
+
+	all := bag(tokens)
+	spans := make([]span, len(boundaries))
+	for i, b := range boundaries {
+		next := len(segments)
+		if i+1 < len(boundaries) {
+			next = boundaries[i+1]
+		}
+		spans[i] = span{
+			start:   b,
+			end:     next,
+			title:   keywordTitle(bag(tokens[b:next]), all),
+			summary: opening(segments[b:next]),
+		}
+	}
+	return spans
+}
+
+// keywordTitle names a chapter after its words that are most frequent
+// relative to the whole transcript
+func keywordTitle(chapter, all map[string]float64) string {
+	type scored struct {
+		word  string
+		score float64
+	}
+	var ranked []scored
+	for w, n := range chapter {
+		if n < 2 {
+			continue
+		}
+		ranked = append(ranked, scored{w, n * n / all[w]})
+	}
+	sort.Slice(ranked, func(a, b int) bool {
+		if ranked[a].score != ranked[b].score {
+			return ranked[a].score > ranked[b].score
+		}
+		return ranked[a].word < ranked[b].word
+	})
+	var top []string
+	for _, r := range ranked {
+		if len(top) == 3 {
+			break
+		}
+		top = append(top, r.word)
+	}
+	if len(top) == 0 {
+		return ""
+	}
+	title := []rune(strings.Join(top, ", "))
+	title[0] = unicode.ToUpper(title[0])
+	return string(title)
+}
+
+// opening returns the first sentences of a chapter
+func opening(segments []export.Segment) string {
+	var b strings.Builder
+	for _, seg := range segments {
+		if b.Len() >= maxSummaryChars/2 {
+			break
+		}
+		if b.Len() > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(seg.Text)
+	}
+	text := b.String()
+	if len(text) <= maxSummaryChars {
+		return text
+	}
+	cut := strings.LastIndex(text[:maxSummaryChars], " ")
+	if cut <= 0 {
+		cut = maxSummaryChars
+	}
+	return strings.TrimRight(text[:cut], ",;:") + "…"
+}
+
+func words(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	out := fields[:0]
+	for _, f := range fields {
+		f = strings.Trim(f, "'")
+		if len([]rune(f)) >= 3 && !stopwords[f] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func bag(groups [][]string) map[string]float64 {
+	counts := make(map[string]float64)
+	for _, g := range groups {
+		for _, w := range g {
+			counts[w]++
+		}
+	}
+	return counts
+}
+
+func cosine(a, b map[string]float64) float64 {
+	var dot, na, nb float64
+	for w, x := range a {
+		dot += x * b[w]
+		na += x * x
+	}
+	for _, y := range b {
+		nb += y * y
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+var stopwords = func() map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(`about above after again against all also and any are aren't because been before being
+		below between both but can can't cannot could couldn't did didn't does doesn't doing don't down during each few
+		for from further get gets getting going gonna got had hadn't has hasn't have haven't having her here hers herself
+		him himself his how i'd i'll i'm i've into isn't it's its itself just know let's like little lot make many maybe
+		mean more most much must mustn't myself need not now off okay once only other ought our ours ourselves out over
+		own pretty probably really right same say see she she'd she'll she's should shouldn't some something such sure
+		than that that's the their theirs them themselves then there there's these they they'd they'll they're they've
+		thing things think this those through too under until very want was wasn't way we'd we'll we're we've well were
+		weren't what what's when where which while who whom why will with won't would wouldn't yeah yes you you'd you'll
+		you're you've your yours yourself yourselves`) {
+		set[w] = true
+	}
+	return set
+}()
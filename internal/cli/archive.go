@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"scriberr/internal/archive"
+
+	"github.com/spf13/cobra"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Back up or move jobs between Scriberr instances",
+}
+
+var archiveExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Download an archive of your jobs",
+	Long: `Download an archive of your jobs with their audio, transcripts, tags,
+speaker names, notes, completed summaries, chats and execution history.
+
+Action items, chapters, translations, review queues, analytics and smart
+analyses are not archived; regenerate them after importing. LLM configs and
+routes are server settings and are not archived either.`,
+	Args:          cobra.NoArgs,
+	RunE:          runArchiveExport,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var archiveImportCmd = &cobra.Command{
+	Use:           "import [archive.zip]",
+	Short:         "Import an archive into this server",
+	Args:          cobra.ExactArgs(1),
+	RunE:          runArchiveImport,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var (
+	archiveOutput string
+	archiveJobIDs []string
+	archiveUserID uint
+)
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+	archiveCmd.AddCommand(archiveExportCmd)
+	archiveCmd.AddCommand(archiveImportCmd)
+
+	archiveExportCmd.Flags().StringVarP(&archiveOutput, "output", "o", "", "Output file (default: scriberr-archive-<timestamp>.zip)")
+	archiveExportCmd.Flags().StringSliceVar(&archiveJobIDs, "job", nil, "Job IDs to export (default: all jobs)")
+	archiveImportCmd.Flags().UintVar(&archiveUserID, "user-id", 0, "Import for another user (admin only)")
+}
+
+func runArchiveExport(cmd *cobra.Command, args []string) error {
+	config, err := requireServerConfig()
+	if err != nil {
+		return err
+	}
+
+	endpoint := config.ServerURL + "/api/v1/archive/export"
+	if len(archiveJobIDs) > 0 {
+		endpoint += "?job_ids=" + url.QueryEscape(strings.Join(archiveJobIDs, ","))
+	}
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+config.Token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("export failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	path := archiveOutput
+	if path == "" {
+		path = fmt.Sprintf("scriberr-archive-%s.zip", time.Now().Format("20060102-150405"))
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	n, err := io.Copy(f, resp.Body)
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("failed to download archive: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	fmt.Printf("Exported archive to %s (%d bytes)\n", path, n)
+	return nil
+}
+
+func runArchiveImport(cmd *cobra.Command, args []string) error {
+	config, err := requireServerConfig()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	// Stream the multipart body so large archives are not buffered in memory
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	go func() {
+		if archiveUserID != 0 {
+			if err := writer.WriteField("user_id", strconv.FormatUint(uint64(archiveUserID), 10)); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		part, err := writer.CreateFormFile("archive", filepath.Base(args[0]))
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(part, file); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(writer.Close())
+	}()
+
+	req, err := http.NewRequest("POST", config.ServerURL+"/api/v1/archive/import", pr)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+config.Token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("import failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result archive.ImportResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	for _, job := range result.Imported {
+		fmt.Printf("Imported %s -> %s %s\n", job.OldID, job.NewID, job.Title)
+	}
+	for _, job := range result.Skipped {
+		fmt.Printf("Skipped  %s %s (%s)\n", job.OldID, job.Title, job.Reason)
+	}
+	fmt.Printf("%d imported, %d skipped\n", len(result.Imported), len(result.Skipped))
+	return nil
+}
+
+// requireServerConfig returns the CLI config, or an error if the server URL
+// or token is missing
+func requireServerConfig() (*Config, error) {
+	config := GetConfig()
+	if config.ServerURL == "" {
+		return nil, fmt.Errorf("server URL not configured. Please run 'scriberr login' or 'scriberr install'")
+	}
+	if config.Token == "" {
+		return nil, fmt.Errorf("not logged in (token missing). Please run 'scriberr login'")
+	}
+	return config, nil
+}
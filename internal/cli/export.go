@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"scriberr/internal/export"
+
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [job-id]",
+	Short: "Export a transcript as SRT, VTT, TXT, Markdown, DOCX or JSON",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runExport,
+	// Execute prints the error; usage would bury it
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var (
+	exportFormat       string
+	exportOutput       string
+	exportGranularity  string
+	exportMaxLineWidth int
+	exportMaxLineCount int
+	exportNotes        bool
+	exportSummary      bool
+)
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "srt", "Output format (srt, vtt, txt, md, docx, json)")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Output file (default: <job-id>.<format>, '-' for stdout)")
+	exportCmd.Flags().StringVar(&exportGranularity, "granularity", "segment", "Timestamp granularity (segment, word, none)")
+	exportCmd.Flags().IntVar(&exportMaxLineWidth, "max-line-width", 0, "Maximum characters per subtitle line")
+	exportCmd.Flags().IntVar(&exportMaxLineCount, "max-line-count", 0, "Maximum lines per subtitle cue")
+	exportCmd.Flags().BoolVar(&exportNotes, "notes", false, "Include notes")
+	exportCmd.Flags().BoolVar(&exportSummary, "summary", false, "Include the latest summary")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	jobID := args[0]
+
+	format, err := export.ParseFormat(exportFormat)
+	if err != nil {
+		return err
+	}
+	granularity, err := export.ParseGranularity(exportGranularity)
+	if err != nil {
+		return err
+	}
+
+	doc, err := FetchExportDocument(jobID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch transcript: %w", err)
+	}
+
+	opts := export.Options{
+		Format:         format,
+		Granularity:    granularity,
+		MaxLineWidth:   exportMaxLineWidth,
+		MaxLineCount:   exportMaxLineCount,
+		IncludeNotes:   exportNotes,
+		IncludeSummary: exportSummary,
+	}
+
+	if exportOutput == "-" {
+		if err := export.Render(os.Stdout, doc, opts); err != nil {
+			return fmt.Errorf("failed to render export: %w", err)
+		}
+		return nil
+	}
+
+	path := exportOutput
+	if path == "" {
+		path = jobID + export.Extension(format)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := export.Render(f, doc, opts); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("failed to render export: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %s to %s\n", jobID, path)
+	return nil
+}
+
+// FetchExportDocument downloads the normalized transcript document for a job.
+// Word timings, notes and summary are always requested so that any local
+// rendering option can be applied afterwards.
+func FetchExportDocument(jobID string) (*export.Document, error) {
+	config := GetConfig()
+	if config.ServerURL == "" {
+		return nil, fmt.Errorf("server URL not configured. Please run 'scriberr login' or 'scriberr install'")
+	}
+	if config.Token == "" {
+		return nil, fmt.Errorf("not logged in (token missing). Please run 'scriberr login'")
+	}
+
+	endpoint := fmt.Sprintf("%s/api/v1/transcription/%s/export?format=json&granularity=word&include_notes=true&include_summary=true",
+		config.ServerURL, url.PathEscape(jobID))
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+config.Token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("export failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var doc export.Document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode transcript: %w", err)
+	}
+	return &doc, nil
+}
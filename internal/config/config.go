@@ -28,6 +28,7 @@ type Config struct {
 	UploadDir      string
 	TranscriptsDir string
 	TempDir        string
+	WaveformDir    string
 
 	// Python/WhisperX configuration
 	WhisperXEnv string
@@ -44,6 +45,10 @@ type Config struct {
 	// Hugging Face configuration
 	HFToken string
 
+	// Translation service configuration (LibreTranslate-compatible)
+	TranslationServiceURL    string
+	TranslationServiceAPIKey string
+
 	// Registration control
 	AllowRegistration bool
 }
@@ -71,11 +76,14 @@ func Load() *Config {
 		UploadDir:      getEnv("UPLOAD_DIR", "data/uploads"),
 		TranscriptsDir: getEnv("TRANSCRIPTS_DIR", "data/transcripts"),
 		TempDir:        getEnv("TEMP_DIR", "data/temp"),
+		WaveformDir:    getEnv("WAVEFORM_DIR", "data/waveforms"),
 		WhisperXEnv:    getEnv("WHISPERX_ENV", "data/whisperx-env"),
 		SecureCookies:  getEnv("SECURE_COOKIES", defaultSecure) == "true",
 		OpenAIAPIKey:   getEnv("OPENAI_API_KEY", ""),
 		GroqAPIKey:     getEnv("GROQ_API_KEY", ""),
 		HFToken:        getEnv("HF_TOKEN", ""),
+		TranslationServiceURL:    getEnv("TRANSLATION_SERVICE_URL", ""),
+		TranslationServiceAPIKey: getEnv("TRANSLATION_SERVICE_API_KEY", ""),
 		AllowRegistration: getEnv("ALLOW_REGISTRATION", "false") == "true",
 	}
 }
@@ -59,6 +59,9 @@ func Initialize(dbPath string) error {
 	sqlDB.SetConnMaxLifetime(30 * time.Minute) // Reset connections every 30 minutes
 	sqlDB.SetConnMaxIdleTime(5 * time.Minute)  // Close idle connections after 5 minutes
 
+	// Databases from before LLM routing have no default LLM config
+	backfillDefaultLLM := !DB.Migrator().HasColumn(&models.LLMConfig{}, "is_default")
+
 	// Auto migrate the schema
 	if err := DB.AutoMigrate(
 		&models.TranscriptionJob{},
@@ -69,13 +72,23 @@ func Initialize(dbPath string) error {
 		&models.APIKey{},
 		&models.TranscriptionProfile{},
 		&models.LLMConfig{},
+		&models.LLMRoute{},
 		&models.ChatSession{},
 		&models.ChatMessage{},
 		&models.SummaryTemplate{},
 		&models.SummarySetting{},
 		&models.Summary{},
 		&models.Note{},
+		&models.ActionItem{},
+		&models.Chapter{},
+		&models.ConversationAnalytics{},
+		&models.TranscriptTranslation{},
+		&models.SmartAnalysis{},
+		&models.Review{},
 		&models.RefreshToken{},
+		&models.TranscriptChunk{},
+		&models.UsageEntry{},
+		&models.ModelPrice{},
 	); err != nil {
 		return fmt.Errorf("failed to auto migrate: %v", err)
 	}
@@ -101,6 +114,76 @@ func Initialize(dbPath string) error {
 		return fmt.Errorf("failed to create unique constraint for speaker mappings: %v", err)
 	}
 
+	// The config every feature used before LLM routing, the first active
+	// one, becomes the default config
+	if backfillDefaultLLM {
+		backfill := `UPDATE llm_configs SET is_default = true WHERE id = (SELECT MIN(id) FROM llm_configs WHERE is_active = true)`
+		if err := DB.Exec(backfill).Error; err != nil {
+			return fmt.Errorf("failed to backfill the default LLM config: %v", err)
+		}
+	}
+
+	if err := createTranscriptSearchIndex(DB); err != nil {
+		return fmt.Errorf("failed to create transcript search index: %v", err)
+	}
+
+	return nil
+}
+
+// segmentColumns and segmentSource select one row per transcript segment of a
+// job row (NEW inside triggers, j in the backfill). Invalid or empty
+// transcripts produce no rows instead of failing the write.
+const (
+	segmentColumns = `
+	SELECT json_extract(seg.value, '$.text'), json_extract(seg.value, '$.speaker'), %[1]s.id, seg.key,
+		json_extract(seg.value, '$.start'), json_extract(seg.value, '$.end')`
+	segmentSource = `json_each(CASE WHEN json_valid(%[1]s.transcript) THEN %[1]s.transcript ELSE '{}' END, '$.segments') AS seg`
+)
+
+func segmentRows(row string) string {
+	return fmt.Sprintf(segmentColumns+" FROM "+segmentSource, row)
+}
+
+// createTranscriptSearchIndex sets up the FTS5 index over transcript segments.
+// Triggers keep it in sync with transcription_jobs.transcript, so every write
+// path (UpdateTranscript, imports, merges, edits through Save) is covered.
+func createTranscriptSearchIndex(db *gorm.DB) error {
+	var existing int64
+	if err := db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'transcript_segments_fts'").Scan(&existing).Error; err != nil {
+		return err
+	}
+
+	statements := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS transcript_segments_fts USING fts5(
+			text, speaker UNINDEXED, job_id UNINDEXED, segment_index UNINDEXED, start UNINDEXED, "end" UNINDEXED,
+			tokenize = 'porter unicode61 remove_diacritics 2'
+		)`,
+		`CREATE TRIGGER IF NOT EXISTS transcript_segments_fts_insert AFTER INSERT ON transcription_jobs BEGIN
+			INSERT INTO transcript_segments_fts (text, speaker, job_id, segment_index, start, "end")` + segmentRows("NEW") + `;
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS transcript_segments_fts_update AFTER UPDATE OF transcript ON transcription_jobs
+		WHEN OLD.transcript IS NOT NEW.transcript BEGIN
+			DELETE FROM transcript_segments_fts WHERE job_id = OLD.id;
+			INSERT INTO transcript_segments_fts (text, speaker, job_id, segment_index, start, "end")` + segmentRows("NEW") + `;
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS transcript_segments_fts_delete AFTER DELETE ON transcription_jobs BEGIN
+			DELETE FROM transcript_segments_fts WHERE job_id = OLD.id;
+		END`,
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+
+	// Index transcripts written before the search index existed
+	if existing == 0 {
+		backfill := `INSERT INTO transcript_segments_fts (text, speaker, job_id, segment_index, start, "end")` +
+			fmt.Sprintf(segmentColumns+" FROM transcription_jobs AS j, "+segmentSource+" WHERE j.transcript IS NOT NULL", "j")
+		if err := db.Exec(backfill).Error; err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,132 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"scriberr/internal/models"
+)
+
+// Chapter export formats
+const (
+	FormatPodcastChapters Format = "podcast_chapters" // Podcasting 2.0 chapters
+	FormatFFMetadata      Format = "ffmetadata"       // FFmpeg metadata, for embedding chapters into audio files
+)
+
+// ParseChapterFormat validates a chapter export format, defaulting to
+// Podcasting 2.0 JSON
+func ParseChapterFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "json", "podcast", "podcasting", "podcast_chapters":
+		return FormatPodcastChapters, nil
+	case "vtt", "webvtt":
+		return FormatVTT, nil
+	case "ffmetadata", "ffmpeg":
+		return FormatFFMetadata, nil
+	default:
+		return "", fmt.Errorf("unsupported chapter export format: %s", s)
+	}
+}
+
+// ChapterContentType returns the MIME type for a chapter export format
+func ChapterContentType(f Format) string {
+	switch f {
+	case FormatPodcastChapters:
+		return "application/json+chapters; charset=utf-8"
+	case FormatFFMetadata:
+		return "text/plain; charset=utf-8"
+	default:
+		return ContentType(f)
+	}
+}
+
+// ChapterExtension returns the file extension (with dot) for a chapter export
+// format
+func ChapterExtension(f Format) string {
+	switch f {
+	case FormatPodcastChapters:
+		return ".chapters.json"
+	case FormatFFMetadata:
+		return ".ffmetadata.txt"
+	default:
+		return ".chapters" + Extension(f)
+	}
+}
+
+// RenderChapters writes the chapters of a recording titled title
+func RenderChapters(w io.Writer, title string, chapters []models.Chapter, f Format) error {
+	switch f {
+	case FormatPodcastChapters:
+		return renderPodcastChapters(w, title, chapters)
+	case FormatVTT:
+		return renderVTTChapters(w, chapters)
+	case FormatFFMetadata:
+		return renderFFMetadata(w, title, chapters)
+	default:
+		return fmt.Errorf("unsupported chapter export format: %s", f)
+	}
+}
+
+// renderPodcastChapters writes the Podcasting 2.0 JSON chapters format
+func renderPodcastChapters(w io.Writer, title string, chapters []models.Chapter) error {
+	type chapter struct {
+		StartTime float64 `json:"startTime"`
+		EndTime   float64 `json:"endTime,omitempty"`
+		Title     string  `json:"title"`
+	}
+	out := struct {
+		Version  string    `json:"version"`
+		Title    string    `json:"title,omitempty"`
+		Chapters []chapter `json:"chapters"`
+	}{Version: "1.2.0", Title: title, Chapters: make([]chapter, len(chapters))}
+	for i, c := range chapters {
+		out.Chapters[i] = chapter{StartTime: roundMillis(c.Start), EndTime: roundMillis(c.End), Title: c.Title}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// renderVTTChapters writes a WebVTT chapters track, one cue per chapter
+func renderVTTChapters(w io.Writer, chapters []models.Chapter) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("WEBVTT\n")
+	for i, c := range chapters {
+		fmt.Fprintf(bw, "\nChapter %d\n%s --> %s\n%s\n", i+1, formatTimestamp(c.Start, "."), formatTimestamp(c.End, "."), vttCueText(c.Title))
+	}
+	return bw.Flush()
+}
+
+// renderFFMetadata writes an FFmpeg metadata file, for use with
+// ffmpeg -i audio -i chapters.txt -map_metadata 1 -map_chapters 1
+func renderFFMetadata(w io.Writer, title string, chapters []models.Chapter) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(";FFMETADATA1\n")
+	if title != "" {
+		fmt.Fprintf(bw, "title=%s\n", escapeFFMetadata(title))
+	}
+	for _, c := range chapters {
+		fmt.Fprintf(bw, "\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			int64(math.Round(c.Start*1000)), int64(math.Round(c.End*1000)), escapeFFMetadata(c.Title))
+	}
+	return bw.Flush()
+}
+
+// escapeFFMetadata escapes the characters special to FFmpeg metadata values
+func escapeFFMetadata(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n").Replace(s)
+}
+
+// vttCueText keeps a cue on one line and escapes markup characters
+func vttCueText(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func roundMillis(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
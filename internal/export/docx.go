@@ -0,0 +1,146 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+</Types>`
+
+const docxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+</Relationships>`
+
+// docxRun is a single text run inside a paragraph
+type docxRun struct {
+	Text   string
+	Bold   bool
+	Italic bool
+	Size   int // half-points, 0 for default
+	Color  string
+}
+
+// docxBuilder accumulates WordprocessingML paragraphs
+type docxBuilder struct {
+	body bytes.Buffer
+}
+
+func (b *docxBuilder) paragraph(runs ...docxRun) {
+	b.body.WriteString("<w:p>")
+	for _, r := range runs {
+		b.body.WriteString("<w:r>")
+		if r.Bold || r.Italic || r.Size > 0 || r.Color != "" {
+			b.body.WriteString("<w:rPr>")
+			if r.Bold {
+				b.body.WriteString("<w:b/>")
+			}
+			if r.Italic {
+				b.body.WriteString("<w:i/>")
+			}
+			if r.Color != "" {
+				fmt.Fprintf(&b.body, `<w:color w:val="%s"/>`, r.Color)
+			}
+			if r.Size > 0 {
+				fmt.Fprintf(&b.body, `<w:sz w:val="%d"/>`, r.Size)
+			}
+			b.body.WriteString("</w:rPr>")
+		}
+		for i, line := range strings.Split(r.Text, "\n") {
+			if i > 0 {
+				b.body.WriteString("<w:br/>")
+			}
+			b.body.WriteString(`<w:t xml:space="preserve">`)
+			_ = xml.EscapeText(&b.body, []byte(line))
+			b.body.WriteString("</w:t>")
+		}
+		b.body.WriteString("</w:r>")
+	}
+	b.body.WriteString("</w:p>")
+}
+
+func (b *docxBuilder) heading(text string, size int) {
+	b.paragraph(docxRun{Text: text, Bold: true, Size: size})
+}
+
+func (b *docxBuilder) document() []byte {
+	var out bytes.Buffer
+	out.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	out.WriteString(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`)
+	out.Write(b.body.Bytes())
+	out.WriteString(`<w:sectPr/></w:body></w:document>`)
+	return out.Bytes()
+}
+
+func renderDOCX(w io.Writer, doc *Document, opts Options) error {
+	var b docxBuilder
+
+	title := doc.Title
+	if title == "" {
+		title = "Transcript"
+	}
+	b.heading(title, 40)
+
+	if opts.IncludeSummary && doc.Summary != "" {
+		b.heading("Summary", 30)
+		for _, p := range strings.Split(strings.TrimSpace(doc.Summary), "\n\n") {
+			b.paragraph(docxRun{Text: p})
+		}
+	}
+
+	b.heading("Transcript", 30)
+	for _, seg := range doc.Segments {
+		if seg.Text == "" {
+			continue
+		}
+		var runs []docxRun
+		if opts.Granularity != GranularityNone {
+			runs = append(runs, docxRun{Text: "[" + formatClock(seg.Start) + "] ", Color: "808080"})
+		}
+		if seg.Speaker != "" {
+			runs = append(runs, docxRun{Text: seg.Speaker + ": ", Bold: true})
+		}
+		runs = append(runs, docxRun{Text: seg.Text})
+		b.paragraph(runs...)
+	}
+
+	if opts.IncludeNotes && len(doc.Notes) > 0 {
+		b.heading("Notes", 30)
+		for _, n := range doc.Notes {
+			b.paragraph(
+				docxRun{Text: fmt.Sprintf("[%s - %s] ", formatClock(n.Start), formatClock(n.End)), Color: "808080"},
+				docxRun{Text: n.Quote, Italic: true},
+			)
+			b.paragraph(docxRun{Text: strings.TrimSpace(n.Content)})
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	parts := []struct {
+		name string
+		data []byte
+	}{
+		{"[Content_Types].xml", []byte(docxContentTypes)},
+		{"_rels/.rels", []byte(docxRels)},
+		{"word/document.xml", b.document()},
+	}
+	for _, p := range parts {
+		fw, err := zw.Create(p.name)
+		if err != nil {
+			return fmt.Errorf("failed to create docx part %s: %w", p.name, err)
+		}
+		if _, err := fw.Write(p.data); err != nil {
+			return fmt.Errorf("failed to write docx part %s: %w", p.name, err)
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,329 @@
+// Package export converts transcripts to and from interchange formats
+// (subtitles, documents and JSON) for the API, CLI and webhooks.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+)
+
+// Format identifies an export output format
+type Format string
+
+const (
+	FormatSRT      Format = "srt"
+	FormatVTT      Format = "vtt"
+	FormatTXT      Format = "txt"
+	FormatMarkdown Format = "md"
+	FormatDOCX     Format = "docx"
+	FormatJSON     Format = "json"
+)
+
+// Granularity controls how timestamps are emitted
+type Granularity string
+
+const (
+	GranularitySegment Granularity = "segment"
+	GranularityWord    Granularity = "word"
+	GranularityNone    Granularity = "none"
+)
+
+// Options controls how a document is rendered
+type Options struct {
+	Format         Format
+	Granularity    Granularity
+	MaxLineWidth   int
+	MaxLineCount   int
+	IncludeNotes   bool
+	IncludeSummary bool
+}
+
+// Document is the normalized, renderer-agnostic view of a transcript
+type Document struct {
+	JobID    string    `json:"job_id"`
+	Title    string    `json:"title"`
+	Language string    `json:"language,omitempty"`
+	Duration float64   `json:"duration"`
+	Speakers []string  `json:"speakers,omitempty"`
+	Segments []Segment `json:"segments"`
+	Notes    []Note    `json:"notes,omitempty"`
+	Summary  string    `json:"summary,omitempty"`
+}
+
+// Segment is a single transcript segment with resolved speaker name
+type Segment struct {
+	Index       int     `json:"index"`
+	Start       float64 `json:"start"`
+	End         float64 `json:"end"`
+	Speaker     string  `json:"speaker,omitempty"`
+	Text        string  `json:"text"`
+	Translation string  `json:"translation,omitempty"` // Set for bilingual exports
+	Words       []Word  `json:"words,omitempty"`
+}
+
+// Word is a single timed word
+type Word struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Note is an annotation attached to a time range
+type Note struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Quote   string  `json:"quote"`
+	Content string  `json:"content"`
+}
+
+// ParseFormat validates a format name, accepting common aliases
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "srt":
+		return FormatSRT, nil
+	case "vtt", "webvtt":
+		return FormatVTT, nil
+	case "txt", "text":
+		return FormatTXT, nil
+	case "md", "markdown":
+		return FormatMarkdown, nil
+	case "docx", "word":
+		return FormatDOCX, nil
+	case "", "json":
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", s)
+	}
+}
+
+// ParseGranularity validates a timestamp granularity, defaulting to segment
+func ParseGranularity(s string) (Granularity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "segment":
+		return GranularitySegment, nil
+	case "word":
+		return GranularityWord, nil
+	case "none":
+		return GranularityNone, nil
+	default:
+		return "", fmt.Errorf("unsupported timestamp granularity: %s", s)
+	}
+}
+
+// ContentType returns the MIME type for a format
+func ContentType(f Format) string {
+	switch f {
+	case FormatSRT:
+		return "application/x-subrip; charset=utf-8"
+	case FormatVTT:
+		return "text/vtt; charset=utf-8"
+	case FormatTXT:
+		return "text/plain; charset=utf-8"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatDOCX:
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// Extension returns the file extension (with dot) for a format
+func Extension(f Format) string {
+	return "." + string(f)
+}
+
+// DecodeTranscript parses a stored transcript JSON string
+func DecodeTranscript(raw string) (*interfaces.TranscriptResult, error) {
+	var result interfaces.TranscriptResult
+	if err := json.Unmarshal([]byte(raw), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse transcript: %w", err)
+	}
+	return &result, nil
+}
+
+// NewDocument builds a normalized document from a transcript result.
+// speakerNames maps original diarization labels to display names.
+func NewDocument(jobID, title string, result *interfaces.TranscriptResult, speakerNames map[string]string) *Document {
+	doc := &Document{
+		JobID:    jobID,
+		Title:    title,
+		Language: result.Language,
+		Segments: make([]Segment, 0, len(result.Segments)),
+	}
+
+	resolve := func(s *string) string {
+		if s == nil || *s == "" {
+			return ""
+		}
+		if name, ok := speakerNames[*s]; ok && name != "" {
+			return name
+		}
+		return *s
+	}
+
+	seen := make(map[string]bool)
+	for i, seg := range result.Segments {
+		speaker := resolve(seg.Speaker)
+		if speaker != "" && !seen[speaker] {
+			seen[speaker] = true
+			doc.Speakers = append(doc.Speakers, speaker)
+		}
+		doc.Segments = append(doc.Segments, Segment{
+			Index:   i,
+			Start:   seg.Start,
+			End:     seg.End,
+			Speaker: speaker,
+			Text:    strings.TrimSpace(seg.Text),
+		})
+		if seg.End > doc.Duration {
+			doc.Duration = seg.End
+		}
+	}
+
+	// Attach word timings to the segment they fall into
+	if len(result.WordSegments) > 0 && len(doc.Segments) > 0 {
+		j := 0
+		for _, w := range result.WordSegments {
+			mid := (w.Start + w.End) / 2
+			for j < len(doc.Segments)-1 && mid >= doc.Segments[j].End {
+				j++
+			}
+			doc.Segments[j].Words = append(doc.Segments[j].Words, Word{
+				Start: w.Start,
+				End:   w.End,
+				Text:  strings.TrimSpace(w.Word),
+			})
+		}
+	}
+
+	return doc
+}
+
+// SpeakerNames converts stored speaker mappings to a lookup map
+func SpeakerNames(mappings []models.SpeakerMapping) map[string]string {
+	names := make(map[string]string, len(mappings))
+	for _, m := range mappings {
+		names[m.OriginalSpeaker] = m.CustomName
+	}
+	return names
+}
+
+// Translate replaces the text of each segment with its translation from a
+// translation track, texts[i] translating the transcript's segment i. With
+// bilingual the translations are added below the original texts instead.
+// Word timings belong to the original language and are dropped.
+func (d *Document) Translate(language string, texts []string, bilingual bool) {
+	for i := range d.Segments {
+		seg := &d.Segments[i]
+		if seg.Index >= len(texts) {
+			continue
+		}
+		text := strings.TrimSpace(texts[seg.Index])
+		if bilingual {
+			seg.Translation = text
+		} else {
+			seg.Text = text
+		}
+		seg.Words = nil
+	}
+	if !bilingual {
+		d.Language = language
+	}
+}
+
+// AttachNotes adds notes to the document ordered by start time
+func (d *Document) AttachNotes(notes []models.Note) {
+	for _, n := range notes {
+		d.Notes = append(d.Notes, Note{
+			Start:   n.StartTime,
+			End:     n.EndTime,
+			Quote:   n.Quote,
+			Content: n.Content,
+		})
+	}
+	sort.SliceStable(d.Notes, func(i, j int) bool { return d.Notes[i].Start < d.Notes[j].Start })
+}
+
+// Render writes the document in the requested format
+func Render(w io.Writer, doc *Document, opts Options) error {
+	switch opts.Format {
+	case FormatSRT:
+		return renderSRT(w, doc, opts)
+	case FormatVTT:
+		return renderVTT(w, doc, opts)
+	case FormatTXT:
+		return renderTXT(w, doc, opts)
+	case FormatMarkdown:
+		return renderMarkdown(w, doc, opts)
+	case FormatDOCX:
+		return renderDOCX(w, doc, opts)
+	case FormatJSON, "":
+		return renderJSON(w, doc, opts)
+	default:
+		return fmt.Errorf("unsupported export format: %s", opts.Format)
+	}
+}
+
+// RenderString is a convenience wrapper returning the rendered output as a string
+func RenderString(doc *Document, opts Options) (string, error) {
+	var sb strings.Builder
+	if err := Render(&sb, doc, opts); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func renderJSON(w io.Writer, doc *Document, opts Options) error {
+	out := *doc
+	if !opts.IncludeNotes {
+		out.Notes = nil
+	}
+	if !opts.IncludeSummary {
+		out.Summary = ""
+	}
+	if opts.Granularity != GranularityWord {
+		out.Segments = make([]Segment, len(doc.Segments))
+		for i, seg := range doc.Segments {
+			seg.Words = nil
+			out.Segments[i] = seg
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// formatClock formats seconds as HH:MM:SS
+func formatClock(seconds float64) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	total := int(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, (total%3600)/60, total%60)
+}
+
+// formatTimestamp formats seconds as HH:MM:SS<sep>mmm
+func formatTimestamp(seconds float64, sep string) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	ms := int64(seconds*1000 + 0.5)
+	h := ms / 3600000
+	m := (ms % 3600000) / 60000
+	s := (ms % 60000) / 1000
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", h, m, s, sep, ms%1000)
+}
+
+func speakerPrefix(speaker string) string {
+	if speaker == "" {
+		return ""
+	}
+	return speaker + ": "
+}
@@ -0,0 +1,256 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string { return &s }
+
+func sampleDocument() *Document {
+	result := &interfaces.TranscriptResult{
+		Language: "en",
+		Segments: []interfaces.TranscriptSegment{
+			{Start: 0, End: 2.5, Text: " Hello there everyone", Speaker: strPtr("SPEAKER_00")},
+			{Start: 2.5, End: 6, Text: "Welcome to the weekly sync meeting today", Speaker: strPtr("SPEAKER_01")},
+		},
+	}
+	doc := NewDocument("job-1", "Weekly Sync", result, map[string]string{"SPEAKER_00": "Alice"})
+	doc.Summary = "Short summary."
+	return doc
+}
+
+func TestRenderSRT(t *testing.T) {
+	out, err := RenderString(sampleDocument(), Options{Format: FormatSRT})
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(out, "1\n00:00:00,000 --> 00:00:02,500\nAlice: Hello there everyone\n"))
+	assert.Contains(t, out, "2\n00:00:02,500 --> 00:00:06,000\nSPEAKER_01: Welcome")
+}
+
+func TestRenderVTTSplitsLongCues(t *testing.T) {
+	out, err := RenderString(sampleDocument(), Options{Format: FormatVTT, MaxLineWidth: 12, MaxLineCount: 1, IncludeSummary: true})
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(out, "WEBVTT\n\nNOTE Summary\nShort summary.\n"))
+	assert.Contains(t, out, "<v Alice>Hello there")
+	// Second segment must be split into several cues covering its full range
+	assert.Contains(t, out, "00:00:02.500 --> ")
+	assert.Contains(t, out, " --> 00:00:06.000")
+	assert.GreaterOrEqual(t, strings.Count(out, "-->"), 5)
+}
+
+func TestRenderMarkdownAndText(t *testing.T) {
+	doc := sampleDocument()
+
+	md, err := RenderString(doc, Options{Format: FormatMarkdown, IncludeSummary: true})
+	require.NoError(t, err)
+	assert.Contains(t, md, "# Weekly Sync")
+	assert.Contains(t, md, "## Summary\n\nShort summary.")
+	assert.Contains(t, md, "**Alice** `00:00:00`: Hello there everyone")
+
+	txt, err := RenderString(doc, Options{Format: FormatTXT, Granularity: GranularityNone})
+	require.NoError(t, err)
+	assert.Contains(t, txt, "Alice: Hello there everyone\n")
+	assert.NotContains(t, txt, "Short summary.")
+}
+
+func TestRenderDOCX(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, sampleDocument(), Options{Format: FormatDOCX}))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	names := map[string]bool{}
+	for _, f := range zr.File {
+		names[f.Name] = true
+	}
+	assert.True(t, names["[Content_Types].xml"])
+	assert.True(t, names["word/document.xml"])
+}
+
+func TestParseTranscriptFile(t *testing.T) {
+	t.Run("SRT with inline speakers", func(t *testing.T) {
+		srt := "1\n00:00:00,000 --> 00:00:02,000\nAlice: Hi\n\n2\n00:00:02,000 --> 00:00:04,500\nBob: Hello\n\n3\n00:00:04,500 --> 00:00:06,000\nAlice: Bye\n"
+		result, source, err := ParseTranscriptFile("meeting.srt", []byte(srt), 0)
+		require.NoError(t, err)
+		assert.Equal(t, SourceSRT, source)
+		require.Len(t, result.Segments, 3)
+		assert.Equal(t, "Alice", *result.Segments[0].Speaker)
+		assert.Equal(t, "Hi", result.Segments[0].Text)
+		// Bob only appears once, so the prefix is kept as text
+		assert.Nil(t, result.Segments[1].Speaker)
+		assert.Equal(t, 4.5, result.Segments[2].Start)
+	})
+
+	t.Run("VTT voice tags", func(t *testing.T) {
+		vtt := "WEBVTT\n\nNOTE ignored\n\ncue-1\n00:01.000 --> 00:03.250 align:start\n<v Carol>Good <b>morning</b>\n"
+		result, source, err := ParseTranscriptFile("x.vtt", []byte(vtt), 0)
+		require.NoError(t, err)
+		assert.Equal(t, SourceVTT, source)
+		require.Len(t, result.Segments, 1)
+		assert.Equal(t, "Carol", *result.Segments[0].Speaker)
+		assert.Equal(t, "Good morning", result.Segments[0].Text)
+		assert.Equal(t, 3.25, result.Segments[0].End)
+	})
+
+	t.Run("Otter text", func(t *testing.T) {
+		otter := "Speaker 1  0:00\nWelcome everyone.\n\nSpeaker 2  0:07\nThanks for having me.\n"
+		result, source, err := ParseTranscriptFile("otter.txt", []byte(otter), 20)
+		require.NoError(t, err)
+		assert.Equal(t, SourceOtter, source)
+		require.Len(t, result.Segments, 2)
+		assert.Equal(t, 7.0, result.Segments[0].End)
+		assert.Equal(t, 20.0, result.Segments[1].End)
+		assert.Equal(t, "Speaker 2", *result.Segments[1].Speaker)
+	})
+
+	t.Run("Descript text round trip", func(t *testing.T) {
+		txt, err := RenderString(sampleDocument(), Options{Format: FormatTXT})
+		require.NoError(t, err)
+		result, source, err := ParseTranscriptFile("export.txt", []byte(txt), 6)
+		require.NoError(t, err)
+		assert.Equal(t, SourceDescript, source)
+		require.Len(t, result.Segments, 2)
+		assert.Equal(t, "Alice", *result.Segments[0].Speaker)
+	})
+}
+
+func TestSliceTranscript(t *testing.T) {
+	result := &interfaces.TranscriptResult{
+		Segments: []interfaces.TranscriptSegment{
+			{Start: 0, End: 4, Text: "first"},
+			{Start: 4, End: 10, Text: "second"},
+			{Start: 10, End: 12, Text: "third"},
+		},
+		WordSegments: []interfaces.TranscriptWord{
+			{Start: 3, End: 3.5, Word: "a"},
+			{Start: 5, End: 5.5, Word: "b"},
+		},
+	}
+
+	part := SliceTranscript(result, 3, 8, 20)
+	require.Len(t, part.Segments, 2)
+	assert.Equal(t, 20.0, part.Segments[0].Start)
+	assert.Equal(t, 21.0, part.Segments[0].End)
+	assert.Equal(t, 25.0, part.Segments[1].End)
+	require.Len(t, part.WordSegments, 2)
+	assert.Equal(t, 22.0, part.WordSegments[1].Start)
+	assert.Equal(t, "first second", part.Text)
+
+	reel := &interfaces.TranscriptResult{}
+	AppendTranscript(reel, part)
+	AppendTranscript(reel, SliceTranscript(result, 10, 12, 25))
+	assert.Len(t, reel.Segments, 3)
+	assert.Equal(t, "first second third", reel.Text)
+}
+
+func TestRenderTasks(t *testing.T) {
+	due := "2026-10-23"
+	at := 83.0
+	completed := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+	tasks := []Task{
+		{ActionItem: models.ActionItem{ID: "a1", TranscriptionID: "job-1", Title: "Send the budget, with notes", Assignee: "Alice", DueDate: &due, SourceTime: &at,
+			Quote: "I'll send the budget by Friday", Status: models.ActionItemStatusOpen}, Source: "Weekly Sync"},
+		{ActionItem: models.ActionItem{ID: "a2", TranscriptionID: "job-1", Title: "Book the room", Status: models.ActionItemStatusDone, CompletedAt: &completed}, Source: "Weekly Sync"},
+	}
+
+	var md bytes.Buffer
+	require.NoError(t, RenderTasks(&md, tasks, FormatMarkdown))
+	assert.Equal(t, "# Action items\n\n## Weekly Sync\n\n- [ ] Send the budget, with notes — @Alice, due 2026-10-23, `00:01:23`\n- [x] Book the room\n", md.String())
+
+	var csv bytes.Buffer
+	require.NoError(t, RenderTasks(&csv, tasks, FormatCSV))
+	assert.Contains(t, csv.String(), "id,title,assignee,due_date,status,transcription_id,transcription,timestamp,quote\n")
+	assert.Contains(t, csv.String(), `a1,"Send the budget, with notes",Alice,2026-10-23,open,job-1,Weekly Sync,00:01:23,I'll send the budget by Friday`)
+
+	var ics bytes.Buffer
+	require.NoError(t, RenderTasks(&ics, tasks, FormatICS))
+	out := ics.String()
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.Contains(t, out, "SUMMARY:Send the budget\\, with notes\r\n")
+	assert.Contains(t, out, "DUE;VALUE=DATE:20261023\r\n")
+	assert.Contains(t, out, "STATUS:COMPLETED\r\nCOMPLETED:20261020T090000Z\r\n")
+	for _, line := range strings.Split(out, "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+
+	_, err := ParseTaskFormat("pdf")
+	assert.Error(t, err)
+}
+
+func TestRenderChapters(t *testing.T) {
+	chapters := []models.Chapter{
+		{Title: "Intro", Start: 0, End: 95.5},
+		{Title: "Budget; Q4 = plan", Start: 95.5, End: 610.25},
+	}
+
+	var podcast bytes.Buffer
+	require.NoError(t, RenderChapters(&podcast, "Weekly Sync", chapters, FormatPodcastChapters))
+	var parsed struct {
+		Version  string `json:"version"`
+		Title    string `json:"title"`
+		Chapters []struct {
+			StartTime float64 `json:"startTime"`
+			EndTime   float64 `json:"endTime"`
+			Title     string  `json:"title"`
+		} `json:"chapters"`
+	}
+	require.NoError(t, json.Unmarshal(podcast.Bytes(), &parsed))
+	assert.Equal(t, "1.2.0", parsed.Version)
+	assert.Equal(t, "Weekly Sync", parsed.Title)
+	require.Len(t, parsed.Chapters, 2)
+	assert.Equal(t, 95.5, parsed.Chapters[1].StartTime)
+	assert.Equal(t, "Budget; Q4 = plan", parsed.Chapters[1].Title)
+
+	var vtt bytes.Buffer
+	require.NoError(t, RenderChapters(&vtt, "", chapters, FormatVTT))
+	assert.Equal(t, "WEBVTT\n\nChapter 1\n00:00:00.000 --> 00:01:35.500\nIntro\n\nChapter 2\n00:01:35.500 --> 00:10:10.250\nBudget; Q4 = plan\n", vtt.String())
+
+	var ff bytes.Buffer
+	require.NoError(t, RenderChapters(&ff, "Weekly Sync", chapters, FormatFFMetadata))
+	assert.Contains(t, ff.String(), ";FFMETADATA1\ntitle=Weekly Sync\n")
+	assert.Contains(t, ff.String(), "[CHAPTER]\nTIMEBASE=1/1000\nSTART=95500\nEND=610250\ntitle=Budget\\; Q4 \\= plan\n")
+
+	format, err := ParseChapterFormat("json")
+	require.NoError(t, err)
+	assert.Equal(t, FormatPodcastChapters, format)
+	assert.NotEqual(t, FormatJSON, format)
+	assert.Equal(t, ".chapters.json", ChapterExtension(format))
+
+	_, err = ParseChapterFormat("srt")
+	assert.Error(t, err)
+}
+
+func TestTranslateDocument(t *testing.T) {
+	texts := []string{"Xin chào mọi người", "Chào mừng đến buổi họp hàng tuần hôm nay"}
+
+	doc := sampleDocument()
+	doc.Translate("vi", texts, true)
+	srt, err := RenderString(doc, Options{Format: FormatSRT})
+	require.NoError(t, err)
+	assert.Contains(t, srt, "1\n00:00:00,000 --> 00:00:02,500\nAlice: Hello there everyone\nXin chào mọi người\n")
+	assert.Equal(t, 2, strings.Count(srt, "-->"))
+	txt, err := RenderString(doc, Options{Format: FormatTXT})
+	require.NoError(t, err)
+	assert.Contains(t, txt, "    Xin chào mọi người\n")
+
+	doc = sampleDocument()
+	doc.Translate("vi", texts, false)
+	assert.Equal(t, "vi", doc.Language)
+	srt, err = RenderString(doc, Options{Format: FormatSRT})
+	require.NoError(t, err)
+	assert.Contains(t, srt, "Alice: Xin chào mọi người\n")
+	assert.NotContains(t, srt, "Hello there")
+}
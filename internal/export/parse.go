@@ -0,0 +1,410 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"scriberr/internal/transcription/interfaces"
+)
+
+// Source formats recognised by ParseTranscriptFile
+const (
+	SourceSRT      = "srt"
+	SourceVTT      = "vtt"
+	SourceJSON     = "json"
+	SourceOtter    = "otter"
+	SourceDescript = "descript"
+	SourceText     = "txt"
+)
+
+var (
+	cueTimingPattern   = regexp.MustCompile(`^\s*((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})\s*-->\s*((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})`)
+	voiceTagPattern    = regexp.MustCompile(`^<v(?:\.[^ >]+)*\s+([^>]+)>`)
+	markupTagPattern   = regexp.MustCompile(`</?[^>]+>`)
+	speakerLinePattern = regexp.MustCompile(`^\[?([^\[\]:]{1,40}?)\]?:\s+(.+)$`)
+	otterHeaderPattern = regexp.MustCompile(`^(.+?)\s{2,}(\d{1,2}:\d{2}(?::\d{2})?)\s*$`)
+	bracketTimePattern = regexp.MustCompile(`^\[(\d{1,2}:\d{2}(?::\d{2})?(?:[.,]\d+)?)\]\s*(.*)$`)
+)
+
+// ParseTranscriptFile parses an existing transcript in SRT, WebVTT, JSON or
+// one of the plain text variants (Otter, Descript, timestamped or untimed
+// text). duration is the audio length in seconds and is used to close the
+// last segment of formats that carry only start times; pass 0 if unknown.
+// The detected source format is returned alongside the result.
+func ParseTranscriptFile(filename string, data []byte, duration float64) (*interfaces.TranscriptResult, string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return nil, "", fmt.Errorf("transcript file is empty")
+	}
+
+	var (
+		result *interfaces.TranscriptResult
+		source string
+		err    error
+	)
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	switch {
+	case ext == ".json" || strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "["):
+		result, err = parseJSONTranscript([]byte(trimmed))
+		source = SourceJSON
+	case ext == ".vtt" || strings.HasPrefix(trimmed, "WEBVTT"):
+		result, err = parseCues(content, true)
+		source = SourceVTT
+	case ext == ".srt" || looksLikeSRT(trimmed):
+		result, err = parseCues(content, false)
+		source = SourceSRT
+	default:
+		result, source, err = parseTextTranscript(content, duration)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if len(result.Segments) == 0 {
+		return nil, "", fmt.Errorf("no transcript segments found")
+	}
+
+	if result.Text == "" {
+		parts := make([]string, 0, len(result.Segments))
+		for _, seg := range result.Segments {
+			parts = append(parts, seg.Text)
+		}
+		result.Text = strings.Join(parts, " ")
+	}
+	if result.Metadata == nil {
+		result.Metadata = map[string]string{}
+	}
+	result.Metadata["source_format"] = source
+	result.ModelUsed = "imported"
+
+	return result, source, nil
+}
+
+func looksLikeSRT(s string) bool {
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for i := 0; i < 3 && scanner.Scan(); i++ {
+		if cueTimingPattern.MatchString(scanner.Text()) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCueTimestamp parses HH:MM:SS,mmm / HH:MM:SS.mmm / MM:SS.mmm
+func parseCueTimestamp(s string) (float64, error) {
+	s = strings.Replace(strings.TrimSpace(s), ",", ".", 1)
+	parts := strings.Split(s, ":")
+	var total float64
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		total = total*60 + v
+	}
+	return total, nil
+}
+
+// parseCues handles both SRT and WebVTT cue lists
+func parseCues(content string, vtt bool) (*interfaces.TranscriptResult, error) {
+	result := &interfaces.TranscriptResult{}
+	blocks := strings.Split(content, "\n\n")
+
+	for _, block := range blocks {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		if len(lines) == 0 || lines[0] == "" {
+			continue
+		}
+		if vtt && (strings.HasPrefix(lines[0], "WEBVTT") || strings.HasPrefix(lines[0], "NOTE") ||
+			strings.HasPrefix(lines[0], "STYLE") || strings.HasPrefix(lines[0], "REGION")) {
+			continue
+		}
+
+		// Skip the optional cue identifier / SRT counter
+		timingIdx := -1
+		for i, l := range lines {
+			if cueTimingPattern.MatchString(l) {
+				timingIdx = i
+				break
+			}
+		}
+		if timingIdx < 0 {
+			continue
+		}
+
+		m := cueTimingPattern.FindStringSubmatch(lines[timingIdx])
+		start, err := parseCueTimestamp(m[1])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseCueTimestamp(m[2])
+		if err != nil {
+			return nil, err
+		}
+
+		var speaker string
+		textLines := lines[timingIdx+1:]
+		if len(textLines) > 0 {
+			if vm := voiceTagPattern.FindStringSubmatch(textLines[0]); vm != nil {
+				speaker = strings.TrimSpace(vm[1])
+			}
+		}
+		text := strings.TrimSpace(markupTagPattern.ReplaceAllString(strings.Join(textLines, " "), ""))
+		if text == "" {
+			continue
+		}
+
+		seg := interfaces.TranscriptSegment{Start: start, End: end, Text: text}
+		if speaker != "" {
+			seg.Speaker = &speaker
+		}
+		result.Segments = append(result.Segments, seg)
+	}
+
+	extractInlineSpeakers(result.Segments)
+	return result, nil
+}
+
+// extractInlineSpeakers turns "Name: text" prefixes into speakers when the
+// same prefix occurs on more than one segment, so one-off labels such as
+// "Note: ..." are left untouched
+func extractInlineSpeakers(segments []interfaces.TranscriptSegment) {
+	counts := make(map[string]int)
+	for _, seg := range segments {
+		if seg.Speaker != nil {
+			continue
+		}
+		if m := speakerLinePattern.FindStringSubmatch(seg.Text); m != nil {
+			counts[strings.TrimSpace(m[1])]++
+		}
+	}
+	for i := range segments {
+		if segments[i].Speaker != nil {
+			continue
+		}
+		m := speakerLinePattern.FindStringSubmatch(segments[i].Text)
+		if m == nil {
+			continue
+		}
+		name := strings.TrimSpace(m[1])
+		if counts[name] < 2 {
+			continue
+		}
+		segments[i].Speaker = &name
+		segments[i].Text = strings.TrimSpace(m[2])
+	}
+}
+
+// parseJSONTranscript accepts Scriberr's own transcript JSON, the normalized
+// export document, WhisperX/Whisper output and bare segment arrays
+func parseJSONTranscript(data []byte) (*interfaces.TranscriptResult, error) {
+	type jsonWord struct {
+		Start   float64 `json:"start"`
+		End     float64 `json:"end"`
+		Word    string  `json:"word"`
+		Text    string  `json:"text"`
+		Score   float64 `json:"score"`
+		Speaker *string `json:"speaker,omitempty"`
+	}
+	type jsonSegment struct {
+		Start   float64    `json:"start"`
+		End     float64    `json:"end"`
+		Text    string     `json:"text"`
+		Speaker *string    `json:"speaker,omitempty"`
+		Words   []jsonWord `json:"words,omitempty"`
+	}
+	var doc struct {
+		Text         string        `json:"text"`
+		Language     string        `json:"language"`
+		Segments     []jsonSegment `json:"segments"`
+		WordSegments []jsonWord    `json:"word_segments"`
+	}
+
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &doc.Segments); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON transcript: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON transcript: %w", err)
+	}
+
+	toWord := func(w jsonWord) interfaces.TranscriptWord {
+		text := w.Word
+		if text == "" {
+			text = w.Text
+		}
+		return interfaces.TranscriptWord{Start: w.Start, End: w.End, Word: text, Score: w.Score, Speaker: w.Speaker}
+	}
+
+	result := &interfaces.TranscriptResult{Text: doc.Text, Language: doc.Language}
+	for _, seg := range doc.Segments {
+		if seg.Speaker != nil && *seg.Speaker == "" {
+			seg.Speaker = nil
+		}
+		result.Segments = append(result.Segments, interfaces.TranscriptSegment{
+			Start:   seg.Start,
+			End:     seg.End,
+			Text:    strings.TrimSpace(seg.Text),
+			Speaker: seg.Speaker,
+		})
+		for _, w := range seg.Words {
+			word := toWord(w)
+			if word.Speaker == nil {
+				word.Speaker = seg.Speaker
+			}
+			result.WordSegments = append(result.WordSegments, word)
+		}
+	}
+	if len(result.WordSegments) == 0 {
+		for _, w := range doc.WordSegments {
+			result.WordSegments = append(result.WordSegments, toWord(w))
+		}
+	}
+	return result, nil
+}
+
+// parseTextTranscript handles plain text exports. Otter writes a
+// "Speaker  0:03" header line before each paragraph; Descript (and our own
+// TXT export) prefixes lines with "[00:00:03] Speaker:". Anything else is
+// treated as untimed paragraphs spread evenly over the audio duration.
+func parseTextTranscript(content string, duration float64) (*interfaces.TranscriptResult, string, error) {
+	lines := strings.Split(content, "\n")
+
+	otterHeaders, bracketLines := 0, 0
+	for _, l := range lines {
+		l = strings.TrimSpace(l)
+		if otterHeaderPattern.MatchString(l) {
+			otterHeaders++
+		} else if bracketTimePattern.MatchString(l) {
+			bracketLines++
+		}
+	}
+
+	var (
+		segments []interfaces.TranscriptSegment
+		source   string
+	)
+
+	switch {
+	case otterHeaders > 0 && otterHeaders >= bracketLines:
+		source = SourceOtter
+		var current *interfaces.TranscriptSegment
+		var text []string
+		flush := func() {
+			if current != nil && len(text) > 0 {
+				current.Text = strings.Join(text, " ")
+				segments = append(segments, *current)
+			}
+			current, text = nil, nil
+		}
+		for _, l := range lines {
+			l = strings.TrimSpace(l)
+			if m := otterHeaderPattern.FindStringSubmatch(l); m != nil {
+				flush()
+				start, err := parseCueTimestamp(m[2])
+				if err != nil {
+					return nil, "", err
+				}
+				speaker := strings.TrimSpace(m[1])
+				current = &interfaces.TranscriptSegment{Start: start, Speaker: &speaker}
+				continue
+			}
+			if l != "" && current != nil {
+				text = append(text, l)
+			}
+		}
+		flush()
+
+	case bracketLines > 0:
+		source = SourceDescript
+		for _, l := range lines {
+			m := bracketTimePattern.FindStringSubmatch(strings.TrimSpace(l))
+			if m == nil {
+				// Continuation of the previous line
+				if t := strings.TrimSpace(l); t != "" && len(segments) > 0 {
+					segments[len(segments)-1].Text += " " + t
+				}
+				continue
+			}
+			start, err := parseCueTimestamp(m[1])
+			if err != nil {
+				return nil, "", err
+			}
+			seg := interfaces.TranscriptSegment{Start: start, Text: strings.TrimSpace(m[2])}
+			if sm := speakerLinePattern.FindStringSubmatch(seg.Text); sm != nil {
+				speaker := strings.TrimSpace(sm[1])
+				seg.Speaker = &speaker
+				seg.Text = strings.TrimSpace(sm[2])
+			}
+			if seg.Text != "" {
+				segments = append(segments, seg)
+			}
+		}
+
+	default:
+		source = SourceText
+		for _, para := range strings.Split(content, "\n\n") {
+			para = strings.Join(strings.Fields(para), " ")
+			if para != "" {
+				segments = append(segments, interfaces.TranscriptSegment{Text: para})
+			}
+		}
+		extractInlineSpeakers(segments)
+		distributeByLength(segments, duration)
+		return &interfaces.TranscriptResult{Segments: segments}, source, nil
+	}
+
+	closeSegments(segments, duration)
+	return &interfaces.TranscriptResult{Segments: segments}, source, nil
+}
+
+// closeSegments fills end times from the next segment's start, using the
+// audio duration (or a speaking-rate estimate) for the last one
+func closeSegments(segments []interfaces.TranscriptSegment, duration float64) {
+	for i := range segments {
+		if i+1 < len(segments) {
+			segments[i].End = segments[i+1].Start
+			continue
+		}
+		estimate := segments[i].Start + float64(len(strings.Fields(segments[i].Text)))/2.5
+		if duration > segments[i].Start {
+			segments[i].End = duration
+		} else {
+			segments[i].End = estimate
+		}
+	}
+}
+
+// distributeByLength assigns timings to untimed segments proportionally to
+// their length across the audio duration
+func distributeByLength(segments []interfaces.TranscriptSegment, duration float64) {
+	total := 0
+	for _, seg := range segments {
+		total += len(seg.Text)
+	}
+	if total == 0 {
+		return
+	}
+	if duration <= 0 {
+		// Assume an average speaking rate when the duration is unknown
+		words := 0
+		for _, seg := range segments {
+			words += len(strings.Fields(seg.Text))
+		}
+		duration = float64(words) / 2.5
+	}
+	pos := 0
+	for i := range segments {
+		segments[i].Start = duration * float64(pos) / float64(total)
+		pos += len(segments[i].Text)
+		segments[i].End = duration * float64(pos) / float64(total)
+	}
+}
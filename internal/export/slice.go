@@ -0,0 +1,63 @@
+package export
+
+import (
+	"strings"
+
+	"scriberr/internal/transcription/interfaces"
+)
+
+// SliceTranscript returns the part of a transcript that overlaps
+// [start, end), with segments and words clipped to the range and shifted so
+// that start maps to offset. It is used for clip captions and to build the
+// transcript of concatenated highlight reels.
+func SliceTranscript(result *interfaces.TranscriptResult, start, end, offset float64) *interfaces.TranscriptResult {
+	shift := offset - start
+	out := &interfaces.TranscriptResult{
+		Language:  result.Language,
+		ModelUsed: result.ModelUsed,
+		Segments:  []interfaces.TranscriptSegment{},
+	}
+
+	var texts []string
+	for _, seg := range result.Segments {
+		if seg.End <= start || seg.Start >= end {
+			continue
+		}
+		seg.Start = clamp(seg.Start, start, end) + shift
+		seg.End = clamp(seg.End, start, end) + shift
+		out.Segments = append(out.Segments, seg)
+		texts = append(texts, strings.TrimSpace(seg.Text))
+	}
+	for _, w := range result.WordSegments {
+		if w.End <= start || w.Start >= end {
+			continue
+		}
+		w.Start = clamp(w.Start, start, end) + shift
+		w.End = clamp(w.End, start, end) + shift
+		out.WordSegments = append(out.WordSegments, w)
+	}
+	out.Text = strings.Join(texts, " ")
+	return out
+}
+
+// AppendTranscript appends the segments and words of part to result
+func AppendTranscript(result, part *interfaces.TranscriptResult) {
+	result.Segments = append(result.Segments, part.Segments...)
+	result.WordSegments = append(result.WordSegments, part.WordSegments...)
+	if part.Text != "" {
+		if result.Text != "" {
+			result.Text += " "
+		}
+		result.Text += part.Text
+	}
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,203 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// cue is a single subtitle entry
+type cue struct {
+	Start   float64
+	End     float64
+	Speaker string
+	Lines   []string
+}
+
+// buildCues splits the document into subtitle cues honoring line limits
+func buildCues(doc *Document, opts Options) []cue {
+	var cues []cue
+	for _, seg := range doc.Segments {
+		if seg.Translation != "" {
+			cues = append(cues, bilingualCue(seg, opts.MaxLineWidth))
+			continue
+		}
+		if opts.Granularity == GranularityWord && len(seg.Words) > 0 {
+			for _, w := range seg.Words {
+				if w.Text == "" {
+					continue
+				}
+				cues = append(cues, cue{Start: w.Start, End: w.End, Speaker: seg.Speaker, Lines: []string{w.Text}})
+			}
+			continue
+		}
+		cues = append(cues, splitSegment(seg, opts.MaxLineWidth, opts.MaxLineCount)...)
+	}
+	return cues
+}
+
+// bilingualCue shows a segment's text above its translation. Both stay in
+// one cue, so line count limits do not apply.
+func bilingualCue(seg Segment, maxWidth int) cue {
+	var lines []string
+	for _, text := range []string{seg.Text, seg.Translation} {
+		if tokens := strings.Fields(text); len(tokens) > 0 {
+			lines = append(lines, joinLines(wrapTokens(tokens, maxWidth))...)
+		}
+	}
+	return cue{Start: seg.Start, End: seg.End, Speaker: seg.Speaker, Lines: lines}
+}
+
+// splitSegment wraps a segment's text and splits it into as many cues as
+// needed to respect maxCount lines per cue. Word timings are used for the
+// cue boundaries when available, otherwise time is distributed by length.
+func splitSegment(seg Segment, maxWidth, maxCount int) []cue {
+	tokens := strings.Fields(seg.Text)
+	timed := len(seg.Words) > 0
+	if timed {
+		tokens = tokens[:0]
+		for _, w := range seg.Words {
+			if w.Text != "" {
+				tokens = append(tokens, w.Text)
+			}
+		}
+		timed = len(tokens) == len(seg.Words)
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	lines := wrapTokens(tokens, maxWidth)
+	if maxCount <= 0 || len(lines) <= maxCount {
+		return []cue{{Start: seg.Start, End: seg.End, Speaker: seg.Speaker, Lines: joinLines(lines)}}
+	}
+
+	totalChars := 0
+	for _, t := range tokens {
+		totalChars += len(t) + 1
+	}
+
+	var cues []cue
+	consumed := 0
+	chars := 0
+	for i := 0; i < len(lines); i += maxCount {
+		end := i + maxCount
+		if end > len(lines) {
+			end = len(lines)
+		}
+		group := lines[i:end]
+		count := 0
+		groupChars := 0
+		for _, l := range group {
+			count += len(l)
+			for _, t := range l {
+				groupChars += len(t) + 1
+			}
+		}
+
+		c := cue{Speaker: seg.Speaker, Lines: joinLines(group)}
+		if timed {
+			c.Start = seg.Words[consumed].Start
+			c.End = seg.Words[consumed+count-1].End
+		} else {
+			duration := seg.End - seg.Start
+			c.Start = seg.Start + duration*float64(chars)/float64(totalChars)
+			c.End = seg.Start + duration*float64(chars+groupChars)/float64(totalChars)
+		}
+		consumed += count
+		chars += groupChars
+		cues = append(cues, c)
+	}
+	return cues
+}
+
+// wrapTokens greedily packs tokens into lines no wider than maxWidth runes
+func wrapTokens(tokens []string, maxWidth int) [][]string {
+	if maxWidth <= 0 {
+		return [][]string{tokens}
+	}
+	var lines [][]string
+	var current []string
+	width := 0
+	for _, t := range tokens {
+		w := len([]rune(t))
+		if len(current) > 0 && width+1+w > maxWidth {
+			lines = append(lines, current)
+			current = nil
+			width = 0
+		}
+		if len(current) > 0 {
+			width++
+		}
+		current = append(current, t)
+		width += w
+	}
+	if len(current) > 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+func joinLines(lines [][]string) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = strings.Join(l, " ")
+	}
+	return out
+}
+
+func renderSRT(w io.Writer, doc *Document, opts Options) error {
+	bw := bufio.NewWriter(w)
+	for i, c := range buildCues(doc, opts) {
+		lines := c.Lines
+		if c.Speaker != "" && len(lines) > 0 {
+			lines = append([]string{speakerPrefix(c.Speaker) + lines[0]}, lines[1:]...)
+		}
+		fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n",
+			i+1,
+			formatTimestamp(c.Start, ","),
+			formatTimestamp(c.End, ","),
+			strings.Join(lines, "\n"))
+	}
+	return bw.Flush()
+}
+
+func renderVTT(w io.Writer, doc *Document, opts Options) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("WEBVTT\n\n")
+
+	if opts.IncludeSummary && doc.Summary != "" {
+		fmt.Fprintf(bw, "NOTE Summary\n%s\n\n", vttNoteText(doc.Summary))
+	}
+	if opts.IncludeNotes {
+		for _, n := range doc.Notes {
+			fmt.Fprintf(bw, "NOTE %s - %s\n%s\n\n",
+				formatTimestamp(n.Start, "."), formatTimestamp(n.End, "."), vttNoteText(n.Content))
+		}
+	}
+
+	for _, c := range buildCues(doc, opts) {
+		lines := c.Lines
+		if c.Speaker != "" && len(lines) > 0 {
+			lines = append([]string{fmt.Sprintf("<v %s>%s", c.Speaker, lines[0])}, lines[1:]...)
+		}
+		fmt.Fprintf(bw, "%s --> %s\n%s\n\n",
+			formatTimestamp(c.Start, "."),
+			formatTimestamp(c.End, "."),
+			strings.Join(lines, "\n"))
+	}
+	return bw.Flush()
+}
+
+// vttNoteText makes free text safe for a NOTE block (no blank lines or arrows)
+func vttNoteText(s string) string {
+	s = strings.ReplaceAll(s, "-->", "->")
+	var out []string
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) != "" {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
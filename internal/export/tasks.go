@@ -0,0 +1,219 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"scriberr/internal/models"
+)
+
+// Task export formats
+const (
+	FormatCSV Format = "csv"
+	FormatICS Format = "ics"
+)
+
+// Task is an action item with the title of the transcription it came from
+type Task struct {
+	models.ActionItem
+	Source string // Title of the transcription
+}
+
+// ParseTaskFormat validates a task export format, defaulting to Markdown
+func ParseTaskFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "md", "markdown":
+		return FormatMarkdown, nil
+	case "csv":
+		return FormatCSV, nil
+	case "ics", "ical", "icalendar":
+		return FormatICS, nil
+	default:
+		return "", fmt.Errorf("unsupported task export format: %s", s)
+	}
+}
+
+// TaskContentType returns the MIME type for a task export format
+func TaskContentType(f Format) string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatICS:
+		return "text/calendar; charset=utf-8"
+	default:
+		return ContentType(f)
+	}
+}
+
+// RenderTasks writes tasks as a Markdown checklist grouped by transcription,
+// a CSV table or an iCalendar of VTODOs
+func RenderTasks(w io.Writer, tasks []Task, f Format) error {
+	switch f {
+	case FormatMarkdown:
+		return renderTaskChecklist(w, tasks)
+	case FormatCSV:
+		return renderTaskCSV(w, tasks)
+	case FormatICS:
+		return renderTaskICS(w, tasks)
+	default:
+		return fmt.Errorf("unsupported task export format: %s", f)
+	}
+}
+
+func renderTaskChecklist(w io.Writer, tasks []Task) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("# Action items\n")
+
+	// Group by transcription, in order of first appearance
+	var order []string
+	groups := make(map[string][]Task)
+	for _, t := range tasks {
+		if _, ok := groups[t.TranscriptionID]; !ok {
+			order = append(order, t.TranscriptionID)
+		}
+		groups[t.TranscriptionID] = append(groups[t.TranscriptionID], t)
+	}
+	for _, id := range order {
+		group := groups[id]
+		source := group[0].Source
+		if source == "" {
+			source = "Transcription " + id
+		}
+		fmt.Fprintf(bw, "\n## %s\n\n", source)
+		for _, t := range group {
+			check := " "
+			if t.Status == models.ActionItemStatusDone {
+				check = "x"
+			}
+			var details []string
+			if t.Assignee != "" {
+				details = append(details, "@"+t.Assignee)
+			}
+			if t.DueDate != nil {
+				details = append(details, "due "+*t.DueDate)
+			}
+			if t.SourceTime != nil {
+				details = append(details, fmt.Sprintf("`%s`", formatClock(*t.SourceTime)))
+			}
+			line := t.Title
+			if len(details) > 0 {
+				line += " — " + strings.Join(details, ", ")
+			}
+			fmt.Fprintf(bw, "- [%s] %s\n", check, line)
+		}
+	}
+	if len(tasks) == 0 {
+		bw.WriteString("\n_None_\n")
+	}
+	return bw.Flush()
+}
+
+func renderTaskCSV(w io.Writer, tasks []Task) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"id", "title", "assignee", "due_date", "status", "transcription_id", "transcription", "timestamp", "quote"}); err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		due, timestamp := "", ""
+		if t.DueDate != nil {
+			due = *t.DueDate
+		}
+		if t.SourceTime != nil {
+			timestamp = formatClock(*t.SourceTime)
+		}
+		if err := cw.Write([]string{t.ID, t.Title, t.Assignee, due, t.Status, t.TranscriptionID, t.Source, timestamp, t.Quote}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// renderTaskICS writes an RFC 5545 calendar with a VTODO per task
+func renderTaskICS(w io.Writer, tasks []Task) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		bw.WriteString(foldICS(s))
+		bw.WriteString("\r\n")
+	}
+	utc := func(t time.Time) string {
+		return t.UTC().Format("20060102T150405Z")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Scriberr//Action items//EN")
+	for _, t := range tasks {
+		line("BEGIN:VTODO")
+		line("UID:" + t.ID + "@scriberr")
+		line("DTSTAMP:" + utc(t.UpdatedAt))
+		line("CREATED:" + utc(t.CreatedAt))
+		line("SUMMARY:" + escapeICS(t.Title))
+
+		var description []string
+		if t.Assignee != "" {
+			description = append(description, "Assignee: "+t.Assignee)
+		}
+		if t.Source != "" {
+			source := "From: " + t.Source
+			if t.SourceTime != nil {
+				source += " at " + formatClock(*t.SourceTime)
+			}
+			description = append(description, source)
+		}
+		if t.Quote != "" {
+			description = append(description, "\""+t.Quote+"\"")
+		}
+		if len(description) > 0 {
+			line("DESCRIPTION:" + escapeICS(strings.Join(description, "\n")))
+		}
+		if t.DueDate != nil {
+			if due, err := time.Parse("2006-01-02", *t.DueDate); err == nil {
+				line("DUE;VALUE=DATE:" + due.Format("20060102"))
+			}
+		}
+		if t.Status == models.ActionItemStatusDone {
+			line("STATUS:COMPLETED")
+			if t.CompletedAt != nil {
+				line("COMPLETED:" + utc(*t.CompletedAt))
+			}
+		} else {
+			line("STATUS:NEEDS-ACTION")
+		}
+		line("END:VTODO")
+	}
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// escapeICS escapes an iCalendar text value
+func escapeICS(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// foldICS folds a content line into lines of at most 75 octets, without
+// splitting UTF-8 sequences
+func foldICS(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+	var b strings.Builder
+	width := limit
+	for len(s) > width {
+		cut := width
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		width = limit - 1 // Continuation lines start with a space
+	}
+	b.WriteString(s)
+	return b.String()
+}
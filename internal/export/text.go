@@ -0,0 +1,94 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func renderTXT(w io.Writer, doc *Document, opts Options) error {
+	bw := bufio.NewWriter(w)
+
+	if doc.Title != "" {
+		fmt.Fprintf(bw, "%s\n\n", doc.Title)
+	}
+
+	if opts.IncludeSummary && doc.Summary != "" {
+		fmt.Fprintf(bw, "SUMMARY\n\n%s\n\n", strings.TrimSpace(doc.Summary))
+		bw.WriteString("TRANSCRIPT\n\n")
+	}
+
+	for _, seg := range doc.Segments {
+		if seg.Text == "" {
+			continue
+		}
+		if opts.Granularity != GranularityNone {
+			fmt.Fprintf(bw, "[%s] ", formatClock(seg.Start))
+		}
+		fmt.Fprintf(bw, "%s%s\n", speakerPrefix(seg.Speaker), seg.Text)
+		if seg.Translation != "" {
+			fmt.Fprintf(bw, "    %s\n", seg.Translation)
+		}
+	}
+
+	if opts.IncludeNotes && len(doc.Notes) > 0 {
+		bw.WriteString("\nNOTES\n\n")
+		for _, n := range doc.Notes {
+			fmt.Fprintf(bw, "[%s - %s] \"%s\"\n%s\n\n",
+				formatClock(n.Start), formatClock(n.End), n.Quote, strings.TrimSpace(n.Content))
+		}
+	}
+
+	return bw.Flush()
+}
+
+func renderMarkdown(w io.Writer, doc *Document, opts Options) error {
+	bw := bufio.NewWriter(w)
+
+	title := doc.Title
+	if title == "" {
+		title = "Transcript"
+	}
+	fmt.Fprintf(bw, "# %s\n\n", title)
+
+	if opts.IncludeSummary && doc.Summary != "" {
+		fmt.Fprintf(bw, "## Summary\n\n%s\n\n", strings.TrimSpace(doc.Summary))
+	}
+
+	bw.WriteString("## Transcript\n\n")
+	for _, seg := range doc.Segments {
+		if seg.Text == "" {
+			continue
+		}
+		var prefix []string
+		if seg.Speaker != "" {
+			prefix = append(prefix, fmt.Sprintf("**%s**", seg.Speaker))
+		}
+		if opts.Granularity != GranularityNone {
+			prefix = append(prefix, fmt.Sprintf("`%s`", formatClock(seg.Start)))
+		}
+		text := seg.Text
+		if seg.Translation != "" {
+			text += "  \n_" + seg.Translation + "_"
+		}
+		if len(prefix) > 0 {
+			fmt.Fprintf(bw, "%s: %s\n\n", strings.Join(prefix, " "), text)
+		} else {
+			fmt.Fprintf(bw, "%s\n\n", text)
+		}
+	}
+
+	if opts.IncludeNotes && len(doc.Notes) > 0 {
+		bw.WriteString("## Notes\n\n")
+		for _, n := range doc.Notes {
+			fmt.Fprintf(bw, "- `%s - %s` %s\n", formatClock(n.Start), formatClock(n.End), strings.TrimSpace(n.Content))
+			if n.Quote != "" {
+				fmt.Fprintf(bw, "  > %s\n", n.Quote)
+			}
+		}
+		bw.WriteString("\n")
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,185 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// embeddingBatchSize bounds the number of inputs sent in one embeddings request
+const embeddingBatchSize = 64
+
+// DefaultEmbeddingModel returns the embedding model used for a provider when
+// the configuration does not name one
+func DefaultEmbeddingModel(provider string) string {
+	switch strings.ToLower(provider) {
+	case "ollama":
+		return "nomic-embed-text"
+	default:
+		return "text-embedding-3-small"
+	}
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Usage struct {
+		PromptTokens int `json:"prompt_tokens"`
+	} `json:"usage"`
+}
+
+// Embed returns one embedding vector per input using the OpenAI-compatible
+// /embeddings endpoint
+func (s *OpenAIService) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	out := make([][]float32, 0, len(inputs))
+	for start := 0; start < len(inputs); start += embeddingBatchSize {
+		end := min(start+embeddingBatchSize, len(inputs))
+		batch, err := s.embedBatch(ctx, model, inputs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, batch...)
+	}
+	return out, nil
+}
+
+func (s *OpenAIService) embedBatch(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	jsonData, err := json.Marshal(embeddingRequest{Model: model, Input: inputs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/embeddings", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error: %d - %s", resp.StatusCode, truncate(string(body), 500))
+	}
+
+	var embResp embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(embResp.Data) != len(inputs) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(inputs), len(embResp.Data))
+	}
+
+	reportEmbedding(ctx, inputs, embResp.Usage.PromptTokens)
+	sort.Slice(embResp.Data, func(i, j int) bool { return embResp.Data[i].Index < embResp.Data[j].Index })
+	out := make([][]float32, len(embResp.Data))
+	for i, d := range embResp.Data {
+		out[i] = d.Embedding
+	}
+	return out, nil
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings      [][]float32 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+}
+
+type ollamaLegacyEmbedRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+type ollamaLegacyEmbedResponse struct {
+	Embedding []float32 `json:"embedding"`
+}
+
+// Embed returns one embedding vector per input using Ollama's /api/embed
+// endpoint, falling back to the single-input /api/embeddings endpoint on
+// older servers
+func (s *OllamaService) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	out := make([][]float32, 0, len(inputs))
+	for start := 0; start < len(inputs); start += embeddingBatchSize {
+		end := min(start+embeddingBatchSize, len(inputs))
+		batch, err := s.embedBatch(ctx, model, inputs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, batch...)
+	}
+	return out, nil
+}
+
+func (s *OllamaService) embedBatch(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	var embResp ollamaEmbedResponse
+	status, err := s.postJSON(ctx, "/api/embed", embeddingRequest{Model: model, Input: inputs}, &embResp)
+	if status == http.StatusNotFound {
+		return s.embedLegacy(ctx, model, inputs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(embResp.Embeddings) != len(inputs) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(inputs), len(embResp.Embeddings))
+	}
+	reportEmbedding(ctx, inputs, embResp.PromptEvalCount)
+	return embResp.Embeddings, nil
+}
+
+func (s *OllamaService) embedLegacy(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	out := make([][]float32, len(inputs))
+	for i, input := range inputs {
+		var embResp ollamaLegacyEmbedResponse
+		if _, err := s.postJSON(ctx, "/api/embeddings", ollamaLegacyEmbedRequest{Model: model, Prompt: input}, &embResp); err != nil {
+			return nil, err
+		}
+		out[i] = embResp.Embedding
+	}
+	reportEmbedding(ctx, inputs, 0)
+	return out, nil
+}
+
+// postJSON posts a JSON body to an Ollama endpoint and decodes the response,
+// returning the HTTP status so callers can react to missing endpoints
+func (s *OllamaService) postJSON(ctx context.Context, path string, body, out any) (int, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+path, bytes.NewBuffer(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, fmt.Errorf("API error: %d - %s", resp.StatusCode, truncate(string(respBody), 500))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
@@ -13,9 +13,9 @@ type GroqService struct {
 // NewGroqService creates a new Groq service
 func NewGroqService(apiKey string) *GroqService {
 	baseURL := "https://api.groq.com/openai/v1"
-	return &GroqService{
-		OpenAIService: NewOpenAIService(apiKey, &baseURL),
-	}
+	svc := NewOpenAIService(apiKey, &baseURL)
+	svc.streamUsage = true
+	return &GroqService{OpenAIService: svc}
 }
 
 // GetContextWindow returns the context window size for a given Groq model
@@ -69,8 +69,10 @@ func (s *OllamaService) GetModels(ctx context.Context) ([]string, error) {
 
 // Ollama chat API payloads
 type ollamaChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
 }
 
 type ollamaChatRequest struct {
@@ -78,6 +80,7 @@ type ollamaChatRequest struct {
 	Messages []ollamaChatMessage `json:"messages"`
 	Stream   bool                `json:"stream"`
 	Options  map[string]any      `json:"options,omitempty"`
+	Tools    []Tool              `json:"tools,omitempty"`
 }
 
 type ollamaChatResponse struct {
@@ -87,18 +90,17 @@ type ollamaChatResponse struct {
 		Content string `json:"content"`
 	} `json:"message"`
 	Done bool `json:"done"`
+
+	// Token counts, sent with the final response
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
 }
 
 // ChatCompletion performs a non-streaming chat completion against Ollama
 func (s *OllamaService) ChatCompletion(ctx context.Context, model string, messages []ChatMessage, temperature float64) (*ChatResponse, error) {
-	// Map to Ollama messages
-	msgs := make([]ollamaChatMessage, 0, len(messages))
-	for _, m := range messages {
-		msgs = append(msgs, ollamaChatMessage(m))
-	}
 	reqBody := ollamaChatRequest{
 		Model:    model,
-		Messages: msgs,
+		Messages: toOllamaMessages(messages),
 		Stream:   false,
 	}
 	if temperature > 0 {
@@ -140,6 +142,10 @@ func (s *OllamaService) ChatCompletion(ctx context.Context, model string, messag
 	}}
 	cr.Choices[0].Message.Role = oResp.Message.Role
 	cr.Choices[0].Message.Content = oResp.Message.Content
+	cr.Usage.PromptTokens = oResp.PromptEvalCount
+	cr.Usage.CompletionTokens = oResp.EvalCount
+	cr.Usage.TotalTokens = oResp.PromptEvalCount + oResp.EvalCount
+	reportCompletion(ctx, messages, oResp.Message.Content, oResp.PromptEvalCount, oResp.EvalCount)
 	return cr, nil
 }
 
@@ -152,11 +158,7 @@ func (s *OllamaService) ChatCompletionStream(ctx context.Context, model string,
 		defer close(contentChan)
 		defer close(errorChan)
 
-		msgs := make([]ollamaChatMessage, 0, len(messages))
-		for _, m := range messages {
-			msgs = append(msgs, ollamaChatMessage(m))
-		}
-		reqBody := ollamaChatRequest{Model: model, Messages: msgs, Stream: true}
+		reqBody := ollamaChatRequest{Model: model, Messages: toOllamaMessages(messages), Stream: true}
 		if temperature > 0 {
 			reqBody.Options = map[string]any{"temperature": temperature}
 		}
@@ -192,6 +194,7 @@ func (s *OllamaService) ChatCompletionStream(ctx context.Context, model string,
 			return
 		}
 
+		var completion strings.Builder
 		scanner := bufio.NewScanner(resp.Body)
 		for scanner.Scan() {
 			if ctx.Err() != nil {
@@ -212,8 +215,10 @@ func (s *OllamaService) ChatCompletionStream(ctx context.Context, model string,
 				case <-ctx.Done():
 					return
 				}
+				completion.WriteString(chunk.Message.Content)
 			}
 			if chunk.Done {
+				reportCompletion(ctx, messages, completion.String(), chunk.PromptEvalCount, chunk.EvalCount)
 				return
 			}
 		}
@@ -18,6 +18,9 @@ type OpenAIService struct {
 	apiKey  string
 	baseURL string
 	client  *http.Client
+	// streamUsage asks for token usage at the end of streams, which not
+	// every OpenAI-compatible server accepts
+	streamUsage bool
 }
 
 // NewOpenAIService creates a new OpenAI service
@@ -32,6 +35,7 @@ func NewOpenAIService(apiKey string, baseURL *string) *OpenAIService {
 		client: &http.Client{
 			Timeout: 300 * time.Second,
 		},
+		streamUsage: url == "https://api.openai.com/v1",
 	}
 }
 
@@ -39,6 +43,11 @@ func NewOpenAIService(apiKey string, baseURL *string) *OpenAIService {
 type ChatMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+
+	// ToolCalls are the tools an assistant message calls
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID is the call a "tool" message holds the result of
+	ToolCallID string `json:"tool_call_id,omitempty"`
 }
 
 // ChatRequest represents the OpenAI chat completion request
@@ -48,6 +57,13 @@ type ChatRequest struct {
 	Stream      bool          `json:"stream"`
 	Temperature float64       `json:"temperature,omitempty"`
 	MaxTokens   int           `json:"max_tokens,omitempty"`
+
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// StreamOptions configures a streaming chat completion
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 // ChatResponse represents the OpenAI chat completion response
@@ -85,6 +101,11 @@ type ChatStreamResponse struct {
 		} `json:"delta"`
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
+	// Usage is sent in the last chunk when requested with StreamOptions
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage,omitempty"`
 }
 
 // ModelsResponse represents the OpenAI models list response
@@ -185,6 +206,11 @@ func (s *OpenAIService) ChatCompletion(ctx context.Context, model string, messag
 	}
 
 	log.Printf("[openai] chat completion ok model=%s choices=%d", model, len(chatResp.Choices))
+	completion := ""
+	if len(chatResp.Choices) > 0 {
+		completion = chatResp.Choices[0].Message.Content
+	}
+	reportCompletion(ctx, messages, completion, chatResp.Usage.PromptTokens, chatResp.Usage.CompletionTokens)
 	return &chatResp, nil
 }
 
@@ -207,6 +233,9 @@ func (s *OpenAIService) ChatCompletionStream(ctx context.Context, model string,
 		if temperature != 0 {
 			reqBody.Temperature = temperature
 		}
+		if s.streamUsage {
+			reqBody.StreamOptions = &StreamOptions{IncludeUsage: true}
+		}
 
 		jsonData, err := json.Marshal(reqBody)
 		if err != nil {
@@ -239,6 +268,14 @@ func (s *OpenAIService) ChatCompletionStream(ctx context.Context, model string,
 			return
 		}
 
+		var completion strings.Builder
+		var promptTokens, completionTokens int
+		defer func() {
+			if ctx.Err() == nil && completion.Len() > 0 {
+				reportCompletion(ctx, messages, completion.String(), promptTokens, completionTokens)
+			}
+		}()
+
 		scanner := bufio.NewScanner(resp.Body)
 		loggedFirst := false
 		for scanner.Scan() {
@@ -264,6 +301,9 @@ func (s *OpenAIService) ChatCompletionStream(ctx context.Context, model string,
 				// Skip invalid JSON chunks
 				continue
 			}
+			if chunk.Usage != nil {
+				promptTokens, completionTokens = chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens
+			}
 
 			// Extract content from the chunk
 			if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
@@ -272,6 +312,7 @@ func (s *OpenAIService) ChatCompletionStream(ctx context.Context, model string,
 				case <-ctx.Done():
 					return
 				}
+				completion.WriteString(chunk.Choices[0].Delta.Content)
 				if !loggedFirst {
 					loggedFirst = true
 					log.Printf("[openai] chat stream first content model=%s", model)
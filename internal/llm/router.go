@@ -0,0 +1,342 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// featureParents are the features an unrouted feature borrows its route from
+// before falling back to the default config
+var featureParents = map[string]string{
+	models.LLMFeatureChatTitle: models.LLMFeatureChat,
+}
+
+// DefaultContextWindow is the context window assumed for models whose
+// provider does not report one
+const DefaultContextWindow = 4096
+
+// Target is a provider config and model serving a feature
+type Target struct {
+	Config  *models.LLMConfig
+	Service Service
+	Model   string // Empty until a caller supplies a model
+}
+
+// Route is the ordered list of targets for a feature: the primary first,
+// then the fallbacks
+type Route struct {
+	Feature string
+	Targets []Target
+
+	// UserID and JobID attribute the route's usage
+	UserID *uint
+	JobID  *string
+
+	recorder UsageRecorder
+}
+
+// Router resolves features to provider configs and models
+type Router struct {
+	configs  repository.LLMConfigRepository
+	recorder UsageRecorder
+}
+
+// NewRouter creates a router over the stored configs and routes
+func NewRouter(configs repository.LLMConfigRepository) *Router {
+	return &Router{configs: configs}
+}
+
+// SetUsageRecorder records the usage of every call made through the routes
+// the router resolves
+func (r *Router) SetUsageRecorder(recorder UsageRecorder) {
+	r.recorder = recorder
+}
+
+// Resolve returns the route of feature for a user: their own route, else the
+// global one, else the route of the parent feature, else the default config
+// with no model. Inactive, deleted or incomplete configs are skipped.
+func (r *Router) Resolve(ctx context.Context, feature string, userID *uint) (*Route, error) {
+	for f := feature; f != ""; f = featureParents[f] {
+		scopes := []*uint{nil}
+		if userID != nil {
+			scopes = []*uint{userID, nil}
+		}
+		for _, scope := range scopes {
+			stored, err := r.configs.FindRoute(ctx, f, scope)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get LLM route: %w", err)
+			}
+			if stored != nil {
+				route, err := r.build(ctx, feature, stored)
+				if err != nil {
+					return nil, err
+				}
+				route.UserID, route.recorder = userID, r.recorder
+				return route, nil
+			}
+		}
+	}
+
+	cfg, err := r.configs.GetActive(ctx)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("no active LLM configuration found")
+		}
+		return nil, fmt.Errorf("failed to get LLM config: %w", err)
+	}
+	svc, err := NewFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Route{Feature: feature, Targets: []Target{{Config: cfg, Service: svc}}, UserID: userID, recorder: r.recorder}, nil
+}
+
+func (r *Router) build(ctx context.Context, feature string, stored *models.LLMRoute) (*Route, error) {
+	route := &Route{Feature: feature}
+	for _, t := range stored.Targets {
+		cfg, err := r.configs.FindByID(ctx, t.ConfigID)
+		if err != nil || cfg == nil || !cfg.IsActive {
+			logger.Warn("Route skips an unavailable LLM config", "feature", feature, "config_id", t.ConfigID)
+			continue
+		}
+		svc, err := NewFromConfig(cfg)
+		if err != nil {
+			logger.Warn("Route skips an unusable LLM config", "feature", feature, "config_id", t.ConfigID, "error", err)
+			continue
+		}
+		route.Targets = append(route.Targets, Target{Config: cfg, Service: svc, Model: t.Model})
+	}
+	if len(route.Targets) == 0 {
+		return nil, fmt.Errorf("no usable LLM configuration is routed for %s", feature)
+	}
+	return route, nil
+}
+
+// Primary returns the first target of the route
+func (r *Route) Primary() Target {
+	return r.Targets[0]
+}
+
+// ContextWindow returns the context window of the primary target's model, or
+// DefaultContextWindow when the provider cannot tell
+func (r *Route) ContextWindow(ctx context.Context) int {
+	primary := r.Primary()
+	contextWindow, err := primary.Service.GetContextWindow(ctx, primary.Model)
+	if err != nil {
+		logger.Warn("Context window unknown, using the default", "feature", r.Feature, "model", primary.Model, "context_window", DefaultContextWindow, "error", err)
+		return DefaultContextWindow
+	}
+	return contextWindow
+}
+
+// WithModel returns a copy of the route in which targets without a model use
+// model. Models set on the route take precedence.
+func (r *Route) WithModel(model string) *Route {
+	out := r.clone()
+	for i := range out.Targets {
+		if out.Targets[i].Model == "" {
+			out.Targets[i].Model = model
+		}
+	}
+	return out
+}
+
+// ForJob returns a copy of the route whose usage is attributed to a job
+func (r *Route) ForJob(jobID string) *Route {
+	out := r.clone()
+	out.JobID = &jobID
+	return out
+}
+
+func (r *Route) clone() *Route {
+	out := *r
+	out.Targets = make([]Target, len(r.Targets))
+	copy(out.Targets, r.Targets)
+	return &out
+}
+
+// Track returns ctx in which the usage of calls to t is recorded against the
+// route's feature, user and job
+func (r *Route) Track(ctx context.Context, t Target) context.Context {
+	if r.recorder == nil {
+		return ctx
+	}
+	return WithUsageReporter(ctx, func(u Usage) {
+		r.recorder.Record(context.WithoutCancel(ctx), &models.UsageEntry{
+			UserID:       r.UserID,
+			JobID:        r.JobID,
+			Feature:      r.Feature,
+			Provider:     t.Config.Provider,
+			Model:        t.Model,
+			InputTokens:  u.PromptTokens,
+			OutputTokens: u.CompletionTokens,
+			Estimated:    u.Estimated,
+		})
+	})
+}
+
+// Provider returns the provider of the primary target
+func (r *Route) Provider() string {
+	return r.Primary().Config.Provider
+}
+
+// each calls fn with every target that has a model until fn succeeds, and
+// returns the last error if none does. fn gets a context tracking the
+// target's usage.
+func (r *Route) each(ctx context.Context, fn func(ctx context.Context, t Target) error) error {
+	var lastErr error
+	for i, t := range r.Targets {
+		if t.Model == "" {
+			lastErr = fmt.Errorf("no model set for %s", r.Feature)
+			continue
+		}
+		if err := fn(r.Track(ctx, t), t); err != nil {
+			lastErr = err
+			if i < len(r.Targets)-1 {
+				logger.Warn("LLM call failed, trying the next target", "feature", r.Feature, "provider", t.Config.Provider, "model", t.Model, "error", err)
+			}
+			continue
+		}
+		return nil
+	}
+	return lastErr
+}
+
+// ChatCompletion performs a non-streaming chat completion, falling back to
+// the next target on failure
+func (r *Route) ChatCompletion(ctx context.Context, messages []ChatMessage, temperature float64) (*ChatResponse, error) {
+	var resp *ChatResponse
+	err := r.each(ctx, func(ctx context.Context, t Target) error {
+		var err error
+		resp, err = t.Service.ChatCompletion(ctx, t.Model, messages, temperature)
+		if err == nil && (resp == nil || len(resp.Choices) == 0) {
+			err = fmt.Errorf("no choices in response")
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ChatCompletionWithTools performs a chat completion with tools, falling back
+// to the next target on failure
+func (r *Route) ChatCompletionWithTools(ctx context.Context, messages []ChatMessage, tools []Tool, temperature float64) (*ChatMessage, error) {
+	var msg *ChatMessage
+	err := r.each(ctx, func(ctx context.Context, t Target) error {
+		var err error
+		msg, err = t.Service.ChatCompletionWithTools(ctx, t.Model, messages, tools, temperature)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// ChatCompletionStream streams a chat completion. A target that fails before
+// sending any content is replaced by the next one; one that does not support
+// streaming is asked for a non-streaming completion first.
+func (r *Route) ChatCompletionStream(ctx context.Context, messages []ChatMessage, temperature float64) (<-chan string, <-chan error) {
+	contentChan := make(chan string, 100)
+	errorChan := make(chan error, 1)
+
+	go func() {
+		defer close(contentChan)
+		defer close(errorChan)
+
+		err := r.each(ctx, func(tctx context.Context, t Target) error {
+			started := false
+			cc, ec := t.Service.ChatCompletionStream(tctx, t.Model, messages, temperature)
+			for chunk := range cc {
+				started = true
+				select {
+				case contentChan <- chunk:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+			err := <-ec
+			if err == nil || started {
+				// Content already sent cannot be retried elsewhere
+				if err != nil {
+					errorChan <- err
+				}
+				return nil
+			}
+			if !IsStreamUnsupported(err) {
+				return err
+			}
+
+			logger.Info("Streaming unsupported, falling back to non-streaming", "feature", r.Feature, "provider", t.Config.Provider, "model", t.Model)
+			resp, err := t.Service.ChatCompletion(tctx, t.Model, messages, temperature)
+			if err != nil {
+				return err
+			}
+			if resp == nil || len(resp.Choices) == 0 {
+				return fmt.Errorf("no choices in response")
+			}
+			contentChan <- resp.Choices[0].Message.Content
+			return nil
+		})
+		if err != nil {
+			errorChan <- err
+		}
+	}()
+
+	return contentChan, errorChan
+}
+
+// IsStreamUnsupported tells whether err means the model or organization
+// cannot stream completions
+func IsStreamUnsupported(err error) bool {
+	errStr := err.Error()
+	return strings.Contains(errStr, "\"param\": \"stream\"") || strings.Contains(errStr, "unsupported_value") || strings.Contains(errStr, "must be verified to stream")
+}
+
+// EnsureGroqSmartAnalysis keeps deployments configured with a Groq API key
+// running smart analysis on Groq: it stores the key as a Groq config and
+// routes smart analysis to it unless a global route already exists. The
+// config is never the default one, so features without a route and the
+// default config's settings are left alone.
+func EnsureGroqSmartAnalysis(ctx context.Context, configs repository.LLMConfigRepository, apiKey, model string) error {
+	all, _, err := configs.List(ctx, 0, 1000)
+	if err != nil {
+		return err
+	}
+	var groq *models.LLMConfig
+	for i := range all {
+		if all[i].Provider == "groq" && !all[i].IsDefault {
+			groq = &all[i]
+			break
+		}
+	}
+	if groq == nil {
+		groq = &models.LLMConfig{Name: "Groq", Provider: "groq", APIKey: &apiKey, IsActive: true}
+		if err := configs.Create(ctx, groq); err != nil {
+			return err
+		}
+	} else if groq.APIKey == nil || *groq.APIKey != apiKey {
+		groq.APIKey = &apiKey
+		if err := configs.Update(ctx, groq); err != nil {
+			return err
+		}
+	}
+
+	existing, err := configs.FindRoute(ctx, models.LLMFeatureSmartAnalysis, nil)
+	if err != nil || existing != nil {
+		return err
+	}
+	return configs.SaveRoute(ctx, &models.LLMRoute{
+		Feature: models.LLMFeatureSmartAnalysis,
+		Targets: []models.LLMRouteTarget{{ConfigID: groq.ID, Model: model}},
+	})
+}
@@ -1,6 +1,13 @@
 package llm
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"scriberr/internal/models"
+)
 
 // Service is a provider-agnostic LLM interface
 type Service interface {
@@ -8,4 +15,39 @@ type Service interface {
 	ChatCompletion(ctx context.Context, model string, messages []ChatMessage, temperature float64) (*ChatResponse, error)
 	ChatCompletionStream(ctx context.Context, model string, messages []ChatMessage, temperature float64) (<-chan string, <-chan error)
 	GetContextWindow(ctx context.Context, model string) (int, error)
+	Embed(ctx context.Context, model string, inputs []string) ([][]float32, error)
+	ChatCompletionWithTools(ctx context.Context, model string, messages []ChatMessage, tools []Tool, temperature float64) (*ChatMessage, error)
+	ChatCompletionJSON(ctx context.Context, model string, messages []ChatMessage, schema json.RawMessage, temperature float64) (*ChatResponse, error)
+}
+
+// NewFromConfig creates the service for a stored LLM configuration
+func NewFromConfig(cfg *models.LLMConfig) (Service, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "openai":
+		if cfg.APIKey == nil || *cfg.APIKey == "" {
+			return nil, fmt.Errorf("OpenAI API key not configured")
+		}
+		return NewOpenAIService(*cfg.APIKey, cfg.OpenAIBaseURL), nil
+	case "ollama":
+		if cfg.BaseURL == nil || *cfg.BaseURL == "" {
+			return nil, fmt.Errorf("Ollama base URL not configured")
+		}
+		return NewOllamaService(*cfg.BaseURL), nil
+	case "groq":
+		if cfg.APIKey == nil || *cfg.APIKey == "" {
+			return nil, fmt.Errorf("Groq API key not configured")
+		}
+		return NewGroqService(*cfg.APIKey), nil
+	default:
+		return nil, fmt.Errorf("unsupported LLM provider: %s", cfg.Provider)
+	}
+}
+
+// EmbeddingModel returns the embedding model configured for cfg, or the
+// provider default
+func EmbeddingModel(cfg *models.LLMConfig) string {
+	if cfg.EmbeddingModel != nil && *cfg.EmbeddingModel != "" {
+		return *cfg.EmbeddingModel
+	}
+	return DefaultEmbeddingModel(cfg.Provider)
 }
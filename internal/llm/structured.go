@@ -0,0 +1,185 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"scriberr/pkg/logger"
+)
+
+// Response formats of structured completions, from the strictest
+const (
+	formatJSONSchema = "json_schema" // Output constrained to the schema
+	formatJSONObject = "json_object" // Output constrained to JSON
+	formatNone       = ""            // The prompt alone asks for JSON
+)
+
+// responseFormat is the OpenAI response_format of a chat completion
+type responseFormat struct {
+	Type       string          `json:"type"`
+	JSONSchema *jsonSchemaSpec `json:"json_schema,omitempty"`
+}
+
+type jsonSchemaSpec struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+type structuredChatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []ChatMessage   `json:"messages"`
+	Stream         bool            `json:"stream"`
+	Temperature    float64         `json:"temperature,omitempty"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+// ChatCompletionJSON performs a non-streaming chat completion whose answer is
+// JSON matching schema. It asks for structured outputs and falls back to JSON
+// mode, then to the prompt alone, for servers that reject them. The answer
+// still has to be validated: not every server enforces the schema.
+func (s *OpenAIService) ChatCompletionJSON(ctx context.Context, model string, messages []ChatMessage, schema json.RawMessage, temperature float64) (*ChatResponse, error) {
+	var lastErr error
+	for _, format := range []string{formatJSONSchema, formatJSONObject, formatNone} {
+		reqBody := structuredChatRequest{Model: model, Messages: messages}
+		if temperature != 0 {
+			reqBody.Temperature = temperature
+		}
+		switch format {
+		case formatJSONSchema:
+			reqBody.ResponseFormat = &responseFormat{Type: format, JSONSchema: &jsonSchemaSpec{Name: "result", Schema: schema}}
+		case formatJSONObject:
+			reqBody.ResponseFormat = &responseFormat{Type: format}
+		}
+
+		chatResp, status, err := s.postChat(ctx, reqBody)
+		if err == nil {
+			logger.Debug("Structured completion succeeded", "model", model, "format", format)
+			completion := ""
+			if len(chatResp.Choices) > 0 {
+				completion = chatResp.Choices[0].Message.Content
+			}
+			reportCompletion(ctx, messages, completion, chatResp.Usage.PromptTokens, chatResp.Usage.CompletionTokens)
+			return chatResp, nil
+		}
+		lastErr = err
+		// Only a rejected request is retried with a looser format
+		if status != http.StatusBadRequest && status != http.StatusUnprocessableEntity {
+			return nil, err
+		}
+		logger.Warn("Structured completion format rejected, retrying with a looser one", "model", model, "format", format, "error", err)
+	}
+	return nil, lastErr
+}
+
+// postChat sends a non-streaming chat completion request and returns the
+// HTTP status of failed requests
+func (s *OpenAIService) postChat(ctx context.Context, body any) (*ChatResponse, int, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, resp.StatusCode, fmt.Errorf("API error: %d - %s", resp.StatusCode, truncate(string(respBody), 500))
+	}
+
+	var chatResp ChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &chatResp, resp.StatusCode, nil
+}
+
+// ChatCompletionJSON performs a non-streaming chat completion against Ollama
+// whose answer is constrained to schema
+func (s *OllamaService) ChatCompletionJSON(ctx context.Context, model string, messages []ChatMessage, schema json.RawMessage, temperature float64) (*ChatResponse, error) {
+	reqBody := struct {
+		ollamaChatRequest
+		Format json.RawMessage `json:"format"`
+	}{
+		ollamaChatRequest: ollamaChatRequest{Model: model, Messages: toOllamaMessages(messages)},
+		Format:            schema,
+	}
+	if temperature > 0 {
+		reqBody.Options = map[string]any{"temperature": temperature}
+	}
+
+	var oResp ollamaChatResponse
+	if _, err := s.postJSON(ctx, "/api/chat", reqBody, &oResp); err != nil {
+		return nil, err
+	}
+	reportCompletion(ctx, messages, oResp.Message.Content, oResp.PromptEvalCount, oResp.EvalCount)
+
+	cr := &ChatResponse{Model: oResp.Model}
+	cr.Choices = make([]struct {
+		Index   int `json:"index"`
+		Message struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	}, 1)
+	cr.Choices[0].Message.Role = "assistant"
+	cr.Choices[0].Message.Content = oResp.Message.Content
+	cr.Usage.PromptTokens = oResp.PromptEvalCount
+	cr.Usage.CompletionTokens = oResp.EvalCount
+	cr.Usage.TotalTokens = oResp.PromptEvalCount + oResp.EvalCount
+	return cr, nil
+}
+
+// ChatCompletionJSON performs a structured chat completion, falling back to
+// the next target on failure
+func (r *Route) ChatCompletionJSON(ctx context.Context, messages []ChatMessage, schema json.RawMessage, temperature float64) (*ChatResponse, error) {
+	var resp *ChatResponse
+	err := r.each(ctx, func(ctx context.Context, t Target) error {
+		var err error
+		resp, err = t.Service.ChatCompletionJSON(ctx, t.Model, messages, schema, temperature)
+		if err == nil && (resp == nil || len(resp.Choices) == 0) {
+			err = fmt.Errorf("no choices in response")
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ExtractJSON returns the JSON value in a model's answer, without the
+// Markdown code fence or prose some models wrap it in
+func ExtractJSON(answer string) string {
+	answer = strings.TrimSpace(answer)
+	if strings.HasPrefix(answer, "```") {
+		answer = strings.TrimPrefix(answer, "```json")
+		answer = strings.TrimPrefix(answer, "```")
+		answer = strings.TrimSuffix(strings.TrimSpace(answer), "```")
+		answer = strings.TrimSpace(answer)
+	}
+	if json.Valid([]byte(answer)) {
+		return answer
+	}
+	// Fall back to the outermost object or array
+	start := strings.IndexAny(answer, "{[")
+	end := strings.LastIndexAny(answer, "}]")
+	if start >= 0 && end > start {
+		return answer[start : end+1]
+	}
+	return answer
+}
@@ -0,0 +1,180 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"scriberr/pkg/logger"
+)
+
+// Tool describes a function the model may call, in the OpenAI tools format
+type Tool struct {
+	Type     string       `json:"type"` // Always "function"
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction is the name, description and JSON schema parameters of a tool
+type ToolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+// ToolCall is a call of a tool requested by the model
+type ToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"` // Always "function"
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction is the called tool and its arguments as a JSON object
+type ToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// NewFunctionTool creates a function tool with a JSON schema for its parameters
+func NewFunctionTool(name, description string, parameters map[string]any) Tool {
+	return Tool{Type: "function", Function: ToolFunction{Name: name, Description: description, Parameters: parameters}}
+}
+
+type toolChatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []ChatMessage `json:"messages"`
+	Tools       []Tool        `json:"tools,omitempty"`
+	Stream      bool          `json:"stream"`
+	Temperature float64       `json:"temperature,omitempty"`
+}
+
+type toolChatResponse struct {
+	Choices []struct {
+		Message      ChatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// ChatCompletionWithTools performs a non-streaming chat completion in which
+// the model may call tools, and returns the assistant message
+func (s *OpenAIService) ChatCompletionWithTools(ctx context.Context, model string, messages []ChatMessage, tools []Tool, temperature float64) (*ChatMessage, error) {
+	reqBody := toolChatRequest{Model: model, Messages: messages, Tools: tools}
+	if temperature != 0 {
+		reqBody.Temperature = temperature
+	}
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	logger.Debug("Tool chat request", "model", model, "messages", len(messages), "tools", len(tools))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error: %d - %s", resp.StatusCode, truncate(string(body), 500))
+	}
+
+	var chatResp toolChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in response")
+	}
+	msg := chatResp.Choices[0].Message
+	msg.Role = "assistant"
+	reportCompletion(ctx, messages, msg.Content, chatResp.Usage.PromptTokens, chatResp.Usage.CompletionTokens)
+	return &msg, nil
+}
+
+// Ollama takes tool call arguments as JSON objects and identifies tool
+// results by tool name rather than call ID
+type ollamaToolCall struct {
+	ID       string `json:"id,omitempty"`
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+func toOllamaMessages(messages []ChatMessage) []ollamaChatMessage {
+	toolNames := make(map[string]string)
+	msgs := make([]ollamaChatMessage, 0, len(messages))
+	for _, m := range messages {
+		om := ollamaChatMessage{Role: m.Role, Content: m.Content}
+		for _, tc := range m.ToolCalls {
+			toolNames[tc.ID] = tc.Function.Name
+			var call ollamaToolCall
+			call.ID = tc.ID
+			call.Function.Name = tc.Function.Name
+			call.Function.Arguments = json.RawMessage(tc.Function.Arguments)
+			if !json.Valid(call.Function.Arguments) {
+				call.Function.Arguments = json.RawMessage("{}")
+			}
+			om.ToolCalls = append(om.ToolCalls, call)
+		}
+		if m.ToolCallID != "" {
+			om.ToolName = toolNames[m.ToolCallID]
+		}
+		msgs = append(msgs, om)
+	}
+	return msgs
+}
+
+// ChatCompletionWithTools performs a non-streaming chat completion against
+// Ollama in which the model may call tools, and returns the assistant message
+func (s *OllamaService) ChatCompletionWithTools(ctx context.Context, model string, messages []ChatMessage, tools []Tool, temperature float64) (*ChatMessage, error) {
+	reqBody := ollamaChatRequest{Model: model, Messages: toOllamaMessages(messages), Tools: tools}
+	if temperature > 0 {
+		reqBody.Options = map[string]any{"temperature": temperature}
+	}
+
+	var oResp struct {
+		Message struct {
+			Content   string           `json:"content"`
+			ToolCalls []ollamaToolCall `json:"tool_calls"`
+		} `json:"message"`
+		PromptEvalCount int `json:"prompt_eval_count"`
+		EvalCount       int `json:"eval_count"`
+	}
+	if _, err := s.postJSON(ctx, "/api/chat", reqBody, &oResp); err != nil {
+		return nil, err
+	}
+	reportCompletion(ctx, messages, oResp.Message.Content, oResp.PromptEvalCount, oResp.EvalCount)
+
+	msg := &ChatMessage{Role: "assistant", Content: oResp.Message.Content}
+	for i, tc := range oResp.Message.ToolCalls {
+		id := tc.ID
+		if id == "" {
+			id = fmt.Sprintf("call_%d", i)
+		}
+		args := string(tc.Function.Arguments)
+		if args == "" || args == "null" {
+			args = "{}"
+		}
+		msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+			ID:       id,
+			Type:     "function",
+			Function: ToolCallFunction{Name: tc.Function.Name, Arguments: args},
+		})
+	}
+	return msg, nil
+}
@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"scriberr/internal/models"
+)
+
+// Usage is the token usage of one LLM call
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	// Estimated is set when the provider reported no counts
+	Estimated bool
+}
+
+// UsageRecorder stores the usage of LLM and cloud ASR calls
+type UsageRecorder interface {
+	Record(ctx context.Context, entry *models.UsageEntry)
+}
+
+type usageReporterKey struct{}
+
+// WithUsageReporter returns ctx in which LLM calls report their token usage
+// to fn, in addition to the reporters of the parent context
+func WithUsageReporter(ctx context.Context, fn func(Usage)) context.Context {
+	parent, _ := ctx.Value(usageReporterKey{}).(func(Usage))
+	return context.WithValue(ctx, usageReporterKey{}, func(u Usage) {
+		fn(u)
+		if parent != nil {
+			parent(u)
+		}
+	})
+}
+
+// reportUsage passes the usage of a call made with ctx to its reporters
+func reportUsage(ctx context.Context, u Usage) {
+	if fn, ok := ctx.Value(usageReporterKey{}).(func(Usage)); ok {
+		fn(u)
+	}
+}
+
+// reportCompletion reports the usage of a completion, estimating it from the
+// text when the provider sent no counts
+func reportCompletion(ctx context.Context, messages []ChatMessage, completion string, promptTokens, completionTokens int) {
+	u := Usage{PromptTokens: promptTokens, CompletionTokens: completionTokens}
+	if promptTokens == 0 && completionTokens == 0 {
+		texts := make([]string, len(messages))
+		for i, m := range messages {
+			texts[i] = m.Content
+		}
+		u = Usage{PromptTokens: EstimateTokens(texts...), CompletionTokens: EstimateTokens(completion), Estimated: true}
+	}
+	reportUsage(ctx, u)
+}
+
+// reportEmbedding reports the usage of an embeddings call
+func reportEmbedding(ctx context.Context, inputs []string, promptTokens int) {
+	u := Usage{PromptTokens: promptTokens}
+	if promptTokens == 0 {
+		u = Usage{PromptTokens: EstimateTokens(inputs...), Estimated: true}
+	}
+	reportUsage(ctx, u)
+}
+
+// EstimateTokens approximates the token count of texts at four characters
+// per token
+func EstimateTokens(texts ...string) int {
+	chars := 0
+	for _, t := range texts {
+		chars += utf8.RuneCountInString(t)
+	}
+	return (chars + 3) / 4
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Action item statuses
+const (
+	ActionItemStatusOpen = "open"
+	ActionItemStatusDone = "done"
+)
+
+// ActionItem is a commitment extracted from a transcript, tracked as a task
+type ActionItem struct {
+	ID              string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	TranscriptionID string     `json:"transcription_id" gorm:"type:varchar(36);not null;index"`
+	UserID          *uint      `json:"user_id,omitempty" gorm:"index"` // Owner of the transcription, for listing tasks across jobs
+	Title           string     `json:"title" gorm:"type:text;not null"`
+	Assignee        string     `json:"assignee,omitempty" gorm:"type:varchar(255)"` // Custom speaker name when the assignee is a speaker
+	Speaker         string     `json:"speaker,omitempty" gorm:"type:varchar(100)"`  // Original speaker label of the assignee
+	DueDate         *string    `json:"due_date,omitempty" gorm:"type:varchar(10)"`  // YYYY-MM-DD
+	SourceTime      *float64   `json:"source_time,omitempty" gorm:"type:real"`      // Start of the segment it was said in, in seconds
+	Quote           string     `json:"quote,omitempty" gorm:"type:text"`            // Text of that segment
+	Status          string     `json:"status" gorm:"type:varchar(20);not null;default:'open';index"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relationships
+	Transcription TranscriptionJob `json:"-" gorm:"foreignKey:TranscriptionID;constraint:OnDelete:CASCADE"`
+}
+
+// BeforeCreate ensures ActionItem has a UUID primary key
+func (a *ActionItem) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// ConversationAnalytics holds the conversation metrics of a transcript
+type ConversationAnalytics struct {
+	TranscriptionID string             `json:"transcription_id" gorm:"primaryKey;type:varchar(36)"`
+	Duration        float64            `json:"duration" gorm:"type:real"`      // In seconds, up to the end of the last segment
+	SpeechTime      float64            `json:"speech_time" gorm:"type:real"`   // Seconds in which anyone speaks
+	SilenceRatio    float64            `json:"silence_ratio" gorm:"type:real"` // Share of the duration in which no one speaks
+	OverlapTime     float64            `json:"overlap_time" gorm:"type:real"`  // Seconds in which two or more speakers speak at once
+	Turns           int                `json:"turns"`
+	Speakers        []SpeakerAnalytics `json:"speakers" gorm:"type:text;serializer:json"`
+	CreatedAt       time.Time          `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time          `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relationships
+	Transcription TranscriptionJob `json:"-" gorm:"foreignKey:TranscriptionID;constraint:OnDelete:CASCADE"`
+}
+
+// SpeakerAnalytics holds the conversation metrics of one speaker
+type SpeakerAnalytics struct {
+	Speaker          string  `json:"speaker"`        // Diarization label
+	Name             string  `json:"name,omitempty"` // Custom name, filled when read
+	TalkTime         float64 `json:"talk_time"`      // In seconds
+	TalkShare        float64 `json:"talk_share"`     // Share of everyone's talk time
+	Turns            int     `json:"turns"`
+	LongestMonologue float64 `json:"longest_monologue"` // Longest uninterrupted turn, in seconds
+	Interruptions    int     `json:"interruptions"`     // Turns started while another speaker was still speaking
+	Interrupted      int     `json:"interrupted"`       // Times another speaker started while this one was speaking
+	OverlapTime      float64 `json:"overlap_time"`      // Seconds spoken while another speaker was speaking
+	Words            int     `json:"words"`
+	WordsPerMinute   float64 `json:"words_per_minute"`
+	Fillers          int     `json:"fillers"`
+	FillerRate       float64 `json:"filler_rate"` // Share of words that are fillers
+	Questions        int     `json:"questions"`
+}
+
+// TableName keeps the table name singular like the model
+func (ConversationAnalytics) TableName() string {
+	return "conversation_analytics"
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Chapter sources
+const (
+	ChapterSourceLLM       = "llm"       // Boundaries, titles and summaries written by a model
+	ChapterSourceHeuristic = "heuristic" // Boundaries at topic shifts and pauses, titles from keywords
+)
+
+// Chapter is a topical section of a transcript
+type Chapter struct {
+	ID              string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	TranscriptionID string    `json:"transcription_id" gorm:"type:varchar(36);not null;index"`
+	Position        int       `json:"position" gorm:"not null"` // 0-based order in the transcript
+	Title           string    `json:"title" gorm:"type:text;not null"`
+	Summary         string    `json:"summary,omitempty" gorm:"type:text"`
+	Start           float64   `json:"start" gorm:"type:real;not null"` // In seconds
+	End             float64   `json:"end" gorm:"type:real;not null"`
+	StartSegment    int       `json:"start_segment" gorm:"not null"` // Index of the first transcript segment
+	Source          string    `json:"source" gorm:"type:varchar(20);not null"`
+	Model           string    `json:"model,omitempty" gorm:"type:varchar(255)"`
+	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// Relationships
+	Transcription TranscriptionJob `json:"-" gorm:"foreignKey:TranscriptionID;constraint:OnDelete:CASCADE"`
+}
+
+// BeforeCreate ensures Chapter has a UUID primary key
+func (c *Chapter) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
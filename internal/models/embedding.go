@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// TranscriptChunk is a window of consecutive transcript segments with its
+// embedding, used to retrieve the parts of a transcript relevant to a chat turn
+type TranscriptChunk struct {
+	ID                 uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	TranscriptionJobID string `json:"transcription_job_id" gorm:"type:varchar(36);not null;index"`
+	ChunkIndex         int    `json:"chunk_index" gorm:"type:int;not null"`
+
+	// Segment range covered by the chunk (end exclusive) and its time bounds
+	StartSegment int     `json:"start_segment" gorm:"type:int;not null"`
+	EndSegment   int     `json:"end_segment" gorm:"type:int;not null"`
+	StartTime    float64 `json:"start_time" gorm:"type:real;not null"`
+	EndTime      float64 `json:"end_time" gorm:"type:real;not null"`
+
+	Text string `json:"text" gorm:"type:text;not null"`
+
+	// Embedding is a normalized little-endian float32 vector. Model and
+	// TranscriptHash tell whether it is still valid for the job.
+	Embedding      []byte `json:"-" gorm:"type:blob;not null"`
+	Dimensions     int    `json:"dimensions" gorm:"type:int;not null"`
+	Model          string `json:"model" gorm:"type:varchar(100);not null"`
+	TranscriptHash string `json:"transcript_hash" gorm:"type:varchar(64);not null"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// Relationships
+	TranscriptionJob TranscriptionJob `json:"-" gorm:"foreignKey:TranscriptionJobID;constraint:OnDelete:CASCADE"`
+}
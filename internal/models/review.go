@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Review states of a transcription, also kept on the job for listing filters
+const (
+	ReviewStatusPending  = "pending"   // Queued, no reviewer yet
+	ReviewStatusInReview = "in_review" // Claimed by or assigned to a reviewer
+	ReviewStatusReviewed = "reviewed"  // Every segment checked
+)
+
+// Review states of a queued segment
+const (
+	ReviewItemPending   = "pending"
+	ReviewItemAccepted  = "accepted"  // The text was right
+	ReviewItemCorrected = "corrected" // The reviewer fixed the text
+)
+
+// Review is the queue of weak segments of a transcript for a person to
+// check: segments below a confidence threshold or flagged by the quality
+// check.
+type Review struct {
+	TranscriptionID string       `json:"transcription_id" gorm:"primaryKey;type:varchar(36)"`
+	Status          string       `json:"status" gorm:"type:varchar(20);not null;index"`
+	Threshold       float64      `json:"threshold"` // Segments below this confidence are queued
+	AssigneeID      *uint        `json:"assignee_id,omitempty" gorm:"index"`
+	AssignedAt      *time.Time   `json:"assigned_at,omitempty"`
+	CompletedBy     *uint        `json:"completed_by,omitempty"`
+	CompletedAt     *time.Time   `json:"completed_at,omitempty"`
+	Total           int          `json:"total"`    // Queued segments
+	Reviewed        int          `json:"reviewed"` // Queued segments accepted or corrected
+	Items           []ReviewItem `json:"items" gorm:"type:text;serializer:json"`
+	CreatedAt       time.Time    `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relationships
+	Transcription TranscriptionJob `json:"-" gorm:"foreignKey:TranscriptionID;constraint:OnDelete:CASCADE"`
+}
+
+// ReviewItem is one queued transcript segment
+type ReviewItem struct {
+	Segment    int        `json:"segment"` // Index of the segment in the transcript
+	Start      float64    `json:"start"`
+	End        float64    `json:"end"`
+	Speaker    *string    `json:"speaker,omitempty"`
+	Text       string     `json:"text"`                 // Text as queued
+	Confidence *float64   `json:"confidence,omitempty"` // Mean word score, or the segment's own probability
+	Flags      []string   `json:"flags,omitempty"`      // Quality check flags
+	Status     string     `json:"status"`
+	Correction *string    `json:"correction,omitempty"`
+	ReviewedBy *uint      `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Smart analysis statuses
+const (
+	SmartAnalysisStatusCompleted = "completed"
+	SmartAnalysisStatusFailed    = "failed"
+)
+
+// Review states of a proposed change
+const (
+	ChangeStatusPending  = "pending"
+	ChangeStatusAccepted = "accepted"
+	ChangeStatusRejected = "rejected"
+)
+
+// SmartAnalysis holds the corrections a model proposed for a transcript.
+// They are kept for review and only change the transcript once accepted.
+type SmartAnalysis struct {
+	TranscriptionID string          `json:"transcription_id" gorm:"primaryKey;type:varchar(36)"`
+	Status          string          `json:"status" gorm:"type:varchar(20);not null"`
+	Error           *string         `json:"error,omitempty" gorm:"type:text"`
+	Language        string          `json:"language,omitempty" gorm:"type:varchar(16)"` // Language the transcript was proofread in
+	Model           string          `json:"model,omitempty" gorm:"type:varchar(255)"`
+	Segments        int             `json:"segments"` // Segments analyzed
+	Changes         []SegmentChange `json:"changes" gorm:"type:text;serializer:json"`
+	CreatedAt       time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relationships
+	Transcription TranscriptionJob `json:"-" gorm:"foreignKey:TranscriptionID;constraint:OnDelete:CASCADE"`
+}
+
+// SegmentChange is the correction proposed for one transcript segment
+type SegmentChange struct {
+	Segment         int     `json:"segment"` // Index of the segment in the transcript
+	Start           float64 `json:"start"`
+	End             float64 `json:"end"`
+	OriginalText    string  `json:"original_text"`
+	ProposedText    string  `json:"proposed_text"`
+	OriginalSpeaker *string `json:"original_speaker,omitempty"`
+	ProposedSpeaker *string `json:"proposed_speaker,omitempty"` // Set when the speaker changes
+	Status          string  `json:"status"`
+}
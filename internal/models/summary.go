@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,13 +10,14 @@ import (
 
 // SummaryTemplate represents a saved summarization prompt/template
 type SummaryTemplate struct {
-	ID          string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
-	Name        string    `json:"name" gorm:"type:varchar(255);not null"`
-	Description *string   `json:"description,omitempty" gorm:"type:text"`
-	Model       string    `json:"model" gorm:"type:varchar(255);not null;default:''"`
-	Prompt      string    `json:"prompt" gorm:"type:text;not null"`
-	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID          string          `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Name        string          `json:"name" gorm:"type:varchar(255);not null"`
+	Description *string         `json:"description,omitempty" gorm:"type:text"`
+	Model       string          `json:"model" gorm:"type:varchar(255);not null;default:''"`
+	Prompt      string          `json:"prompt" gorm:"type:text;not null"`
+	Schema      json.RawMessage `json:"schema,omitempty" gorm:"type:text"` // JSON schema of structured summaries; without one summaries are free text
+	CreatedAt   time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
 func (st *SummaryTemplate) BeforeCreate(tx *gorm.DB) error {
@@ -32,15 +34,31 @@ type SummarySetting struct {
 	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
-// Summary stores a generated summary linked to a transcription
+// Summary statuses. Summaries are generated in the background, from pending
+// to completed or failed.
+const (
+	SummaryStatusPending    = "pending"
+	SummaryStatusProcessing = "processing"
+	SummaryStatusCompleted  = "completed"
+	SummaryStatusFailed     = "failed"
+)
+
+// Summary stores a generated summary linked to a transcription. Every
+// generated summary is kept as the transcription's summary history.
 type Summary struct {
-	ID              string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
-	TranscriptionID string    `json:"transcription_id" gorm:"type:varchar(36);index;not null"`
-	TemplateID      *string   `json:"template_id,omitempty" gorm:"type:varchar(36)"`
-	Model           string    `json:"model" gorm:"type:varchar(255);not null"`
-	Content         string    `json:"content" gorm:"type:text;not null"`
-	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt       time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID              string          `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	TranscriptionID string          `json:"transcription_id" gorm:"type:varchar(36);index;not null"`
+	TemplateID      *string         `json:"template_id,omitempty" gorm:"type:varchar(36)"`
+	Model           string          `json:"model" gorm:"type:varchar(255);not null"`
+	Mode            string          `json:"mode,omitempty" gorm:"type:varchar(20);not null;default:''"` // "auto", "single" or "map_reduce"
+	Status          string          `json:"status" gorm:"type:varchar(20);not null;default:'completed';index"`
+	ErrorMessage    *string         `json:"error_message,omitempty" gorm:"type:text"`
+	Content         string          `json:"content" gorm:"type:text;not null"`     // Markdown, rendered from Structured for structured summaries
+	Structured      json.RawMessage `json:"structured,omitempty" gorm:"type:text"` // Result of templates with a schema
+	Input           string          `json:"-" gorm:"type:text"`                    // Client-supplied text summarized instead of the transcript
+	CompletedAt     *time.Time      `json:"completed_at,omitempty"`
+	CreatedAt       time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
 
 	// Relationships
 	Transcription TranscriptionJob `json:"transcription,omitempty" gorm:"foreignKey:TranscriptionID;constraint:OnDelete:CASCADE"`
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"os"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,7 @@ type TranscriptionJob struct {
 	Title                 *string        `json:"title,omitempty" gorm:"type:text"`
 	Status                JobStatus      `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
 	AudioPath             string         `json:"audio_path" gorm:"type:text;not null"`
+	VideoPath             *string        `json:"video_path,omitempty" gorm:"type:text"` // Original video for video uploads
 	Transcript            *string        `json:"transcript,omitempty" gorm:"type:text"`
 	Diarization           bool           `json:"diarization" gorm:"type:boolean;default:false"`
 	Summary               *string        `json:"summary,omitempty" gorm:"type:text"`
@@ -25,6 +27,11 @@ type TranscriptionJob struct {
 	MergeError            *string        `json:"merge_error,omitempty" gorm:"type:text"`
 	IndividualTranscripts *string        `json:"individual_transcripts,omitempty" gorm:"type:text"` // JSON-serialized map[string]*string
 	Hidden                bool           `json:"hidden" gorm:"type:boolean;default:false"`
+	AudioHash             *string        `json:"audio_hash,omitempty" gorm:"type:varchar(64);index"` // SHA-256 of the audio file, filled lazily
+	Tags                  []string       `json:"tags,omitempty" gorm:"type:text;serializer:json"`
+	TranslateTo           []string       `json:"translate_to,omitempty" gorm:"type:text;serializer:json"` // Languages the transcript is translated into on completion
+	ReviewStatus          string         `json:"review_status,omitempty" gorm:"type:varchar(20);index"`   // State of the human review, empty when none is queued
+	LanguageMix           []LanguageTime `json:"language_mix,omitempty" gorm:"type:text;serializer:json"` // Languages spoken in the recording, from the language identification pass
 	CreatedAt             time.Time      `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt             time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt             gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index" swaggertype:"string"`
@@ -37,6 +44,13 @@ type TranscriptionJob struct {
 	MultiTrackFiles []MultiTrackFile `json:"multi_track_files,omitempty" gorm:"foreignKey:TranscriptionJobID"`
 }
 
+// LanguageTime is the speech time of one language in a recording
+type LanguageTime struct {
+	Language string  `json:"language"`
+	Seconds  float64 `json:"seconds"`
+	Share    float64 `json:"share"` // Share of the recording's speech time
+}
+
 // JobStatus represents the status of a transcription job
 type JobStatus string
 
@@ -48,6 +62,13 @@ const (
 	StatusFailed     JobStatus = "failed"
 )
 
+// Execution sources
+const (
+	ExecutionSourceTranscribed = "transcribed"
+	ExecutionSourceImported    = "imported"
+	ExecutionSourceHighlight   = "highlight"
+)
+
 // WhisperXParams contains parameters for WhisperX transcription
 type WhisperXParams struct {
 	// Model family (whisper or nvidia)
@@ -126,7 +147,26 @@ type WhisperXParams struct {
 	IsMultiTrackEnabled bool `json:"is_multi_track_enabled" gorm:"type:boolean;default:false"`
 
 	// Webhook settings
-	CallbackURL *string `json:"callback_url,omitempty" gorm:"type:text"`
+	CallbackURL     *string `json:"callback_url,omitempty" gorm:"type:text"`
+	CallbackFormats *string `json:"callback_formats,omitempty" gorm:"type:varchar(100)"` // Comma-separated export formats embedded in the webhook payload (e.g. "srt,vtt")
+
+	// Summary settings
+	SummaryTemplates *string `json:"summary_templates,omitempty" gorm:"type:text"` // Comma-separated IDs of summary templates run when the job completes
+
+	// Smart analysis settings
+	SkipSmartAnalysis   bool    `json:"skip_smart_analysis" gorm:"type:boolean;default:false"`
+	SmartAnalysisPrompt *string `json:"smart_analysis_prompt,omitempty" gorm:"type:text"` // Proofreading instructions replacing the defaults for the transcript language; {{title}} and {{language}} are filled in
+
+	// Quality check of segments: "flag" marks hallucinated and low-quality segments for review, "drop" removes them, "off" keeps the transcript as is
+	QualityCheck string `json:"quality_check" gorm:"type:varchar(10);default:'flag'"`
+
+	// Language identification settings
+	LanguageDetection bool    `json:"language_detection" gorm:"type:boolean;default:false"` // Identify the language of each speech region and transcribe it in that language, for recordings that switch languages
+	Languages         *string `json:"languages,omitempty" gorm:"type:text"`                 // Comma-separated languages expected in the recording, e.g. "vi,en"; regions identified as another language use the first
+	LanguageModels    *string `json:"language_models,omitempty" gorm:"type:text"`           // Comma-separated language=model routes, e.g. "vi=fpt_ai,en=groq_whisper"
+
+	// Review settings
+	ReviewThreshold *float64 `json:"review_threshold,omitempty" gorm:"type:real"` // When set, segments below this confidence are queued for human review on completion, along with the flagged ones that always are
 
 	// OpenAI settings
 	APIKey *string `json:"api_key,omitempty" gorm:"type:text"`
@@ -143,6 +183,17 @@ func (tj *TranscriptionJob) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// PlaybackAudioPath returns the audio the job plays back: the merged mix for
+// multi-track jobs when it exists, otherwise the original audio
+func (tj *TranscriptionJob) PlaybackAudioPath() string {
+	if tj.IsMultiTrack && tj.MergedAudioPath != nil && *tj.MergedAudioPath != "" {
+		if _, err := os.Stat(*tj.MergedAudioPath); err == nil {
+			return *tj.MergedAudioPath
+		}
+	}
+	return tj.AudioPath
+}
+
 // User represents a user for authentication
 type User struct {
 	ID                       uint      `json:"id" gorm:"primaryKey"`
@@ -153,6 +204,7 @@ type User struct {
 	Role                     string    `json:"role" gorm:"not null;default:'user';type:varchar(20)"`
 	DefaultProfileID         *string   `json:"default_profile_id,omitempty" gorm:"type:varchar(36)"`
 	AutoTranscriptionEnabled bool      `json:"auto_transcription_enabled" gorm:"not null;default:false"`
+	AutoSummaryTemplates     *string   `json:"auto_summary_templates,omitempty" gorm:"type:text"` // Comma-separated IDs of summary templates run on each completed job
 	CreatedAt                time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt                time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
@@ -211,27 +263,69 @@ func (tp *TranscriptionProfile) BeforeSave(tx *gorm.DB) error {
 
 // LLMConfig represents LLM configuration settings
 type LLMConfig struct {
-	ID            uint      `json:"id" gorm:"primaryKey"`
-	Provider      string    `json:"provider" gorm:"not null;type:varchar(50)"` // "ollama" or "openai"
-	BaseURL       *string   `json:"base_url,omitempty" gorm:"type:text"`       // For Ollama
-	OpenAIBaseURL *string   `json:"openai_base_url,omitempty" gorm:"type:text"` // For OpenAI custom endpoint
-	APIKey        *string   `json:"api_key,omitempty" gorm:"type:text"`        // For OpenAI (encrypted)
-	IsActive      bool      `json:"is_active" gorm:"type:boolean;default:false"`
-	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Name           string    `json:"name" gorm:"type:varchar(100)"`
+	Provider       string    `json:"provider" gorm:"not null;type:varchar(50)"`          // "ollama", "openai" or "groq"
+	BaseURL        *string   `json:"base_url,omitempty" gorm:"type:text"`                // For Ollama
+	OpenAIBaseURL  *string   `json:"openai_base_url,omitempty" gorm:"type:text"`         // For OpenAI custom endpoint
+	APIKey         *string   `json:"api_key,omitempty" gorm:"type:text"`                 // For OpenAI and Groq (encrypted)
+	EmbeddingModel *string   `json:"embedding_model,omitempty" gorm:"type:varchar(100)"` // For transcript retrieval; provider default if empty
+	IsActive       bool      `json:"is_active" gorm:"type:boolean;default:false"`        // Usable by features and routes
+	IsDefault      bool      `json:"is_default" gorm:"type:boolean;default:false"`       // Used by features without a route
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
-// BeforeSave ensures only one LLM config can be active
+// BeforeSave ensures only one LLM config is the default
 func (lc *LLMConfig) BeforeSave(tx *gorm.DB) error {
-	if lc.IsActive {
-		// Set all other configs to not active
-		if err := tx.Model(&LLMConfig{}).Where("id != ?", lc.ID).Update("is_active", false).Error; err != nil {
+	if lc.IsDefault {
+		// Set all other configs to not default
+		if err := tx.Model(&LLMConfig{}).Where("id != ?", lc.ID).Update("is_default", false).Error; err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// Features whose LLM provider and model can be routed
+const (
+	LLMFeatureChat          = "chat"
+	LLMFeatureChatTitle     = "chat_title"
+	LLMFeatureSummarization = "summarization"
+	LLMFeatureSmartAnalysis = "smart_analysis"
+	LLMFeatureTranslation   = "translation"
+	LLMFeatureEmbeddings    = "embeddings"
+)
+
+// LLMFeatures lists the routable features
+var LLMFeatures = []string{
+	LLMFeatureChat,
+	LLMFeatureChatTitle,
+	LLMFeatureSummarization,
+	LLMFeatureSmartAnalysis,
+	LLMFeatureTranslation,
+	LLMFeatureEmbeddings,
+}
+
+// LLMRoute maps a feature to the LLM configs and models that serve it, tried
+// in order until one succeeds. Routes of a user take precedence over the
+// global route of the feature.
+type LLMRoute struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	UserID    *uint            `json:"user_id,omitempty" gorm:"index"` // Nil for the global route
+	Feature   string           `json:"feature" gorm:"type:varchar(50);not null;index"`
+	Targets   []LLMRouteTarget `json:"targets" gorm:"type:text;serializer:json"`
+	CreatedAt time.Time        `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time        `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// LLMRouteTarget is one provider config and model of a route. An empty
+// model leaves the choice to the caller, e.g. the model of a chat session.
+type LLMRouteTarget struct {
+	ConfigID uint   `json:"config_id"`
+	Model    string `json:"model,omitempty"`
+}
+
 // ChatSession represents a chat session with a transcript
 type ChatSession struct {
 	ID              string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
@@ -247,12 +341,49 @@ type ChatSession struct {
 	CreatedAt       time.Time  `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt       time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 
+	// Scope of the transcripts used as context. TranscriptionID is the
+	// anchor job the session is listed and access-checked under.
+	Scope ChatScope `json:"scope" gorm:"embedded;embeddedPrefix:scope_"`
+
+	// Language selects the translation tracks used as context instead of
+	// the original transcripts, for the jobs that have one
+	Language string `json:"language,omitempty" gorm:"type:varchar(16)"`
+
+	// ActiveLeafID is the last message of the branch shown and used as
+	// context. It is nil for sessions from before branching, whose messages
+	// form a single path in creation order.
+	ActiveLeafID *uint `json:"active_leaf_id,omitempty"`
+
 	// Relationships
 	Transcription TranscriptionJob `json:"transcription,omitempty" gorm:"foreignKey:TranscriptionID;constraint:OnDelete:CASCADE"`
 	Job           TranscriptionJob `json:"job,omitempty" gorm:"foreignKey:JobID;constraint:OnDelete:CASCADE"`
 	Messages      []ChatMessage    `json:"messages,omitempty" gorm:"foreignKey:ChatSessionID;constraint:OnDelete:CASCADE"`
 }
 
+// Chat scope types
+const (
+	ChatScopeJob    = "job"    // The session's transcription only
+	ChatScopeJobs   = "jobs"   // An explicit set of jobs
+	ChatScopeTag    = "tag"    // The most recent jobs with a tag
+	ChatScopeSearch = "search" // The jobs best matching a saved full-text search
+)
+
+// ChatScope selects the transcripts a chat session draws its context from.
+// Tag and search scopes are resolved on every message, so jobs added later
+// join the conversation.
+type ChatScope struct {
+	Type   string   `json:"type" gorm:"type:varchar(20);default:'job'"`
+	JobIDs []string `json:"job_ids,omitempty" gorm:"type:text;serializer:json"`
+	Tag    string   `json:"tag,omitempty" gorm:"type:varchar(64)"`
+	Query  string   `json:"query,omitempty" gorm:"type:text"` // FTS5 query for search scopes
+	Limit  int      `json:"limit,omitempty" gorm:"type:int"`  // Max jobs for tag and search scopes
+}
+
+// IsMultiJob reports whether the scope can cover more than the anchor job
+func (s ChatScope) IsMultiJob() bool {
+	return s.Type != "" && s.Type != ChatScopeJob
+}
+
 // BeforeCreate sets the ID if not already set
 func (cs *ChatSession) BeforeCreate(tx *gorm.DB) error {
 	if cs.ID == "" {
@@ -266,18 +397,52 @@ func (cs *ChatSession) BeforeCreate(tx *gorm.DB) error {
 
 // ChatMessage represents a message in a chat session
 type ChatMessage struct {
-	ID            uint      `json:"id" gorm:"primaryKey;autoIncrement"`
-	SessionID     string    `json:"session_id" gorm:"type:varchar(36);not null;index"`
-	ChatSessionID string    `json:"chat_session_id" gorm:"type:varchar(36);not null;index"`
-	Role          string    `json:"role" gorm:"type:varchar(20);not null"` // "user" or "assistant"
-	Content       string    `json:"content" gorm:"type:text;not null"`
-	TokensUsed    *int      `json:"tokens_used,omitempty" gorm:"type:integer"`
-	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+	ID            uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	SessionID     string `json:"session_id" gorm:"type:varchar(36);not null;index"`
+	ChatSessionID string `json:"chat_session_id" gorm:"type:varchar(36);not null;index"`
+	Role          string `json:"role" gorm:"type:varchar(20);not null"` // "user", "assistant", "tool_call" or "tool_result"
+	Content       string `json:"content" gorm:"type:text;not null"`
+	TokensUsed    *int   `json:"tokens_used,omitempty" gorm:"type:integer"`
+	// ParentID is the message this one follows; messages sharing a parent
+	// are alternative branches. It is nil for first messages.
+	ParentID  *uint     `json:"parent_id,omitempty" gorm:"index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// Citations are the transcript passages an assistant message cites
+	Citations []ChatCitation `json:"citations,omitempty" gorm:"type:text;serializer:json"`
+
+	// Tool calls of agent mode and their results are messages of their own,
+	// linked by the call ID
+	ToolCallID    *string `json:"tool_call_id,omitempty" gorm:"type:varchar(100)"`
+	ToolName      *string `json:"tool_name,omitempty" gorm:"type:varchar(50)"`
+	ToolArguments *string `json:"tool_arguments,omitempty" gorm:"type:text"` // JSON object
+	ToolStatus    *string `json:"tool_status,omitempty" gorm:"type:varchar(20)"`
 
 	// Relationships
 	ChatSession ChatSession `json:"chat_session,omitempty" gorm:"foreignKey:ChatSessionID;constraint:OnDelete:CASCADE"`
 }
 
+// Tool call statuses
+const (
+	ToolStatusPending  = "pending"  // Waiting for the user to confirm a mutating call
+	ToolStatusExecuted = "executed" // Run, with its result in a tool_result message
+	ToolStatusRejected = "rejected" // Declined by the user
+	ToolStatusFailed   = "failed"   // Run, but returned an error
+)
+
+// ChatCitation links a marker in an assistant message, such as "[#12]", to the
+// transcript segments it cites
+type ChatCitation struct {
+	Marker       string  `json:"marker"`        // Marker as written in the message
+	JobID        string  `json:"job_id"`        // Cited transcription
+	StartSegment int     `json:"start_segment"` // First cited segment index
+	EndSegment   int     `json:"end_segment"`   // Last cited segment index (inclusive)
+	Start        float64 `json:"start"`         // Start time of the first segment in seconds
+	End          float64 `json:"end"`           // End time of the last segment in seconds
+	Text         string  `json:"text"`          // Text of the cited segments
+	Supported    bool    `json:"supported"`     // Whether the segments share wording with the cited claim
+}
+
 // BeforeCreate sets both session IDs to the same value for compatibility
 func (cm *ChatMessage) BeforeCreate(tx *gorm.DB) error {
 	if cm.SessionID == "" {
@@ -320,6 +485,9 @@ type TranscriptionJobExecution struct {
 	Status       JobStatus `json:"status" gorm:"type:varchar(20);not null"`
 	ErrorMessage *string   `json:"error_message,omitempty" gorm:"type:text"`
 
+	// Source records how the transcript was produced ("transcribed" or "imported")
+	Source string `json:"source" gorm:"type:varchar(20);not null;default:'transcribed'"`
+
 	// Metadata
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
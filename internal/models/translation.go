@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Translation statuses
+const (
+	TranslationStatusPending    = "pending"
+	TranslationStatusProcessing = "processing"
+	TranslationStatusCompleted  = "completed"
+	TranslationStatusFailed     = "failed"
+)
+
+// Translation providers
+const (
+	TranslationProviderLLM     = "llm"     // The translation LLM route
+	TranslationProviderService = "service" // A LibreTranslate-compatible translation service
+)
+
+// TranscriptTranslation is a transcript translated into another language,
+// segment by segment. Its segments keep the timings and speakers of the
+// original ones, in the same order.
+type TranscriptTranslation struct {
+	ID              string              `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	TranscriptionID string              `json:"transcription_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_translation_job_language"`
+	Language        string              `json:"language" gorm:"type:varchar(16);not null;uniqueIndex:idx_translation_job_language"` // Target language code
+	SourceLanguage  string              `json:"source_language,omitempty" gorm:"type:varchar(16)"`
+	Provider        string              `json:"provider" gorm:"type:varchar(20);not null"`
+	Model           string              `json:"model,omitempty" gorm:"type:varchar(255)"`
+	Status          string              `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	Error           *string             `json:"error,omitempty" gorm:"type:text"`
+	Translated      int                 `json:"translated"` // Segments translated so far
+	Total           int                 `json:"total"`
+	Segments        []TranslatedSegment `json:"segments,omitempty" gorm:"type:text;serializer:json"`
+	CreatedAt       time.Time           `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time           `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relationships
+	Transcription TranscriptionJob `json:"-" gorm:"foreignKey:TranscriptionID;constraint:OnDelete:CASCADE"`
+}
+
+// TranslatedSegment is a transcript segment in a translation track
+type TranslatedSegment struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Text    string  `json:"text"`
+	Speaker *string `json:"speaker,omitempty"`
+}
+
+// BeforeCreate ensures TranscriptTranslation has a UUID primary key
+func (t *TranscriptTranslation) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
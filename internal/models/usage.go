@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+)
+
+// UsageFeatureTranscription is the usage feature of cloud ASR calls; LLM
+// calls use the LLM feature they were routed for
+const UsageFeatureTranscription = "transcription"
+
+// UsageEntry is one billable call to an LLM or a cloud ASR provider. Unit
+// prices are copied from the price list when the call is recorded, so later
+// price changes do not rewrite history.
+type UsageEntry struct {
+	ID       uint    `json:"id" gorm:"primaryKey"`
+	UserID   *uint   `json:"user_id,omitempty" gorm:"index"`
+	JobID    *string `json:"job_id,omitempty" gorm:"type:varchar(36);index"`
+	Feature  string  `json:"feature" gorm:"type:varchar(50);not null;index"`
+	Provider string  `json:"provider" gorm:"type:varchar(50);not null"`
+	Model    string  `json:"model" gorm:"type:varchar(100)"`
+
+	InputTokens  int     `json:"input_tokens" gorm:"type:integer;default:0"`
+	OutputTokens int     `json:"output_tokens" gorm:"type:integer;default:0"`
+	AudioSeconds float64 `json:"audio_seconds" gorm:"type:real;default:0"`
+	// Estimated is set when the provider reported no token counts
+	Estimated bool `json:"estimated" gorm:"type:boolean;default:false"`
+
+	// Unit prices in USD and the resulting cost
+	InputPrice  float64 `json:"input_price"`  // Per million input tokens
+	OutputPrice float64 `json:"output_price"` // Per million output tokens
+	AudioPrice  float64 `json:"audio_price"`  // Per audio minute
+	Cost        float64 `json:"cost"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+// ModelPrice is the price of a provider's model in USD. An empty model sets
+// the price of every model of the provider without its own entry.
+type ModelPrice struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	Provider         string    `json:"provider" gorm:"type:varchar(50);not null;uniqueIndex:idx_model_prices_model"`
+	Model            string    `json:"model" gorm:"type:varchar(100);uniqueIndex:idx_model_prices_model"`
+	InputPerMillion  float64   `json:"input_per_million"`
+	OutputPerMillion float64   `json:"output_per_million"`
+	AudioPerMinute   float64   `json:"audio_per_minute"`
+	CreatedAt        time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// Price fills in the entry's unit prices from p and computes its cost
+func (e *UsageEntry) Price(p *ModelPrice) {
+	if p == nil {
+		return
+	}
+	e.InputPrice = p.InputPerMillion
+	e.OutputPrice = p.OutputPerMillion
+	e.AudioPrice = p.AudioPerMinute
+	e.Cost = float64(e.InputTokens)*p.InputPerMillion/1e6 +
+		float64(e.OutputTokens)*p.OutputPerMillion/1e6 +
+		e.AudioSeconds*p.AudioPerMinute/60
+}
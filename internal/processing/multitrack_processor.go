@@ -8,6 +8,7 @@ import (
 	"scriberr/internal/audio"
 	"scriberr/internal/models"
 	"scriberr/internal/repository"
+	"scriberr/internal/waveform"
 	"scriberr/pkg/logger"
 
 	"gorm.io/gorm"
@@ -19,6 +20,7 @@ type MultiTrackProcessor struct {
 	audioMerger *audio.AudioMerger
 	db          *gorm.DB
 	jobRepo     repository.JobRepository
+	waveforms   *waveform.Service
 }
 
 // NewMultiTrackProcessor creates a new multi-track processor
@@ -31,6 +33,11 @@ func NewMultiTrackProcessor(db *gorm.DB, jobRepo repository.JobRepository) *Mult
 	}
 }
 
+// SetWaveformService sets the service used to precompute waveforms of merged audio
+func (p *MultiTrackProcessor) SetWaveformService(s *waveform.Service) {
+	p.waveforms = s
+}
+
 // ProcessMultiTrackJob processes a multi-track job by parsing the .aup file and merging audio
 func (p *MultiTrackProcessor) ProcessMultiTrackJob(ctx context.Context, jobID string) error {
 	// Get the job from database
@@ -110,6 +117,7 @@ func (p *MultiTrackProcessor) ProcessMultiTrackJob(ctx context.Context, jobID st
 		"merge_status":      "completed",
 		"merge_error":       nil,
 		"audio_path":        outputPath, // Update main audio path to point to merged file
+		"audio_hash":        nil,
 	}
 
 	if err := p.db.Model(&models.TranscriptionJob{}).Where("id = ?", jobID).Updates(updates).Error; err != nil {
@@ -118,6 +126,10 @@ func (p *MultiTrackProcessor) ProcessMultiTrackJob(ctx context.Context, jobID st
 		return fmt.Errorf("failed to update job with merged path: %w", err)
 	}
 
+	if p.waveforms != nil {
+		p.waveforms.Schedule(jobID)
+	}
+
 	logger.Info("Successfully completed multi-track processing", "job_id", jobID, "output_path", outputPath)
 	return nil
 }
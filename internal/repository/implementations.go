@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"scriberr/internal/models"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -53,7 +55,7 @@ type JobRepository interface {
 	FindWithAssociations(ctx context.Context, id string) (*models.TranscriptionJob, error)
 	FindActiveTrackJobs(ctx context.Context, parentJobID string) ([]models.TranscriptionJob, error)
 	FindLatestCompletedExecution(ctx context.Context, jobID string) (*models.TranscriptionJobExecution, error)
-	ListWithParams(ctx context.Context, userID uint, offset, limit int, sortBy, sortOrder, searchQuery string, updatedAfter *time.Time) ([]models.TranscriptionJob, int64, error)
+	ListWithParams(ctx context.Context, userID uint, offset, limit int, sortBy, sortOrder, searchQuery string, updatedAfter *time.Time, filter JobFilter) ([]models.TranscriptionJob, int64, error)
 	ListByUser(ctx context.Context, userID uint, offset, limit int) ([]models.TranscriptionJob, int64, error)
 	UpdateTranscript(ctx context.Context, jobID string, transcript string) error
 	CreateExecution(ctx context.Context, execution *models.TranscriptionJobExecution) error
@@ -61,11 +63,56 @@ type JobRepository interface {
 	DeleteExecutionsByJobID(ctx context.Context, jobID string) error
 	DeleteMultiTrackFilesByJobID(ctx context.Context, jobID string) error
 	UpdateStatus(ctx context.Context, jobID string, status models.JobStatus) error
+	UpdateReviewStatus(ctx context.Context, jobID string, status string) error
+	UpdateLanguageMix(ctx context.Context, jobID string, mix []models.LanguageTime) error
 	UpdateError(ctx context.Context, jobID string, errorMsg string) error
 	FindByStatus(ctx context.Context, status models.JobStatus) ([]models.TranscriptionJob, error)
 	CountByStatus(ctx context.Context, status models.JobStatus) (int64, error)
 	UpdateSummary(ctx context.Context, jobID string, summary string) error
 	UpdateAudioPath(ctx context.Context, jobID string, audioPath string) error
+	UpdateAudioHash(ctx context.Context, jobID string, hash string) error
+	FindByAudioHash(ctx context.Context, userID uint, hash string) (*models.TranscriptionJob, error)
+	ListExecutionsByJobID(ctx context.Context, jobID string) ([]models.TranscriptionJobExecution, error)
+	UpdateTags(ctx context.Context, jobID string, tags []string) error
+	SearchSegments(ctx context.Context, params SegmentSearchParams) ([]SegmentSearchHit, int64, error)
+	SearchJobIDs(ctx context.Context, params SegmentSearchParams) ([]string, error)
+	ListByTag(ctx context.Context, userID uint, tag string, limit int) ([]models.TranscriptionJob, error)
+}
+
+// JobFilter narrows a job listing
+type JobFilter struct {
+	Status       models.JobStatus
+	ReviewStatus string // "none" selects jobs without a review
+}
+
+// SegmentSearchParams filters a full-text search over transcript segments.
+// Query uses FTS5 syntax: "quoted phrases", prefix* terms, AND/OR/NOT.
+type SegmentSearchParams struct {
+	UserID  uint
+	Query   string
+	Speaker string // Original label or mapped speaker name
+	Tag     string
+	Status  models.JobStatus
+	From    *time.Time // Job creation time, inclusive
+	To      *time.Time // Job creation time, exclusive
+	Offset  int
+	Limit   int
+}
+
+// SegmentSearchHit is a transcript segment matching a search
+type SegmentSearchHit struct {
+	JobID        string           `json:"job_id"`
+	JobTitle     *string          `json:"job_title,omitempty"`
+	JobStatus    models.JobStatus `json:"job_status"`
+	JobCreatedAt time.Time        `json:"job_created_at"`
+	SegmentIndex int              `json:"segment_index"`
+	Start        float64          `json:"start"`
+	End          float64          `json:"end"`
+	Speaker      *string          `json:"speaker,omitempty"`      // Original label, e.g. SPEAKER_00
+	SpeakerName  *string          `json:"speaker_name,omitempty"` // Mapped name, if any
+	Text         string           `json:"text"`
+	Snippet      string           `json:"snippet"` // Matches wrapped in <mark></mark>
+	Rank         float64          `json:"rank"`    // bm25, lower is better
 }
 
 type jobRepository struct {
@@ -79,7 +126,31 @@ func NewJobRepository(db *gorm.DB) JobRepository {
 }
 
 func (r *jobRepository) UpdateAudioPath(ctx context.Context, jobID string, audioPath string) error {
-	return r.db.WithContext(ctx).Model(&models.TranscriptionJob{}).Where("id = ?", jobID).Update("audio_path", audioPath).Error
+	// The stored hash describes the old file, so clear it along with the path
+	return r.db.WithContext(ctx).Model(&models.TranscriptionJob{}).Where("id = ?", jobID).
+		Updates(map[string]interface{}{"audio_path": audioPath, "audio_hash": nil}).Error
+}
+
+func (r *jobRepository) UpdateAudioHash(ctx context.Context, jobID string, hash string) error {
+	return r.db.WithContext(ctx).Model(&models.TranscriptionJob{}).Where("id = ?", jobID).Update("audio_hash", hash).Error
+}
+
+func (r *jobRepository) FindByAudioHash(ctx context.Context, userID uint, hash string) (*models.TranscriptionJob, error) {
+	var job models.TranscriptionJob
+	err := r.db.WithContext(ctx).Where("user_id = ? AND audio_hash = ?", userID, hash).First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *jobRepository) ListExecutionsByJobID(ctx context.Context, jobID string) ([]models.TranscriptionJobExecution, error) {
+	var executions []models.TranscriptionJobExecution
+	err := r.db.WithContext(ctx).Where("transcription_job_id = ?", jobID).Order("created_at ASC").Find(&executions).Error
+	if err != nil {
+		return nil, err
+	}
+	return executions, nil
 }
 
 func (r *jobRepository) FindWithAssociations(ctx context.Context, id string) (*models.TranscriptionJob, error) {
@@ -94,7 +165,7 @@ func (r *jobRepository) FindWithAssociations(ctx context.Context, id string) (*m
 	return &job, nil
 }
 
-func (r *jobRepository) ListWithParams(ctx context.Context, userID uint, offset, limit int, sortBy, sortOrder, searchQuery string, updatedAfter *time.Time) ([]models.TranscriptionJob, int64, error) {
+func (r *jobRepository) ListWithParams(ctx context.Context, userID uint, offset, limit int, sortBy, sortOrder, searchQuery string, updatedAfter *time.Time, filter JobFilter) ([]models.TranscriptionJob, int64, error) {
 	var jobs []models.TranscriptionJob
 	var count int64
 
@@ -114,6 +185,17 @@ func (r *jobRepository) ListWithParams(ctx context.Context, userID uint, offset,
 		db = db.Where("title LIKE ? OR audio_path LIKE ?", search, search)
 	}
 
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	switch filter.ReviewStatus {
+	case "":
+	case "none":
+		db = db.Where("review_status IS NULL OR review_status = ''")
+	default:
+		db = db.Where("review_status = ?", filter.ReviewStatus)
+	}
+
 	// Count total matching records
 	if err := db.Count(&count).Error; err != nil {
 		return nil, 0, err
@@ -139,8 +221,86 @@ func (r *jobRepository) ListWithParams(ctx context.Context, userID uint, offset,
 	return jobs, count, nil
 }
 
+func (r *jobRepository) UpdateTags(ctx context.Context, jobID string, tags []string) error {
+	return r.db.WithContext(ctx).Model(&models.TranscriptionJob{ID: jobID}).Select("tags").Updates(&models.TranscriptionJob{Tags: tags}).Error
+}
+
+func (r *jobRepository) SearchSegments(ctx context.Context, params SegmentSearchParams) ([]SegmentSearchHit, int64, error) {
+	db := r.segmentSearchQuery(ctx, params)
+
+	var count int64
+	if err := db.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var hits []SegmentSearchHit
+	err := db.Select(`f.job_id, j.title AS job_title, j.status AS job_status, j.created_at AS job_created_at,
+			f.segment_index, f.start, f."end", f.speaker, sm.custom_name AS speaker_name, f.text,
+			snippet(transcript_segments_fts, 0, '<mark>', '</mark>', '…', 24) AS snippet,
+			bm25(transcript_segments_fts) AS rank`).
+		Order("rank, j.created_at DESC, f.segment_index").
+		Offset(params.Offset).Limit(params.Limit).
+		Scan(&hits).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return hits, count, nil
+}
+
+// segmentSearchQuery builds the filtered full-text query over transcript
+// segments of the user's visible jobs
+func (r *jobRepository) segmentSearchQuery(ctx context.Context, params SegmentSearchParams) *gorm.DB {
+	// The FTS table is maintained by triggers on transcription_jobs (see
+	// database.createTranscriptSearchIndex)
+	db := r.db.WithContext(ctx).
+		Table("transcript_segments_fts AS f").
+		Joins("JOIN transcription_jobs AS j ON j.id = f.job_id").
+		Joins("LEFT JOIN speaker_mappings AS sm ON sm.transcription_job_id = f.job_id AND sm.original_speaker = f.speaker").
+		Where("transcript_segments_fts MATCH ?", params.Query).
+		Where("j.user_id = ? AND j.hidden = ? AND j.deleted_at IS NULL", params.UserID, false)
+
+	if params.Speaker != "" {
+		db = db.Where("(f.speaker = ? COLLATE NOCASE OR sm.custom_name = ? COLLATE NOCASE)", params.Speaker, params.Speaker)
+	}
+	if params.Tag != "" {
+		db = db.Where("EXISTS (SELECT 1 FROM json_each(CASE WHEN json_valid(j.tags) THEN j.tags ELSE '[]' END) WHERE value = ?)", params.Tag)
+	}
+	if params.Status != "" {
+		db = db.Where("j.status = ?", params.Status)
+	}
+	if params.From != nil {
+		db = db.Where("j.created_at >= ?", *params.From)
+	}
+	if params.To != nil {
+		db = db.Where("j.created_at < ?", *params.To)
+	}
+	return db
+}
+
+func (r *jobRepository) SearchJobIDs(ctx context.Context, params SegmentSearchParams) ([]string, error) {
+	var ids []string
+	err := r.segmentSearchQuery(ctx, params).
+		Select("f.job_id").
+		Group("f.job_id").
+		Order("MIN(bm25(transcript_segments_fts)), MAX(j.created_at) DESC").
+		Offset(params.Offset).Limit(params.Limit).
+		Pluck("f.job_id", &ids).Error
+	return ids, err
+}
+
+func (r *jobRepository) ListByTag(ctx context.Context, userID uint, tag string, limit int) ([]models.TranscriptionJob, error) {
+	var jobs []models.TranscriptionJob
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND hidden = ?", userID, false).
+		Where("EXISTS (SELECT 1 FROM json_each(CASE WHEN json_valid(tags) THEN tags ELSE '[]' END) WHERE value = ?)", tag).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
 func (r *jobRepository) ListByUser(ctx context.Context, userID uint, offset, limit int) ([]models.TranscriptionJob, int64, error) {
-	return r.ListWithParams(ctx, userID, offset, limit, "", "", "", nil)
+	return r.ListWithParams(ctx, userID, offset, limit, "", "", "", nil, JobFilter{})
 }
 
 func (r *jobRepository) UpdateTranscript(ctx context.Context, jobID string, transcript string) error {
@@ -189,6 +349,14 @@ func (r *jobRepository) UpdateStatus(ctx context.Context, jobID string, status m
 	return r.db.WithContext(ctx).Model(&models.TranscriptionJob{}).Where("id = ?", jobID).Update("status", status).Error
 }
 
+func (r *jobRepository) UpdateReviewStatus(ctx context.Context, jobID string, status string) error {
+	return r.db.WithContext(ctx).Model(&models.TranscriptionJob{}).Where("id = ?", jobID).Update("review_status", status).Error
+}
+
+func (r *jobRepository) UpdateLanguageMix(ctx context.Context, jobID string, mix []models.LanguageTime) error {
+	return r.db.WithContext(ctx).Model(&models.TranscriptionJob{ID: jobID}).Select("language_mix").Updates(&models.TranscriptionJob{LanguageMix: mix}).Error
+}
+
 func (r *jobRepository) UpdateError(ctx context.Context, jobID string, errorMsg string) error {
 	return r.db.WithContext(ctx).Model(&models.TranscriptionJob{}).Where("id = ?", jobID).Update("error_message", errorMsg).Error
 }
@@ -287,10 +455,15 @@ func (r *profileRepository) FindByName(ctx context.Context, name string) (*model
 	return &profile, nil
 }
 
-// LLMConfigRepository handles LLM configuration operations
+// LLMConfigRepository handles LLM configuration and routing operations
 type LLMConfigRepository interface {
 	Repository[models.LLMConfig]
 	GetActive(ctx context.Context) (*models.LLMConfig, error)
+	GetDefault(ctx context.Context) (*models.LLMConfig, error)
+	FindRoute(ctx context.Context, feature string, userID *uint) (*models.LLMRoute, error)
+	ListRoutes(ctx context.Context, userID *uint) ([]models.LLMRoute, error)
+	SaveRoute(ctx context.Context, route *models.LLMRoute) error
+	DeleteRoute(ctx context.Context, feature string, userID *uint) error
 }
 
 type llmConfigRepository struct {
@@ -303,15 +476,77 @@ func NewLLMConfigRepository(db *gorm.DB) LLMConfigRepository {
 	}
 }
 
+// GetActive returns the default config if it is active. Other active
+// configs are only used through routes.
 func (r *llmConfigRepository) GetActive(ctx context.Context) (*models.LLMConfig, error) {
 	var config models.LLMConfig
-	err := r.db.WithContext(ctx).Where("is_active = ?", true).First(&config).Error
+	err := r.db.WithContext(ctx).Where("is_active = ? AND is_default = ?", true, true).First(&config).Error
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// GetDefault returns the default config, active or not
+func (r *llmConfigRepository) GetDefault(ctx context.Context) (*models.LLMConfig, error) {
+	var config models.LLMConfig
+	err := r.db.WithContext(ctx).Where("is_default = ?", true).First(&config).Error
 	if err != nil {
 		return nil, err
 	}
 	return &config, nil
 }
 
+// routeScope restricts a query to the global route (nil userID) or a user's
+func routeScope(db *gorm.DB, userID *uint) *gorm.DB {
+	if userID == nil {
+		return db.Where("user_id IS NULL")
+	}
+	return db.Where("user_id = ?", *userID)
+}
+
+// FindRoute returns the route of feature in exactly the given scope, or nil
+func (r *llmConfigRepository) FindRoute(ctx context.Context, feature string, userID *uint) (*models.LLMRoute, error) {
+	var route models.LLMRoute
+	err := routeScope(r.db.WithContext(ctx), userID).Where("feature = ?", feature).First(&route).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+// ListRoutes returns the global routes and, if userID is set, the user's
+func (r *llmConfigRepository) ListRoutes(ctx context.Context, userID *uint) ([]models.LLMRoute, error) {
+	var routes []models.LLMRoute
+	query := r.db.WithContext(ctx).Where("user_id IS NULL")
+	if userID != nil {
+		query = query.Or("user_id = ?", *userID)
+	}
+	err := query.Order("feature ASC, user_id ASC").Find(&routes).Error
+	return routes, err
+}
+
+// SaveRoute creates the route or replaces the one of the same feature and scope
+func (r *llmConfigRepository) SaveRoute(ctx context.Context, route *models.LLMRoute) error {
+	existing, err := r.FindRoute(ctx, route.Feature, route.UserID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		route.ID = existing.ID
+		route.CreatedAt = existing.CreatedAt
+	}
+	return r.db.WithContext(ctx).Save(route).Error
+}
+
+// DeleteRoute removes the route of feature in the given scope
+func (r *llmConfigRepository) DeleteRoute(ctx context.Context, feature string, userID *uint) error {
+	return routeScope(r.db.WithContext(ctx), userID).Where("feature = ?", feature).Delete(&models.LLMRoute{}).Error
+}
+
 // SummaryRepository handles summary templates and settings
 type SummaryRepository interface {
 	Repository[models.SummaryTemplate]
@@ -319,6 +554,11 @@ type SummaryRepository interface {
 	SaveSettings(ctx context.Context, settings *models.SummarySetting) error
 	SaveSummary(ctx context.Context, summary *models.Summary) error
 	GetLatestSummary(ctx context.Context, transcriptionID string) (*models.Summary, error)
+	FindSummary(ctx context.Context, id string) (*models.Summary, error)
+	UpdateSummary(ctx context.Context, summary *models.Summary) error
+	DeleteSummary(ctx context.Context, id string) error
+	ListUnfinishedSummaries(ctx context.Context) ([]models.Summary, error)
+	ListByTranscriptionID(ctx context.Context, transcriptionID string) ([]models.Summary, error)
 	DeleteByTranscriptionID(ctx context.Context, transcriptionID string) error
 }
 
@@ -351,15 +591,57 @@ func (r *summaryRepository) SaveSummary(ctx context.Context, summary *models.Sum
 	return r.db.WithContext(ctx).Create(summary).Error
 }
 
+// GetLatestSummary returns the newest completed summary of a transcription
 func (r *summaryRepository) GetLatestSummary(ctx context.Context, transcriptionID string) (*models.Summary, error) {
 	var summary models.Summary
-	err := r.db.WithContext(ctx).Where("transcription_id = ?", transcriptionID).Order("created_at DESC").First(&summary).Error
+	err := r.db.WithContext(ctx).Where("transcription_id = ? AND status = ?", transcriptionID, models.SummaryStatusCompleted).Order("created_at DESC").First(&summary).Error
 	if err != nil {
 		return nil, err
 	}
 	return &summary, nil
 }
 
+// FindSummary returns a summary by ID, or nil if there is none
+func (r *summaryRepository) FindSummary(ctx context.Context, id string) (*models.Summary, error) {
+	var summary models.Summary
+	err := r.db.WithContext(ctx).First(&summary, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &summary, nil
+}
+
+func (r *summaryRepository) UpdateSummary(ctx context.Context, summary *models.Summary) error {
+	return r.db.WithContext(ctx).Save(summary).Error
+}
+
+func (r *summaryRepository) DeleteSummary(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&models.Summary{}, "id = ?", id).Error
+}
+
+// ListUnfinishedSummaries returns the summaries still queued or generating,
+// oldest first
+func (r *summaryRepository) ListUnfinishedSummaries(ctx context.Context) ([]models.Summary, error) {
+	var summaries []models.Summary
+	err := r.db.WithContext(ctx).Where("status IN ?", []string{models.SummaryStatusPending, models.SummaryStatusProcessing}).Order("created_at ASC").Find(&summaries).Error
+	if err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+func (r *summaryRepository) ListByTranscriptionID(ctx context.Context, transcriptionID string) ([]models.Summary, error) {
+	var summaries []models.Summary
+	err := r.db.WithContext(ctx).Where("transcription_id = ?", transcriptionID).Order("created_at DESC").Find(&summaries).Error
+	if err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
 func (r *summaryRepository) DeleteByTranscriptionID(ctx context.Context, transcriptionID string) error {
 	return r.db.WithContext(ctx).Where("transcription_id = ?", transcriptionID).Delete(&models.Summary{}).Error
 }
@@ -370,6 +652,12 @@ type ChatRepository interface {
 	GetSessionWithMessages(ctx context.Context, id string) (*models.ChatSession, error)
 	GetSessionWithTranscription(ctx context.Context, id string) (*models.ChatSession, error)
 	AddMessage(ctx context.Context, message *models.ChatMessage) error
+	AppendMessage(ctx context.Context, session *models.ChatSession, message *models.ChatMessage) error
+	AddBranchMessage(ctx context.Context, session *models.ChatSession, message *models.ChatMessage, parentID *uint) error
+	SetActiveLeaf(ctx context.Context, session *models.ChatSession, leafID uint) error
+	GetMessageTree(ctx context.Context, session *models.ChatSession) ([]models.ChatMessage, error)
+	GetMessage(ctx context.Context, sessionID string, messageID uint) (*models.ChatMessage, error)
+	UpdateMessage(ctx context.Context, message *models.ChatMessage) error
 	ListByJob(ctx context.Context, jobID string) ([]models.ChatSession, error)
 	DeleteSession(ctx context.Context, id string) error
 	GetMessages(ctx context.Context, sessionID string, limit int) ([]models.ChatMessage, error)
@@ -410,6 +698,111 @@ func (r *chatRepository) AddMessage(ctx context.Context, message *models.ChatMes
 	return r.db.WithContext(ctx).Create(message).Error
 }
 
+// AppendMessage adds a message after the last one of the session's active
+// branch and makes it the new end of the branch
+func (r *chatRepository) AppendMessage(ctx context.Context, session *models.ChatSession, message *models.ChatMessage) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		leaf, err := linkChatMessages(tx, session.ID)
+		if err != nil {
+			return err
+		}
+		return addChatMessage(tx, session, message, leaf)
+	})
+}
+
+// AddBranchMessage adds a message after parentID, or as a first message if
+// nil, starting a new branch that becomes the active one
+func (r *chatRepository) AddBranchMessage(ctx context.Context, session *models.ChatSession, message *models.ChatMessage, parentID *uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := linkChatMessages(tx, session.ID); err != nil {
+			return err
+		}
+		return addChatMessage(tx, session, message, parentID)
+	})
+}
+
+func addChatMessage(tx *gorm.DB, session *models.ChatSession, message *models.ChatMessage, parentID *uint) error {
+	message.SessionID = session.ID
+	message.ChatSessionID = session.ID
+	message.ParentID = parentID
+	if err := tx.Create(message).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.ChatSession{}).Where("id = ?", session.ID).Update("active_leaf_id", message.ID).Error; err != nil {
+		return err
+	}
+	session.ActiveLeafID = &message.ID
+	return nil
+}
+
+// SetActiveLeaf makes the branch ending at leafID the session's active one
+func (r *chatRepository) SetActiveLeaf(ctx context.Context, session *models.ChatSession, leafID uint) error {
+	err := r.db.WithContext(ctx).Model(&models.ChatSession{}).Where("id = ?", session.ID).Update("active_leaf_id", leafID).Error
+	if err != nil {
+		return err
+	}
+	session.ActiveLeafID = &leafID
+	return nil
+}
+
+// GetMessageTree returns every message of the session in creation order and
+// sets session.ActiveLeafID to the end of the active branch
+func (r *chatRepository) GetMessageTree(ctx context.Context, session *models.ChatSession) ([]models.ChatMessage, error) {
+	var messages []models.ChatMessage
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		leaf, err := linkChatMessages(tx, session.ID)
+		if err != nil {
+			return err
+		}
+		session.ActiveLeafID = leaf
+		return tx.Where("chat_session_id = ?", session.ID).Order("id ASC").Find(&messages).Error
+	})
+	return messages, err
+}
+
+// linkChatMessages returns the active leaf of a session, first chaining the
+// messages of sessions from before branching in creation order
+func linkChatMessages(tx *gorm.DB, sessionID string) (*uint, error) {
+	var session models.ChatSession
+	if err := tx.Select("id", "active_leaf_id").Where("id = ?", sessionID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	if session.ActiveLeafID != nil {
+		return session.ActiveLeafID, nil
+	}
+
+	var messages []models.ChatMessage
+	if err := tx.Select("id").Where("chat_session_id = ?", sessionID).Order("created_at ASC, id ASC").Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	for i := 1; i < len(messages); i++ {
+		if err := tx.Model(&models.ChatMessage{}).Where("id = ?", messages[i].ID).Update("parent_id", messages[i-1].ID).Error; err != nil {
+			return nil, err
+		}
+	}
+	leaf := messages[len(messages)-1].ID
+	if err := tx.Model(&models.ChatSession{}).Where("id = ?", sessionID).Update("active_leaf_id", leaf).Error; err != nil {
+		return nil, err
+	}
+	return &leaf, nil
+}
+
+func (r *chatRepository) GetMessage(ctx context.Context, sessionID string, messageID uint) (*models.ChatMessage, error) {
+	var message models.ChatMessage
+	err := r.db.WithContext(ctx).Where("id = ? AND chat_session_id = ?", messageID, sessionID).First(&message).Error
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+func (r *chatRepository) UpdateMessage(ctx context.Context, message *models.ChatMessage) error {
+	return r.db.WithContext(ctx).Save(message).Error
+}
+
 func (r *chatRepository) ListByJob(ctx context.Context, jobID string) ([]models.ChatSession, error) {
 	var sessions []models.ChatSession
 	err := r.db.WithContext(ctx).Where("transcription_id = ?", jobID).Order("created_at DESC").Find(&sessions).Error
@@ -448,7 +841,7 @@ func (r *chatRepository) DeleteByJobID(ctx context.Context, jobID string) error
 
 func (r *chatRepository) GetMessages(ctx context.Context, sessionID string, limit int) ([]models.ChatMessage, error) {
 	var messages []models.ChatMessage
-	query := r.db.WithContext(ctx).Where("chat_session_id = ?", sessionID).Order("created_at ASC")
+	query := r.db.WithContext(ctx).Where("chat_session_id = ?", sessionID).Order("created_at ASC, id ASC")
 	if limit > 0 {
 		query = query.Limit(limit)
 	}
@@ -542,6 +935,337 @@ func (r *noteRepository) DeleteByTranscriptionID(ctx context.Context, transcript
 	return r.db.WithContext(ctx).Where("transcription_id = ?", transcriptionID).Delete(&models.Note{}).Error
 }
 
+// ActionItemRepository handles action items extracted from transcripts
+type ActionItemRepository interface {
+	Repository[models.ActionItem]
+	ListByJob(ctx context.Context, jobID string) ([]models.ActionItem, error)
+	ListTasks(ctx context.Context, filter ActionItemFilter) ([]models.ActionItem, error)
+	ReplaceForJob(ctx context.Context, jobID string, items []models.ActionItem) error
+	DeleteByTranscriptionID(ctx context.Context, transcriptionID string) error
+}
+
+// ActionItemFilter selects action items across transcriptions
+type ActionItemFilter struct {
+	UserID          *uint
+	TranscriptionID string
+	Status          string
+	Assignee        string // Case-insensitive
+}
+
+type actionItemRepository struct {
+	*BaseRepository[models.ActionItem]
+}
+
+func NewActionItemRepository(db *gorm.DB) ActionItemRepository {
+	return &actionItemRepository{
+		BaseRepository: NewBaseRepository[models.ActionItem](db),
+	}
+}
+
+func (r *actionItemRepository) ListByJob(ctx context.Context, jobID string) ([]models.ActionItem, error) {
+	var items []models.ActionItem
+	err := r.db.WithContext(ctx).Where("transcription_id = ?", jobID).Order("source_time IS NULL, source_time ASC, created_at ASC").Find(&items).Error
+	return items, err
+}
+
+// ListTasks lists action items, soonest due first and undated ones last
+func (r *actionItemRepository) ListTasks(ctx context.Context, filter ActionItemFilter) ([]models.ActionItem, error) {
+	query := r.db.WithContext(ctx).Model(&models.ActionItem{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.TranscriptionID != "" {
+		query = query.Where("transcription_id = ?", filter.TranscriptionID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Assignee != "" {
+		query = query.Where("LOWER(assignee) = LOWER(?)", filter.Assignee)
+	}
+	var items []models.ActionItem
+	err := query.Order("due_date IS NULL, due_date ASC, created_at DESC").Find(&items).Error
+	return items, err
+}
+
+func (r *actionItemRepository) ReplaceForJob(ctx context.Context, jobID string, items []models.ActionItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("transcription_id = ?", jobID).Delete(&models.ActionItem{}).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Create(&items).Error
+	})
+}
+
+func (r *actionItemRepository) DeleteByTranscriptionID(ctx context.Context, transcriptionID string) error {
+	return r.db.WithContext(ctx).Where("transcription_id = ?", transcriptionID).Delete(&models.ActionItem{}).Error
+}
+
+// ChapterRepository stores the chapters of transcripts
+type ChapterRepository interface {
+	ListByJob(ctx context.Context, jobID string) ([]models.Chapter, error)
+	ReplaceForJob(ctx context.Context, jobID string, chapters []models.Chapter) error
+	DeleteByTranscriptionID(ctx context.Context, transcriptionID string) error
+}
+
+type chapterRepository struct {
+	db *gorm.DB
+}
+
+func NewChapterRepository(db *gorm.DB) ChapterRepository {
+	return &chapterRepository{db: db}
+}
+
+func (r *chapterRepository) ListByJob(ctx context.Context, jobID string) ([]models.Chapter, error) {
+	var chapters []models.Chapter
+	err := r.db.WithContext(ctx).Where("transcription_id = ?", jobID).Order("position ASC").Find(&chapters).Error
+	return chapters, err
+}
+
+func (r *chapterRepository) ReplaceForJob(ctx context.Context, jobID string, chapters []models.Chapter) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("transcription_id = ?", jobID).Delete(&models.Chapter{}).Error; err != nil {
+			return err
+		}
+		if len(chapters) == 0 {
+			return nil
+		}
+		return tx.Create(&chapters).Error
+	})
+}
+
+func (r *chapterRepository) DeleteByTranscriptionID(ctx context.Context, transcriptionID string) error {
+	return r.db.WithContext(ctx).Where("transcription_id = ?", transcriptionID).Delete(&models.Chapter{}).Error
+}
+
+// AnalyticsRepository stores the conversation analytics of transcripts
+type AnalyticsRepository interface {
+	FindByJob(ctx context.Context, jobID string) (*models.ConversationAnalytics, error)
+	Save(ctx context.Context, analytics *models.ConversationAnalytics) error
+	List(ctx context.Context, filter AnalyticsFilter) ([]models.ConversationAnalytics, error)
+	DeleteByTranscriptionID(ctx context.Context, transcriptionID string) error
+}
+
+// AnalyticsFilter selects analytics by the owner and creation time of their
+// transcriptions
+type AnalyticsFilter struct {
+	UserID *uint
+	From   *time.Time // Inclusive
+	To     *time.Time // Exclusive
+}
+
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+func (r *analyticsRepository) FindByJob(ctx context.Context, jobID string) (*models.ConversationAnalytics, error) {
+	var analytics models.ConversationAnalytics
+	err := r.db.WithContext(ctx).Where("transcription_id = ?", jobID).First(&analytics).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &analytics, nil
+}
+
+func (r *analyticsRepository) Save(ctx context.Context, analytics *models.ConversationAnalytics) error {
+	return r.db.WithContext(ctx).Save(analytics).Error
+}
+
+// List returns analytics with their transcriptions loaded, oldest
+// transcription first
+func (r *analyticsRepository) List(ctx context.Context, filter AnalyticsFilter) ([]models.ConversationAnalytics, error) {
+	query := r.db.WithContext(ctx).Model(&models.ConversationAnalytics{}).
+		Joins("JOIN transcription_jobs AS j ON j.id = conversation_analytics.transcription_id AND j.deleted_at IS NULL")
+	if filter.UserID != nil {
+		query = query.Where("j.user_id = ?", *filter.UserID)
+	}
+	if filter.From != nil {
+		query = query.Where("j.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("j.created_at < ?", *filter.To)
+	}
+	var items []models.ConversationAnalytics
+	err := query.Preload("Transcription").Order("j.created_at ASC").Find(&items).Error
+	return items, err
+}
+
+func (r *analyticsRepository) DeleteByTranscriptionID(ctx context.Context, transcriptionID string) error {
+	return r.db.WithContext(ctx).Where("transcription_id = ?", transcriptionID).Delete(&models.ConversationAnalytics{}).Error
+}
+
+// TranslationRepository stores the translation tracks of transcripts
+type TranslationRepository interface {
+	FindByJobAndLanguage(ctx context.Context, jobID, language string) (*models.TranscriptTranslation, error)
+	ListByJob(ctx context.Context, jobID string) ([]models.TranscriptTranslation, error)
+	ListUnfinished(ctx context.Context) ([]models.TranscriptTranslation, error)
+	Save(ctx context.Context, translation *models.TranscriptTranslation) error
+	UpdateProgress(ctx context.Context, id string, translated int) error
+	Delete(ctx context.Context, id string) error
+	DeleteByTranscriptionID(ctx context.Context, transcriptionID string) error
+}
+
+type translationRepository struct {
+	db *gorm.DB
+}
+
+func NewTranslationRepository(db *gorm.DB) TranslationRepository {
+	return &translationRepository{db: db}
+}
+
+func (r *translationRepository) FindByJobAndLanguage(ctx context.Context, jobID, language string) (*models.TranscriptTranslation, error) {
+	var translation models.TranscriptTranslation
+	err := r.db.WithContext(ctx).Where("transcription_id = ? AND language = ?", jobID, language).First(&translation).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
+
+// ListByJob lists the translations of a job without their segments
+func (r *translationRepository) ListByJob(ctx context.Context, jobID string) ([]models.TranscriptTranslation, error) {
+	var translations []models.TranscriptTranslation
+	err := r.db.WithContext(ctx).Omit("segments").Where("transcription_id = ?", jobID).Order("language ASC").Find(&translations).Error
+	return translations, err
+}
+
+func (r *translationRepository) ListUnfinished(ctx context.Context) ([]models.TranscriptTranslation, error) {
+	var translations []models.TranscriptTranslation
+	err := r.db.WithContext(ctx).Omit("segments").
+		Where("status IN ?", []string{models.TranslationStatusPending, models.TranslationStatusProcessing}).
+		Order("created_at ASC").Find(&translations).Error
+	return translations, err
+}
+
+func (r *translationRepository) Save(ctx context.Context, translation *models.TranscriptTranslation) error {
+	return r.db.WithContext(ctx).Save(translation).Error
+}
+
+func (r *translationRepository) UpdateProgress(ctx context.Context, id string, translated int) error {
+	return r.db.WithContext(ctx).Model(&models.TranscriptTranslation{}).Where("id = ?", id).Update("translated", translated).Error
+}
+
+func (r *translationRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.TranscriptTranslation{}).Error
+}
+
+func (r *translationRepository) DeleteByTranscriptionID(ctx context.Context, transcriptionID string) error {
+	return r.db.WithContext(ctx).Where("transcription_id = ?", transcriptionID).Delete(&models.TranscriptTranslation{}).Error
+}
+
+// SmartAnalysisRepository stores the corrections proposed for transcripts
+type SmartAnalysisRepository interface {
+	FindByJob(ctx context.Context, jobID string) (*models.SmartAnalysis, error)
+	Save(ctx context.Context, analysis *models.SmartAnalysis) error
+	DeleteByTranscriptionID(ctx context.Context, transcriptionID string) error
+}
+
+type smartAnalysisRepository struct {
+	db *gorm.DB
+}
+
+func NewSmartAnalysisRepository(db *gorm.DB) SmartAnalysisRepository {
+	return &smartAnalysisRepository{db: db}
+}
+
+func (r *smartAnalysisRepository) FindByJob(ctx context.Context, jobID string) (*models.SmartAnalysis, error) {
+	var analysis models.SmartAnalysis
+	err := r.db.WithContext(ctx).Where("transcription_id = ?", jobID).First(&analysis).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &analysis, nil
+}
+
+func (r *smartAnalysisRepository) Save(ctx context.Context, analysis *models.SmartAnalysis) error {
+	return r.db.WithContext(ctx).Save(analysis).Error
+}
+
+func (r *smartAnalysisRepository) DeleteByTranscriptionID(ctx context.Context, transcriptionID string) error {
+	return r.db.WithContext(ctx).Where("transcription_id = ?", transcriptionID).Delete(&models.SmartAnalysis{}).Error
+}
+
+// ReviewRepository stores the human review queues of transcripts
+type ReviewRepository interface {
+	FindByJob(ctx context.Context, jobID string) (*models.Review, error)
+	List(ctx context.Context, filter ReviewFilter) ([]models.Review, error)
+	Save(ctx context.Context, review *models.Review) error
+	DeleteByTranscriptionID(ctx context.Context, transcriptionID string) error
+}
+
+// ReviewFilter selects reviews across transcriptions
+type ReviewFilter struct {
+	UserID     *uint // Reviews of the user's transcriptions or assigned to the user
+	Status     string
+	AssigneeID *uint
+	Unassigned bool
+}
+
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+func (r *reviewRepository) FindByJob(ctx context.Context, jobID string) (*models.Review, error) {
+	var review models.Review
+	err := r.db.WithContext(ctx).Where("transcription_id = ?", jobID).First(&review).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// List lists reviews, oldest first so the queue is worked in order
+func (r *reviewRepository) List(ctx context.Context, filter ReviewFilter) ([]models.Review, error) {
+	query := r.db.WithContext(ctx).Model(&models.Review{}).
+		Joins("JOIN transcription_jobs ON transcription_jobs.id = reviews.transcription_id AND transcription_jobs.deleted_at IS NULL")
+	if filter.UserID != nil {
+		query = query.Where("transcription_jobs.user_id = ? OR reviews.assignee_id = ?", *filter.UserID, *filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("reviews.status = ?", filter.Status)
+	}
+	if filter.AssigneeID != nil {
+		query = query.Where("reviews.assignee_id = ?", *filter.AssigneeID)
+	}
+	if filter.Unassigned {
+		query = query.Where("reviews.assignee_id IS NULL")
+	}
+	var reviews []models.Review
+	err := query.Order("reviews.created_at ASC").Find(&reviews).Error
+	return reviews, err
+}
+
+func (r *reviewRepository) Save(ctx context.Context, review *models.Review) error {
+	return r.db.WithContext(ctx).Save(review).Error
+}
+
+func (r *reviewRepository) DeleteByTranscriptionID(ctx context.Context, transcriptionID string) error {
+	return r.db.WithContext(ctx).Where("transcription_id = ?", transcriptionID).Delete(&models.Review{}).Error
+}
+
 // SpeakerMappingRepository handles speaker mappings
 type SpeakerMappingRepository interface {
 	Repository[models.SpeakerMapping]
@@ -626,3 +1350,191 @@ func (r *refreshTokenRepository) Revoke(ctx context.Context, id uint) error {
 func (r *refreshTokenRepository) RevokeByHash(ctx context.Context, hash string) error {
 	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).Where("hashed = ?", hash).Update("revoked", true).Error
 }
+
+// TranscriptChunkRepository stores transcript chunks and their embeddings
+type TranscriptChunkRepository interface {
+	ListByJob(ctx context.Context, jobID string) ([]models.TranscriptChunk, error)
+	ReplaceForJob(ctx context.Context, jobID string, chunks []models.TranscriptChunk) error
+	DeleteByJobID(ctx context.Context, jobID string) error
+}
+
+type transcriptChunkRepository struct {
+	db *gorm.DB
+}
+
+func NewTranscriptChunkRepository(db *gorm.DB) TranscriptChunkRepository {
+	return &transcriptChunkRepository{db: db}
+}
+
+func (r *transcriptChunkRepository) ListByJob(ctx context.Context, jobID string) ([]models.TranscriptChunk, error) {
+	var chunks []models.TranscriptChunk
+	err := r.db.WithContext(ctx).Where("transcription_job_id = ?", jobID).Order("chunk_index ASC").Find(&chunks).Error
+	return chunks, err
+}
+
+func (r *transcriptChunkRepository) ReplaceForJob(ctx context.Context, jobID string, chunks []models.TranscriptChunk) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("transcription_job_id = ?", jobID).Delete(&models.TranscriptChunk{}).Error; err != nil {
+			return err
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		return tx.Create(&chunks).Error
+	})
+}
+
+func (r *transcriptChunkRepository) DeleteByJobID(ctx context.Context, jobID string) error {
+	return r.db.WithContext(ctx).Where("transcription_job_id = ?", jobID).Delete(&models.TranscriptChunk{}).Error
+}
+
+// UsageRepository stores the usage ledger and the price list
+type UsageRepository interface {
+	Record(ctx context.Context, entry *models.UsageEntry) error
+	ListEntries(ctx context.Context, filter UsageFilter, offset, limit int) ([]models.UsageEntry, int64, error)
+	Report(ctx context.Context, filter UsageFilter, groupBy []string, period string) ([]UsageReportRow, error)
+	FindPrice(ctx context.Context, provider, model string) (*models.ModelPrice, error)
+	ListPrices(ctx context.Context) ([]models.ModelPrice, error)
+	SavePrice(ctx context.Context, price *models.ModelPrice) error
+	DeletePrice(ctx context.Context, id uint) error
+}
+
+// UsageFilter selects usage entries
+type UsageFilter struct {
+	UserID  *uint
+	JobID   string
+	Feature string
+	From    *time.Time // Inclusive
+	To      *time.Time // Exclusive
+}
+
+// Usage report groupings and periods
+var (
+	UsageGroupings = []string{"user", "feature", "provider", "model"}
+	UsagePeriods   = map[string]string{"day": "%Y-%m-%d", "month": "%Y-%m", "year": "%Y"}
+)
+
+// UsageReportRow totals the usage of one group and period
+type UsageReportRow struct {
+	UserID       *uint   `json:"user_id,omitempty"`
+	Feature      string  `json:"feature,omitempty"`
+	Provider     string  `json:"provider,omitempty"`
+	Model        string  `json:"model,omitempty"`
+	Period       string  `json:"period,omitempty"`
+	Calls        int64   `json:"calls"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	AudioSeconds float64 `json:"audio_seconds"`
+	Cost         float64 `json:"cost"`
+}
+
+type usageRepository struct {
+	db *gorm.DB
+}
+
+func NewUsageRepository(db *gorm.DB) UsageRepository {
+	return &usageRepository{db: db}
+}
+
+func (r *usageRepository) Record(ctx context.Context, entry *models.UsageEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *usageRepository) filtered(ctx context.Context, filter UsageFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&models.UsageEntry{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.JobID != "" {
+		query = query.Where("job_id = ?", filter.JobID)
+	}
+	if filter.Feature != "" {
+		query = query.Where("feature = ?", filter.Feature)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
+
+func (r *usageRepository) ListEntries(ctx context.Context, filter UsageFilter, offset, limit int) ([]models.UsageEntry, int64, error) {
+	var total int64
+	if err := r.filtered(ctx, filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var entries []models.UsageEntry
+	err := r.filtered(ctx, filter).Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&entries).Error
+	return entries, total, err
+}
+
+// Report totals the filtered usage by the groupBy columns (see
+// UsageGroupings) and by period (see UsagePeriods), if set
+func (r *usageRepository) Report(ctx context.Context, filter UsageFilter, groupBy []string, period string) ([]UsageReportRow, error) {
+	columns := []string{
+		"COUNT(*) AS calls",
+		"COALESCE(SUM(input_tokens), 0) AS input_tokens",
+		"COALESCE(SUM(output_tokens), 0) AS output_tokens",
+		"COALESCE(SUM(audio_seconds), 0) AS audio_seconds",
+		"COALESCE(SUM(cost), 0) AS cost",
+	}
+	var groups []string
+	for _, g := range groupBy {
+		column := g
+		if g == "user" {
+			column = "user_id"
+		}
+		columns = append(columns, column)
+		groups = append(groups, column)
+	}
+	if format, ok := UsagePeriods[period]; ok {
+		// created_at is stored as text; strftime reads its leading date and time
+		columns = append(columns, fmt.Sprintf("strftime('%s', substr(created_at, 1, 19)) AS period", format))
+		groups = append(groups, "period")
+	}
+
+	query := r.filtered(ctx, filter).Select(strings.Join(columns, ", "))
+	if len(groups) > 0 {
+		query = query.Group(strings.Join(groups, ", ")).Order(strings.Join(groups, ", "))
+	}
+	var rows []UsageReportRow
+	err := query.Scan(&rows).Error
+	return rows, err
+}
+
+// FindPrice returns the price of the model, else the provider's default
+// price, else nil
+func (r *usageRepository) FindPrice(ctx context.Context, provider, model string) (*models.ModelPrice, error) {
+	var prices []models.ModelPrice
+	err := r.db.WithContext(ctx).Where("provider = ? AND (model = ? OR model = '')", provider, model).
+		Order("model DESC").Limit(1).Find(&prices).Error
+	if err != nil || len(prices) == 0 {
+		return nil, err
+	}
+	return &prices[0], nil
+}
+
+func (r *usageRepository) ListPrices(ctx context.Context) ([]models.ModelPrice, error) {
+	var prices []models.ModelPrice
+	err := r.db.WithContext(ctx).Order("provider ASC, model ASC").Find(&prices).Error
+	return prices, err
+}
+
+// SavePrice creates the price or replaces the one of the same provider and model
+func (r *usageRepository) SavePrice(ctx context.Context, price *models.ModelPrice) error {
+	var existing models.ModelPrice
+	err := r.db.WithContext(ctx).Where("provider = ? AND model = ?", price.Provider, price.Model).First(&existing).Error
+	if err == nil {
+		price.ID = existing.ID
+		price.CreatedAt = existing.CreatedAt
+	} else if err != gorm.ErrRecordNotFound {
+		return err
+	}
+	return r.db.WithContext(ctx).Save(price).Error
+}
+
+func (r *usageRepository) DeletePrice(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.ModelPrice{}, id).Error
+}
@@ -0,0 +1,101 @@
+package retrieval
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+)
+
+const (
+	// maxChunkChars is the target text size of a chunk, small enough to keep
+	// embeddings focused and several chunks within any chat context
+	maxChunkChars = 1200
+	// chunkOverlap is the number of segments shared by consecutive chunks so
+	// a statement split across a boundary is retrievable from both sides
+	chunkOverlap = 1
+)
+
+// ChunkSegments groups consecutive segments into windows of about
+// maxChunkChars. The returned chunks carry segment ranges, times and text but
+// no embedding.
+func ChunkSegments(segments []interfaces.TranscriptSegment) []models.TranscriptChunk {
+	var chunks []models.TranscriptChunk
+	start := 0
+	for start < len(segments) {
+		end := start
+		size := 0
+		for end < len(segments) {
+			text := strings.TrimSpace(segments[end].Text)
+			if end > start && size+len(text) > maxChunkChars {
+				break
+			}
+			size += len(text) + 1
+			end++
+		}
+
+		lines := make([]string, 0, end-start)
+		for _, seg := range segments[start:end] {
+			if text := strings.TrimSpace(seg.Text); text != "" {
+				lines = append(lines, text)
+			}
+		}
+		if len(lines) > 0 {
+			chunks = append(chunks, models.TranscriptChunk{
+				ChunkIndex:   len(chunks),
+				StartSegment: start,
+				EndSegment:   end,
+				StartTime:    segments[start].Start,
+				EndTime:      segments[end-1].End,
+				Text:         strings.Join(lines, "\n"),
+			})
+		}
+
+		if end == len(segments) {
+			break
+		}
+		next := end - chunkOverlap
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return chunks
+}
+
+// encodeVector normalizes v to unit length and encodes it as little-endian
+// float32, so similarity is a plain dot product
+func encodeVector(v []float32) []byte {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	norm = math.Sqrt(norm)
+	if norm == 0 {
+		norm = 1
+	}
+
+	buf := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(float64(x)/norm)))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := 0; i < len(a) && i < len(b); i++ {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,43 @@
+package retrieval
+
+import (
+	"strings"
+	"testing"
+
+	"scriberr/internal/transcription/interfaces"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkSegments(t *testing.T) {
+	long := strings.Repeat("word ", 100) // 500 chars
+	segments := []interfaces.TranscriptSegment{
+		{Start: 0, End: 5, Text: long},
+		{Start: 5, End: 10, Text: long},
+		{Start: 10, End: 15, Text: long},
+		{Start: 15, End: 20, Text: " short "},
+	}
+
+	chunks := ChunkSegments(segments)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, 0, chunks[0].StartSegment)
+	assert.Equal(t, 2, chunks[0].EndSegment)
+	assert.Equal(t, 0.0, chunks[0].StartTime)
+	assert.Equal(t, 10.0, chunks[0].EndTime)
+
+	// Consecutive chunks overlap by one segment
+	assert.Equal(t, 1, chunks[1].StartSegment)
+	assert.Equal(t, 4, chunks[1].EndSegment)
+	assert.Equal(t, 1, chunks[1].ChunkIndex)
+	assert.True(t, strings.HasSuffix(chunks[1].Text, "\nshort"))
+
+	assert.Empty(t, ChunkSegments(nil))
+}
+
+func TestVectorEncodingIsNormalized(t *testing.T) {
+	v := decodeVector(encodeVector([]float32{3, 4}))
+	assert.InDelta(t, 0.6, v[0], 1e-6)
+	assert.InDelta(t, 0.8, v[1], 1e-6)
+	assert.InDelta(t, 1.0, dot(v, v), 1e-6)
+}
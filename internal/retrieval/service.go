@@ -0,0 +1,250 @@
+// Package retrieval embeds windows of transcript segments and retrieves the
+// ones most relevant to a question, so chat can work with transcripts longer
+// than the model's context window.
+package retrieval
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"scriberr/internal/llm"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/internal/transcription/interfaces"
+	"scriberr/pkg/logger"
+)
+
+// Hit is a chunk retrieved for a query with its cosine similarity
+type Hit struct {
+	Chunk models.TranscriptChunk
+	Score float64
+}
+
+// Service maintains the vector index of transcript chunks, stored in SQLite
+// next to the job, using the embedding model routed for the job's owner
+type Service struct {
+	jobRepo   repository.JobRepository
+	chunkRepo repository.TranscriptChunkRepository
+	router    *llm.Router
+
+	mu       sync.Mutex
+	inflight map[string]*indexing
+}
+
+type indexing struct {
+	done   chan struct{}
+	chunks []models.TranscriptChunk
+	err    error
+}
+
+// NewService creates a retrieval service
+func NewService(jobRepo repository.JobRepository, chunkRepo repository.TranscriptChunkRepository, router *llm.Router) *Service {
+	return &Service{
+		jobRepo:   jobRepo,
+		chunkRepo: chunkRepo,
+		router:    router,
+		inflight:  make(map[string]*indexing),
+	}
+}
+
+// Schedule indexes a job's transcript in the background. It is called when a
+// job completes; failures (e.g. no LLM configured) are logged and the index is
+// built on demand later.
+func (s *Service) Schedule(jobID string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+
+		job, err := s.jobRepo.FindByID(ctx, jobID)
+		if err != nil || job == nil {
+			return
+		}
+		if _, err := s.Index(ctx, job); err != nil {
+			logger.Warn("Transcript indexing failed", "job_id", jobID, "error", err)
+		}
+	}()
+}
+
+// Index returns the job's chunks, embedding the transcript first if it has no
+// index yet or the index was built from another transcript or from a model
+// that is no longer routed for embeddings
+func (s *Service) Index(ctx context.Context, job *models.TranscriptionJob) ([]models.TranscriptChunk, error) {
+	if job.Transcript == nil || *job.Transcript == "" {
+		return nil, fmt.Errorf("job has no transcript")
+	}
+	embedders, err := s.embedders(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := transcriptHash(*job.Transcript)
+	existing, err := s.chunkRepo.ListByJob(ctx, job.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 && existing[0].TranscriptHash == hash && findEmbedder(embedders, existing[0].Model) != nil {
+		return existing, nil
+	}
+
+	// Share the work between concurrent callers for the same job
+	key := job.ID + "|" + hash + "|" + embedders[0].model
+	s.mu.Lock()
+	if ix, ok := s.inflight[key]; ok {
+		s.mu.Unlock()
+		select {
+		case <-ix.done:
+			return ix.chunks, ix.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	ix := &indexing{done: make(chan struct{})}
+	s.inflight[key] = ix
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.inflight, key)
+		s.mu.Unlock()
+		close(ix.done)
+	}()
+
+	// Fall back to the next embedding model if one fails
+	for _, e := range embedders {
+		ix.chunks, ix.err = s.build(ctx, job.ID, *job.Transcript, hash, e)
+		if ix.err == nil {
+			break
+		}
+		logger.Warn("Transcript embedding failed", "job_id", job.ID, "model", e.model, "error", ix.err)
+	}
+	return ix.chunks, ix.err
+}
+
+func (s *Service) build(ctx context.Context, jobID, transcript, hash string, e embedder) ([]models.TranscriptChunk, error) {
+	model := e.model
+	var result interfaces.TranscriptResult
+	if err := json.Unmarshal([]byte(transcript), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse transcript: %w", err)
+	}
+
+	start := time.Now()
+	chunks := ChunkSegments(result.Segments)
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("transcript has no text")
+	}
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.Text
+	}
+	vectors, err := e.embed(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed transcript: %w", err)
+	}
+	if len(vectors) != len(chunks) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(chunks), len(vectors))
+	}
+
+	for i := range chunks {
+		chunks[i].TranscriptionJobID = jobID
+		chunks[i].Embedding = encodeVector(vectors[i])
+		chunks[i].Dimensions = len(vectors[i])
+		chunks[i].Model = model
+		chunks[i].TranscriptHash = hash
+	}
+	if err := s.chunkRepo.ReplaceForJob(ctx, jobID, chunks); err != nil {
+		return nil, fmt.Errorf("failed to store transcript index: %w", err)
+	}
+
+	logger.Info("Indexed transcript", "job_id", jobID, "chunks", len(chunks), "model", model, "duration", time.Since(start))
+	return chunks, nil
+}
+
+// Search returns the k chunks of the job's transcript most similar to query,
+// best first
+func (s *Service) Search(ctx context.Context, job *models.TranscriptionJob, query string, k int) ([]Hit, error) {
+	chunks, err := s.Index(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	// The query must be embedded by the model the index was built with
+	embedders, err := s.embedders(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	e := findEmbedder(embedders, chunks[0].Model)
+	if e == nil {
+		return nil, fmt.Errorf("embedding model %s is no longer routed", chunks[0].Model)
+	}
+	vectors, err := e.embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("expected 1 embedding, got %d", len(vectors))
+	}
+	q := decodeVector(encodeVector(vectors[0]))
+
+	hits := make([]Hit, 0, len(chunks))
+	for _, c := range chunks {
+		hits = append(hits, Hit{Chunk: c, Score: dot(q, decodeVector(c.Embedding))})
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if k > 0 && len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits, nil
+}
+
+// Delete removes a job's index
+func (s *Service) Delete(ctx context.Context, jobID string) error {
+	return s.chunkRepo.DeleteByJobID(ctx, jobID)
+}
+
+// embedder is a routed target and the embedding model to use with it
+type embedder struct {
+	route  *llm.Route
+	target llm.Target
+	model  string
+}
+
+func (e *embedder) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.target.Service.Embed(e.route.Track(ctx, e.target), e.model, texts)
+}
+
+// embedders returns the embedding models routed for a job's owner, preferred
+// first. Targets without a model use the embedding model of their config.
+func (s *Service) embedders(ctx context.Context, job *models.TranscriptionJob) ([]embedder, error) {
+	route, err := s.router.Resolve(ctx, models.LLMFeatureEmbeddings, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+	route = route.ForJob(job.ID)
+	embedders := make([]embedder, len(route.Targets))
+	for i, t := range route.Targets {
+		if t.Model == "" {
+			t.Model = llm.EmbeddingModel(t.Config)
+		}
+		embedders[i] = embedder{route: route, target: t, model: t.Model}
+	}
+	return embedders, nil
+}
+
+func findEmbedder(embedders []embedder, model string) *embedder {
+	for i := range embedders {
+		if embedders[i].model == model {
+			return &embedders[i]
+		}
+	}
+	return nil
+}
+
+func transcriptHash(transcript string) string {
+	sum := sha256.Sum256([]byte(transcript))
+	return hex.EncodeToString(sum[:])
+}
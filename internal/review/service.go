@@ -0,0 +1,319 @@
+// Package review queues the weak parts of transcripts for a person to check:
+// segments the recognizer was unsure of and segments the quality check
+// flagged. A reviewer claims a job's queue, accepts or corrects each segment,
+// and completes the review, which marks the job as reviewed.
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"scriberr/internal/export"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/internal/transcription/interfaces"
+	"scriberr/pkg/logger"
+)
+
+// DefaultThreshold is the confidence below which segments are queued when no
+// threshold is given
+const DefaultThreshold = 0.6
+
+var (
+	// ErrNothingToReview is returned when no segment needs review
+	ErrNothingToReview = errors.New("no segment needs review")
+	// ErrClaimed is returned when someone else reviews the job
+	ErrClaimed = errors.New("the review is assigned to another reviewer")
+	// ErrCompleted is returned when changing a completed review
+	ErrCompleted = errors.New("the review is completed")
+	// ErrIncomplete is returned when completing a review with pending segments
+	ErrIncomplete = errors.New("segments are still pending review")
+	// ErrTranscriptChanged is returned when a corrected segment no longer
+	// matches the transcript
+	ErrTranscriptChanged = errors.New("the transcript changed since it was queued for review")
+)
+
+// Decision is a reviewer's verdict on a queued segment: the text is accepted
+// as is, or replaced by Text
+type Decision struct {
+	Segment int     `json:"segment"`
+	Text    *string `json:"text,omitempty"`
+}
+
+// Service builds and works review queues
+type Service struct {
+	jobRepo    repository.JobRepository
+	reviewRepo repository.ReviewRepository
+}
+
+// NewService creates a review service
+func NewService(jobRepo repository.JobRepository, reviewRepo repository.ReviewRepository) *Service {
+	return &Service{
+		jobRepo:    jobRepo,
+		reviewRepo: reviewRepo,
+	}
+}
+
+// Queue queues a completed job's flagged segments, and its low-confidence
+// segments when the job sets a review threshold. It is called when a job
+// completes; failures are logged and the queue can be built on demand later.
+func (s *Service) Queue(jobID string) {
+	ctx := context.Background()
+	job, err := s.jobRepo.FindByID(ctx, jobID)
+	if err != nil || job == nil || job.Transcript == nil {
+		return
+	}
+	// Without a threshold no confidence is low enough to be queued
+	threshold := 0.0
+	if job.Parameters.ReviewThreshold != nil {
+		threshold = *job.Parameters.ReviewThreshold
+	}
+	if _, err := s.Create(ctx, job, threshold); err != nil && !errors.Is(err, ErrNothingToReview) {
+		logger.Warn("Failed to queue review", "job_id", jobID, "error", err)
+	}
+}
+
+// Create queues the segments of a job's transcript below threshold or
+// flagged by the quality check, replacing its earlier review
+func (s *Service) Create(ctx context.Context, job *models.TranscriptionJob, threshold float64) (*models.Review, error) {
+	if job.Transcript == nil || *job.Transcript == "" {
+		return nil, fmt.Errorf("transcription has no transcript")
+	}
+	result, err := export.DecodeTranscript(*job.Transcript)
+	if err != nil {
+		return nil, err
+	}
+	items := Collect(result, threshold)
+	if len(items) == 0 {
+		return nil, ErrNothingToReview
+	}
+	review := &models.Review{
+		TranscriptionID: job.ID,
+		Status:          models.ReviewStatusPending,
+		Threshold:       threshold,
+		Items:           items,
+	}
+	if err := s.save(ctx, review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// Get returns the review of a job, or nil
+func (s *Service) Get(ctx context.Context, jobID string) (*models.Review, error) {
+	return s.reviewRepo.FindByJob(ctx, jobID)
+}
+
+// List lists reviews across transcriptions
+func (s *Service) List(ctx context.Context, filter repository.ReviewFilter) ([]models.Review, error) {
+	return s.reviewRepo.List(ctx, filter)
+}
+
+// Delete removes the review of a job
+func (s *Service) Delete(ctx context.Context, jobID string) error {
+	if err := s.reviewRepo.DeleteByTranscriptionID(ctx, jobID); err != nil {
+		return err
+	}
+	return s.jobRepo.UpdateReviewStatus(ctx, jobID, "")
+}
+
+// Claim assigns an unassigned review to userID
+func (s *Service) Claim(ctx context.Context, review *models.Review, userID uint) error {
+	if review.AssigneeID != nil && *review.AssigneeID != userID {
+		return ErrClaimed
+	}
+	return s.Assign(ctx, review, &userID)
+}
+
+// Assign hands a review to a reviewer, or back to the queue when assignee is
+// nil
+func (s *Service) Assign(ctx context.Context, review *models.Review, assignee *uint) error {
+	if review.Status == models.ReviewStatusReviewed {
+		return ErrCompleted
+	}
+	if assignee == nil {
+		review.AssigneeID, review.AssignedAt = nil, nil
+	} else if review.AssigneeID == nil || *review.AssigneeID != *assignee {
+		now := time.Now()
+		id := *assignee
+		review.AssigneeID, review.AssignedAt = &id, &now
+	}
+	return s.save(ctx, review)
+}
+
+// Decide records a reviewer's decisions and applies the corrections to the
+// transcript. Nothing is applied if a corrected segment was edited since it
+// was queued. An unassigned review is claimed by the reviewer. Callers check
+// the decisions are about queued segments.
+func (s *Service) Decide(ctx context.Context, job *models.TranscriptionJob, review *models.Review, userID uint, decisions []Decision) error {
+	if review.Status == models.ReviewStatusReviewed {
+		return ErrCompleted
+	}
+	if review.AssigneeID != nil && *review.AssigneeID != userID {
+		return ErrClaimed
+	}
+	byIndex := make(map[int]Decision, len(decisions))
+	for _, d := range decisions {
+		byIndex[d.Segment] = d
+	}
+
+	var result *interfaces.TranscriptResult
+	now := time.Now()
+	for i := range review.Items {
+		item := &review.Items[i]
+		d, ok := byIndex[item.Segment]
+		if !ok {
+			continue
+		}
+		current := item.Text
+		if item.Correction != nil {
+			current = *item.Correction
+		}
+		proposed := item.Text
+		if d.Text != nil {
+			proposed = strings.Join(strings.Fields(*d.Text), " ")
+		}
+		if proposed != current {
+			if result == nil {
+				if job.Transcript == nil || *job.Transcript == "" {
+					return fmt.Errorf("transcription has no transcript")
+				}
+				var err error
+				if result, err = export.DecodeTranscript(*job.Transcript); err != nil {
+					return err
+				}
+			}
+			if item.Segment >= len(result.Segments) || strings.TrimSpace(result.Segments[item.Segment].Text) != current {
+				return ErrTranscriptChanged
+			}
+			result.Segments[item.Segment].Text = proposed
+		}
+		if proposed == item.Text {
+			item.Status, item.Correction = models.ReviewItemAccepted, nil
+		} else {
+			item.Status, item.Correction = models.ReviewItemCorrected, &proposed
+		}
+		reviewer := userID
+		item.ReviewedBy, item.ReviewedAt = &reviewer, &now
+	}
+
+	if result != nil {
+		texts := make([]string, 0, len(result.Segments))
+		for _, seg := range result.Segments {
+			if text := strings.TrimSpace(seg.Text); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		result.Text = strings.Join(texts, " ")
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		if err := s.jobRepo.UpdateTranscript(ctx, job.ID, string(data)); err != nil {
+			return fmt.Errorf("failed to update transcript: %w", err)
+		}
+		transcript := string(data)
+		job.Transcript = &transcript
+	}
+	if review.AssigneeID == nil {
+		reviewer := userID
+		review.AssigneeID, review.AssignedAt = &reviewer, &now
+	}
+	return s.save(ctx, review)
+}
+
+// Complete closes a review once every segment is decided and marks the job
+// as reviewed
+func (s *Service) Complete(ctx context.Context, review *models.Review, userID uint) error {
+	if review.Status == models.ReviewStatusReviewed {
+		return ErrCompleted
+	}
+	if review.AssigneeID != nil && *review.AssigneeID != userID {
+		return ErrClaimed
+	}
+	for _, item := range review.Items {
+		if item.Status == models.ReviewItemPending {
+			return ErrIncomplete
+		}
+	}
+	now := time.Now()
+	review.Status = models.ReviewStatusReviewed
+	review.CompletedBy, review.CompletedAt = &userID, &now
+	return s.save(ctx, review)
+}
+
+// save stores a review with its progress and mirrors its state on the job
+func (s *Service) save(ctx context.Context, review *models.Review) error {
+	review.Total, review.Reviewed = len(review.Items), 0
+	for _, item := range review.Items {
+		if item.Status != models.ReviewItemPending {
+			review.Reviewed++
+		}
+	}
+	if review.Status != models.ReviewStatusReviewed {
+		review.Status = models.ReviewStatusPending
+		if review.AssigneeID != nil {
+			review.Status = models.ReviewStatusInReview
+		}
+	}
+	if err := s.reviewRepo.Save(ctx, review); err != nil {
+		return fmt.Errorf("failed to save review: %w", err)
+	}
+	if err := s.jobRepo.UpdateReviewStatus(ctx, review.TranscriptionID, review.Status); err != nil {
+		return fmt.Errorf("failed to update review status: %w", err)
+	}
+	return nil
+}
+
+// Collect returns the segments of a transcript below threshold or flagged by
+// the quality check
+func Collect(result *interfaces.TranscriptResult, threshold float64) []models.ReviewItem {
+	var items []models.ReviewItem
+	for i, seg := range result.Segments {
+		text := strings.TrimSpace(seg.Text)
+		if text == "" {
+			continue
+		}
+		confidence := Confidence(seg, result.WordSegments)
+		if len(seg.QualityFlags) == 0 && (confidence == nil || *confidence >= threshold) {
+			continue
+		}
+		items = append(items, models.ReviewItem{
+			Segment:    i,
+			Start:      seg.Start,
+			End:        seg.End,
+			Speaker:    seg.Speaker,
+			Text:       text,
+			Confidence: confidence,
+			Flags:      seg.QualityFlags,
+			Status:     models.ReviewItemPending,
+		})
+	}
+	return items
+}
+
+// Confidence returns the mean score of the aligned words of a segment, else
+// the probability of its tokens, or nil when the model reports neither
+func Confidence(seg interfaces.TranscriptSegment, words []interfaces.TranscriptWord) *float64 {
+	sum, n := 0.0, 0
+	for _, w := range words {
+		if w.Score > 0 && w.Start >= seg.Start && w.End <= seg.End {
+			sum += w.Score
+			n++
+		}
+	}
+	if n > 0 {
+		confidence := sum / float64(n)
+		return &confidence
+	}
+	if seg.AvgLogprob != nil {
+		confidence := math.Exp(*seg.AvgLogprob)
+		return &confidence
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package review
+
+import (
+	"testing"
+
+	"scriberr/internal/transcription/interfaces"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func floatPtr(f float64) *float64 { return &f }
+
+func TestCollect(t *testing.T) {
+	result := &interfaces.TranscriptResult{
+		Segments: []interfaces.TranscriptSegment{
+			{Start: 0, End: 2, Text: "Clear speech."},
+			{Start: 2, End: 4, Text: "mumbled words"},
+			{Start: 4, End: 6, Text: "Unaligned but unsure.", AvgLogprob: floatPtr(-1.2)},
+			{Start: 6, End: 8, Text: "No signals at all."},
+			{Start: 8, End: 9, Text: "Thanks for watching!", QualityFlags: []string{"silence_hallucination"}},
+			{Start: 9, End: 10, Text: "  "},
+		},
+		WordSegments: []interfaces.TranscriptWord{
+			{Start: 0, End: 1, Word: "Clear", Score: 0.9},
+			{Start: 1, End: 2, Word: "speech.", Score: 0.8},
+			{Start: 2, End: 3, Word: "mumbled", Score: 0.3},
+			{Start: 3, End: 4, Word: "words", Score: 0.5},
+			{Start: 8, End: 9, Word: "Thanks", Score: 0.99},
+		},
+	}
+
+	items := Collect(result, DefaultThreshold)
+	require.Len(t, items, 3)
+	assert.Equal(t, 1, items[0].Segment)
+	assert.InDelta(t, 0.4, *items[0].Confidence, 0.001)
+	assert.Equal(t, 2, items[1].Segment)
+	assert.InDelta(t, 0.301, *items[1].Confidence, 0.001)
+	assert.Equal(t, 4, items[2].Segment)
+	assert.Equal(t, []string{"silence_hallucination"}, items[2].Flags)
+
+	// Flagged segments are queued whatever the threshold
+	items = Collect(result, 0)
+	require.Len(t, items, 1)
+	assert.Equal(t, 4, items[0].Segment)
+
+	assert.Nil(t, Confidence(result.Segments[3], result.WordSegments))
+}
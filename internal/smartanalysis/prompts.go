@@ -0,0 +1,96 @@
+package smartanalysis
+
+import (
+	"strings"
+
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+	"scriberr/internal/translate"
+)
+
+// defaultPrompt proofreads transcripts in any language. Prompts fill in
+// {{title}}, {{language}} and {{guidance}}, the advice for the transcript's
+// language.
+const defaultPrompt = "Recording title: {{title}}\n\n" +
+	"Act as a professional proofreader of an automatic transcript in {{language}}. " +
+	"Each numbered line below is one segment, prefixed with its speaker label when it has one.\n" +
+	"1. Fix words the recognizer misheard, spelling, grammar slips, capitalization and punctuation, keeping the speaker's wording, meaning and tone. " +
+	"Do not rephrase, summarize, merge or split lines.\n" +
+	"2. If a line is clearly attributed to the wrong speaker, or has no speaker, give the right label, reusing the labels of the transcript." +
+	"{{guidance}}"
+
+// answerInstructions follow every prompt, so custom prompts only describe
+// the corrections
+const answerInstructions = "List only the lines you changed, each with its number, the full corrected text, " +
+	"and its speaker label if the speaker changed (otherwise an empty string). List none if nothing needs fixing."
+
+const schema = `{
+	"type": "object",
+	"properties": {
+		"changes": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"n": {"type": "integer"},
+					"text": {"type": "string"},
+					"speaker": {"type": "string"}
+				},
+				"required": ["n", "text", "speaker"],
+				"additionalProperties": false
+			}
+		}
+	},
+	"required": ["changes"],
+	"additionalProperties": false
+}`
+
+// languageGuidance holds the common recognition errors of languages, by
+// primary language subtag
+var languageGuidance = map[string]string{
+	"vi": "Restore missing or wrong Vietnamese diacritics and fix words confused with similar-sounding ones (e.g. 'phép tạp' -> 'phức tạp').",
+	"en": "Fix homophones the recognizer confused (e.g. 'their' and 'there') and spell names consistently.",
+	"de": "Capitalize nouns and join compound words the recognizer split.",
+	"fr": "Restore accents and elisions, and use French punctuation spacing.",
+	"es": "Restore accents and add the opening ¿ and ¡ marks.",
+	"pt": "Restore accents and cedillas.",
+	"ja": "Fix kanji chosen for the wrong homophone and use Japanese punctuation (、。).",
+	"zh": "Fix characters chosen for the wrong homophone and use full-width Chinese punctuation.",
+	"ko": "Fix spacing between words and words confused with similar-sounding ones.",
+}
+
+// transcriptLanguage returns the language code a job's transcript is
+// proofread in: the requested language, else the detected one
+func transcriptLanguage(job *models.TranscriptionJob, result *interfaces.TranscriptResult) string {
+	for _, code := range []*string{job.Parameters.Language, &result.Language} {
+		if code == nil || *code == "" || *code == "auto" {
+			continue
+		}
+		if language, ok := translate.NormalizeLanguage(*code); ok {
+			return language
+		}
+	}
+	return ""
+}
+
+// buildPrompt fills in a prompt template, the job's own or the default one
+func buildPrompt(job *models.TranscriptionJob, language string) string {
+	template := defaultPrompt
+	if job.Parameters.SmartAnalysisPrompt != nil && strings.TrimSpace(*job.Parameters.SmartAnalysisPrompt) != "" {
+		template = *job.Parameters.SmartAnalysisPrompt
+	}
+	title := "Untitled"
+	if job.Title != nil && *job.Title != "" {
+		title = *job.Title
+	}
+	name := "its original language"
+	guidance := ""
+	if language != "" {
+		name = translate.LanguageName(language)
+		primary, _, _ := strings.Cut(language, "-")
+		if g, ok := languageGuidance[primary]; ok {
+			guidance = "\n3. " + g
+		}
+	}
+	return strings.NewReplacer("{{title}}", title, "{{language}}", name, "{{guidance}}", guidance).Replace(template)
+}
@@ -0,0 +1,331 @@
+// Package smartanalysis proofreads transcripts with the smart analysis LLM
+// route: it fixes misheard words, punctuation and speaker labels. The
+// corrections are proposed segment by segment and only change the transcript
+// once they are reviewed and accepted.
+package smartanalysis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"scriberr/internal/export"
+	"scriberr/internal/llm"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/internal/summarize"
+	"scriberr/internal/transcription/interfaces"
+	"scriberr/pkg/logger"
+)
+
+const (
+	// maxBatchLines bounds the segments proofread in one call
+	maxBatchLines = 60
+	// contextLines is the number of lines of the previous batch shown with
+	// each batch, so speaker labels stay consistent across batches
+	contextLines = 3
+)
+
+// ErrTranscriptChanged is returned when accepted changes no longer match the
+// transcript they were proposed for
+var ErrTranscriptChanged = errors.New("the transcript changed since it was analyzed")
+
+// Service proposes and applies transcript corrections
+type Service struct {
+	jobRepo      repository.JobRepository
+	analysisRepo repository.SmartAnalysisRepository
+	summaryRepo  repository.SummaryRepository
+	router       *llm.Router
+}
+
+// NewService creates a smart analysis service
+func NewService(jobRepo repository.JobRepository, analysisRepo repository.SmartAnalysisRepository, summaryRepo repository.SummaryRepository, router *llm.Router) *Service {
+	return &Service{
+		jobRepo:      jobRepo,
+		analysisRepo: analysisRepo,
+		summaryRepo:  summaryRepo,
+		router:       router,
+	}
+}
+
+// Analyze proposes corrections of a job's fresh transcript. It is the smart
+// analysis stage of the transcription pipeline: jobs skipping the stage and
+// owners without a model routed for smart analysis are left alone.
+func (s *Service) Analyze(ctx context.Context, job *models.TranscriptionJob, result *interfaces.TranscriptResult) error {
+	if job.Parameters.SkipSmartAnalysis || s.router == nil || len(result.Segments) == 0 {
+		return nil
+	}
+	route, err := s.router.Resolve(ctx, models.LLMFeatureSmartAnalysis, job.UserID)
+	if err != nil || route.Primary().Model == "" {
+		return nil
+	}
+	_, err = s.run(ctx, job, result, route.ForJob(job.ID))
+	return err
+}
+
+// Run analyzes a job's stored transcript on demand, replacing its earlier
+// analysis. model defaults to the route's model, then the default summary
+// model.
+func (s *Service) Run(ctx context.Context, job *models.TranscriptionJob, model string) (*models.SmartAnalysis, error) {
+	if job.Transcript == nil || *job.Transcript == "" {
+		return nil, fmt.Errorf("transcription has no transcript")
+	}
+	result, err := export.DecodeTranscript(*job.Transcript)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Segments) == 0 {
+		return nil, fmt.Errorf("transcript has no segments")
+	}
+	route, err := s.route(ctx, job, model)
+	if err != nil {
+		return nil, err
+	}
+	return s.run(ctx, job, result, route)
+}
+
+// Get returns the analysis of a job, or nil
+func (s *Service) Get(ctx context.Context, jobID string) (*models.SmartAnalysis, error) {
+	return s.analysisRepo.FindByJob(ctx, jobID)
+}
+
+// Delete removes the analysis of a job
+func (s *Service) Delete(ctx context.Context, jobID string) error {
+	return s.analysisRepo.DeleteByTranscriptionID(ctx, jobID)
+}
+
+// Review accepts and rejects proposed changes, by segment index, and applies
+// the accepted ones to the transcript. Nothing is applied if a segment to
+// change was edited since the analysis. Callers check the decisions are
+// about pending or rejected changes of the analysis.
+func (s *Service) Review(ctx context.Context, job *models.TranscriptionJob, analysis *models.SmartAnalysis, accept, reject []int) error {
+	decisions := make(map[int]string, len(accept)+len(reject))
+	for _, i := range reject {
+		decisions[i] = models.ChangeStatusRejected
+	}
+	for _, i := range accept {
+		decisions[i] = models.ChangeStatusAccepted
+	}
+
+	var result *interfaces.TranscriptResult
+	if len(accept) > 0 {
+		if job.Transcript == nil || *job.Transcript == "" {
+			return fmt.Errorf("transcription has no transcript")
+		}
+		var err error
+		if result, err = export.DecodeTranscript(*job.Transcript); err != nil {
+			return err
+		}
+	}
+	for i := range analysis.Changes {
+		change := &analysis.Changes[i]
+		status, ok := decisions[change.Segment]
+		if !ok {
+			continue
+		}
+		if status == models.ChangeStatusAccepted {
+			if change.Segment >= len(result.Segments) || strings.TrimSpace(result.Segments[change.Segment].Text) != change.OriginalText {
+				return ErrTranscriptChanged
+			}
+			seg := &result.Segments[change.Segment]
+			seg.Text = change.ProposedText
+			if change.ProposedSpeaker != nil {
+				speaker := *change.ProposedSpeaker
+				seg.Speaker = &speaker
+			}
+		}
+		change.Status = status
+	}
+
+	if result != nil {
+		texts := make([]string, 0, len(result.Segments))
+		for _, seg := range result.Segments {
+			if text := strings.TrimSpace(seg.Text); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		result.Text = strings.Join(texts, " ")
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		if err := s.jobRepo.UpdateTranscript(ctx, job.ID, string(data)); err != nil {
+			return fmt.Errorf("failed to update transcript: %w", err)
+		}
+		transcript := string(data)
+		job.Transcript = &transcript
+	}
+	if err := s.analysisRepo.Save(ctx, analysis); err != nil {
+		return fmt.Errorf("failed to save smart analysis: %w", err)
+	}
+	return nil
+}
+
+// run analyzes result and stores the outcome, failed or not
+func (s *Service) run(ctx context.Context, job *models.TranscriptionJob, result *interfaces.TranscriptResult, route *llm.Route) (*models.SmartAnalysis, error) {
+	start := time.Now()
+	language := transcriptLanguage(job, result)
+	analysis := &models.SmartAnalysis{
+		TranscriptionID: job.ID,
+		Status:          models.SmartAnalysisStatusCompleted,
+		Language:        language,
+		Model:           route.Primary().Model,
+		Segments:        len(result.Segments),
+	}
+	changes, err := propose(ctx, route, buildPrompt(job, language), result.Segments)
+	if err != nil {
+		msg := err.Error()
+		analysis.Status = models.SmartAnalysisStatusFailed
+		analysis.Error = &msg
+	} else {
+		analysis.Changes = changes
+	}
+	if err := s.analysisRepo.Save(ctx, analysis); err != nil {
+		return nil, fmt.Errorf("failed to save smart analysis: %w", err)
+	}
+	if err != nil {
+		return analysis, err
+	}
+	logger.Info("Proposed smart analysis changes", "job_id", job.ID, "changes", len(changes), "language", language,
+		"model", analysis.Model, "segments", len(result.Segments), "duration_ms", time.Since(start).Milliseconds())
+	return analysis, nil
+}
+
+// route resolves the model that proofreads
+func (s *Service) route(ctx context.Context, job *models.TranscriptionJob, model string) (*llm.Route, error) {
+	if s.router == nil {
+		return nil, fmt.Errorf("no LLM configured")
+	}
+	route, err := s.router.Resolve(ctx, models.LLMFeatureSmartAnalysis, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if model == "" && route.Primary().Model == "" {
+		if settings, err := s.summaryRepo.GetSettings(ctx); err == nil {
+			model = settings.DefaultModel
+		}
+	}
+	route = route.WithModel(model).ForJob(job.ID)
+	if route.Primary().Model == "" {
+		return nil, fmt.Errorf("no model configured for smart analysis")
+	}
+	return route, nil
+}
+
+// propose proofreads the whole transcript in batches and returns the changed
+// segments
+func propose(ctx context.Context, route *llm.Route, prompt string, segments []interfaces.TranscriptSegment) ([]models.SegmentChange, error) {
+	contextWindow := route.ContextWindow(ctx)
+	parsed, err := summarize.ParseSchema([]byte(schema))
+	if err != nil {
+		return nil, err
+	}
+	summarizer := summarize.New(route, contextWindow)
+	// The answer may repeat every line, so each batch gets at most a third
+	// of the window
+	budget := contextWindow / 3 * 4 // In characters, at 4 per token
+
+	lines := make([]string, len(segments))
+	for i, seg := range segments {
+		text := strings.Join(strings.Fields(seg.Text), " ")
+		if seg.Speaker != nil && *seg.Speaker != "" {
+			text = *seg.Speaker + ": " + text
+		}
+		lines[i] = fmt.Sprintf("[%d] %s", i, text)
+	}
+
+	var changes []models.SegmentChange
+	for _, batch := range batches(segments, lines, budget) {
+		content := prompt
+		if first := batch[0]; first > 0 {
+			content += "\n\nPrevious lines, for context only:\n" + strings.Join(lines[max(first-contextLines, 0):first], "\n")
+		}
+		content += "\n\n" + answerInstructions + "\n\nLines to proofread:"
+		batchLines := make([]string, len(batch))
+		for i, n := range batch {
+			batchLines[i] = lines[n]
+		}
+		raw, err := summarizer.Structured(ctx, content, parsed, batchLines, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze transcript: %w", err)
+		}
+		var answer struct {
+			Changes []struct {
+				N       int    `json:"n"`
+				Text    string `json:"text"`
+				Speaker string `json:"speaker"`
+			} `json:"changes"`
+		}
+		if err := json.Unmarshal(raw, &answer); err != nil {
+			return nil, fmt.Errorf("failed to parse smart analysis: %w", err)
+		}
+		inBatch := make(map[int]bool, len(batch))
+		for _, n := range batch {
+			inBatch[n] = true
+		}
+		for _, c := range answer.Changes {
+			if !inBatch[c.N] {
+				continue
+			}
+			delete(inBatch, c.N)
+			if change, ok := diff(c.N, segments[c.N], c.Text, c.Speaker); ok {
+				changes = append(changes, change)
+			}
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Segment < changes[j].Segment })
+	return changes, nil
+}
+
+// diff returns the change of a segment to the proposed text and speaker, if
+// they differ from its own. Whitespace differences are ignored.
+func diff(n int, seg interfaces.TranscriptSegment, text, speaker string) (models.SegmentChange, bool) {
+	original := strings.TrimSpace(seg.Text)
+	proposed := strings.Join(strings.Fields(text), " ")
+	if proposed == "" {
+		proposed = original
+	}
+	change := models.SegmentChange{
+		Segment:         n,
+		Start:           seg.Start,
+		End:             seg.End,
+		OriginalText:    original,
+		ProposedText:    proposed,
+		OriginalSpeaker: seg.Speaker,
+		Status:          models.ChangeStatusPending,
+	}
+	if speaker = strings.TrimSpace(speaker); speaker != "" && (seg.Speaker == nil || *seg.Speaker != speaker) {
+		change.ProposedSpeaker = &speaker
+	}
+	changed := proposed != strings.Join(strings.Fields(original), " ") || change.ProposedSpeaker != nil
+	return change, changed
+}
+
+// batches groups the indexes of non-empty segments into consecutive batches
+// of at most maxBatchLines lines and budget characters; a longer line is
+// batched alone
+func batches(segments []interfaces.TranscriptSegment, lines []string, budget int) [][]int {
+	var out [][]int
+	var current []int
+	size := 0
+	for i, seg := range segments {
+		if strings.TrimSpace(seg.Text) == "" {
+			continue
+		}
+		n := len(lines[i]) + 1
+		if len(current) > 0 && (size+n > budget || len(current) == maxBatchLines) {
+			out = append(out, current)
+			current, size = nil, 0
+		}
+		current = append(current, i)
+		size += n
+	}
+	if len(current) > 0 {
+		out = append(out, current)
+	}
+	return out
+}
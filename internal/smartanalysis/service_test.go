@@ -0,0 +1,77 @@
+package smartanalysis
+
+import (
+	"testing"
+
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestBuildPrompt(t *testing.T) {
+	job := &models.TranscriptionJob{Title: strPtr("Standup")}
+	result := &interfaces.TranscriptResult{Language: "vi"}
+
+	language := transcriptLanguage(job, result)
+	assert.Equal(t, "vi", language)
+	prompt := buildPrompt(job, language)
+	assert.Contains(t, prompt, "Recording title: Standup")
+	assert.Contains(t, prompt, "transcript in Vietnamese")
+	assert.Contains(t, prompt, "diacritics")
+
+	// The requested language wins over the detected one
+	job.Parameters.Language = strPtr("EN")
+	language = transcriptLanguage(job, result)
+	assert.Equal(t, "en", language)
+	assert.NotContains(t, buildPrompt(job, language), "diacritics")
+
+	assert.Contains(t, buildPrompt(job, ""), "transcript in its original language")
+
+	job.Parameters.SmartAnalysisPrompt = strPtr("Fix the medical terms of {{title}}, in {{language}}.")
+	assert.Equal(t, "Fix the medical terms of Standup, in English.", buildPrompt(job, language))
+}
+
+func TestDiff(t *testing.T) {
+	seg := interfaces.TranscriptSegment{Start: 1, End: 2, Text: " hello  world", Speaker: strPtr("SPEAKER_00")}
+
+	_, changed := diff(0, seg, "hello world", "")
+	assert.False(t, changed)
+	_, changed = diff(0, seg, "hello world", "SPEAKER_00")
+	assert.False(t, changed)
+
+	change, changed := diff(3, seg, "Hello, world.", "")
+	assert.True(t, changed)
+	assert.Equal(t, 3, change.Segment)
+	assert.Equal(t, "hello  world", change.OriginalText)
+	assert.Equal(t, "Hello, world.", change.ProposedText)
+	assert.Nil(t, change.ProposedSpeaker)
+	assert.Equal(t, models.ChangeStatusPending, change.Status)
+
+	change, changed = diff(3, seg, "", "SPEAKER_01")
+	assert.True(t, changed)
+	assert.Equal(t, "hello  world", change.ProposedText)
+	assert.Equal(t, "SPEAKER_01", *change.ProposedSpeaker)
+}
+
+func TestBatchesCoverTranscript(t *testing.T) {
+	segments := make([]interfaces.TranscriptSegment, 150)
+	lines := make([]string, len(segments))
+	for i := range segments {
+		segments[i].Text = "some words"
+		lines[i] = "[0] some words"
+	}
+	segments[7].Text = " "
+
+	out := batches(segments, lines, 1000)
+	var covered []int
+	for _, batch := range out {
+		assert.LessOrEqual(t, len(batch), maxBatchLines)
+		covered = append(covered, batch...)
+	}
+	assert.Len(t, covered, 149)
+	assert.NotContains(t, covered, 7)
+	assert.Equal(t, 149, covered[len(covered)-1])
+}
@@ -0,0 +1,118 @@
+package summarize
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// SplitTemplateIDs splits a comma-separated list of summary template IDs
+func SplitTemplateIDs(list *string) []string {
+	if list == nil {
+		return nil
+	}
+	var ids []string
+	for _, id := range strings.Split(*list, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// JoinTemplateIDs stores a list of summary template IDs, nil when empty
+func JoinTemplateIDs(ids []string) *string {
+	if len(ids) == 0 {
+		return nil
+	}
+	list := strings.Join(ids, ",")
+	return &list
+}
+
+// QueueAutoSummaries queues a summary for each template attached to a
+// completed job, through its parameters (e.g. from its profile) or its
+// owner's settings. Templates that no longer exist are recorded as failed
+// summaries; nothing here fails the job.
+func (s *Service) QueueAutoSummaries(ctx context.Context, job *models.TranscriptionJob) []models.Summary {
+	ids := SplitTemplateIDs(job.Parameters.SummaryTemplates)
+	if job.UserID != nil && s.userRepo != nil {
+		if user, err := s.userRepo.FindByID(ctx, *job.UserID); err == nil && user != nil {
+			ids = append(ids, SplitTemplateIDs(user.AutoSummaryTemplates)...)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	defaultModel := ""
+	if settings, err := s.summaryRepo.GetSettings(ctx); err == nil {
+		defaultModel = settings.DefaultModel
+	}
+
+	var summaries []models.Summary
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		templateID := id
+		summary := models.Summary{TranscriptionID: job.ID, TemplateID: &templateID}
+		template, err := s.summaryRepo.FindByID(ctx, id)
+		if err != nil || template == nil {
+			msg := fmt.Sprintf("summary template %s not found", id)
+			if err != nil {
+				msg = fmt.Sprintf("failed to load summary template %s: %v", id, err)
+			}
+			now := time.Now()
+			summary.Status = models.SummaryStatusFailed
+			summary.ErrorMessage = &msg
+			summary.CompletedAt = &now
+			if err := s.summaryRepo.SaveSummary(ctx, &summary); err != nil {
+				logger.Error("Failed to record auto summary", "job_id", job.ID, "template_id", id, "error", err)
+				continue
+			}
+			summaries = append(summaries, summary)
+			continue
+		}
+
+		summary.Model = template.Model
+		if summary.Model == "" {
+			summary.Model = defaultModel
+		}
+		if err := s.Enqueue(ctx, &summary); err != nil {
+			logger.Error("Failed to queue auto summary", "job_id", job.ID, "template_id", id, "error", err)
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+	logger.Info("Queued auto summaries", "job_id", job.ID, "count", len(summaries))
+	return summaries
+}
+
+// WaitForSummaries waits until the summaries are finished, or ctx is done,
+// and returns their stored state
+func (s *Service) WaitForSummaries(ctx context.Context, summaries []models.Summary) []models.Summary {
+	finished := make([]models.Summary, 0, len(summaries))
+	for _, summary := range summaries {
+		s.mu.Lock()
+		t, ok := s.tasks[summary.ID]
+		s.mu.Unlock()
+		if ok {
+			select {
+			case <-t.finished:
+			case <-ctx.Done():
+			}
+		}
+		if stored, err := s.summaryRepo.FindSummary(ctx, summary.ID); err == nil && stored != nil {
+			summary = *stored
+		}
+		finished = append(finished, summary)
+	}
+	return finished
+}
@@ -0,0 +1,465 @@
+package summarize
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"scriberr/internal/llm"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/internal/sse"
+	"scriberr/internal/transcription/interfaces"
+	"scriberr/pkg/logger"
+)
+
+// Summary modes
+const (
+	ModeAuto      = "auto"       // One call if the transcript fits the context window, else map-reduce
+	ModeSingle    = "single"     // Always one call
+	ModeMapReduce = "map_reduce" // Always summarize chunks, then combine them
+)
+
+// DefaultPrompt is used for summaries without a template
+const DefaultPrompt = "Summarize the following transcript. Start with a short overview, then list the main topics, decisions and action items."
+
+const (
+	// maxConcurrentSummaries bounds the summaries generated at once; the
+	// others wait in the queue
+	maxConcurrentSummaries = 2
+	// summaryTimeout allows long generations for large transcripts and
+	// smaller models
+	summaryTimeout = 60 * time.Minute
+)
+
+// Update is an event of a summary being generated
+type Update struct {
+	Status   string    `json:"status,omitempty"`   // Set when the status changes
+	Text     string    `json:"text,omitempty"`     // Next piece of the summary
+	Progress *Progress `json:"progress,omitempty"` // Set for each finished chunk of a map-reduce summary
+	Error    string    `json:"error,omitempty"`
+}
+
+// Service generates summaries as queued background tasks, so they survive
+// the client that requested them. Every summary is stored with its status,
+// and status changes and progress are broadcast as "summary_update" events
+// to the subscribers of the transcription.
+type Service struct {
+	jobRepo            repository.JobRepository
+	summaryRepo        repository.SummaryRepository
+	speakerMappingRepo repository.SpeakerMappingRepository
+	userRepo           repository.UserRepository
+	router             *llm.Router
+	broadcaster        *sse.Broadcaster
+
+	slots chan struct{}
+	mu    sync.Mutex
+	tasks map[string]*task
+}
+
+// task is a queued or running summary
+type task struct {
+	cancel   context.CancelFunc
+	finished chan struct{}
+
+	mu      sync.Mutex
+	updates []Update
+	changed chan struct{} // Closed and replaced on each update
+	deleted bool
+}
+
+// NewService creates a summary service. The broadcaster may be nil.
+func NewService(jobRepo repository.JobRepository, summaryRepo repository.SummaryRepository, speakerMappingRepo repository.SpeakerMappingRepository, userRepo repository.UserRepository, router *llm.Router, broadcaster *sse.Broadcaster) *Service {
+	return &Service{
+		jobRepo:            jobRepo,
+		summaryRepo:        summaryRepo,
+		speakerMappingRepo: speakerMappingRepo,
+		userRepo:           userRepo,
+		router:             router,
+		broadcaster:        broadcaster,
+		slots:              make(chan struct{}, maxConcurrentSummaries),
+		tasks:              make(map[string]*task),
+	}
+}
+
+// Enqueue stores summary as pending and generates it in the background. The
+// summary needs a transcription; template, model, mode and input are
+// optional.
+func (s *Service) Enqueue(ctx context.Context, summary *models.Summary) error {
+	if summary.Mode == "" {
+		summary.Mode = ModeAuto
+	}
+	summary.Status = models.SummaryStatusPending
+	summary.Content = ""
+	summary.Structured = nil
+	if err := s.summaryRepo.SaveSummary(ctx, summary); err != nil {
+		return fmt.Errorf("failed to save summary: %w", err)
+	}
+	s.start(*summary)
+	return nil
+}
+
+// Resume requeues the summaries left unfinished by a restart
+func (s *Service) Resume(ctx context.Context) error {
+	summaries, err := s.summaryRepo.ListUnfinishedSummaries(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range summaries {
+		summaries[i].Status = models.SummaryStatusPending
+		summaries[i].Content = ""
+		summaries[i].Structured = nil
+		s.start(summaries[i])
+	}
+	if len(summaries) > 0 {
+		logger.Info("Resumed unfinished summaries", "count", len(summaries))
+	}
+	return nil
+}
+
+// Watch streams the updates of a queued or running summary, from the first,
+// until it finishes or ctx is done. A summary that already finished streams
+// its stored outcome. It returns false if the summary is neither being
+// generated nor finished.
+func (s *Service) Watch(ctx context.Context, summaryID string) (<-chan Update, bool) {
+	s.mu.Lock()
+	t, ok := s.tasks[summaryID]
+	s.mu.Unlock()
+	if !ok {
+		return s.watchStored(ctx, summaryID)
+	}
+
+	out := make(chan Update)
+	go func() {
+		defer close(out)
+		last := false
+		for next := 0; ; {
+			t.mu.Lock()
+			updates, changed := t.updates[next:], t.changed
+			t.mu.Unlock()
+			for _, u := range updates {
+				select {
+				case out <- u:
+				case <-ctx.Done():
+					return
+				}
+				if u.Status == models.SummaryStatusCompleted || u.Status == models.SummaryStatusFailed {
+					return
+				}
+			}
+			next += len(updates)
+			if last {
+				return
+			}
+			select {
+			case <-changed:
+			case <-t.finished:
+				// Deliver what was published before the end, then stop
+				last = true
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, true
+}
+
+// watchStored streams the outcome of a finished summary. Tasks store their
+// outcome before they stop being tracked, so a summary that is not tracked
+// is either finished or was never queued.
+func (s *Service) watchStored(ctx context.Context, summaryID string) (<-chan Update, bool) {
+	summary, err := s.summaryRepo.FindSummary(ctx, summaryID)
+	if err != nil || summary == nil {
+		return nil, false
+	}
+	var updates []Update
+	switch summary.Status {
+	case models.SummaryStatusCompleted:
+		updates = []Update{{Text: summary.Content}, {Status: models.SummaryStatusCompleted}}
+	case models.SummaryStatusFailed:
+		msg := ""
+		if summary.ErrorMessage != nil {
+			msg = *summary.ErrorMessage
+		}
+		updates = []Update{{Status: models.SummaryStatusFailed, Error: msg}}
+	default:
+		return nil, false
+	}
+
+	out := make(chan Update, len(updates))
+	for _, u := range updates {
+		out <- u
+	}
+	close(out)
+	return out, true
+}
+
+// Delete removes a summary, stopping it first if it is still being
+// generated, and refreshes the summary cached on the transcription
+func (s *Service) Delete(ctx context.Context, summary *models.Summary) error {
+	s.mu.Lock()
+	t, ok := s.tasks[summary.ID]
+	s.mu.Unlock()
+	if ok {
+		t.mu.Lock()
+		t.deleted = true
+		t.mu.Unlock()
+		t.cancel()
+		select {
+		case <-t.finished:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := s.summaryRepo.DeleteSummary(ctx, summary.ID); err != nil {
+		return err
+	}
+	if summary.Status == models.SummaryStatusCompleted {
+		content := ""
+		if latest, err := s.summaryRepo.GetLatestSummary(ctx, summary.TranscriptionID); err == nil {
+			content = latest.Content
+		}
+		_ = s.jobRepo.UpdateSummary(ctx, summary.TranscriptionID, content)
+	}
+	return nil
+}
+
+// start queues the generation of a pending summary. The task works on its
+// own copy of the summary.
+func (s *Service) start(queued models.Summary) {
+	summary := &queued
+	ctx, cancel := context.WithTimeout(context.Background(), summaryTimeout)
+	t := &task{cancel: cancel, finished: make(chan struct{}), changed: make(chan struct{})}
+	s.mu.Lock()
+	s.tasks[summary.ID] = t
+	s.mu.Unlock()
+	s.publish(t, summary, Update{Status: models.SummaryStatusPending})
+
+	go func() {
+		defer func() {
+			cancel()
+			s.mu.Lock()
+			delete(s.tasks, summary.ID)
+			s.mu.Unlock()
+			close(t.finished)
+		}()
+
+		select {
+		case s.slots <- struct{}{}:
+			defer func() { <-s.slots }()
+		case <-ctx.Done():
+			s.finish(t, summary, ctx.Err())
+			return
+		}
+		s.finish(t, summary, s.generate(ctx, t, summary))
+	}()
+}
+
+// generate writes the summary's content as it streams in
+func (s *Service) generate(ctx context.Context, t *task, summary *models.Summary) error {
+	start := time.Now()
+	summary.Status = models.SummaryStatusProcessing
+	if err := s.summaryRepo.UpdateSummary(ctx, summary); err != nil {
+		return fmt.Errorf("failed to update summary: %w", err)
+	}
+	s.publish(t, summary, Update{Status: models.SummaryStatusProcessing})
+
+	job, err := s.jobRepo.FindByID(ctx, summary.TranscriptionID)
+	if err != nil || job == nil {
+		return fmt.Errorf("transcription not found")
+	}
+	route, err := s.router.Resolve(ctx, models.LLMFeatureSummarization, job.UserID)
+	if err != nil {
+		return err
+	}
+	route = route.WithModel(summary.Model).ForJob(job.ID)
+	primary := route.Primary()
+	summary.Model = primary.Model
+	if summary.Model == "" {
+		return fmt.Errorf("model is required")
+	}
+
+	var content <-chan string
+	var errs <-chan error
+	if summary.Input != "" {
+		// The client's text is summarized as is in one call
+		logger.Info("Generating summary", "summary_id", summary.ID, "transcription_id", job.ID, "provider", route.Provider(), "model", summary.Model, "content_len", len(summary.Input))
+		content, errs = route.ChatCompletionStream(ctx, []llm.ChatMessage{{Role: "user", Content: summary.Input}}, 0.0)
+	} else {
+		prompt := DefaultPrompt
+		var schema *Schema
+		if summary.TemplateID != nil {
+			template, err := s.summaryRepo.FindByID(ctx, *summary.TemplateID)
+			if err != nil {
+				return fmt.Errorf("failed to load summary template: %w", err)
+			}
+			if template != nil {
+				prompt = template.Prompt
+				if len(template.Schema) > 0 {
+					if schema, err = ParseSchema(template.Schema); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		lines, err := s.transcriptLines(ctx, job)
+		if err != nil {
+			return err
+		}
+		contextWindow := route.ContextWindow(ctx)
+		summarizer := New(route, contextWindow)
+		summarizer.OnProgress(func(p Progress) {
+			s.publish(t, summary, Update{Progress: &p})
+		})
+		mapReduce := summary.Mode == ModeMapReduce || (summary.Mode != ModeSingle && !summarizer.Fits(prompt, lines))
+		logger.Info("Generating summary", "summary_id", summary.ID, "transcription_id", job.ID, "provider", route.Provider(), "model", summary.Model,
+			"lines", len(lines), "context_window", contextWindow, "map_reduce", mapReduce, "structured", schema != nil)
+		if schema != nil {
+			return s.generateStructured(ctx, t, summary, summarizer, prompt, schema, lines, mapReduce, start)
+		}
+		if mapReduce {
+			content, errs = summarizer.MapReduce(ctx, prompt, lines)
+		} else {
+			content, errs = summarizer.Single(ctx, prompt, lines)
+		}
+	}
+
+	var text strings.Builder
+	for chunk := range content {
+		if text.Len() == 0 && chunk != "" {
+			logger.Debug("First summary chunk", "summary_id", summary.ID, "at_ms", time.Since(start).Milliseconds())
+		}
+		text.WriteString(chunk)
+		s.publish(t, summary, Update{Text: chunk})
+	}
+	// Partial content is kept when generation fails
+	summary.Content = text.String()
+	if err := <-errs; err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if strings.TrimSpace(summary.Content) == "" {
+		return fmt.Errorf("model returned no text")
+	}
+	logger.Info("Summary completed", "summary_id", summary.ID, "model", summary.Model, "bytes", len(summary.Content), "duration_ms", time.Since(start).Milliseconds())
+	return nil
+}
+
+// generateStructured stores the summary's JSON result along with its
+// Markdown rendering, which is published in one piece once validated
+func (s *Service) generateStructured(ctx context.Context, t *task, summary *models.Summary, summarizer *Summarizer, prompt string, schema *Schema, lines []string, mapReduce bool, start time.Time) error {
+	result, err := summarizer.Structured(ctx, prompt, schema, lines, mapReduce)
+	if err != nil {
+		return err
+	}
+	summary.Structured = result
+	summary.Content = RenderMarkdown(schema, result)
+	s.publish(t, summary, Update{Text: summary.Content})
+	logger.Info("Summary completed", "summary_id", summary.ID, "model", summary.Model, "structured_bytes", len(result), "duration_ms", time.Since(start).Milliseconds())
+	return nil
+}
+
+// finish stores the outcome of a summary, unless it was deleted meanwhile
+func (s *Service) finish(t *task, summary *models.Summary, err error) {
+	t.mu.Lock()
+	deleted := t.deleted
+	t.mu.Unlock()
+	if deleted {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	summary.CompletedAt = &now
+	update := Update{Status: models.SummaryStatusCompleted}
+	if err != nil {
+		msg := err.Error()
+		if err == context.DeadlineExceeded {
+			msg = "summary generation timed out"
+		}
+		summary.Status = models.SummaryStatusFailed
+		summary.ErrorMessage = &msg
+		update = Update{Status: models.SummaryStatusFailed, Error: msg}
+		logger.Error("Summary failed", "summary_id", summary.ID, "transcription_id", summary.TranscriptionID, "model", summary.Model, "error", err)
+	} else {
+		summary.Status = models.SummaryStatusCompleted
+		summary.ErrorMessage = nil
+	}
+	if err := s.summaryRepo.UpdateSummary(ctx, summary); err != nil {
+		logger.Error("Failed to store summary", "summary_id", summary.ID, "error", err)
+	}
+	if summary.Status == models.SummaryStatusCompleted {
+		// Cache the newest summary on the transcription for quick access
+		_ = s.jobRepo.UpdateSummary(ctx, summary.TranscriptionID, summary.Content)
+	}
+	s.publish(t, summary, update)
+}
+
+// publish records an update for watchers and broadcasts status changes and
+// progress to the transcription's subscribers
+func (s *Service) publish(t *task, summary *models.Summary, u Update) {
+	t.mu.Lock()
+	t.updates = append(t.updates, u)
+	close(t.changed)
+	t.changed = make(chan struct{})
+	t.mu.Unlock()
+
+	if s.broadcaster == nil || (u.Status == "" && u.Progress == nil) {
+		return
+	}
+	payload := map[string]interface{}{
+		"summary_id":       summary.ID,
+		"transcription_id": summary.TranscriptionID,
+		"status":           summary.Status,
+	}
+	if u.Status != "" {
+		payload["status"] = u.Status
+	}
+	if u.Progress != nil {
+		payload["progress"] = u.Progress
+	}
+	if u.Error != "" {
+		payload["error"] = u.Error
+	}
+	s.broadcaster.Broadcast(summary.TranscriptionID, "summary_update", payload)
+}
+
+// transcriptLines renders a job's transcript as one "Speaker: text" line per
+// segment, with custom speaker names
+func (s *Service) transcriptLines(ctx context.Context, job *models.TranscriptionJob) ([]string, error) {
+	if job.Transcript == nil || *job.Transcript == "" {
+		return nil, fmt.Errorf("transcription has no transcript")
+	}
+	var result interfaces.TranscriptResult
+	if err := json.Unmarshal([]byte(*job.Transcript), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse transcript: %w", err)
+	}
+
+	speakers := make(map[string]string)
+	if mappings, err := s.speakerMappingRepo.ListByJob(ctx, job.ID); err == nil {
+		for _, m := range mappings {
+			speakers[m.OriginalSpeaker] = m.CustomName
+		}
+	}
+	lines := make([]string, 0, len(result.Segments))
+	for _, seg := range result.Segments {
+		text := strings.TrimSpace(seg.Text)
+		if seg.Speaker != nil && *seg.Speaker != "" {
+			speaker := *seg.Speaker
+			if custom, ok := speakers[speaker]; ok {
+				speaker = custom
+			}
+			text = speaker + ": " + text
+		}
+		lines = append(lines, text)
+	}
+	return lines, nil
+}
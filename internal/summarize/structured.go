@@ -0,0 +1,436 @@
+package summarize
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"scriberr/internal/llm"
+)
+
+// maxRepairs is the number of times the model is asked to fix a structured
+// result that does not match the schema
+const maxRepairs = 2
+
+// Schema is the JSON schema of a structured summary. Results are validated
+// against the subset of JSON schema that describes such documents: type,
+// properties, required, additionalProperties (false), items and enum.
+type Schema struct {
+	Type                 any                `json:"type,omitempty"` // A type name or a list of them
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+
+	order []string // Property names in declaration order
+	raw   json.RawMessage
+}
+
+// UnmarshalJSON parses a schema, remembering the order of its properties
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type plain Schema
+	if err := json.Unmarshal(data, (*plain)(s)); err != nil {
+		return err
+	}
+	s.raw = append(json.RawMessage(nil), data...)
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if props, ok := fields["properties"]; ok {
+		s.order = objectKeys(props)
+	}
+	return nil
+}
+
+// Raw returns the schema as it was declared
+func (s *Schema) Raw() json.RawMessage {
+	return s.raw
+}
+
+// ParseSchema parses the JSON schema of a template
+func ParseSchema(data []byte) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	if schema.Type == nil && schema.Properties == nil {
+		return nil, fmt.Errorf("invalid JSON schema: it must declare a type or properties")
+	}
+	if err := schema.check("$"); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	return &schema, nil
+}
+
+func (s *Schema) check(path string) error {
+	for _, t := range s.types() {
+		switch t {
+		case "object", "array", "string", "number", "integer", "boolean", "null":
+		default:
+			return fmt.Errorf("%s: unknown type %q", path, t)
+		}
+	}
+	for name, prop := range s.Properties {
+		if prop == nil {
+			return fmt.Errorf("%s.%s: empty schema", path, name)
+		}
+		if err := prop.check(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.check(path + "[]")
+	}
+	return nil
+}
+
+func (s *Schema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var types []string
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	if s.Properties != nil {
+		return []string{"object"}
+	}
+	return nil
+}
+
+// propertyNames returns the names of the properties of an object, declared
+// ones first
+func (s *Schema) propertyNames(value map[string]any) []string {
+	names := append([]string(nil), s.order...)
+	var extra []string
+	for name := range value {
+		if _, declared := s.Properties[name]; !declared {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	return append(names, extra...)
+}
+
+func (s *Schema) property(name string) *Schema {
+	if s != nil {
+		if p, ok := s.Properties[name]; ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// Validate returns the ways value does not match the schema
+func (s *Schema) Validate(value any) []string {
+	var problems []string
+	s.validate(value, "$", &problems)
+	return problems
+}
+
+func (s *Schema) validate(value any, path string, problems *[]string) {
+	if s == nil {
+		return
+	}
+	if types := s.types(); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if hasType(value, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			*problems = append(*problems, fmt.Sprintf("%s must be of type %s", path, strings.Join(types, " or ")))
+			return
+		}
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*problems = append(*problems, fmt.Sprintf("%s must be one of %v", path, s.Enum))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s.%s is required", path, name))
+			}
+		}
+		for name, item := range v {
+			if prop, ok := s.Properties[name]; ok {
+				prop.validate(item, path+"."+name, problems)
+			} else if allowed, ok := s.AdditionalProperties.(bool); ok && !allowed {
+				*problems = append(*problems, fmt.Sprintf("%s.%s is not allowed", path, name))
+			}
+		}
+	case []any:
+		for i, item := range v {
+			s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	}
+}
+
+func hasType(value any, t string) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+// Structured returns a summary of lines as JSON matching schema, following
+// prompt. Transcripts too long for one call are summarized in chunks first,
+// as for text summaries. Invalid answers are sent back to the model to be
+// repaired.
+func (s *Summarizer) Structured(ctx context.Context, prompt string, schema *Schema, lines []string, mapReduce bool) (json.RawMessage, error) {
+	content := prompt + "\n\n" + strings.Join(lines, "\n")
+	if mapReduce {
+		partials, err := s.partials(ctx, prompt, lines)
+		if err != nil {
+			return nil, err
+		}
+		s.report(Progress{Stage: StageReduce, Done: 0, Total: 1})
+		content = reducePrompt(prompt, partials)
+	}
+
+	messages := []llm.ChatMessage{{Role: "user", Content: content + "\n\n" + structuredInstructions(schema)}}
+	for attempt := 0; ; attempt++ {
+		resp, err := s.route.ChatCompletionJSON(ctx, messages, schema.Raw(), 0.0)
+		if err != nil {
+			return nil, err
+		}
+		answer := resp.Choices[0].Message.Content
+		result, problems := parseStructured(answer, schema)
+		if len(problems) == 0 {
+			if mapReduce {
+				s.report(Progress{Stage: StageReduce, Done: 1, Total: 1})
+			}
+			return result, nil
+		}
+		if attempt == maxRepairs {
+			return nil, fmt.Errorf("model returned JSON not matching the schema: %s", strings.Join(problems, "; "))
+		}
+		messages = append(messages,
+			llm.ChatMessage{Role: "assistant", Content: answer},
+			llm.ChatMessage{Role: "user", Content: "Your answer does not match the JSON schema:\n- " + strings.Join(problems, "\n- ") +
+				"\n\nReply with the corrected JSON only."},
+		)
+	}
+}
+
+func structuredInstructions(schema *Schema) string {
+	return "Answer with only a JSON value, without any other text, that matches this JSON schema:\n\n" + string(schema.Raw())
+}
+
+// parseStructured parses and validates a model's structured answer, returning
+// it compacted
+func parseStructured(answer string, schema *Schema) (json.RawMessage, []string) {
+	text := llm.ExtractJSON(answer)
+	var value any
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return nil, []string{"the answer is not valid JSON: " + err.Error()}
+	}
+	if problems := schema.Validate(value); len(problems) > 0 {
+		return nil, problems
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(text)); err != nil {
+		return nil, []string{"the answer is not valid JSON: " + err.Error()}
+	}
+	return compact.Bytes(), nil
+}
+
+// RenderMarkdown renders a structured summary as Markdown: a section per
+// property of the top-level object, lists for arrays
+func RenderMarkdown(schema *Schema, result json.RawMessage) string {
+	var value any
+	if err := json.Unmarshal(result, &value); err != nil {
+		return string(result)
+	}
+	var b strings.Builder
+	obj, ok := value.(map[string]any)
+	if !ok {
+		renderBlock(&b, value, schema)
+		return strings.TrimSpace(b.String())
+	}
+	for _, name := range schema.propertyNames(obj) {
+		v, ok := obj[name]
+		if !ok {
+			continue
+		}
+		prop := schema.property(name)
+		fmt.Fprintf(&b, "## %s\n\n", title(name, prop))
+		renderBlock(&b, v, prop)
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func renderBlock(b *strings.Builder, value any, schema *Schema) {
+	switch v := value.(type) {
+	case []any:
+		if len(v) == 0 {
+			b.WriteString("_None_\n")
+			return
+		}
+		var items *Schema
+		if schema != nil {
+			items = schema.Items
+		}
+		for _, item := range v {
+			fmt.Fprintf(b, "- %s\n", inline(item, items))
+		}
+	case map[string]any:
+		var s *Schema
+		if schema != nil {
+			s = schema
+		} else {
+			s = &Schema{}
+		}
+		for _, name := range s.propertyNames(v) {
+			if item, ok := v[name]; ok {
+				fmt.Fprintf(b, "- **%s:** %s\n", title(name, s.property(name)), inline(item, s.property(name)))
+			}
+		}
+	case nil:
+		b.WriteString("_None_\n")
+	default:
+		fmt.Fprintf(b, "%s\n", inline(v, schema))
+	}
+}
+
+// inline renders a value on one line. Objects read as their first text
+// field, followed by the others in parentheses.
+func inline(value any, schema *Schema) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return "—"
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			var items *Schema
+			if schema != nil {
+				items = schema.Items
+			}
+			parts[i] = inline(item, items)
+		}
+		return strings.Join(parts, ", ")
+	case map[string]any:
+		s := schema
+		if s == nil {
+			s = &Schema{}
+		}
+		main := ""
+		var details []string
+		for _, name := range s.propertyNames(v) {
+			item, ok := v[name]
+			if !ok || item == nil {
+				continue
+			}
+			if text, isText := item.(string); isText && main == "" {
+				main = text
+				continue
+			}
+			details = append(details, strings.ToLower(title(name, s.property(name)))+": "+inline(item, s.property(name)))
+		}
+		if len(details) == 0 {
+			return main
+		}
+		if main == "" {
+			return strings.Join(details, ", ")
+		}
+		return main + " (" + strings.Join(details, ", ") + ")"
+	case float64:
+		if v == math.Trunc(v) {
+			return fmt.Sprintf("%d", int64(v))
+		}
+		return fmt.Sprint(v)
+	case bool:
+		if v {
+			return "Yes"
+		}
+		return "No"
+	}
+	return fmt.Sprint(value)
+}
+
+// title is the heading of a property: its schema title, or its name in
+// sentence case
+func title(name string, schema *Schema) string {
+	if schema != nil && schema.Title != "" {
+		return schema.Title
+	}
+	words := strings.Fields(strings.NewReplacer("_", " ", "-", " ").Replace(name))
+	if len(words) == 0 {
+		return name
+	}
+	text := strings.ToLower(strings.Join(words, " "))
+	return strings.ToUpper(text[:1]) + text[1:]
+}
+
+// objectKeys returns the keys of a JSON object in document order
+func objectKeys(data json.RawMessage) []string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return keys
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return keys
+		}
+		keys = append(keys, key)
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return keys
+		}
+	}
+	return keys
+}
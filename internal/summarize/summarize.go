@@ -0,0 +1,297 @@
+// Package summarize summarizes transcripts of any length: transcripts that
+// fit the model's context window are summarized in one call, longer ones are
+// split into chunks that are summarized in parallel and then combined.
+package summarize
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"scriberr/internal/llm"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Progress stages
+const (
+	StageMap    = "map"    // Summarizing the chunks of the transcript
+	StageReduce = "reduce" // Combining the chunk summaries
+)
+
+const (
+	// defaultConcurrency is the number of chunks summarized at once
+	defaultConcurrency = 4
+	// minChunkTokens keeps chunks useful when the prompt takes most of a
+	// small context window
+	minChunkTokens = 256
+)
+
+// Progress reports a finished step of a chunked summary
+type Progress struct {
+	Stage string `json:"stage"`
+	Done  int    `json:"done"`
+	Total int    `json:"total"`
+}
+
+// Summarizer summarizes text with the model of a route
+type Summarizer struct {
+	route         *llm.Route
+	contextWindow int // In tokens, estimated at 4 characters per token
+	concurrency   int
+	onProgress    func(Progress)
+}
+
+// New creates a summarizer for a model with the given context window
+func New(route *llm.Route, contextWindow int) *Summarizer {
+	return &Summarizer{route: route, contextWindow: contextWindow, concurrency: defaultConcurrency}
+}
+
+// SetConcurrency sets how many chunks are summarized at once
+func (s *Summarizer) SetConcurrency(n int) {
+	s.concurrency = max(n, 1)
+}
+
+// OnProgress sets a callback for each finished chunk. It may be called from
+// several goroutines, but never concurrently.
+func (s *Summarizer) OnProgress(fn func(Progress)) {
+	s.onProgress = fn
+}
+
+// chunkBudget is the number of transcript tokens that fit one call next to
+// prompt, leaving a quarter of the window for the answer
+func (s *Summarizer) chunkBudget(prompt string) int {
+	return max(s.contextWindow*3/4-llm.EstimateTokens(prompt)-64, minChunkTokens)
+}
+
+// Chunks splits lines into consecutive chunks that each fit one call with
+// prompt. A single chunk means the text can be summarized at once. Lines
+// longer than a chunk are split.
+func (s *Summarizer) Chunks(prompt string, lines []string) [][]string {
+	budget := s.chunkBudget(mapPrompt(prompt, 1, 1, ""))
+	var chunks [][]string
+	var current []string
+	tokens := 0
+	for _, line := range lines {
+		for _, part := range splitLine(line, budget) {
+			t := llm.EstimateTokens(part) + 1
+			if len(current) > 0 && tokens+t > budget {
+				chunks = append(chunks, current)
+				current, tokens = nil, 0
+			}
+			current = append(current, part)
+			tokens += t
+		}
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// Fits tells whether lines can be summarized with prompt in one call
+func (s *Summarizer) Fits(prompt string, lines []string) bool {
+	return llm.EstimateTokens(prompt)+llm.EstimateTokens(lines...)+len(lines) <= s.chunkBudget("")
+}
+
+// Single streams a summary of lines made in one call, whatever their length
+func (s *Summarizer) Single(ctx context.Context, prompt string, lines []string) (<-chan string, <-chan error) {
+	content := prompt + "\n\n" + strings.Join(lines, "\n")
+	return s.route.ChatCompletionStream(ctx, []llm.ChatMessage{{Role: "user", Content: content}}, 0.0)
+}
+
+// MapReduce streams a summary of lines made by summarizing chunks of them in
+// parallel and combining the chunk summaries following prompt
+func (s *Summarizer) MapReduce(ctx context.Context, prompt string, lines []string) (<-chan string, <-chan error) {
+	contentChan := make(chan string, 100)
+	errorChan := make(chan error, 1)
+
+	go func() {
+		defer close(contentChan)
+		defer close(errorChan)
+
+		partials, err := s.partials(ctx, prompt, lines)
+		if err != nil {
+			errorChan <- err
+			return
+		}
+
+		s.report(Progress{Stage: StageReduce, Done: 0, Total: 1})
+		cc, ec := s.route.ChatCompletionStream(ctx, []llm.ChatMessage{{Role: "user", Content: reducePrompt(prompt, partials)}}, 0.0)
+		for chunk := range cc {
+			select {
+			case contentChan <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := <-ec; err != nil {
+			errorChan <- fmt.Errorf("failed to combine chunk summaries: %w", err)
+			return
+		}
+		s.report(Progress{Stage: StageReduce, Done: 1, Total: 1})
+	}()
+
+	return contentChan, errorChan
+}
+
+// Stream streams a summary of lines, in one call if they fit the context
+// window and by map-reduce otherwise
+func (s *Summarizer) Stream(ctx context.Context, prompt string, lines []string) (<-chan string, <-chan error) {
+	if s.Fits(prompt, lines) {
+		return s.Single(ctx, prompt, lines)
+	}
+	return s.MapReduce(ctx, prompt, lines)
+}
+
+// Summarize returns a summary of lines, in one call if they fit the context
+// window and by map-reduce otherwise
+func (s *Summarizer) Summarize(ctx context.Context, prompt string, lines []string) (string, error) {
+	contentChan, errorChan := s.Stream(ctx, prompt, lines)
+	var summary strings.Builder
+	for chunk := range contentChan {
+		summary.WriteString(chunk)
+	}
+	if err := <-errorChan; err != nil {
+		return "", err
+	}
+	return summary.String(), nil
+}
+
+// partials summarizes chunks of lines, combining the chunk summaries until
+// they fit the final call
+func (s *Summarizer) partials(ctx context.Context, prompt string, lines []string) ([]string, error) {
+	partials, err := s.mapChunks(ctx, prompt, s.Chunks(prompt, lines))
+	if err != nil {
+		return nil, err
+	}
+	for round := 0; len(partials) > 1 && !s.Fits(reducePrompt(prompt, nil), partials); round++ {
+		groups := s.Chunks(reducePrompt(prompt, nil), partials)
+		if len(groups) >= len(partials) || round > 8 {
+			break // Summaries this long cannot be combined any further
+		}
+		if partials, err = s.combine(ctx, prompt, groups); err != nil {
+			return nil, err
+		}
+	}
+	return partials, nil
+}
+
+// mapChunks summarizes every chunk, in parallel, in order
+func (s *Summarizer) mapChunks(ctx context.Context, prompt string, chunks [][]string) ([]string, error) {
+	return s.parallel(ctx, StageMap, len(chunks), func(ctx context.Context, i int) (string, error) {
+		text := mapPrompt(prompt, i+1, len(chunks), strings.Join(chunks[i], "\n"))
+		summary, err := s.complete(ctx, text)
+		if err != nil {
+			return "", fmt.Errorf("failed to summarize chunk %d of %d: %w", i+1, len(chunks), err)
+		}
+		return summary, nil
+	})
+}
+
+// combine merges each group of consecutive partial summaries into one
+func (s *Summarizer) combine(ctx context.Context, prompt string, groups [][]string) ([]string, error) {
+	return s.parallel(ctx, StageReduce, len(groups), func(ctx context.Context, i int) (string, error) {
+		summary, err := s.complete(ctx, combinePrompt(prompt, groups[i]))
+		if err != nil {
+			return "", fmt.Errorf("failed to combine chunk summaries: %w", err)
+		}
+		return summary, nil
+	})
+}
+
+// parallel runs fn for 0..n-1 with bounded concurrency and returns the
+// results in order, reporting progress as each finishes
+func (s *Summarizer) parallel(ctx context.Context, stage string, n int, fn func(ctx context.Context, i int) (string, error)) ([]string, error) {
+	results := make([]string, n)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.concurrency)
+
+	var mu sync.Mutex
+	done := 0
+	for i := 0; i < n; i++ {
+		g.Go(func() error {
+			out, err := fn(gctx, i)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			results[i] = out
+			done++
+			s.report(Progress{Stage: stage, Done: done, Total: n})
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *Summarizer) complete(ctx context.Context, content string) (string, error) {
+	resp, err := s.route.ChatCompletion(ctx, []llm.ChatMessage{{Role: "user", Content: content}}, 0.0)
+	if err != nil {
+		return "", err
+	}
+	text := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if text == "" {
+		return "", fmt.Errorf("model returned no text")
+	}
+	return text, nil
+}
+
+func (s *Summarizer) report(p Progress) {
+	if s.onProgress != nil {
+		s.onProgress(p)
+	}
+}
+
+func mapPrompt(prompt string, part, parts int, text string) string {
+	return fmt.Sprintf("Below is part %d of %d of a transcript that is too long to read at once. "+
+		"Write a detailed summary of this part only, keeping every topic, decision, action item, name, number and date, "+
+		"so that it can be combined with the summaries of the other parts. "+
+		"The combined summary will follow these instructions:\n\n%s\n\nTranscript part %d of %d:\n\n%s",
+		part, parts, prompt, part, parts, text)
+}
+
+func combinePrompt(prompt string, partials []string) string {
+	return fmt.Sprintf("Below are summaries of consecutive parts of a long transcript. "+
+		"Merge them into one detailed summary in the same order, keeping every topic, decision, action item, name, number and date. "+
+		"The final summary will follow these instructions:\n\n%s\n\n%s", prompt, joinParts(partials))
+}
+
+func reducePrompt(prompt string, partials []string) string {
+	return prompt + "\n\nThe transcript was too long to read at once, so it was summarized in consecutive parts. " +
+		"Treat the summaries of the parts below as the transcript.\n\n" + joinParts(partials)
+}
+
+func joinParts(partials []string) string {
+	var b strings.Builder
+	for i, p := range partials {
+		fmt.Fprintf(&b, "Part %d:\n%s\n\n", i+1, p)
+	}
+	return b.String()
+}
+
+// splitLine splits a line longer than budget tokens at spaces
+func splitLine(line string, budget int) []string {
+	maxChars := budget * 4
+	var parts []string
+	for utf8.RuneCountInString(line) > maxChars {
+		end := 0
+		for i := 0; i < maxChars; i++ {
+			_, size := utf8.DecodeRuneInString(line[end:])
+			end += size
+		}
+		cut := strings.LastIndex(line[:end], " ")
+		if cut <= 0 {
+			cut = end
+		}
+		parts = append(parts, line[:cut])
+		line = strings.TrimLeft(line[cut:], " ")
+	}
+	return append(parts, line)
+}
@@ -209,16 +209,16 @@ func (a *GroqAdapter) Transcribe(ctx context.Context, input interfaces.AudioInpu
 		Duration float64 `json:"duration"`
 		Text     string  `json:"text"`
 		Segments []struct {
-			ID               int     `json:"id"`
-			Seek             int     `json:"seek"`
-			Start            float64 `json:"start"`
-			End              float64 `json:"end"`
-			Text             string  `json:"text"`
-			Tokens           []int   `json:"tokens"`
-			Temperature      float64 `json:"temperature"`
-			AvgLogprob       float64 `json:"avg_logprob"`
-			CompressionRatio float64 `json:"compression_ratio"`
-			NoSpeechProb     float64 `json:"no_speech_prob"`
+			ID               int      `json:"id"`
+			Seek             int      `json:"seek"`
+			Start            float64  `json:"start"`
+			End              float64  `json:"end"`
+			Text             string   `json:"text"`
+			Tokens           []int    `json:"tokens"`
+			Temperature      float64  `json:"temperature"`
+			AvgLogprob       *float64 `json:"avg_logprob"`
+			CompressionRatio *float64 `json:"compression_ratio"`
+			NoSpeechProb     *float64 `json:"no_speech_prob"`
 		} `json:"segments"`
 	}
 
@@ -238,9 +238,12 @@ func (a *GroqAdapter) Transcribe(ctx context.Context, input interfaces.AudioInpu
 
 	for i, seg := range groqResponse.Segments {
 		result.Segments[i] = interfaces.TranscriptSegment{
-			Start: seg.Start,
-			End:   seg.End,
-			Text:  seg.Text,
+			Start:            seg.Start,
+			End:              seg.End,
+			Text:             seg.Text,
+			AvgLogprob:       seg.AvgLogprob,
+			CompressionRatio: seg.CompressionRatio,
+			NoSpeechProb:     seg.NoSpeechProb,
 		}
 	}
 	
@@ -260,16 +260,16 @@ func (a *OpenAIAdapter) Transcribe(ctx context.Context, input interfaces.AudioIn
 		Duration float64 `json:"duration"`
 		Text     string  `json:"text"`
 		Segments []struct {
-			ID               int     `json:"id"`
-			Seek             int     `json:"seek"`
-			Start            float64 `json:"start"`
-			End              float64 `json:"end"`
-			Text             string  `json:"text"`
-			Tokens           []int   `json:"tokens"`
-			Temperature      float64 `json:"temperature"`
-			AvgLogprob       float64 `json:"avg_logprob"`
-			CompressionRatio float64 `json:"compression_ratio"`
-			NoSpeechProb     float64 `json:"no_speech_prob"`
+			ID               int      `json:"id"`
+			Seek             int      `json:"seek"`
+			Start            float64  `json:"start"`
+			End              float64  `json:"end"`
+			Text             string   `json:"text"`
+			Tokens           []int    `json:"tokens"`
+			Temperature      float64  `json:"temperature"`
+			AvgLogprob       *float64 `json:"avg_logprob"`
+			CompressionRatio *float64 `json:"compression_ratio"`
+			NoSpeechProb     *float64 `json:"no_speech_prob"`
 		} `json:"segments"`
 		Words []struct {
 			Word  string  `json:"word"`
@@ -299,9 +299,12 @@ func (a *OpenAIAdapter) Transcribe(ctx context.Context, input interfaces.AudioIn
 	if len(openAIResponse.Segments) > 0 {
 		for i, seg := range openAIResponse.Segments {
 			result.Segments[i] = interfaces.TranscriptSegment{
-				Start: seg.Start,
-				End:   seg.End,
-				Text:  seg.Text,
+				Start:            seg.Start,
+				End:              seg.End,
+				Text:             seg.Text,
+				AvgLogprob:       seg.AvgLogprob,
+				CompressionRatio: seg.CompressionRatio,
+				NoSpeechProb:     seg.NoSpeechProb,
 			}
 		}
 	} else if openAIResponse.Text != "" {
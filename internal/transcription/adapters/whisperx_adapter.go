@@ -597,10 +597,13 @@ func (w *WhisperXAdapter) parseResult(outputDir string, input interfaces.AudioIn
 	// Parse WhisperX JSON format
 	var whisperxResult struct {
 		Segments []struct {
-			Start   float64 `json:"start"`
-			End     float64 `json:"end"`
-			Text    string  `json:"text"`
-			Speaker *string `json:"speaker,omitempty"`
+			Start            float64  `json:"start"`
+			End              float64  `json:"end"`
+			Text             string   `json:"text"`
+			Speaker          *string  `json:"speaker,omitempty"`
+			AvgLogprob       *float64 `json:"avg_logprob,omitempty"`
+			CompressionRatio *float64 `json:"compression_ratio,omitempty"`
+			NoSpeechProb     *float64 `json:"no_speech_prob,omitempty"`
 		} `json:"segments"`
 		Word []struct {
 			Start   float64 `json:"start"`
@@ -629,10 +632,13 @@ func (w *WhisperXAdapter) parseResult(outputDir string, input interfaces.AudioIn
 	var textParts []string
 	for i, seg := range whisperxResult.Segments {
 		result.Segments[i] = interfaces.TranscriptSegment{
-			Start:   seg.Start,
-			End:     seg.End,
-			Text:    seg.Text,
-			Speaker: seg.Speaker,
+			Start:            seg.Start,
+			End:              seg.End,
+			Text:             seg.Text,
+			Speaker:          seg.Speaker,
+			AvgLogprob:       seg.AvgLogprob,
+			CompressionRatio: seg.CompressionRatio,
+			NoSpeechProb:     seg.NoSpeechProb,
 		}
 		textParts = append(textParts, seg.Text)
 	}
@@ -0,0 +1,168 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"scriberr/internal/transcription/interfaces"
+	"scriberr/pkg/logger"
+)
+
+// whisperXAlignScript runs WhisperX's wav2vec2 forced alignment on existing segments
+const whisperXAlignScript = `
+import json, sys, whisperx
+audio_path, segments_path, language, device, out_path = sys.argv[1:6]
+with open(segments_path) as f:
+    segments = json.load(f)
+audio = whisperx.load_audio(audio_path)
+model_a, metadata = whisperx.load_align_model(language_code=language, device=device)
+result = whisperx.align(segments, model_a, metadata, audio, device, return_char_alignments=False)
+with open(out_path, "w") as f:
+    json.dump(result, f)
+`
+
+// Align implements interfaces.AlignmentAdapter using WhisperX forced alignment
+func (w *WhisperXAdapter) Align(ctx context.Context, input interfaces.AudioInput, transcript *interfaces.TranscriptResult, procCtx interfaces.ProcessingContext) (*interfaces.TranscriptResult, error) {
+	startTime := time.Now()
+
+	language := transcript.Language
+	if language == "" || language == "auto" {
+		return nil, fmt.Errorf("forced alignment requires a known transcript language")
+	}
+
+	tempDir, err := w.CreateTempDirectory(procCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer w.CleanupTempDirectory(tempDir)
+
+	type alignSegment struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	}
+	segments := make([]alignSegment, len(transcript.Segments))
+	for i, seg := range transcript.Segments {
+		segments[i] = alignSegment{Start: seg.Start, End: seg.End, Text: seg.Text}
+	}
+	segmentsData, err := json.Marshal(segments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal segments: %w", err)
+	}
+	segmentsPath := filepath.Join(tempDir, "segments.json")
+	if err := os.WriteFile(segmentsPath, segmentsData, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write segments: %w", err)
+	}
+	outPath := filepath.Join(tempDir, "aligned.json")
+
+	device := os.Getenv("WHISPERX_ALIGN_DEVICE")
+	if device == "" {
+		device = "cpu"
+	}
+
+	whisperxPath := filepath.Join(w.envPath, "WhisperX")
+	cmd := exec.CommandContext(ctx, "uv", "run", "--native-tls", "--project", whisperxPath,
+		"python", "-c", whisperXAlignScript,
+		input.FilePath, segmentsPath, language, device, outPath)
+	cmd.Env = append(os.Environ(), "PYTHONUNBUFFERED=1")
+
+	logger.Info("Running WhisperX forced alignment", "job_id", procCtx.JobID, "segments", len(segments), "language", language)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() == context.Canceled {
+			return nil, fmt.Errorf("alignment was cancelled")
+		}
+		tail := string(out)
+		if len(tail) > 2048 {
+			tail = tail[len(tail)-2048:]
+		}
+		return nil, fmt.Errorf("WhisperX alignment failed: %w\nLogs:\n%s", err, tail)
+	}
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alignment result: %w", err)
+	}
+
+	var aligned struct {
+		Segments []struct {
+			Start float64 `json:"start"`
+			End   float64 `json:"end"`
+			Text  string  `json:"text"`
+		} `json:"segments"`
+		WordSegments []struct {
+			Start float64 `json:"start"`
+			End   float64 `json:"end"`
+			Word  string  `json:"word"`
+			Score float64 `json:"score"`
+		} `json:"word_segments"`
+	}
+	if err := json.Unmarshal(data, &aligned); err != nil {
+		return nil, fmt.Errorf("failed to parse alignment result: %w", err)
+	}
+
+	// Speakers come from the original transcript, matched by time overlap
+	speakerAt := func(start, end float64) *string {
+		var best *string
+		bestOverlap := 0.0
+		for _, seg := range transcript.Segments {
+			overlap := minFloat(end, seg.End) - maxFloat(start, seg.Start)
+			if overlap > bestOverlap {
+				bestOverlap = overlap
+				best = seg.Speaker
+			}
+		}
+		return best
+	}
+
+	result := &interfaces.TranscriptResult{
+		Text:           transcript.Text,
+		Language:       transcript.Language,
+		Confidence:     transcript.Confidence,
+		ModelUsed:      transcript.ModelUsed,
+		Metadata:       transcript.Metadata,
+		ProcessingTime: time.Since(startTime),
+	}
+	for _, seg := range aligned.Segments {
+		result.Segments = append(result.Segments, interfaces.TranscriptSegment{
+			Start:   seg.Start,
+			End:     seg.End,
+			Text:    seg.Text,
+			Speaker: speakerAt(seg.Start, seg.End),
+		})
+	}
+	for _, word := range aligned.WordSegments {
+		result.WordSegments = append(result.WordSegments, interfaces.TranscriptWord{
+			Start:   word.Start,
+			End:     word.End,
+			Word:    word.Word,
+			Score:   word.Score,
+			Speaker: speakerAt(word.Start, word.End),
+		})
+	}
+	if result.Metadata == nil {
+		result.Metadata = map[string]string{}
+	}
+	result.Metadata["aligned_with"] = "whisperx"
+
+	logger.Info("WhisperX alignment completed", "job_id", procCtx.JobID, "words", len(result.WordSegments), "processing_time", result.ProcessingTime)
+	return result, nil
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"scriberr/internal/models"
+	"scriberr/internal/repository"
 	"scriberr/internal/transcription/adapters"
 	"scriberr/internal/transcription/interfaces"
 	"scriberr/internal/transcription/registry"
@@ -84,8 +85,8 @@ func (m *MockJobRepository) DeleteMultiTrackFilesByJobID(ctx context.Context, jo
 	return args.Error(0)
 }
 
-func (m *MockJobRepository) ListWithParams(ctx context.Context, offset, limit int, sortBy, sortOrder, searchQuery string, updatedAfter *time.Time) ([]models.TranscriptionJob, int64, error) {
-	args := m.Called(ctx, offset, limit, sortBy, sortOrder, searchQuery, updatedAfter)
+func (m *MockJobRepository) ListWithParams(ctx context.Context, userID uint, offset, limit int, sortBy, sortOrder, searchQuery string, updatedAfter *time.Time, filter repository.JobFilter) ([]models.TranscriptionJob, int64, error) {
+	args := m.Called(ctx, userID, offset, limit, sortBy, sortOrder, searchQuery, updatedAfter, filter)
 	return args.Get(0).([]models.TranscriptionJob), args.Get(1).(int64), args.Error(2)
 }
 
@@ -133,6 +134,71 @@ func (m *MockJobRepository) UpdateSummary(ctx context.Context, jobID string, sum
 	return args.Error(0)
 }
 
+func (m *MockJobRepository) UpdateReviewStatus(ctx context.Context, jobID string, status string) error {
+	args := m.Called(ctx, jobID, status)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) UpdateLanguageMix(ctx context.Context, jobID string, mix []models.LanguageTime) error {
+	args := m.Called(ctx, jobID, mix)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) UpdateAudioPath(ctx context.Context, jobID string, audioPath string) error {
+	args := m.Called(ctx, jobID, audioPath)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) UpdateAudioHash(ctx context.Context, jobID string, hash string) error {
+	args := m.Called(ctx, jobID, hash)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) FindByAudioHash(ctx context.Context, userID uint, hash string) (*models.TranscriptionJob, error) {
+	args := m.Called(ctx, userID, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TranscriptionJob), args.Error(1)
+}
+
+func (m *MockJobRepository) ListExecutionsByJobID(ctx context.Context, jobID string) ([]models.TranscriptionJobExecution, error) {
+	args := m.Called(ctx, jobID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TranscriptionJobExecution), args.Error(1)
+}
+
+func (m *MockJobRepository) UpdateTags(ctx context.Context, jobID string, tags []string) error {
+	args := m.Called(ctx, jobID, tags)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) SearchSegments(ctx context.Context, params repository.SegmentSearchParams) ([]repository.SegmentSearchHit, int64, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]repository.SegmentSearchHit), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockJobRepository) SearchJobIDs(ctx context.Context, params repository.SegmentSearchParams) ([]string, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockJobRepository) ListByTag(ctx context.Context, userID uint, tag string, limit int) ([]models.TranscriptionJob, error) {
+	args := m.Called(ctx, userID, tag, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TranscriptionJob), args.Error(1)
+}
+
 // MockTranscriptionAdapter is a mock implementation of TranscriptionAdapter
 type MockTranscriptionAdapter struct {
 	mock.Mock
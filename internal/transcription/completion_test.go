@@ -0,0 +1,37 @@
+package transcription
+
+import (
+	"context"
+	"testing"
+
+	"scriberr/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAutoSummarizer struct {
+	queued []string
+}
+
+func (f *fakeAutoSummarizer) QueueAutoSummaries(ctx context.Context, job *models.TranscriptionJob) []models.Summary {
+	f.queued = append(f.queued, job.ID)
+	return []models.Summary{{TranscriptionID: job.ID}}
+}
+
+func (f *fakeAutoSummarizer) WaitForSummaries(ctx context.Context, summaries []models.Summary) []models.Summary {
+	return summaries
+}
+
+func TestRunCompletionHooks(t *testing.T) {
+	service := NewUnifiedTranscriptionService(new(MockJobRepository), "data/temp", "data/transcripts")
+	summarizer := &fakeAutoSummarizer{}
+	service.SetAutoSummarizer(summarizer)
+	var completed []string
+	service.OnJobCompleted(func(jobID string) { completed = append(completed, jobID) })
+
+	// Transcribed and imported jobs complete the same way
+	summaries := service.runCompletionHooks(context.Background(), &models.TranscriptionJob{ID: "imported-job"})
+	assert.Len(t, summaries, 1)
+	assert.Equal(t, []string{"imported-job"}, summarizer.queued)
+	assert.Equal(t, []string{"imported-job"}, completed)
+}
@@ -0,0 +1,141 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"scriberr/internal/export"
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+	"scriberr/pkg/logger"
+)
+
+// ImportTranscript parses an existing transcript file for a job whose audio
+// is already stored and completes the job without running transcription.
+// The execution record's source marks the import; the job's model
+// parameters are left alone, as no model produced the transcript. When align
+// is set, forced alignment runs in the background to add word timings; the
+// job (created by the caller in processing state) is completed once it
+// finishes, keeping the unaligned transcript on failure.
+func (u *UnifiedTranscriptionService) ImportTranscript(ctx context.Context, job *models.TranscriptionJob, filename string, data []byte, align bool) (*interfaces.TranscriptResult, error) {
+	startTime := time.Now()
+
+	audioInput, err := u.createAudioInput(job.AudioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	result, source, err := export.ParseTranscriptFile(filename, data, audioInput.Duration.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	if result.Language == "" && job.Parameters.Language != nil {
+		result.Language = *job.Parameters.Language
+	}
+
+	job.Diarization = hasSpeakers(result)
+	job.Parameters.Diarize = job.Diarization
+	if err := u.jobRepo.Update(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to update job: %w", err)
+	}
+
+	execution := &models.TranscriptionJobExecution{
+		TranscriptionJobID: job.ID,
+		StartedAt:          startTime,
+		ActualParameters:   job.Parameters,
+		Status:             models.StatusProcessing,
+		Source:             models.ExecutionSourceImported,
+	}
+	if err := u.jobRepo.CreateExecution(ctx, execution); err != nil {
+		return nil, fmt.Errorf("failed to create execution record: %w", err)
+	}
+
+	if err := u.saveTranscriptionResults(job.ID, result); err != nil {
+		return nil, err
+	}
+
+	finish := func(status models.JobStatus, errMsg string) {
+		completedAt := time.Now()
+		execution.CompletedAt = &completedAt
+		execution.Status = status
+		if errMsg != "" {
+			execution.ErrorMessage = &errMsg
+		}
+		execution.CalculateProcessingDuration()
+		_ = u.jobRepo.UpdateExecution(context.Background(), execution)
+
+		if err := u.jobRepo.UpdateStatus(context.Background(), job.ID, status); err != nil {
+			logger.Error("Failed to update imported job status", "job_id", job.ID, "error", err)
+		}
+		if u.broadcaster != nil {
+			u.broadcaster.Broadcast(job.ID, "job_update", map[string]interface{}{
+				"job_id": job.ID,
+				"status": status,
+			})
+		}
+		if status == models.StatusCompleted {
+			u.runCompletionHooks(context.Background(), job)
+		}
+	}
+
+	if !align {
+		finish(models.StatusCompleted, "")
+		logger.Info("Imported transcript", "job_id", job.ID, "source", source, "segments", len(result.Segments))
+		return result, nil
+	}
+
+	go func() {
+		alignCtx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+		defer cancel()
+
+		aligned, err := u.alignTranscript(alignCtx, job, audioInput, result)
+		if err != nil {
+			logger.Warn("Forced alignment failed, keeping imported timings", "job_id", job.ID, "error", err)
+			finish(models.StatusCompleted, fmt.Sprintf("alignment skipped: %v", err))
+			return
+		}
+		if err := u.saveTranscriptionResults(job.ID, aligned); err != nil {
+			logger.Error("Failed to save aligned transcript", "job_id", job.ID, "error", err)
+		}
+		finish(models.StatusCompleted, "")
+		logger.Info("Imported transcript aligned", "job_id", job.ID, "words", len(aligned.WordSegments))
+	}()
+
+	return result, nil
+}
+
+// alignTranscript runs forced alignment through the WhisperX adapter
+func (u *UnifiedTranscriptionService) alignTranscript(ctx context.Context, job *models.TranscriptionJob, audioInput interfaces.AudioInput, result *interfaces.TranscriptResult) (*interfaces.TranscriptResult, error) {
+	adapter, err := u.registry.GetTranscriptionAdapter(ModelWhisperX)
+	if err != nil {
+		return nil, fmt.Errorf("no alignment adapter available: %w", err)
+	}
+	aligner, ok := adapter.(interfaces.AlignmentAdapter)
+	if !ok {
+		return nil, fmt.Errorf("adapter %s does not support alignment", ModelWhisperX)
+	}
+
+	procCtx := interfaces.ProcessingContext{
+		JobID:           job.ID,
+		OutputDirectory: filepath.Join(u.outputDirectory, job.ID),
+		TempDirectory:   u.tempDirectory,
+		Metadata:        map[string]string{},
+	}
+	if err := os.MkdirAll(procCtx.OutputDirectory, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	return aligner.Align(ctx, audioInput, result, procCtx)
+}
+
+func hasSpeakers(result *interfaces.TranscriptResult) bool {
+	for _, seg := range result.Segments {
+		if seg.Speaker != nil && *seg.Speaker != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -62,6 +62,15 @@ type TranscriptSegment struct {
 	Text     string  `json:"text"`
 	Speaker  *string `json:"speaker,omitempty"`
 	Language *string `json:"language,omitempty"`
+
+	// Quality signals of Whisper models, when the model reports them
+	AvgLogprob       *float64 `json:"avg_logprob,omitempty"`
+	CompressionRatio *float64 `json:"compression_ratio,omitempty"`
+	NoSpeechProb     *float64 `json:"no_speech_prob,omitempty"`
+
+	// QualityFlags mark a segment the quality check suspects, e.g. a
+	// hallucination, for review
+	QualityFlags []string `json:"quality_flags,omitempty"`
 }
 
 // TranscriptWord represents word-level timing information
@@ -161,6 +170,13 @@ type DiarizationAdapter interface {
 	GetMinSpeakers() int
 }
 
+// AlignmentAdapter refines an existing transcript with word-level timings
+// (forced alignment) without re-transcribing the audio
+type AlignmentAdapter interface {
+	// Align aligns the transcript text to the audio and returns a transcript with word timings
+	Align(ctx context.Context, input AudioInput, transcript *TranscriptResult, procCtx ProcessingContext) (*TranscriptResult, error)
+}
+
 // CompositeAdapter can combine transcription and diarization
 type CompositeAdapter interface {
 	TranscriptionAdapter
@@ -0,0 +1,292 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"scriberr/internal/audio"
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+	"scriberr/internal/translate"
+	"scriberr/pkg/logger"
+)
+
+const (
+	// Speech regions are joined up to minRegionSeconds, enough to identify
+	// their language, and kept within Whisper's 30 second window
+	minRegionSeconds  = 4.0
+	maxRegionSeconds  = 30.0
+	minSilenceSeconds = 0.4
+)
+
+// identifierModels identify languages when the job's model cannot, in order
+// of preference
+var identifierModels = []string{ModelGroq, ModelOpenAI, ModelWhisperX}
+
+// regionTranscript is the transcript of one speech region, timed from the
+// start of the region
+type regionTranscript struct {
+	span     audio.Span
+	language string
+	result   *interfaces.TranscriptResult
+}
+
+// transcribeByLanguage is the language identification pass for recordings
+// that switch languages. It splits the audio into speech regions, identifies
+// the language of each with a model that detects languages, and transcribes
+// each region with the model and language setting routed for its language.
+// It returns nil when no model can identify languages.
+func (u *UnifiedTranscriptionService) transcribeByLanguage(ctx context.Context, job *models.TranscriptionJob, input interfaces.AudioInput, modelID string, procCtx interfaces.ProcessingContext) (*interfaces.TranscriptResult, []models.LanguageTime, error) {
+	identifierID, identifier := u.languageIdentifier(ctx, modelID)
+	if identifier == nil {
+		logger.Warn("No model can identify languages, transcribing the recording as a whole", "job_id", job.ID)
+		return nil, nil, nil
+	}
+
+	duration := input.Duration.Seconds()
+	silences, err := audio.DetectSilences(ctx, input.FilePath, duration, minSilenceSeconds)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to detect speech regions: %w", err)
+	}
+	regions := audio.SpeechRegions(silences, duration, minRegionSeconds, maxRegionSeconds)
+	if len(regions) == 0 {
+		return nil, nil, nil
+	}
+
+	dir, err := os.MkdirTemp(u.tempDirectory, "regions-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create region directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	candidates := expectedLanguages(job.Parameters)
+	routes := languageRoutes(job.Parameters.LanguageModels)
+	autoParams := job.Parameters
+	autoParams.Language = nil
+	identifyParams := u.convertParametersForModel(autoParams, identifierID)
+
+	logger.Info("Identifying languages", "job_id", job.ID, "identifier", identifierID, "regions", len(regions))
+	parts := make([]regionTranscript, 0, len(regions))
+	for i, span := range regions {
+		path := filepath.Join(dir, fmt.Sprintf("region_%04d.wav", i))
+		if err := audio.ExtractClip(ctx, input.FilePath, path, span.Start, span.End, "wav"); err != nil {
+			return nil, nil, fmt.Errorf("failed to extract speech region: %w", err)
+		}
+		regionInput := interfaces.AudioInput{
+			FilePath:   path,
+			Format:     "wav",
+			SampleRate: input.SampleRate,
+			Channels:   input.Channels,
+			Duration:   time.Duration(span.Duration() * float64(time.Second)),
+			Metadata:   map[string]string{},
+		}
+		if info, err := os.Stat(path); err == nil {
+			regionInput.Size = info.Size()
+		}
+
+		identified, err := identifier.Transcribe(ctx, regionInput, identifyParams, procCtx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to identify the language of region %d: %w", i, err)
+		}
+		u.recordTranscriptionUsage(ctx, job, identifier.GetCapabilities(), identifyParams, regionInput, identified)
+
+		detected, _ := translate.LanguageCode(identified.Language)
+		language := chooseLanguage(detected, candidates)
+		part := regionTranscript{span: span, language: language, result: identified}
+		if target := u.routeLanguage(ctx, language, modelID, routes); language != "" && (target != identifierID || language != detected) {
+			if result, err := u.transcribeRegion(ctx, job, target, language, regionInput, procCtx); err != nil {
+				logger.Warn("Region transcription failed, keeping the identification transcript", "job_id", job.ID, "region", i, "model_id", target, "error", err)
+			} else {
+				part.result = result
+			}
+		}
+		parts = append(parts, part)
+	}
+
+	result, mix := joinRegions(parts)
+	logger.Info("Transcribed by language", "job_id", job.ID, "language_mix", result.Metadata["language_mix"])
+	return result, mix, nil
+}
+
+// transcribeRegion transcribes a speech region with a model, in a language
+func (u *UnifiedTranscriptionService) transcribeRegion(ctx context.Context, job *models.TranscriptionJob, modelID, language string, input interfaces.AudioInput, procCtx interfaces.ProcessingContext) (*interfaces.TranscriptResult, error) {
+	adapter, err := u.registry.GetTranscriptionAdapter(modelID)
+	if err != nil {
+		return nil, err
+	}
+	params := job.Parameters
+	params.Language = &language
+	converted := u.convertParametersForModel(params, modelID)
+	result, err := adapter.Transcribe(ctx, input, converted, procCtx)
+	if err != nil {
+		return nil, err
+	}
+	u.recordTranscriptionUsage(ctx, job, adapter.GetCapabilities(), converted, input, result)
+	return result, nil
+}
+
+// languageIdentifier returns the job's model if it detects languages, else
+// the first ready model that does
+func (u *UnifiedTranscriptionService) languageIdentifier(ctx context.Context, modelID string) (string, interfaces.TranscriptionAdapter) {
+	for _, id := range append([]string{modelID}, identifierModels...) {
+		adapter, err := u.registry.GetTranscriptionAdapter(id)
+		if err == nil && adapter.GetCapabilities().Features["language_detection"] && adapter.IsReady(ctx) {
+			return id, adapter
+		}
+	}
+	return "", nil
+}
+
+// routeLanguage picks the model that transcribes a language: the job's route
+// for it, else the job's model if it supports the language, else the best
+// registered model for it
+func (u *UnifiedTranscriptionService) routeLanguage(ctx context.Context, language, modelID string, routes map[string]string) string {
+	ready := func(id string) bool {
+		adapter, err := u.registry.GetTranscriptionAdapter(id)
+		return err == nil && adapter.IsReady(ctx)
+	}
+	if id, ok := routes[language]; ok && ready(id) {
+		return id
+	}
+	if capabilities, err := u.registry.GetCapabilities(modelID); err == nil && supportsLanguage(capabilities, language) {
+		return modelID
+	}
+	if id, err := u.registry.SelectBestTranscriptionModel(interfaces.ModelRequirements{Language: language}); err == nil && ready(id) {
+		return id
+	}
+	return modelID
+}
+
+// supportsLanguage tells whether a model transcribes a language
+func supportsLanguage(capabilities interfaces.ModelCapabilities, language string) bool {
+	primary, _, _ := strings.Cut(language, "-")
+	for _, l := range capabilities.SupportedLanguages {
+		if l == "*" || strings.EqualFold(l, language) || strings.EqualFold(l, primary) {
+			return true
+		}
+	}
+	return false
+}
+
+// expectedLanguages returns the languages a job expects, its main language
+// first
+func expectedLanguages(params models.WhisperXParams) []string {
+	var languages []string
+	add := func(language string) {
+		if code, ok := translate.LanguageCode(language); ok && !slices.Contains(languages, code) {
+			languages = append(languages, code)
+		}
+	}
+	if params.Language != nil && *params.Language != "" && *params.Language != "auto" {
+		add(*params.Language)
+	}
+	if params.Languages != nil {
+		for _, language := range strings.Split(*params.Languages, ",") {
+			add(language)
+		}
+	}
+	return languages
+}
+
+// languageRoutes parses language=model routes
+func languageRoutes(spec *string) map[string]string {
+	routes := map[string]string{}
+	if spec == nil {
+		return routes
+	}
+	for _, route := range strings.Split(*spec, ",") {
+		language, model, ok := strings.Cut(route, "=")
+		if !ok {
+			continue
+		}
+		if code, ok := translate.LanguageCode(language); ok && strings.TrimSpace(model) != "" {
+			routes[code] = strings.TrimSpace(model)
+		}
+	}
+	return routes
+}
+
+// chooseLanguage returns the detected language when the job expects it, or
+// expects no language in particular, else the job's main language
+func chooseLanguage(detected string, expected []string) string {
+	if len(expected) == 0 || slices.Contains(expected, detected) {
+		return detected
+	}
+	return expected[0]
+}
+
+// joinRegions joins region transcripts into the transcript of the recording,
+// with the language of each segment, and measures the speech time of each
+// language
+func joinRegions(parts []regionTranscript) (*interfaces.TranscriptResult, []models.LanguageTime) {
+	result := &interfaces.TranscriptResult{Metadata: map[string]string{}}
+	seconds := map[string]float64{}
+	total, spoken := 0.0, 0.0
+	var texts, modelsUsed []string
+	for _, part := range parts {
+		offset := part.span.Start
+		for _, seg := range part.result.Segments {
+			seg.Start += offset
+			seg.End += offset
+			if seg.End <= seg.Start {
+				// Models without timestamps time segments by the region
+				seg.Start, seg.End = part.span.Start, part.span.End
+			}
+			if part.language != "" {
+				language := part.language
+				seg.Language = &language
+			}
+			result.Segments = append(result.Segments, seg)
+		}
+		for _, word := range part.result.WordSegments {
+			word.Start += offset
+			word.End += offset
+			result.WordSegments = append(result.WordSegments, word)
+		}
+		if text := strings.TrimSpace(part.result.Text); text != "" {
+			texts = append(texts, text)
+		}
+		if part.result.ModelUsed != "" && !slices.Contains(modelsUsed, part.result.ModelUsed) {
+			modelsUsed = append(modelsUsed, part.result.ModelUsed)
+		}
+		result.ProcessingTime += part.result.ProcessingTime
+		result.Confidence += part.result.Confidence * part.span.Duration()
+		spoken += part.span.Duration()
+		if part.language != "" && len(part.result.Segments) > 0 {
+			seconds[part.language] += part.span.Duration()
+			total += part.span.Duration()
+		}
+	}
+	result.Text = strings.Join(texts, " ")
+	result.ModelUsed = strings.Join(modelsUsed, ",")
+	if spoken > 0 {
+		result.Confidence /= spoken
+	}
+
+	mix := make([]models.LanguageTime, 0, len(seconds))
+	for language, s := range seconds {
+		mix = append(mix, models.LanguageTime{Language: language, Seconds: s, Share: s / total})
+	}
+	sort.Slice(mix, func(i, j int) bool {
+		if mix[i].Seconds != mix[j].Seconds {
+			return mix[i].Seconds > mix[j].Seconds
+		}
+		return mix[i].Language < mix[j].Language
+	})
+	shares := make([]string, len(mix))
+	for i, m := range mix {
+		shares[i] = m.Language + ":" + strconv.FormatFloat(m.Share, 'f', 2, 64)
+	}
+	if len(mix) > 0 {
+		result.Language = mix[0].Language
+		result.Metadata["language_mix"] = strings.Join(shares, ",")
+	}
+	return result, mix
+}
@@ -174,6 +174,11 @@ func (mt *MultiTrackTranscriber) ProcessMultiTrackTranscription(ctx context.Cont
 		"job_id", jobID,
 		"merge_duration_ms", mergeDuration)
 
+	// Tracks are checked one by one, but merging rebuilds the segments, and
+	// with them their quality flags
+	u := mt.unifiedProcessor.unifiedService
+	mergedTranscript = u.checkQuality(ctx, &job, mergedTranscript, u.modelCapabilities(&job))
+
 	// Serialize merged transcript to JSON
 	mergedTranscriptJSON, err := json.Marshal(mergedTranscript)
 	if err != nil {
@@ -29,6 +29,9 @@ func NewProcessingPipeline() *ProcessingPipeline {
 	// Register default preprocessors
 	pipeline.RegisterPreprocessor(&AudioFormatPreprocessor{})
 
+	// Register default postprocessors
+	pipeline.RegisterPostprocessor(&QualityPostprocessor{})
+
 	return pipeline
 }
 
@@ -61,6 +64,25 @@ func (p *ProcessingPipeline) ProcessAudio(ctx context.Context, input interfaces.
 	return currentInput, nil
 }
 
+// ProcessTranscript applies all applicable postprocessors to a transcript,
+// stopping at the first that fails
+func (p *ProcessingPipeline) ProcessTranscript(ctx context.Context, result *interfaces.TranscriptResult, capabilities interfaces.ModelCapabilities, params map[string]interface{}) (*interfaces.TranscriptResult, error) {
+	current := result
+
+	for _, postprocessor := range p.postprocessors {
+		if postprocessor.AppliesTo(capabilities, params) {
+			logger.Info("Applying postprocessor", "type", fmt.Sprintf("%T", postprocessor))
+			processed, err := postprocessor.ProcessTranscript(ctx, current, params)
+			if err != nil {
+				return nil, fmt.Errorf("%T failed: %w", postprocessor, err)
+			}
+			current = processed
+		}
+	}
+
+	return current, nil
+}
+
 // AudioFormatPreprocessor converts audio to required formats
 type AudioFormatPreprocessor struct{}
 
@@ -0,0 +1,251 @@
+package pipeline
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	"scriberr/internal/transcription/interfaces"
+	"scriberr/pkg/logger"
+)
+
+// Quality check modes, set by the quality_check parameter
+const (
+	QualityCheckFlag = "flag" // Keep suspect segments, marked for review
+	QualityCheckDrop = "drop" // Remove suspect segments from the transcript
+	QualityCheckOff  = "off"
+)
+
+// Quality flags of suspect segments
+const (
+	FlagRepetition           = "repetition"            // The text loops over the same words
+	FlagSilenceHallucination = "silence_hallucination" // Text produced over silence, e.g. "Thanks for watching"
+	FlagSpeechRate           = "speech_rate"           // Far more or less text than can be spoken in the segment's duration
+)
+
+const (
+	// Thresholds Whisper itself uses to reject a decoding
+	maxCompressionRatio = 2.4
+	noSpeechThreshold   = 0.6
+	logprobThreshold    = -1.0
+
+	// A phrase repeated minRepeats times in a row over at least
+	// minLoopTokens tokens is a repetition loop, as are minRepeats segments
+	// in a row with the same text
+	minRepeats    = 4
+	minLoopTokens = 8
+	// maxRepeatedPhrase is the longest phrase, in tokens, checked for loops
+	maxRepeatedPhrase = 6
+
+	// maxCharsPerSecond is faster than anyone speaks in any language
+	maxCharsPerSecond = 25.0
+	// A segment this long with this few characters per second is mostly
+	// silence with a stray phrase
+	minSlowSeconds     = 20.0
+	minCharsPerSecond  = 0.5
+	minRateCheckLength = 10 // Characters below which fast segments are left alone
+)
+
+// silencePhrases are phrases Whisper writes over silence and music, learned
+// from subtitled videos. Segments consisting of one are suspect.
+var silencePhrases = []string{
+	"thanks for watching",
+	"thank you for watching",
+	"thank you so much for watching",
+	"please subscribe",
+	"please like and subscribe",
+	"like and subscribe",
+	"subscribe to my channel",
+	"dont forget to like and subscribe",
+	"subtitles by the amaraorg community",
+	"hãy subscribe cho kênh ghiền mì gõ để không bỏ lỡ những video hấp dẫn",
+	"cảm ơn các bạn đã theo dõi",
+	"ご視聴ありがとうございました",
+	"请不吝点赞 订阅 转发 打赏支持明镜与点点栏目",
+	"字幕由amaraorg社区提供",
+	"untertitel im auftrag des zdf für funk 2017",
+	"untertitel der amaraorg community",
+	"soustitres réalisés par la communauté damaraorg",
+	"merci davoir regardé cette vidéo",
+	"subtítulos realizados por la comunidad de amaraorg",
+	"gracias por ver el video",
+}
+
+// QualityPostprocessor finds segments that look like Whisper hallucinations
+// or recognition failures: repetition loops, text over silence and
+// implausible speech rates. Depending on quality_check they are flagged for
+// review or dropped.
+type QualityPostprocessor struct{}
+
+// AppliesTo checks whether the quality check is enabled
+func (q *QualityPostprocessor) AppliesTo(capabilities interfaces.ModelCapabilities, params map[string]interface{}) bool {
+	mode, _ := params["quality_check"].(string)
+	return mode != QualityCheckOff
+}
+
+// ProcessTranscript flags or drops suspect segments
+func (q *QualityPostprocessor) ProcessTranscript(ctx context.Context, result *interfaces.TranscriptResult, params map[string]interface{}) (*interfaces.TranscriptResult, error) {
+	drop := params["quality_check"] == QualityCheckDrop
+
+	kept := make([]interfaces.TranscriptSegment, 0, len(result.Segments))
+	var dropped []interfaces.TranscriptSegment
+	flagged := 0
+	for i, seg := range result.Segments {
+		var previous []interfaces.TranscriptSegment
+		if i > 0 {
+			previous = result.Segments[max(i-minRepeats+1, 0):i]
+		}
+		flags := CheckSegment(seg, previous)
+		switch {
+		case len(flags) == 0:
+			kept = append(kept, seg)
+		case drop:
+			dropped = append(dropped, seg)
+		default:
+			seg.QualityFlags = flags
+			kept = append(kept, seg)
+			flagged++
+		}
+	}
+	result.Segments = kept
+
+	if len(dropped) > 0 {
+		words := make([]interfaces.TranscriptWord, 0, len(result.WordSegments))
+		for _, w := range result.WordSegments {
+			if !within(w, dropped) {
+				words = append(words, w)
+			}
+		}
+		result.WordSegments = words
+		texts := make([]string, 0, len(kept))
+		for _, seg := range kept {
+			if text := strings.TrimSpace(seg.Text); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		result.Text = strings.Join(texts, " ")
+	}
+	if len(dropped) > 0 || flagged > 0 {
+		logger.Info("Quality check found suspect segments", "flagged", flagged, "dropped", len(dropped))
+	}
+	return result, nil
+}
+
+// ProcessDiarization leaves diarization results unchanged
+func (q *QualityPostprocessor) ProcessDiarization(ctx context.Context, result *interfaces.DiarizationResult, params map[string]interface{}) (*interfaces.DiarizationResult, error) {
+	return result, nil
+}
+
+// CheckSegment returns the quality flags of a segment. previous holds the
+// segments right before it, which a repetition loop may span.
+func CheckSegment(seg interfaces.TranscriptSegment, previous []interfaces.TranscriptSegment) []string {
+	text := normalize(seg.Text)
+	if text == "" {
+		return nil
+	}
+	var flags []string
+
+	if (seg.CompressionRatio != nil && *seg.CompressionRatio > maxCompressionRatio) || loops(tokens(text)) || repeatsPrevious(text, previous) {
+		flags = append(flags, FlagRepetition)
+	}
+
+	silent := seg.NoSpeechProb != nil && *seg.NoSpeechProb > noSpeechThreshold &&
+		(seg.AvgLogprob == nil || *seg.AvgLogprob < logprobThreshold)
+	if silent || isSilencePhrase(text) {
+		flags = append(flags, FlagSilenceHallucination)
+	}
+
+	if duration := seg.End - seg.Start; duration > 0 {
+		chars := float64(len([]rune(text)))
+		rate := chars / duration
+		if (chars >= minRateCheckLength && rate > maxCharsPerSecond) || (duration >= minSlowSeconds && rate < minCharsPerSecond) {
+			flags = append(flags, FlagSpeechRate)
+		}
+	}
+	return flags
+}
+
+// normalize lowercases text and strips punctuation, keeping single spaces
+func normalize(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// tokens splits normalized text into words, or into characters for scripts
+// written without spaces
+func tokens(text string) []string {
+	words := strings.Fields(text)
+	var out []string
+	for _, w := range words {
+		runes := []rune(w)
+		if len(runes) > 1 && unicode.In(runes[0], unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai) {
+			for _, r := range runes {
+				out = append(out, string(r))
+			}
+			continue
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+// loops tells whether a phrase of up to maxRepeatedPhrase tokens repeats
+// in a loop
+func loops(toks []string) bool {
+	for n := 1; n <= maxRepeatedPhrase; n++ {
+		for start := 0; start+n*minRepeats <= len(toks); start++ {
+			repeats := 1
+			for next := start + n; next+n <= len(toks) && slices.Equal(toks[start:start+n], toks[next:next+n]); next += n {
+				repeats++
+			}
+			if repeats >= minRepeats && repeats*n >= minLoopTokens {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// repeatsPrevious tells whether text ends a run of minRepeats segments with
+// the same text
+func repeatsPrevious(text string, previous []interfaces.TranscriptSegment) bool {
+	if len(previous) < minRepeats-1 {
+		return false
+	}
+	for _, p := range previous[len(previous)-(minRepeats-1):] {
+		if normalize(p.Text) != text {
+			return false
+		}
+	}
+	return true
+}
+
+// isSilencePhrase tells whether normalized text is one of the phrases
+// written over silence
+func isSilencePhrase(text string) bool {
+	for _, phrase := range silencePhrases {
+		if text == phrase {
+			return true
+		}
+	}
+	return false
+}
+
+// within tells whether a word falls inside one of segments
+func within(w interfaces.TranscriptWord, segments []interfaces.TranscriptSegment) bool {
+	for _, seg := range segments {
+		if w.Start >= seg.Start && w.End <= seg.End {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"scriberr/internal/transcription/interfaces"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func floatPtr(f float64) *float64 { return &f }
+
+func TestCheckSegment(t *testing.T) {
+	seg := func(start, end float64, text string) interfaces.TranscriptSegment {
+		return interfaces.TranscriptSegment{Start: start, End: end, Text: text}
+	}
+
+	assert.Empty(t, CheckSegment(seg(0, 3, "Let's go over the numbers for this quarter."), nil))
+	assert.Empty(t, CheckSegment(seg(0, 1, "No, no, no."), nil))
+	assert.Empty(t, CheckSegment(seg(0, 30, ""), nil))
+
+	// Repetition loops, within a segment, in scripts without spaces and
+	// across segments
+	assert.Equal(t, []string{FlagRepetition}, CheckSegment(seg(0, 10, "I mean, I mean, I mean, I mean, I mean, we should"), nil))
+	assert.Equal(t, []string{FlagRepetition}, CheckSegment(seg(0, 5, "谢谢谢谢谢谢谢谢谢谢"), nil))
+	previous := []interfaces.TranscriptSegment{seg(0, 2, "Okay."), seg(2, 4, "okay"), seg(4, 6, "Okay!")}
+	assert.Equal(t, []string{FlagRepetition}, CheckSegment(seg(6, 8, "Okay."), previous))
+	assert.Empty(t, CheckSegment(seg(6, 8, "Okay."), previous[1:]))
+	ratio := seg(0, 5, "Sure.")
+	ratio.CompressionRatio = floatPtr(2.8)
+	assert.Equal(t, []string{FlagRepetition}, CheckSegment(ratio, nil))
+
+	// Text over silence, by phrase or by the model's own signals
+	assert.Equal(t, []string{FlagSilenceHallucination}, CheckSegment(seg(50, 53, "Thanks for watching!"), nil))
+	assert.Equal(t, []string{FlagSilenceHallucination}, CheckSegment(seg(50, 55, "Sous-titres réalisés par la communauté d'Amara.org"), nil))
+	silent := seg(0, 3, "And then we left.")
+	silent.NoSpeechProb = floatPtr(0.9)
+	silent.AvgLogprob = floatPtr(-1.3)
+	assert.Equal(t, []string{FlagSilenceHallucination}, CheckSegment(silent, nil))
+	silent.AvgLogprob = floatPtr(-0.2)
+	assert.Empty(t, CheckSegment(silent, nil))
+
+	// Implausible speech rates
+	assert.Equal(t, []string{FlagSpeechRate}, CheckSegment(seg(0, 0.5, "This sentence is far too long for half a second."), nil))
+	assert.Equal(t, []string{FlagSpeechRate}, CheckSegment(seg(0, 40, "Hmm."), nil))
+}
+
+func TestQualityPostprocessor(t *testing.T) {
+	newResult := func() *interfaces.TranscriptResult {
+		return &interfaces.TranscriptResult{
+			Text: "Welcome back. Thank you for watching.",
+			Segments: []interfaces.TranscriptSegment{
+				{Start: 0, End: 2, Text: "Welcome back."},
+				{Start: 30, End: 32, Text: "Thank you for watching."},
+			},
+			WordSegments: []interfaces.TranscriptWord{
+				{Start: 0, End: 1, Word: "Welcome"},
+				{Start: 1, End: 2, Word: "back."},
+				{Start: 30, End: 31, Word: "Thank"},
+				{Start: 31, End: 32, Word: "watching."},
+			},
+		}
+	}
+	q := &QualityPostprocessor{}
+	ctx := context.Background()
+
+	assert.True(t, q.AppliesTo(interfaces.ModelCapabilities{}, map[string]interface{}{"quality_check": ""}))
+	assert.False(t, q.AppliesTo(interfaces.ModelCapabilities{}, map[string]interface{}{"quality_check": QualityCheckOff}))
+
+	flagged, err := q.ProcessTranscript(ctx, newResult(), map[string]interface{}{"quality_check": QualityCheckFlag})
+	assert.NoError(t, err)
+	assert.Len(t, flagged.Segments, 2)
+	assert.Empty(t, flagged.Segments[0].QualityFlags)
+	assert.Equal(t, []string{FlagSilenceHallucination}, flagged.Segments[1].QualityFlags)
+	assert.Len(t, flagged.WordSegments, 4)
+
+	dropped, err := q.ProcessTranscript(ctx, newResult(), map[string]interface{}{"quality_check": QualityCheckDrop})
+	assert.NoError(t, err)
+	assert.Len(t, dropped.Segments, 1)
+	assert.Equal(t, "Welcome back.", dropped.Text)
+	assert.Len(t, dropped.WordSegments, 2)
+}
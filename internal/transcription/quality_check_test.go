@@ -0,0 +1,48 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingPostprocessor struct{}
+
+func (failingPostprocessor) ProcessTranscript(ctx context.Context, result *interfaces.TranscriptResult, params map[string]interface{}) (*interfaces.TranscriptResult, error) {
+	return nil, errors.New("broken")
+}
+
+func (failingPostprocessor) ProcessDiarization(ctx context.Context, result *interfaces.DiarizationResult, params map[string]interface{}) (*interfaces.DiarizationResult, error) {
+	return result, nil
+}
+
+func (failingPostprocessor) AppliesTo(capabilities interfaces.ModelCapabilities, params map[string]interface{}) bool {
+	return true
+}
+
+func TestCheckQuality(t *testing.T) {
+	service := NewUnifiedTranscriptionService(new(MockJobRepository), "data/temp", "data/transcripts")
+	job := &models.TranscriptionJob{ID: "job"}
+	result := &interfaces.TranscriptResult{Segments: []interfaces.TranscriptSegment{
+		{Start: 0, End: 2, Text: "Good morning."},
+		{Start: 40, End: 42, Text: "Thanks for watching!", NoSpeechProb: floatPtr(0.9)},
+	}}
+
+	checked := service.checkQuality(context.Background(), job, result, interfaces.ModelCapabilities{})
+	require.Len(t, checked.Segments, 2)
+	assert.NotEmpty(t, checked.Segments[1].QualityFlags)
+
+	// A broken postprocessor fails the pipeline; the transcript is kept
+	service.pipeline.RegisterPostprocessor(failingPostprocessor{})
+	_, err := service.pipeline.ProcessTranscript(context.Background(), result, interfaces.ModelCapabilities{}, nil)
+	assert.Error(t, err)
+	assert.Same(t, result, service.checkQuality(context.Background(), job, result, interfaces.ModelCapabilities{}))
+}
+
+func floatPtr(f float64) *float64 { return &f }
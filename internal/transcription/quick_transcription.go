@@ -17,6 +17,7 @@ import (
 	"scriberr/internal/models"
 	"scriberr/internal/repository"
 	"scriberr/internal/transcription/interfaces"
+	"scriberr/internal/waveform"
 	"scriberr/pkg/logger"
 
 	"github.com/google/uuid"
@@ -46,6 +47,12 @@ type QuickTranscriptionService struct {
 	tempDir          string
 	cleanupTicker    *time.Ticker
 	stopCleanup      chan bool
+	waveforms        *waveform.Service
+}
+
+// SetWaveformService sets the service used to precompute waveforms of finalized live sessions
+func (qs *QuickTranscriptionService) SetWaveformService(s *waveform.Service) {
+	qs.waveforms = s
 }
 
 // NewQuickTranscriptionService creates a new quick transcription service
@@ -357,7 +364,11 @@ func (qs *QuickTranscriptionService) mergeChunkIntoMaster(ctx context.Context, m
 				masterResult.Segments = append(masterResult.Segments, newResult.Segments[i])
 			}
 
-			updatedJSON, _ := json.Marshal(masterResult)
+			// Chunks are checked one by one; loops across chunks show up here
+			u := qs.unifiedProcessor.unifiedService
+			checked := u.checkQuality(ctx, master, &masterResult, u.modelCapabilities(master))
+
+			updatedJSON, _ := json.Marshal(checked)
 			_ = qs.jobRepo.UpdateTranscript(ctx, masterID, string(updatedJSON))
 			return
 		}
@@ -477,6 +488,8 @@ func (qs *QuickTranscriptionService) FinalizeJob(ctx context.Context, sessionID
 	// Update the master job's audio path in the database
 	if err := qs.jobRepo.UpdateAudioPath(ctx, sessionID, outputPath); err != nil {
 		logger.Error("Failed to update master job audio path", "session_id", sessionID, "error", err)
+	} else if qs.waveforms != nil {
+		qs.waveforms.Schedule(sessionID)
 	}
 
 	// Cleanup the list file
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"scriberr/internal/export"
 	"scriberr/internal/llm"
 	"scriberr/internal/models"
 	"scriberr/internal/repository"
@@ -55,9 +56,27 @@ type UnifiedTranscriptionService struct {
 	defaultModelIDs       map[string]string      // Default model IDs for each task type
 	multiTrackTranscriber *MultiTrackTranscriber // For termination support
 	jobRepo               repository.JobRepository
+	speakerMappingRepo    repository.SpeakerMappingRepository
 	webhookService        *webhook.Service
 	broadcaster           *sse.Broadcaster
-	llmService            llm.Service
+	usageRecorder         llm.UsageRecorder
+	smartAnalyzer         SmartAnalyzer
+	autoSummarizer        AutoSummarizer
+	completionHooks       []func(jobID string)
+}
+
+// SmartAnalyzer proposes corrections of fresh transcripts for review
+type SmartAnalyzer interface {
+	// Analyze stores the corrections proposed for the job's transcript
+	Analyze(ctx context.Context, job *models.TranscriptionJob, result *interfaces.TranscriptResult) error
+}
+
+// AutoSummarizer generates the summaries attached to completed jobs
+type AutoSummarizer interface {
+	// QueueAutoSummaries queues the job's automatic summaries, if any
+	QueueAutoSummaries(ctx context.Context, job *models.TranscriptionJob) []models.Summary
+	// WaitForSummaries returns the summaries once they are finished
+	WaitForSummaries(ctx context.Context, summaries []models.Summary) []models.Summary
 }
 
 // NewUnifiedTranscriptionService creates a new unified transcription service
@@ -83,9 +102,46 @@ func (u *UnifiedTranscriptionService) SetBroadcaster(b *sse.Broadcaster) {
 	u.broadcaster = b
 }
 
-// SetLLMService sets the LLM service for smart analysis
-func (u *UnifiedTranscriptionService) SetLLMService(s llm.Service) {
-	u.llmService = s
+// SetSpeakerMappingRepository sets the source of the custom speaker names
+// used in webhook exports
+func (u *UnifiedTranscriptionService) SetSpeakerMappingRepository(repo repository.SpeakerMappingRepository) {
+	u.speakerMappingRepo = repo
+}
+
+// SetSmartAnalyzer sets the smart analysis stage run on fresh transcripts
+func (u *UnifiedTranscriptionService) SetSmartAnalyzer(a SmartAnalyzer) {
+	u.smartAnalyzer = a
+}
+
+// SetUsageRecorder sets the recorder of cloud transcription usage
+func (u *UnifiedTranscriptionService) SetUsageRecorder(r llm.UsageRecorder) {
+	u.usageRecorder = r
+}
+
+// SetAutoSummarizer sets the generator of the summaries run automatically
+// when jobs complete
+func (u *UnifiedTranscriptionService) SetAutoSummarizer(s AutoSummarizer) {
+	u.autoSummarizer = s
+}
+
+// OnJobCompleted registers a hook run after a job's transcript is completed,
+// either by transcription or import. Hooks must not block.
+func (u *UnifiedTranscriptionService) OnJobCompleted(hook func(jobID string)) {
+	u.completionHooks = append(u.completionHooks, hook)
+}
+
+// runCompletionHooks queues the automatic summaries of a job whose transcript
+// was completed, by transcription or import, and runs the completion hooks.
+// It returns the queued summaries; their failures never fail the job.
+func (u *UnifiedTranscriptionService) runCompletionHooks(ctx context.Context, job *models.TranscriptionJob) []models.Summary {
+	var summaries []models.Summary
+	if u.autoSummarizer != nil {
+		summaries = u.autoSummarizer.QueueAutoSummaries(ctx, job)
+	}
+	for _, hook := range u.completionHooks {
+		hook(job.ID)
+	}
+	return summaries
 }
 
 // Initialize prepares all registered models for use
@@ -129,6 +185,7 @@ func (u *UnifiedTranscriptionService) ProcessJob(ctx context.Context, jobID stri
 		StartedAt:          startTime,
 		ActualParameters:   job.Parameters,
 		Status:             models.StatusProcessing,
+		Source:             models.ExecutionSourceTranscribed,
 	}
 
 	if err := u.jobRepo.CreateExecution(ctx, execution); err != nil {
@@ -156,23 +213,40 @@ func (u *UnifiedTranscriptionService) ProcessJob(ctx context.Context, jobID stri
 
 		_ = u.jobRepo.UpdateExecution(ctx, execution)
 
+		// Summaries attached to the job or its owner run once it completes
+		var summaries []models.Summary
+		if status == models.StatusCompleted {
+			summaries = u.runCompletionHooks(ctx, job)
+		}
+
 		// Broadcast update via SSE
-		if u.broadcaster != nil {
-			u.broadcaster.Broadcast(jobID, "job_update", map[string]interface{}{
-				"job_id": jobID,
-				"status": status,
-				"error":  errorMsg,
-			})
+		u.broadcastJobUpdate(jobID, status, errorMsg, summaries)
+
+		hasWebhook := job.Parameters.CallbackURL != nil && *job.Parameters.CallbackURL != ""
+		if !hasWebhook && len(summaries) == 0 {
+			return
 		}
 
-		// Trigger webhook if callback URL is present
-		if job.Parameters.CallbackURL != nil && *job.Parameters.CallbackURL != "" {
+		// Wait for the summaries before the webhook, without blocking the
+		// main process
+		go func() {
+			if len(summaries) > 0 {
+				summaryCtx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+				summaries = u.autoSummarizer.WaitForSummaries(summaryCtx, summaries)
+				cancel()
+				u.broadcastJobUpdate(jobID, status, errorMsg, summaries)
+			}
+			if !hasWebhook {
+				return
+			}
+
 			payload := webhook.WebhookPayload{
 				JobID:        job.ID,
 				Status:       status,
 				AudioPath:    job.AudioPath,
 				Transcript:   job.Transcript,
 				Summary:      job.Summary,
+				Summaries:    webhook.NewSummaries(summaries),
 				ErrorMessage: execution.ErrorMessage,
 				CompletedAt:  completedAt,
 				Metadata: map[string]interface{}{
@@ -182,17 +256,24 @@ func (u *UnifiedTranscriptionService) ProcessJob(ctx context.Context, jobID stri
 				},
 			}
 
-			// Send webhook asynchronously to not block the main process
-			go func() {
-				// Create a new context with timeout for the webhook
-				webhookCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-				defer cancel()
-
-				if err := u.webhookService.SendWebhook(webhookCtx, *job.Parameters.CallbackURL, payload); err != nil {
-					logger.Error("Failed to send webhook", "job_id", job.ID, "error", err)
+			// Create a new context with timeout for the webhook
+			webhookCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			// The job was loaded before processing, so pick up the saved
+			// transcript and summary
+			if status == models.StatusCompleted {
+				if fresh, err := u.jobRepo.FindByID(webhookCtx, jobID); err == nil && fresh != nil {
+					payload.Transcript = fresh.Transcript
+					payload.Summary = fresh.Summary
+					payload.Exports = u.renderWebhookExports(webhookCtx, fresh)
 				}
-			}()
-		}
+			}
+
+			if err := u.webhookService.SendWebhook(webhookCtx, *job.Parameters.CallbackURL, payload); err != nil {
+				logger.Error("Failed to send webhook", "job_id", job.ID, "error", err)
+			}
+		}()
 	}
 
 	// Check for multi-track processing
@@ -218,6 +299,23 @@ func (u *UnifiedTranscriptionService) ProcessJob(ctx context.Context, jobID stri
 	return nil
 }
 
+// broadcastJobUpdate sends a job's status, and the state of its automatic
+// summaries, to its SSE subscribers
+func (u *UnifiedTranscriptionService) broadcastJobUpdate(jobID string, status models.JobStatus, errorMsg string, summaries []models.Summary) {
+	if u.broadcaster == nil {
+		return
+	}
+	payload := map[string]interface{}{
+		"job_id": jobID,
+		"status": status,
+		"error":  errorMsg,
+	}
+	if len(summaries) > 0 {
+		payload["summaries"] = webhook.NewSummaries(summaries)
+	}
+	u.broadcaster.Broadcast(jobID, "job_update", payload)
+}
+
 // processSingleTrackJob handles single audio file transcription
 //
 //nolint:gocyclo // Orchestrator function with multiple steps
@@ -339,12 +437,28 @@ func (u *UnifiedTranscriptionService) processSingleTrackJob(ctx context.Context,
 			return fmt.Errorf("failed to get transcription adapter: %w", err)
 		}
 
-		// Convert parameters for this specific model
-		params := u.convertParametersForModel(job.Parameters, transcriptionModelID)
+		// Transcribe each speech region in its own language when asked to
+		if job.Parameters.LanguageDetection {
+			var mix []models.LanguageTime
+			transcriptResult, mix, err = u.transcribeByLanguage(ctx, job, preprocessedInput, transcriptionModelID, procCtx)
+			if err != nil {
+				logger.Warn("Language identification failed, transcribing the recording as a whole", "job_id", job.ID, "error", err)
+				transcriptResult = nil
+			}
+			if err := u.jobRepo.UpdateLanguageMix(ctx, job.ID, mix); err != nil {
+				logger.Warn("Failed to save language mix", "job_id", job.ID, "error", err)
+			}
+		}
 
-		transcriptResult, err = transcriptionAdapter.Transcribe(ctx, preprocessedInput, params, procCtx)
-		if err != nil {
-			return fmt.Errorf("transcription failed: %w", err)
+		if transcriptResult == nil {
+			// Convert parameters for this specific model
+			params := u.convertParametersForModel(job.Parameters, transcriptionModelID)
+
+			transcriptResult, err = transcriptionAdapter.Transcribe(ctx, preprocessedInput, params, procCtx)
+			if err != nil {
+				return fmt.Errorf("transcription failed: %w", err)
+			}
+			u.recordTranscriptionUsage(ctx, job, transcriptionAdapter.GetCapabilities(), params, preprocessedInput, transcriptResult)
 		}
 	}
 
@@ -375,15 +489,15 @@ func (u *UnifiedTranscriptionService) processSingleTrackJob(ctx context.Context,
 		}
 	}
 
-	// Apply smart analysis using LLM if available and suitable
-	if u.llmService != nil && transcriptResult != nil {
-		logger.Info("Applying smart analysis for speaker/role identification")
-		title := "Untitled"
-		if job.Title != nil {
-			title = *job.Title
-		}
-		if err := u.ApplySmartAnalysis(ctx, title, job.ID, transcriptResult); err != nil {
-			logger.Warn("Smart analysis failed, proceeding with raw transcript", "error", err)
+	// Flag or drop hallucinated and low-quality segments
+	if transcriptResult != nil {
+		transcriptResult = u.checkQuality(ctx, job, transcriptResult, capabilities)
+	}
+
+	// Propose corrections for review; the transcript is saved as recognized
+	if u.smartAnalyzer != nil && transcriptResult != nil {
+		if err := u.smartAnalyzer.Analyze(ctx, job, transcriptResult); err != nil {
+			logger.Warn("Smart analysis failed, proceeding without proposed corrections", "job_id", job.ID, "error", err)
 		}
 	}
 
@@ -397,6 +511,27 @@ func (u *UnifiedTranscriptionService) processSingleTrackJob(ctx context.Context,
 	return nil
 }
 
+// checkQuality flags or drops the hallucinated and low-quality segments of a
+// transcript, as the job's quality_check parameter asks. The transcript is
+// kept as recognized when the check fails.
+func (u *UnifiedTranscriptionService) checkQuality(ctx context.Context, job *models.TranscriptionJob, result *interfaces.TranscriptResult, capabilities interfaces.ModelCapabilities) *interfaces.TranscriptResult {
+	checked, err := u.pipeline.ProcessTranscript(ctx, result, capabilities, map[string]interface{}{
+		"quality_check": job.Parameters.QualityCheck,
+	})
+	if err != nil {
+		logger.Warn("Quality check failed, keeping the transcript as recognized", "job_id", job.ID, "error", err)
+		return result
+	}
+	return checked
+}
+
+// modelCapabilities returns the capabilities of the model transcribing a job
+func (u *UnifiedTranscriptionService) modelCapabilities(job *models.TranscriptionJob) interfaces.ModelCapabilities {
+	modelID, _, _ := u.selectModels(job.Parameters)
+	capabilities, _ := u.registry.GetCapabilities(modelID)
+	return capabilities
+}
+
 // processMultiTrackJob handles multi-track audio processing
 func (u *UnifiedTranscriptionService) processMultiTrackJob(ctx context.Context, job *models.TranscriptionJob) error {
 	logger.Info("Processing multi-track job", "job_id", job.ID, "track_count", len(job.MultiTrackFiles))
@@ -428,120 +563,32 @@ func (u *UnifiedTranscriptionService) IsMultiTrackJob(jobID string) bool {
 	return u.multiTrackTranscriber != nil && u.multiTrackTranscriber.IsJobMultiTrack(jobID)
 }
 
-// ApplySmartAnalysis uses LLM to identify speakers and roles from the transcript
-func (u *UnifiedTranscriptionService) ApplySmartAnalysis(ctx context.Context, jobTitle string, jobID string, result *interfaces.TranscriptResult) error {
-	if u.llmService == nil {
-		return fmt.Errorf("LLM service not configured")
-	}
-
-	// 1. Prepare segments for the LLM
-	var promptBuilder strings.Builder
-	promptBuilder.WriteString(fmt.Sprintf("Meeting/Recording Title: %s\n", jobTitle))
-	promptBuilder.WriteString("Act as a professional Meeting Secretary. Your task is to proofread and structure the raw transcript segments below.\n")
-	promptBuilder.WriteString("1. **Speaker Identification**: Assign consistent speaker labels (e.g., 'Speaker 1', 'Speaker 2'). If a speaker is unclear, infer from context or carry over the previous speaker. Ensure NO segment is left without a speaker.\n")
-	promptBuilder.WriteString("2. **Proofreading**: Fix spelling errors, typos (e.g., 'phép tạp' -> 'phức tạp'), and grammatical issues in the Vietnamese text while preserving the original meaning and tone.\n")
-	promptBuilder.WriteString("3. **Formatting**: Ensure text is capitalized and punctuated correctly.\n")
-	promptBuilder.WriteString("4. **Summary**: Provide a concise meeting summary.\n\n")
-	
-	promptBuilder.WriteString("Return STRICTLY a JSON object with this structure:\n")
-	promptBuilder.WriteString("{\n")
-	promptBuilder.WriteString("  \"segments\": {\n")
-	promptBuilder.WriteString("    \"0\": { \"speaker\": \"Speaker 1\", \"text\": \"Corrected text for segment 0\" },\n")
-	promptBuilder.WriteString("    \"1\": { \"speaker\": \"Speaker 1\", \"text\": \"Corrected text for segment 1\" }\n")
-	promptBuilder.WriteString("  },\n")
-	promptBuilder.WriteString("  \"summary\": \"The meeting discussed...\"\n")
-	promptBuilder.WriteString("}\n\n")
-	promptBuilder.WriteString("Raw Segments:\n")
-
-	// Limit to first 500 segments to avoid context overflow for very long meetings
-	maxSegments := 500
-	if len(result.Segments) < maxSegments {
-		maxSegments = len(result.Segments)
-	}
-
-	for i := 0; i < maxSegments; i++ {
-		seg := result.Segments[i]
-		promptBuilder.WriteString(fmt.Sprintf("[%d] %s\n", i, seg.Text))
-	}
-
-	promptBuilder.WriteString("\nJSON Output:")
-
-	// 2. Call LLM
-	messages := []llm.ChatMessage{
-		{Role: "system", Content: "You are an expert transcriber and secretary. You output valid JSON only. You fix typos and assign speakers accurately."},
-		{Role: "user", Content: promptBuilder.String()},
-	}
-
-	// Use Llama 3.3 70B for best reasoning capability
-	model := "llama-3.3-70b-versatile"
-	
-	logger.Info("Sending transcript to LLM for secretary-level analysis", "segments", maxSegments)
-	resp, err := u.llmService.ChatCompletion(ctx, model, messages, 0.1)
-	if err != nil {
-		return fmt.Errorf("LLM request failed: %w", err)
-	}
-
-	if len(resp.Choices) == 0 {
-		return fmt.Errorf("empty LLM response")
-	}
-
-	content := resp.Choices[0].Message.Content
-
-	// 3. Parse JSON response
-	// Find JSON block if wrapped in markdown
-	start := strings.Index(content, "{")
-	end := strings.LastIndex(content, "}")
-	if start != -1 && end != -1 {
-		content = content[start : end+1]
-	}
-
-	var analysisResult struct {
-		Segments map[string]struct {
-			Speaker string `json:"speaker"`
-			Text    string `json:"text"`
-		} `json:"segments"`
-		Summary string `json:"summary"`
-	}
-
-	if err := json.Unmarshal([]byte(content), &analysisResult); err != nil {
-		logger.Error("Failed to parse LLM JSON", "content", content, "error", err)
-		return nil // Non-fatal
-	}
-
-	// 4. Update Transcript Segments with Secretary Updates
-	updatedCount := 0
-	for idxStr, data := range analysisResult.Segments {
-		idx, err := strconv.Atoi(idxStr)
-		if err == nil && idx >= 0 && idx < len(result.Segments) {
-			// Update text if provided (correct typos)
-			if data.Text != "" {
-				result.Segments[idx].Text = data.Text
-			}
-			// Update speaker if provided (ensure no nulls)
-			if data.Speaker != "" {
-				spk := data.Speaker
-				result.Segments[idx].Speaker = &spk
-			}
-			updatedCount++
-		}
-	}
-	logger.Info("Applied secretary updates to transcript", "updated_segments", updatedCount)
-
-	// 5. Update Job Summary
-	if analysisResult.Summary != "" {
-		if err := u.jobRepo.UpdateSummary(ctx, jobID, analysisResult.Summary); err != nil {
-			logger.Warn("Failed to save meeting summary", "error", err)
-		} else {
-			logger.Info("Meeting summary saved", "length", len(analysisResult.Summary))
-		}
-	}
-
-	logger.Info("Smart analysis complete", "segments_updated", updatedCount)
-	return nil
+// recordTranscriptionUsage records the audio sent to a cloud transcription
+// provider. Local models are not recorded.
+func (u *UnifiedTranscriptionService) recordTranscriptionUsage(ctx context.Context, job *models.TranscriptionJob, capabilities interfaces.ModelCapabilities, params map[string]interface{}, input interfaces.AudioInput, result *interfaces.TranscriptResult) {
+	provider := capabilities.Metadata["provider"]
+	if u.usageRecorder == nil || provider == "" {
+		return
+	}
+	model, _ := params["model"].(string)
+	if model == "" {
+		model = capabilities.ModelID
+	}
+	seconds := input.Duration.Seconds()
+	if seconds == 0 && result != nil && len(result.Segments) > 0 {
+		seconds = result.Segments[len(result.Segments)-1].End
+	}
+	jobID := job.ID
+	u.usageRecorder.Record(context.WithoutCancel(ctx), &models.UsageEntry{
+		UserID:       job.UserID,
+		JobID:        &jobID,
+		Feature:      models.UsageFeatureTranscription,
+		Provider:     provider,
+		Model:        model,
+		AudioSeconds: seconds,
+	})
 }
 
-
-
 // selectModels determines which models to use based on job parameters
 func (u *UnifiedTranscriptionService) selectModels(params models.WhisperXParams) (transcriptionModelID, diarizationModelID string, err error) {
 	// Determine transcription model
@@ -1100,6 +1147,63 @@ func (u *UnifiedTranscriptionService) saveTranscriptionResults(jobID string, res
 	return nil
 }
 
+// renderWebhookExports renders the transcript in the formats requested via
+// the job's callback_formats parameter for inclusion in the webhook payload,
+// with the custom speaker names like downloaded exports
+func (u *UnifiedTranscriptionService) renderWebhookExports(ctx context.Context, job *models.TranscriptionJob) map[string]string {
+	if job.Parameters.CallbackFormats == nil || job.Transcript == nil {
+		return nil
+	}
+
+	result, err := export.DecodeTranscript(*job.Transcript)
+	if err != nil {
+		logger.Warn("Failed to decode transcript for webhook exports", "job_id", job.ID, "error", err)
+		return nil
+	}
+
+	title := ""
+	if job.Title != nil {
+		title = *job.Title
+	}
+	var names map[string]string
+	if u.speakerMappingRepo != nil {
+		mappings, err := u.speakerMappingRepo.ListByJob(ctx, job.ID)
+		if err != nil {
+			logger.Warn("Failed to load speaker names for webhook exports", "job_id", job.ID, "error", err)
+		}
+		names = export.SpeakerNames(mappings)
+	}
+	doc := export.NewDocument(job.ID, title, result, names)
+	if job.Summary != nil {
+		doc.Summary = *job.Summary
+	}
+
+	opts := export.Options{IncludeSummary: true}
+	if job.Parameters.MaxLineWidth != nil {
+		opts.MaxLineWidth = *job.Parameters.MaxLineWidth
+	}
+	if job.Parameters.MaxLineCount != nil {
+		opts.MaxLineCount = *job.Parameters.MaxLineCount
+	}
+
+	exports := make(map[string]string)
+	for _, name := range strings.Split(*job.Parameters.CallbackFormats, ",") {
+		format, err := export.ParseFormat(name)
+		if err != nil || format == export.FormatDOCX {
+			// Binary formats are not embedded in the JSON payload
+			continue
+		}
+		opts.Format = format
+		rendered, err := export.RenderString(doc, opts)
+		if err != nil {
+			logger.Warn("Failed to render webhook export", "job_id", job.ID, "format", format, "error", err)
+			continue
+		}
+		exports[string(format)] = rendered
+	}
+	return exports
+}
+
 // convertTranscriptResultToJSON converts the interface result to JSON format
 func (u *UnifiedTranscriptionService) convertTranscriptResultToJSON(result *interfaces.TranscriptResult) (string, error) {
 	// Now that the struct fields match the JSON field names, we can directly marshal
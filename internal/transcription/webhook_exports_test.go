@@ -0,0 +1,35 @@
+package transcription
+
+import (
+	"context"
+	"testing"
+
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSpeakerMappingRepo struct {
+	repository.SpeakerMappingRepository
+	mappings []models.SpeakerMapping
+}
+
+func (f *fakeSpeakerMappingRepo) ListByJob(ctx context.Context, jobID string) ([]models.SpeakerMapping, error) {
+	return f.mappings, nil
+}
+
+func TestRenderWebhookExportsUsesSpeakerNames(t *testing.T) {
+	service := NewUnifiedTranscriptionService(new(MockJobRepository), "data/temp", "data/transcripts")
+	service.SetSpeakerMappingRepository(&fakeSpeakerMappingRepo{mappings: []models.SpeakerMapping{
+		{TranscriptionJobID: "job", OriginalSpeaker: "SPEAKER_00", CustomName: "Alice"},
+	}})
+
+	transcript := `{"segments": [{"start": 0, "end": 2, "text": "Hello there.", "speaker": "SPEAKER_00"}]}`
+	formats := "txt"
+	job := &models.TranscriptionJob{ID: "job", Transcript: &transcript, Parameters: models.WhisperXParams{CallbackFormats: &formats}}
+
+	exports := service.renderWebhookExports(context.Background(), job)
+	assert.Contains(t, exports["txt"], "Alice")
+	assert.NotContains(t, exports["txt"], "SPEAKER_00")
+}
@@ -0,0 +1,55 @@
+package translate
+
+import (
+	"regexp"
+	"strings"
+)
+
+// languageCode matches ISO 639 codes with optional subtags, e.g. "vi",
+// "zh-Hant" or "pt-BR"
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// languageNames names common languages in prompts; other codes are passed
+// to the model as they are
+var languageNames = map[string]string{
+	"ar": "Arabic", "de": "German", "en": "English", "es": "Spanish", "fr": "French", "hi": "Hindi",
+	"id": "Indonesian", "it": "Italian", "ja": "Japanese", "ko": "Korean", "nl": "Dutch", "pl": "Polish",
+	"pt": "Portuguese", "ru": "Russian", "th": "Thai", "tr": "Turkish", "uk": "Ukrainian", "vi": "Vietnamese",
+	"zh": "Chinese", "zh-Hans": "Simplified Chinese", "zh-Hant": "Traditional Chinese",
+}
+
+// NormalizeLanguage validates a language code, returning it with a lowercase
+// primary subtag
+func NormalizeLanguage(code string) (string, bool) {
+	code = strings.TrimSpace(code)
+	if i := strings.IndexByte(code, '-'); i > 0 {
+		code = strings.ToLower(code[:i]) + code[i:]
+	} else {
+		code = strings.ToLower(code)
+	}
+	return code, languageCode.MatchString(code)
+}
+
+// LanguageCode returns the code of a language given by code or by English
+// name, as recognizers report it
+func LanguageCode(language string) (string, bool) {
+	for code, name := range languageNames {
+		if strings.EqualFold(name, strings.TrimSpace(language)) {
+			return code, true
+		}
+	}
+	return NormalizeLanguage(language)
+}
+
+// LanguageName returns the English name of a language code for prompts
+func LanguageName(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	if i := strings.IndexByte(code, '-'); i > 0 {
+		if name, ok := languageNames[code[:i]]; ok {
+			return name + " (" + code + ")"
+		}
+	}
+	return code
+}
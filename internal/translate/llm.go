@@ -0,0 +1,133 @@
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"scriberr/internal/llm"
+	"scriberr/internal/summarize"
+)
+
+// maxBatchLines bounds the segments translated in one call, so answers stay
+// short enough for models to keep every line
+const maxBatchLines = 40
+
+const prompt = "Translate each numbered line of the transcript below from %s into %s. " +
+	"Translate every line on its own, keeping its number, meaning and tone; do not merge, split, skip or summarize lines. " +
+	"Keep names, numbers and technical terms accurate."
+
+const schema = `{
+	"type": "object",
+	"properties": {
+		"lines": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"n": {"type": "integer"},
+					"text": {"type": "string"}
+				},
+				"required": ["n", "text"],
+				"additionalProperties": false
+			}
+		}
+	},
+	"required": ["lines"],
+	"additionalProperties": false
+}`
+
+// llmTranslator translates with a model, in batches of lines answered as
+// structured JSON
+type llmTranslator struct {
+	route *llm.Route
+}
+
+func (t *llmTranslator) translate(ctx context.Context, source, target string, texts []string, progress func(done int)) ([]string, error) {
+	contextWindow := t.route.ContextWindow(ctx)
+	parsed, err := summarize.ParseSchema([]byte(schema))
+	if err != nil {
+		return nil, err
+	}
+	// The answer is about as long as the lines, so each batch gets at most
+	// a third of the window
+	summarizer := summarize.New(t.route, contextWindow)
+	budget := contextWindow / 3 * 4 // In characters, at 4 per token
+	from := "the source language"
+	if source != "" {
+		from = LanguageName(source)
+	}
+	instructions := fmt.Sprintf(prompt, from, LanguageName(target))
+
+	out := make([]string, len(texts))
+	done := 0
+	for _, batch := range batches(texts, budget) {
+		missing := batch
+		for attempt := 0; len(missing) > 0; attempt++ {
+			if attempt == 2 {
+				return nil, fmt.Errorf("model did not translate %d of the lines", len(missing))
+			}
+			lines := make([]string, len(missing))
+			for i, n := range missing {
+				lines[i] = fmt.Sprintf("[%d] %s", n, strings.Join(strings.Fields(texts[n]), " "))
+			}
+			raw, err := summarizer.Structured(ctx, instructions, parsed, lines, false)
+			if err != nil {
+				return nil, fmt.Errorf("failed to translate: %w", err)
+			}
+			var answer struct {
+				Lines []struct {
+					N    int    `json:"n"`
+					Text string `json:"text"`
+				} `json:"lines"`
+			}
+			if err := json.Unmarshal(raw, &answer); err != nil {
+				return nil, fmt.Errorf("failed to parse translation: %w", err)
+			}
+			got := make(map[int]string, len(answer.Lines))
+			for _, l := range answer.Lines {
+				if text := strings.TrimSpace(l.Text); text != "" {
+					got[l.N] = text
+				}
+			}
+			var retry []int
+			for _, n := range missing {
+				if text, ok := got[n]; ok {
+					out[n] = text
+					done++
+				} else {
+					retry = append(retry, n)
+				}
+			}
+			missing = retry
+			progress(done)
+		}
+	}
+	return out, nil
+}
+
+// batches groups the indexes of non-empty texts into consecutive batches of
+// at most maxBatchLines lines and budget characters; a longer text is
+// batched alone
+func batches(texts []string, budget int) [][]int {
+	var out [][]int
+	var current []int
+	size := 0
+	for i, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		n := len(text) + 8
+		if len(current) > 0 && (size+n > budget || len(current) == maxBatchLines) {
+			out = append(out, current)
+			current, size = nil, 0
+		}
+		current = append(current, i)
+		size += n
+	}
+	if len(current) > 0 {
+		out = append(out, current)
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// serviceBatchLines is the number of segments sent per request to a
+// translation service
+const serviceBatchLines = 50
+
+// serviceTranslator translates with a LibreTranslate-compatible service, such
+// as a self-hosted LibreTranslate instance
+type serviceTranslator struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func newServiceTranslator(url, apiKey string) *serviceTranslator {
+	return &serviceTranslator{
+		url:    strings.TrimRight(url, "/"),
+		apiKey: apiKey,
+		client: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (t *serviceTranslator) translate(ctx context.Context, source, target string, texts []string, progress func(done int)) ([]string, error) {
+	if source == "" {
+		source = "auto"
+	}
+	out := make([]string, len(texts))
+	for start := 0; start < len(texts); start += serviceBatchLines {
+		end := min(start+serviceBatchLines, len(texts))
+		translated, err := t.request(ctx, source, target, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		copy(out[start:end], translated)
+		progress(end)
+	}
+	return out, nil
+}
+
+// request translates one batch of texts
+func (t *serviceTranslator) request(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	body, err := json.Marshal(map[string]any{
+		"q":       texts,
+		"source":  source,
+		"target":  target,
+		"format":  "text",
+		"api_key": t.apiKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url+"/translate", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("translation service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	var answer struct {
+		TranslatedText []string `json:"translatedText"`
+		Error          string   `json:"error"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = json.Unmarshal(data, &answer)
+		if answer.Error == "" {
+			answer.Error = strings.TrimSpace(string(data))
+		}
+		return nil, fmt.Errorf("translation service returned %d: %s", resp.StatusCode, answer.Error)
+	}
+	if err := json.Unmarshal(data, &answer); err != nil {
+		return nil, fmt.Errorf("failed to parse translation service response: %w", err)
+	}
+	if len(answer.TranslatedText) != len(texts) {
+		return nil, fmt.Errorf("translation service returned %d translations for %d lines", len(answer.TranslatedText), len(texts))
+	}
+	return answer.TranslatedText, nil
+}
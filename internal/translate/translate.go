@@ -0,0 +1,348 @@
+// Package translate produces translation tracks of transcripts: parallel
+// transcripts in other languages, translated segment by segment so they keep
+// the original timings. Tracks are translated by the translation LLM route
+// or by a LibreTranslate-compatible service, as queued background tasks.
+package translate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"scriberr/internal/export"
+	"scriberr/internal/llm"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/pkg/logger"
+)
+
+// ProviderAuto uses the translation service if one is configured, else the
+// translation LLM route
+const ProviderAuto = "auto"
+
+const (
+	// maxConcurrentTranslations bounds the tracks translated at once; the
+	// others wait in the queue
+	maxConcurrentTranslations = 2
+	// translationTimeout allows long transcripts and slower models
+	translationTimeout = 60 * time.Minute
+)
+
+// Options selects how a track is translated
+type Options struct {
+	Language string // Target language code
+	Provider string // Defaults to ProviderAuto
+	Model    string // For the LLM provider; defaults to the route's model, then the default summary model
+}
+
+// translator translates texts, reporting the number done as it goes
+type translator interface {
+	translate(ctx context.Context, source, target string, texts []string, progress func(done int)) ([]string, error)
+}
+
+// Service queues and runs translations
+type Service struct {
+	jobRepo         repository.JobRepository
+	translationRepo repository.TranslationRepository
+	summaryRepo     repository.SummaryRepository
+	router          *llm.Router
+	service         *serviceTranslator // Nil without a translation service
+
+	slots   chan struct{}
+	mu      sync.Mutex
+	running map[string]*run
+}
+
+// run is a queued or running translation
+type run struct {
+	cancel   context.CancelFunc
+	finished chan struct{}
+	deleted  bool
+}
+
+// NewService creates a translation service. serviceURL is the base URL of a
+// LibreTranslate-compatible service and may be empty.
+func NewService(jobRepo repository.JobRepository, translationRepo repository.TranslationRepository, summaryRepo repository.SummaryRepository, router *llm.Router, serviceURL, serviceAPIKey string) *Service {
+	s := &Service{
+		jobRepo:         jobRepo,
+		translationRepo: translationRepo,
+		summaryRepo:     summaryRepo,
+		router:          router,
+		slots:           make(chan struct{}, maxConcurrentTranslations),
+		running:         make(map[string]*run),
+	}
+	if serviceURL != "" {
+		s.service = newServiceTranslator(serviceURL, serviceAPIKey)
+	}
+	return s
+}
+
+// HasTranslationService reports whether a translation service is configured
+func (s *Service) HasTranslationService() bool {
+	return s.service != nil
+}
+
+// Enqueue stores a pending translation of job into opts.Language, replacing a
+// finished one, and translates it in the background. A translation already
+// queued or running is returned as it is.
+func (s *Service) Enqueue(ctx context.Context, job *models.TranscriptionJob, opts Options) (*models.TranscriptTranslation, error) {
+	language, ok := NormalizeLanguage(opts.Language)
+	if !ok {
+		return nil, fmt.Errorf("invalid language code: %s", opts.Language)
+	}
+	provider := opts.Provider
+	if provider == "" || provider == ProviderAuto {
+		provider = models.TranslationProviderLLM
+		if s.service != nil {
+			provider = models.TranslationProviderService
+		}
+	}
+	if provider == models.TranslationProviderService && s.service == nil {
+		return nil, fmt.Errorf("no translation service is configured")
+	}
+
+	translation, err := s.translationRepo.FindByJobAndLanguage(ctx, job.ID, language)
+	if err != nil {
+		return nil, err
+	}
+	if translation == nil {
+		translation = &models.TranscriptTranslation{TranscriptionID: job.ID, Language: language}
+	} else if translation.Status == models.TranslationStatusPending || translation.Status == models.TranslationStatusProcessing {
+		return translation, nil
+	}
+	translation.Provider = provider
+	translation.Model = opts.Model
+	translation.Status = models.TranslationStatusPending
+	translation.Error = nil
+	translation.Translated = 0
+	translation.Segments = nil
+	if err := s.translationRepo.Save(ctx, translation); err != nil {
+		return nil, fmt.Errorf("failed to save translation: %w", err)
+	}
+	s.start(*translation)
+	return translation, nil
+}
+
+// Resume requeues the translations left unfinished by a restart
+func (s *Service) Resume(ctx context.Context) error {
+	translations, err := s.translationRepo.ListUnfinished(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range translations {
+		t.Status = models.TranslationStatusPending
+		s.start(t)
+	}
+	if len(translations) > 0 {
+		logger.Info("Resumed unfinished translations", "count", len(translations))
+	}
+	return nil
+}
+
+// Schedule queues the translations requested for a job when it completes
+func (s *Service) Schedule(jobID string) {
+	ctx := context.Background()
+	job, err := s.jobRepo.FindByID(ctx, jobID)
+	if err != nil || job == nil || job.Transcript == nil {
+		return
+	}
+	for _, language := range job.TranslateTo {
+		if _, err := s.Enqueue(ctx, job, Options{Language: language}); err != nil {
+			logger.Warn("Failed to queue translation", "job_id", jobID, "language", language, "error", err)
+		}
+	}
+}
+
+// List returns the translations of a job, without their segments
+func (s *Service) List(ctx context.Context, jobID string) ([]models.TranscriptTranslation, error) {
+	return s.translationRepo.ListByJob(ctx, jobID)
+}
+
+// Get returns a job's translation into language, or nil
+func (s *Service) Get(ctx context.Context, jobID, language string) (*models.TranscriptTranslation, error) {
+	language, ok := NormalizeLanguage(language)
+	if !ok {
+		return nil, fmt.Errorf("invalid language code: %s", language)
+	}
+	return s.translationRepo.FindByJobAndLanguage(ctx, jobID, language)
+}
+
+// Completed returns a job's completed translation into language, or nil
+func (s *Service) Completed(ctx context.Context, jobID, language string) (*models.TranscriptTranslation, error) {
+	translation, err := s.Get(ctx, jobID, language)
+	if err != nil || translation == nil || translation.Status != models.TranslationStatusCompleted {
+		return nil, err
+	}
+	return translation, nil
+}
+
+// Delete removes a translation, stopping it first if it is running
+func (s *Service) Delete(ctx context.Context, translation *models.TranscriptTranslation) error {
+	s.mu.Lock()
+	r, ok := s.running[translation.ID]
+	if ok {
+		r.deleted = true
+	}
+	s.mu.Unlock()
+	if ok {
+		r.cancel()
+		select {
+		case <-r.finished:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return s.translationRepo.Delete(ctx, translation.ID)
+}
+
+// DeleteByJob removes the translations of a job
+func (s *Service) DeleteByJob(ctx context.Context, jobID string) error {
+	translations, err := s.translationRepo.ListByJob(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	for i := range translations {
+		if err := s.Delete(ctx, &translations[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// start queues a pending translation. The task works on its own copy.
+func (s *Service) start(queued models.TranscriptTranslation) {
+	translation := &queued
+	ctx, cancel := context.WithTimeout(context.Background(), translationTimeout)
+	r := &run{cancel: cancel, finished: make(chan struct{})}
+	s.mu.Lock()
+	s.running[translation.ID] = r
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			cancel()
+			s.mu.Lock()
+			delete(s.running, translation.ID)
+			s.mu.Unlock()
+			close(r.finished)
+		}()
+
+		select {
+		case s.slots <- struct{}{}:
+			defer func() { <-s.slots }()
+		case <-ctx.Done():
+			s.finish(r, translation, ctx.Err())
+			return
+		}
+		s.finish(r, translation, s.translate(ctx, translation))
+	}()
+}
+
+// translate translates the transcript segment by segment
+func (s *Service) translate(ctx context.Context, translation *models.TranscriptTranslation) error {
+	start := time.Now()
+	translation.Status = models.TranslationStatusProcessing
+	if err := s.translationRepo.Save(ctx, translation); err != nil {
+		return fmt.Errorf("failed to update translation: %w", err)
+	}
+
+	job, err := s.jobRepo.FindByID(ctx, translation.TranscriptionID)
+	if err != nil || job == nil {
+		return fmt.Errorf("transcription not found")
+	}
+	if job.Transcript == nil || *job.Transcript == "" {
+		return fmt.Errorf("transcription has no transcript")
+	}
+	result, err := export.DecodeTranscript(*job.Transcript)
+	if err != nil {
+		return err
+	}
+	if source, ok := NormalizeLanguage(result.Language); ok {
+		translation.SourceLanguage = source
+	}
+	texts := make([]string, len(result.Segments))
+	for i, seg := range result.Segments {
+		texts[i] = strings.TrimSpace(seg.Text)
+	}
+	translation.Total = len(texts)
+
+	var t translator
+	switch translation.Provider {
+	case models.TranslationProviderService:
+		if s.service == nil {
+			return fmt.Errorf("no translation service is configured")
+		}
+		t = s.service
+	default:
+		route, err := s.route(ctx, job, translation.Model)
+		if err != nil {
+			return err
+		}
+		translation.Model = route.Primary().Model
+		t = &llmTranslator{route: route}
+	}
+	if err := s.translationRepo.Save(ctx, translation); err != nil {
+		return fmt.Errorf("failed to update translation: %w", err)
+	}
+
+	translated, err := t.translate(ctx, translation.SourceLanguage, translation.Language, texts, func(done int) {
+		translation.Translated = done
+		_ = s.translationRepo.UpdateProgress(ctx, translation.ID, done)
+	})
+	if err != nil {
+		return err
+	}
+	translation.Segments = make([]models.TranslatedSegment, len(result.Segments))
+	for i, seg := range result.Segments {
+		translation.Segments[i] = models.TranslatedSegment{Start: seg.Start, End: seg.End, Text: translated[i], Speaker: seg.Speaker}
+	}
+	translation.Translated = len(texts)
+	logger.Info("Translated transcript", "job_id", job.ID, "language", translation.Language, "provider", translation.Provider,
+		"model", translation.Model, "segments", len(texts), "duration_ms", time.Since(start).Milliseconds())
+	return nil
+}
+
+// route resolves the model that translates
+func (s *Service) route(ctx context.Context, job *models.TranscriptionJob, model string) (*llm.Route, error) {
+	if s.router == nil {
+		return nil, fmt.Errorf("no LLM configured")
+	}
+	route, err := s.router.Resolve(ctx, models.LLMFeatureTranslation, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if model == "" && route.Primary().Model == "" {
+		if settings, err := s.summaryRepo.GetSettings(ctx); err == nil {
+			model = settings.DefaultModel
+		}
+	}
+	route = route.WithModel(model).ForJob(job.ID)
+	if route.Primary().Model == "" {
+		return nil, fmt.Errorf("no model configured for translation")
+	}
+	return route, nil
+}
+
+// finish stores the outcome of a translation unless it was deleted
+func (s *Service) finish(r *run, translation *models.TranscriptTranslation, err error) {
+	s.mu.Lock()
+	deleted := r.deleted
+	s.mu.Unlock()
+	if deleted {
+		return
+	}
+	if err != nil {
+		logger.Error("Translation failed", "job_id", translation.TranscriptionID, "language", translation.Language, "error", err)
+		msg := err.Error()
+		translation.Status = models.TranslationStatusFailed
+		translation.Error = &msg
+		translation.Segments = nil
+	} else {
+		translation.Status = models.TranslationStatusCompleted
+	}
+	if err := s.translationRepo.Save(context.Background(), translation); err != nil {
+		logger.Error("Failed to save translation", "job_id", translation.TranscriptionID, "language", translation.Language, "error", err)
+	}
+}
@@ -0,0 +1,32 @@
+package usage
+
+import (
+	"context"
+
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/pkg/logger"
+)
+
+// Ledger prices usage entries with the current price list and stores them
+type Ledger struct {
+	repo repository.UsageRepository
+}
+
+// NewLedger creates a ledger
+func NewLedger(repo repository.UsageRepository) *Ledger {
+	return &Ledger{repo: repo}
+}
+
+// Record prices and stores an entry. Failures are logged rather than
+// returned so that accounting never fails the call it accounts for.
+func (l *Ledger) Record(ctx context.Context, entry *models.UsageEntry) {
+	price, err := l.repo.FindPrice(ctx, entry.Provider, entry.Model)
+	if err != nil {
+		logger.Warn("Failed to look up model price", "provider", entry.Provider, "model", entry.Model, "error", err)
+	}
+	entry.Price(price)
+	if err := l.repo.Record(ctx, entry); err != nil {
+		logger.Warn("Failed to record usage", "feature", entry.Feature, "provider", entry.Provider, "model", entry.Model, "error", err)
+	}
+}
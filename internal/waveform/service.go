@@ -0,0 +1,219 @@
+// Package waveform precomputes and caches waveform peaks for job audio so
+// clients can draw long recordings without downloading them.
+package waveform
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"scriberr/internal/audio"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/pkg/logger"
+)
+
+// maxConcurrentGenerations bounds the number of ffmpeg decodes running at once
+const maxConcurrentGenerations = 2
+
+// Service generates peaks for every zoom level and caches them on disk under
+// <cacheDir>/<audio sha256>/<samples_per_pixel>.dat, so the cache follows the
+// audio content rather than the job. Merged playback audio, which has no
+// stored hash, is keyed by its path, size and modification time instead.
+type Service struct {
+	jobRepo  repository.JobRepository
+	cacheDir string
+
+	mu       sync.Mutex
+	inflight map[string]*generation
+	slots    chan struct{}
+}
+
+type generation struct {
+	done chan struct{}
+	err  error
+}
+
+// NewService creates a waveform service caching peaks in cacheDir
+func NewService(jobRepo repository.JobRepository, cacheDir string) *Service {
+	return &Service{
+		jobRepo:  jobRepo,
+		cacheDir: cacheDir,
+		inflight: make(map[string]*generation),
+		slots:    make(chan struct{}, maxConcurrentGenerations),
+	}
+}
+
+// Schedule generates the peaks for a job's audio in the background. It is
+// called after uploads and whenever a job's audio is replaced (merges, live
+// session finalization).
+func (s *Service) Schedule(jobID string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+
+		job, err := s.jobRepo.FindByID(ctx, jobID)
+		if err != nil || job == nil {
+			return
+		}
+		if _, _, err := s.ensure(ctx, job); err != nil {
+			logger.Warn("Waveform generation failed", "job_id", jobID, "error", err)
+		}
+	}()
+}
+
+// Get returns the peaks for a zoom level (an index into audio.WaveformLevels),
+// generating them if needed, together with the cache key of the audio they
+// belong to
+func (s *Service) Get(ctx context.Context, job *models.TranscriptionJob, zoom int) (*audio.Waveform, string, error) {
+	if zoom < 0 || zoom >= len(audio.WaveformLevels) {
+		return nil, "", fmt.Errorf("zoom must be between 0 and %d", len(audio.WaveformLevels)-1)
+	}
+	hash, audioPath, err := s.ensure(ctx, job)
+	if err != nil {
+		return nil, "", err
+	}
+
+	f, err := os.Open(s.levelPath(hash, audio.WaveformLevels[zoom]))
+	if err != nil {
+		return nil, "", fmt.Errorf("waveform not available for %s: %w", filepath.Base(audioPath), err)
+	}
+	defer f.Close()
+	w, err := audio.ReadDat(f)
+	if err != nil {
+		return nil, "", err
+	}
+	return w, hash, nil
+}
+
+// ensure makes sure the job's audio has a cache key and its peaks are
+// cached, returning the key and the audio path used
+func (s *Service) ensure(ctx context.Context, job *models.TranscriptionJob) (string, string, error) {
+	audioPath := job.PlaybackAudioPath()
+	if audioPath == "" {
+		return "", "", fmt.Errorf("job has no audio")
+	}
+
+	hash := ""
+	switch {
+	case audioPath != job.AudioPath:
+		key, err := fileKey(audioPath)
+		if err != nil {
+			return "", "", err
+		}
+		hash = key
+	case job.AudioHash != nil && *job.AudioHash != "":
+		hash = *job.AudioHash
+	default:
+		h, err := audio.FileHash(audioPath)
+		if err != nil {
+			return "", "", err
+		}
+		hash = h
+		_ = s.jobRepo.UpdateAudioHash(ctx, job.ID, h)
+	}
+
+	if s.cached(hash) {
+		return hash, audioPath, nil
+	}
+	return hash, audioPath, s.generate(ctx, hash, audioPath)
+}
+
+// generate builds all levels for an audio file, sharing work between
+// concurrent callers for the same hash
+func (s *Service) generate(ctx context.Context, hash, audioPath string) error {
+	s.mu.Lock()
+	if g, ok := s.inflight[hash]; ok {
+		s.mu.Unlock()
+		select {
+		case <-g.done:
+			return g.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	g := &generation{done: make(chan struct{})}
+	s.inflight[hash] = g
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.inflight, hash)
+		s.mu.Unlock()
+		close(g.done)
+	}()
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		g.err = ctx.Err()
+		return g.err
+	}
+
+	g.err = s.write(ctx, hash, audioPath)
+	return g.err
+}
+
+func (s *Service) write(ctx context.Context, hash, audioPath string) error {
+	start := time.Now()
+	levels, err := audio.GenerateWaveforms(ctx, audioPath, audio.WaveformLevels)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(s.cacheDir, hash)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create waveform cache: %w", err)
+	}
+	for _, w := range levels {
+		// Write to a temp file and rename so readers never see partial data
+		tmp, err := os.CreateTemp(dir, "level-*.tmp")
+		if err != nil {
+			return fmt.Errorf("failed to write waveform: %w", err)
+		}
+		if err := w.WriteDat(tmp); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return fmt.Errorf("failed to write waveform: %w", err)
+		}
+		tmp.Close()
+		if err := os.Rename(tmp.Name(), s.levelPath(hash, w.SamplesPerPixel)); err != nil {
+			os.Remove(tmp.Name())
+			return fmt.Errorf("failed to write waveform: %w", err)
+		}
+	}
+
+	logger.Info("Generated waveform", "audio", filepath.Base(audioPath), "pixels", levels[0].Length(), "duration", time.Since(start))
+	return nil
+}
+
+// fileKey identifies a version of a file by its path, size and modification
+// time, without reading it
+func fileKey(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open audio file: %w", err)
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%d", path, info.Size(), info.ModTime().UnixNano())))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (s *Service) cached(hash string) bool {
+	for _, l := range audio.WaveformLevels {
+		if _, err := os.Stat(s.levelPath(hash, l)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Service) levelPath(hash string, samplesPerPixel int) string {
+	return filepath.Join(s.cacheDir, hash, strconv.Itoa(samplesPerPixel)+".dat")
+}
@@ -19,11 +19,44 @@ type WebhookPayload struct {
 	AudioPath    string                 `json:"audio_path"`
 	Transcript   *string                `json:"transcript,omitempty"`
 	Summary      *string                `json:"summary,omitempty"`
+	Summaries    []Summary              `json:"summaries,omitempty"` // Summaries run automatically when the job completed
 	ErrorMessage *string                `json:"error_message,omitempty"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	Exports      map[string]string      `json:"exports,omitempty"` // Rendered transcript keyed by export format
 	CompletedAt  time.Time              `json:"completed_at"`
 }
 
+// Summary is the state of a summary generated for the job
+type Summary struct {
+	ID           string          `json:"id"`
+	TemplateID   *string         `json:"template_id,omitempty"`
+	Model        string          `json:"model"`
+	Status       string          `json:"status"`
+	Content      string          `json:"content,omitempty"`
+	Structured   json.RawMessage `json:"structured,omitempty"` // Set for templates with a JSON schema
+	ErrorMessage *string         `json:"error_message,omitempty"`
+}
+
+// NewSummaries converts stored summaries for a payload
+func NewSummaries(summaries []models.Summary) []Summary {
+	if len(summaries) == 0 {
+		return nil
+	}
+	out := make([]Summary, len(summaries))
+	for i, s := range summaries {
+		out[i] = Summary{
+			ID:           s.ID,
+			TemplateID:   s.TemplateID,
+			Model:        s.Model,
+			Status:       s.Status,
+			Content:      s.Content,
+			Structured:   s.Structured,
+			ErrorMessage: s.ErrorMessage,
+		}
+	}
+	return out
+}
+
 // Service handles webhook operations
 type Service struct {
 	client *http.Client
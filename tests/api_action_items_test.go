@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"scriberr/internal/api"
+	"scriberr/internal/llm"
+	"scriberr/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *APIHandlerTestSuite) TestActionItems() {
+	var mu sync.Mutex
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []llm.ChatMessage `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		prompts = append(prompts, req.Messages[0].Content)
+		mu.Unlock()
+		answer, _ := json.Marshal(`{"action_items": [
+			{"task": "Send the budget", "assignee": "SPEAKER_00", "due_date": "2026-10-23", "segment": 1},
+			{"task": "Book the room", "assignee": "Bob", "due_date": "next week", "segment": null}
+		]}`)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%s}}]}`, answer)
+	}))
+	defer server.Close()
+	suite.helper.DB.Model(&models.LLMConfig{}).Where("is_active = ?", true).Updates(&models.LLMConfig{OpenAIBaseURL: &server.URL})
+	require.NoError(suite.T(), suite.helper.DB.Create(&models.SummarySetting{DefaultModel: "gpt-3.5-turbo"}).Error)
+
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Budget Meeting")
+	job.Status = models.StatusCompleted
+	job.UserID = &suite.helper.TestUser.ID
+	transcript := `{"segments": [
+		{"start": 0.0, "end": 2.0, "text": "Let's review the budget.", "speaker": "SPEAKER_01"},
+		{"start": 83.0, "end": 86.0, "text": "I'll send the budget by Friday.", "speaker": "SPEAKER_00"}
+	]}`
+	job.Transcript = &transcript
+	suite.helper.DB.Save(job)
+	suite.helper.DB.Create(&models.SpeakerMapping{TranscriptionJobID: job.ID, OriginalSpeaker: "SPEAKER_00", CustomName: "Alice"})
+	base := "/api/v1/transcription/" + job.ID + "/action-items"
+
+	resp := suite.makeAuthenticatedRequest("POST", base, nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	var items []models.ActionItem
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &items))
+	require.Len(suite.T(), items, 2)
+
+	// Speakers are resolved to their custom names and segments to timestamps
+	send := items[0]
+	assert.Equal(suite.T(), "Send the budget", send.Title)
+	assert.Equal(suite.T(), "Alice", send.Assignee)
+	assert.Equal(suite.T(), "SPEAKER_00", send.Speaker)
+	require.NotNil(suite.T(), send.DueDate)
+	assert.Equal(suite.T(), "2026-10-23", *send.DueDate)
+	require.NotNil(suite.T(), send.SourceTime)
+	assert.Equal(suite.T(), 83.0, *send.SourceTime)
+	assert.Equal(suite.T(), "I'll send the budget by Friday.", send.Quote)
+	assert.Equal(suite.T(), models.ActionItemStatusOpen, send.Status)
+	// Names that are not speakers are kept, unparseable dates dropped
+	assert.Equal(suite.T(), "Bob", items[1].Assignee)
+	assert.Empty(suite.T(), items[1].Speaker)
+	assert.Nil(suite.T(), items[1].DueDate)
+	mu.Lock()
+	assert.Contains(suite.T(), prompts[0], "[1] [00:01:23] Alice: I'll send the budget by Friday.")
+	mu.Unlock()
+
+	// Tasks are listed across jobs and marked done
+	resp = suite.makeAuthenticatedRequest("PATCH", "/api/v1/tasks/"+send.ID, api.UpdateTaskRequest{Status: stringPtr(models.ActionItemStatusDone)}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	var done models.ActionItem
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &done))
+	assert.Equal(suite.T(), models.ActionItemStatusDone, done.Status)
+	assert.NotNil(suite.T(), done.CompletedAt)
+
+	resp = suite.makeAuthenticatedRequest("PATCH", "/api/v1/tasks/"+send.ID, api.UpdateTaskRequest{DueDate: stringPtr("Friday")}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/tasks?status=open", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	var open []models.ActionItem
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &open))
+	require.Len(suite.T(), open, 1)
+	assert.Equal(suite.T(), "Book the room", open[0].Title)
+
+	// Items extracted again keep their ID and status
+	resp = suite.makeAuthenticatedRequest("POST", base, nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/tasks?assignee=alice", nil, true)
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &items))
+	require.Len(suite.T(), items, 1)
+	assert.Equal(suite.T(), send.ID, items[0].ID)
+	assert.Equal(suite.T(), models.ActionItemStatusDone, items[0].Status)
+
+	// Exports
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/tasks/export", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	assert.Contains(suite.T(), resp.Body.String(), "## Budget Meeting\n\n")
+	assert.Contains(suite.T(), resp.Body.String(), "- [x] Send the budget — @Alice, due 2026-10-23, `00:01:23`\n")
+	assert.Contains(suite.T(), resp.Body.String(), "- [ ] Book the room — @Bob\n")
+
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/tasks/export?format=ics", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	assert.Equal(suite.T(), "text/calendar; charset=utf-8", resp.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), 2, strings.Count(resp.Body.String(), "BEGIN:VTODO"))
+
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/tasks/export?format=csv", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	assert.Equal(suite.T(), 3, strings.Count(resp.Body.String(), "\n"))
+
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/tasks/export?format=pdf", nil, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+
+	resp = suite.makeAuthenticatedRequest("DELETE", "/api/v1/tasks/"+send.ID, nil, true)
+	assert.Equal(suite.T(), http.StatusNoContent, resp.Code)
+	resp = suite.makeAuthenticatedRequest("DELETE", "/api/v1/tasks/"+send.ID, nil, true)
+	assert.Equal(suite.T(), http.StatusNotFound, resp.Code)
+
+	// Long transcripts are read in chunks that keep the segment numbers, and
+	// the items found in several chunks are merged
+	segments := make([]string, 1500)
+	for i := range segments {
+		segments[i] = fmt.Sprintf(`{"start": %d, "end": %d, "text": "We talked about topic number %d for a while.", "speaker": "SPEAKER_01"}`, i*5, i*5+5, i)
+	}
+	long := `{"segments": [` + strings.Join(segments, ",") + `]}`
+	longJob := suite.helper.CreateTestTranscriptionJob(suite.T(), "Long Meeting")
+	longJob.Status = models.StatusCompleted
+	longJob.UserID = &suite.helper.TestUser.ID
+	longJob.Transcript = &long
+	suite.helper.DB.Save(longJob)
+	mu.Lock()
+	prompts = nil
+	mu.Unlock()
+	resp = suite.makeAuthenticatedRequest("POST", "/api/v1/transcription/"+longJob.ID+"/action-items", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &items))
+	assert.Len(suite.T(), items, 2)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Greater(suite.T(), len(prompts), 1)
+	assert.Contains(suite.T(), prompts[len(prompts)-1], "[1499] [02:04:55] SPEAKER_01: We talked about topic number 1499 for a while.")
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"scriberr/internal/analytics"
+	"scriberr/internal/api"
+	"scriberr/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *APIHandlerTestSuite) TestConversationAnalytics() {
+	transcript := `{"segments": [
+		{"start": 0.0, "end": 10.0, "text": "Thanks for joining. Um, what are your goals?", "speaker": "SPEAKER_00"},
+		{"start": 9.0, "end": 20.0, "text": "We want to grow the team this year.", "speaker": "SPEAKER_01"},
+		{"start": 21.0, "end": 24.0, "text": "Great.", "speaker": "SPEAKER_00"}
+	]}`
+	var jobs []*models.TranscriptionJob
+	for _, title := range []string{"Discovery Call", "Follow-up Call"} {
+		job := suite.helper.CreateTestTranscriptionJob(suite.T(), title)
+		job.Status = models.StatusCompleted
+		job.UserID = &suite.helper.TestUser.ID
+		job.Transcript = &transcript
+		suite.helper.DB.Save(job)
+		suite.helper.DB.Create(&models.SpeakerMapping{TranscriptionJobID: job.ID, OriginalSpeaker: "SPEAKER_00", CustomName: "Alice"})
+		jobs = append(jobs, job)
+	}
+
+	resp := suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/"+jobs[0].ID+"/analytics", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	var stats models.ConversationAnalytics
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &stats))
+	assert.Equal(suite.T(), 24.0, stats.Duration)
+	assert.Equal(suite.T(), 3, stats.Turns)
+	require.Len(suite.T(), stats.Speakers, 2)
+	// Speakers are ordered by talk time and named from their mappings
+	assert.Equal(suite.T(), "Alice", stats.Speakers[0].Name)
+	assert.Equal(suite.T(), 13.0, stats.Speakers[0].TalkTime)
+	assert.Equal(suite.T(), 1, stats.Speakers[0].Questions)
+	assert.Equal(suite.T(), 1, stats.Speakers[0].Fillers)
+	assert.Equal(suite.T(), 1, stats.Speakers[0].Interrupted)
+	assert.Equal(suite.T(), "SPEAKER_01", stats.Speakers[1].Speaker)
+	assert.Empty(suite.T(), stats.Speakers[1].Name)
+	assert.Equal(suite.T(), 1, stats.Speakers[1].Interruptions)
+
+	// Aggregates cover named speakers in analyzed transcriptions
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/"+jobs[1].ID+"/analytics", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/analytics/speakers", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	var summaries []analytics.SpeakerSummary
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &summaries))
+	require.Len(suite.T(), summaries, 1)
+	assert.Equal(suite.T(), "Alice", summaries[0].Name)
+	assert.Equal(suite.T(), 2, summaries[0].Transcriptions)
+	assert.Equal(suite.T(), 26.0, summaries[0].TalkTime)
+	assert.Equal(suite.T(), 2, summaries[0].Questions)
+
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/analytics/speakers/alice", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	var history api.SpeakerHistoryResponse
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &history))
+	require.Len(suite.T(), history.History, 2)
+	assert.Equal(suite.T(), "Discovery Call", history.History[0].Title)
+	assert.Equal(suite.T(), 13.0, history.History[0].TalkTime)
+
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/analytics/speakers/bob", nil, true)
+	assert.Equal(suite.T(), http.StatusNotFound, resp.Code)
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/analytics/speakers?from=yesterday", nil, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/analytics/speakers?to=2000-01-01", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	assert.JSONEq(suite.T(), "[]", resp.Body.String())
+
+	// Analytics are removed with their job
+	resp = suite.makeAuthenticatedRequest("DELETE", "/api/v1/transcription/"+jobs[0].ID, nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	var count int64
+	suite.helper.DB.Model(&models.ConversationAnalytics{}).Where("transcription_id = ?", jobs[0].ID).Count(&count)
+	assert.Zero(suite.T(), count)
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"scriberr/internal/api"
+	"scriberr/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *APIHandlerTestSuite) TestChapters() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		answer, _ := json.Marshal(`{"chapters": [
+			{"start_segment": 0, "title": "Garden plans", "summary": "Planting tomatoes.", "continues_previous": false},
+			{"start_segment": 10, "title": "Rocket launch", "summary": "Fuel and countdown.", "continues_previous": false}
+		]}`)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%s}}]}`, answer)
+	}))
+	defer server.Close()
+	suite.helper.DB.Model(&models.LLMConfig{}).Where("is_active = ?", true).Updates(&models.LLMConfig{OpenAIBaseURL: &server.URL})
+	require.NoError(suite.T(), suite.helper.DB.Create(&models.SummarySetting{DefaultModel: "gpt-3.5-turbo"}).Error)
+
+	// Twenty minutes on two topics, split by a long pause
+	var segments []string
+	for i := 0; i < 20; i++ {
+		text := "The garden tomatoes need compost, water and sunny soil."
+		start := float64(i * 60)
+		if i >= 10 {
+			text = "The rocket engine fuel countdown and launch pad checks."
+			start += 8
+		}
+		segments = append(segments, fmt.Sprintf(`{"start": %.1f, "end": %.1f, "text": %q}`, start+1, start+50, text))
+	}
+	transcript := `{"segments": [` + strings.Join(segments, ",") + `]}`
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Weekend Plans")
+	job.Status = models.StatusCompleted
+	job.Transcript = &transcript
+	suite.helper.DB.Save(job)
+	base := "/api/v1/transcription/" + job.ID + "/chapters"
+
+	resp := suite.makeAuthenticatedRequest("GET", base+"/export", nil, true)
+	assert.Equal(suite.T(), http.StatusNotFound, resp.Code)
+
+	resp = suite.makeAuthenticatedRequest("POST", base, nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	var chapters []models.Chapter
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &chapters))
+	require.Len(suite.T(), chapters, 2)
+	assert.Equal(suite.T(), "Garden plans", chapters[0].Title)
+	assert.Equal(suite.T(), models.ChapterSourceLLM, chapters[0].Source)
+	assert.Equal(suite.T(), 0.0, chapters[0].Start)
+	assert.Equal(suite.T(), 609.0, chapters[0].End)
+	assert.Equal(suite.T(), 609.0, chapters[1].Start)
+	assert.Equal(suite.T(), 1198.0, chapters[1].End)
+	assert.Equal(suite.T(), "Fuel and countdown.", chapters[1].Summary)
+
+	// The heuristic finds the same boundary without a model
+	resp = suite.makeAuthenticatedRequest("POST", base, api.GenerateChaptersRequest{Method: "heuristic"}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &chapters))
+	require.Len(suite.T(), chapters, 3)
+	assert.Equal(suite.T(), models.ChapterSourceHeuristic, chapters[0].Source)
+	starts := []float64{chapters[1].Start, chapters[2].Start}
+	assert.Contains(suite.T(), starts, 609.0)
+
+	resp = suite.makeAuthenticatedRequest("GET", base, nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &chapters))
+	assert.Len(suite.T(), chapters, 3)
+
+	resp = suite.makeAuthenticatedRequest("POST", base, api.GenerateChaptersRequest{Method: "magic"}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+
+	// Exports
+	resp = suite.makeAuthenticatedRequest("GET", base+"/export", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	assert.Contains(suite.T(), resp.Body.String(), `"version": "1.2.0"`)
+	assert.Contains(suite.T(), resp.Header().Get("Content-Disposition"), `.chapters.json"`)
+
+	resp = suite.makeAuthenticatedRequest("GET", base+"/export?format=vtt", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	assert.True(suite.T(), strings.HasPrefix(resp.Body.String(), "WEBVTT\n\nChapter 1\n00:00:00.000 --> "))
+
+	resp = suite.makeAuthenticatedRequest("GET", base+"/export?format=ffmetadata", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	assert.Equal(suite.T(), 3, strings.Count(resp.Body.String(), "[CHAPTER]"))
+
+	resp = suite.makeAuthenticatedRequest("GET", base+"/export?format=srt", nil, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+
+	// Chapters are removed with their job
+	resp = suite.makeAuthenticatedRequest("DELETE", "/api/v1/transcription/"+job.ID, nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	var count int64
+	suite.helper.DB.Model(&models.Chapter{}).Where("transcription_id = ?", job.ID).Count(&count)
+	assert.Zero(suite.T(), count)
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	"scriberr/internal/api"
+	"scriberr/internal/llm"
+	"scriberr/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newToolCallingServer scripts an OpenAI-compatible model that searches the
+// transcript and renames a speaker, then answers once the rename has run
+func (suite *APIHandlerTestSuite) newToolCallingServer() *httptest.Server {
+	t := suite.T()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []llm.ChatMessage `json:"messages"`
+			Tools    []llm.Tool        `json:"tools"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.NotEmpty(t, req.Tools)
+
+		// Every call must be answered before the conversation goes on
+		answered := make(map[string]bool)
+		for _, m := range req.Messages {
+			if m.Role == "tool" {
+				answered[m.ToolCallID] = true
+			}
+		}
+		for _, m := range req.Messages {
+			for _, tc := range m.ToolCalls {
+				assert.True(t, answered[tc.ID], "tool call %s has no result", tc.ID)
+			}
+		}
+
+		reply := llm.ChatMessage{Role: "assistant"}
+		last := req.Messages[len(req.Messages)-1]
+		switch {
+		case last.Role == "user":
+			reply.ToolCalls = []llm.ToolCall{
+				{ID: "call_search", Type: "function", Function: llm.ToolCallFunction{Name: "search_transcript", Arguments: `{"query":"deadline"}`}},
+				{ID: "call_rename", Type: "function", Function: llm.ToolCallFunction{Name: "rename_speaker", Arguments: `{"speaker":"SPEAKER_01","new_name":"Alice"}`}},
+			}
+		case strings.Contains(last.Content, "Renamed"):
+			reply.Content = "Alice set the deadline for Friday [#1]."
+		default:
+			reply.Content = "Nothing was renamed."
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"choices": []map[string]any{{"message": reply, "finish_reason": "stop"}}})
+	}))
+}
+
+func (suite *APIHandlerTestSuite) TestChatAgentToolCalls() {
+	server := suite.newToolCallingServer()
+	defer server.Close()
+	suite.helper.DB.Model(&models.LLMConfig{}).Where("is_active = ?", true).Updates(&models.LLMConfig{OpenAIBaseURL: &server.URL})
+
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Agent Test Transcription")
+	job.Status = models.StatusCompleted
+	transcript := `{"segments": [
+		{"start": 0.0, "end": 2.0, "text": "Welcome to the planning call.", "speaker": "SPEAKER_00"},
+		{"start": 2.0, "end": 5.0, "text": "The deadline is Friday.", "speaker": "SPEAKER_01"}]}`
+	job.Transcript = &transcript
+	suite.helper.DB.Save(job)
+	session := suite.helper.CreateTestChatSession(suite.T(), job.ID)
+
+	// The search runs right away; the rename waits for confirmation
+	resp := suite.makeAuthenticatedRequest("POST", "/api/v1/chat/sessions/"+session.ID+"/messages", api.ChatMessageRequest{Content: "Who set the deadline? Name them Alice.", Agent: true}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+
+	var agentResp api.ChatAgentResponse
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &agentResp))
+	assert.True(suite.T(), agentResp.PendingConfirmation)
+	require.Len(suite.T(), agentResp.Messages, 4)
+	assert.Equal(suite.T(), api.RoleUser, agentResp.Messages[0].Role)
+	assert.Equal(suite.T(), api.RoleToolCall, agentResp.Messages[1].Role)
+	assert.Equal(suite.T(), models.ToolStatusExecuted, *agentResp.Messages[1].ToolStatus)
+	rename := agentResp.Messages[2]
+	assert.Equal(suite.T(), "rename_speaker", *rename.ToolName)
+	assert.Equal(suite.T(), models.ToolStatusPending, *rename.ToolStatus)
+	assert.Equal(suite.T(), api.RoleToolResult, agentResp.Messages[3].Role)
+	assert.Contains(suite.T(), agentResp.Messages[3].Content, "The deadline is Friday.")
+
+	var mappings int64
+	suite.helper.DB.Model(&models.SpeakerMapping{}).Where("transcription_job_id = ?", job.ID).Count(&mappings)
+	assert.Equal(suite.T(), int64(0), mappings)
+
+	// Approving runs the rename and lets the assistant finish
+	confirmPath := "/api/v1/chat/sessions/" + session.ID + "/tool-calls/" + strconv.FormatUint(uint64(rename.ID), 10) + "/confirm"
+	approve := true
+	resp = suite.makeAuthenticatedRequest("POST", confirmPath, api.ChatToolConfirmRequest{Approve: &approve}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+
+	agentResp = api.ChatAgentResponse{}
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &agentResp))
+	assert.False(suite.T(), agentResp.PendingConfirmation)
+	require.Len(suite.T(), agentResp.Messages, 3)
+	assert.Equal(suite.T(), models.ToolStatusExecuted, *agentResp.Messages[0].ToolStatus)
+	assert.Equal(suite.T(), "Renamed SPEAKER_01 to Alice.", agentResp.Messages[1].Content)
+	answer := agentResp.Messages[2]
+	assert.Equal(suite.T(), "assistant", answer.Role)
+	require.Len(suite.T(), answer.Citations, 1)
+	assert.Equal(suite.T(), 1, answer.Citations[0].StartSegment)
+
+	var mapping models.SpeakerMapping
+	require.NoError(suite.T(), suite.helper.DB.Where("transcription_job_id = ? AND original_speaker = ?", job.ID, "SPEAKER_01").First(&mapping).Error)
+	assert.Equal(suite.T(), "Alice", mapping.CustomName)
+
+	// Decided calls cannot be confirmed again
+	resp = suite.makeAuthenticatedRequest("POST", confirmPath, api.ChatToolConfirmRequest{Approve: &approve}, true)
+	assert.Equal(suite.T(), http.StatusConflict, resp.Code)
+}
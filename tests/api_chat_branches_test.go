@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+
+	"scriberr/internal/api"
+	"scriberr/internal/llm"
+	"scriberr/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newNumberedReplyServer streams "Reply N" for the Nth request and records
+// the conversation it was sent
+func newNumberedReplyServer(conversations *[][]llm.ChatMessage) *httptest.Server {
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []llm.ChatMessage `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		*conversations = append(*conversations, req.Messages)
+		n := len(*conversations)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Reply %d\"}}]}\n\ndata: [DONE]\n\n", n)
+	}))
+}
+
+func (suite *APIHandlerTestSuite) TestChatBranching() {
+	var conversations [][]llm.ChatMessage
+	server := newNumberedReplyServer(&conversations)
+	defer server.Close()
+	suite.helper.DB.Model(&models.LLMConfig{}).Where("is_active = ?", true).Updates(&models.LLMConfig{OpenAIBaseURL: &server.URL})
+
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Branch Test Transcription")
+	job.Status = models.StatusCompleted
+	transcript := `{"segments": [{"start": 0.0, "end": 2.0, "text": "The budget is ten thousand.", "speaker": "SPEAKER_00"}]}`
+	job.Transcript = &transcript
+	suite.helper.DB.Save(job)
+	session := suite.helper.CreateTestChatSession(suite.T(), job.ID)
+	base := "/api/v1/chat/sessions/" + session.ID
+
+	getSession := func() api.ChatSessionWithMessages {
+		resp := suite.makeAuthenticatedRequest("GET", base, nil, true)
+		require.Equal(suite.T(), http.StatusOK, resp.Code)
+		var s api.ChatSessionWithMessages
+		require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &s))
+		return s
+	}
+	contents := func(s api.ChatSessionWithMessages) []string {
+		var out []string
+		for _, m := range s.Messages {
+			out = append(out, m.Content)
+		}
+		return out
+	}
+
+	resp := suite.makeAuthenticatedRequest("POST", base+"/messages", api.ChatMessageRequest{Content: "What is the budget?"}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	assert.Equal(suite.T(), "Reply 1", resp.Body.String())
+	resp = suite.makeAuthenticatedRequest("POST", base+"/messages", api.ChatMessageRequest{Content: "In euros?"}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	first := getSession()
+	require.Equal(suite.T(), []string{"What is the budget?", "Reply 1", "In euros?", "Reply 2"}, contents(first))
+
+	// Regenerating the last reply answers the same history without it
+	lastReply := first.Messages[3]
+	resp = suite.makeAuthenticatedRequest("POST", base+"/messages/"+strconv.Itoa(int(lastReply.ID))+"/regenerate", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(suite.T(), "Reply 3", resp.Body.String())
+	assert.Len(suite.T(), conversations[2], len(conversations[1]))
+	regenerated := getSession()
+	assert.Equal(suite.T(), []string{"What is the budget?", "Reply 1", "In euros?", "Reply 3"}, contents(regenerated))
+	assert.Equal(suite.T(), []uint{lastReply.ID, regenerated.Messages[3].ID}, regenerated.Messages[3].SiblingIDs)
+	assert.Empty(suite.T(), regenerated.Messages[2].SiblingIDs)
+
+	// Editing the first question starts a branch without the later turns
+	resp = suite.makeAuthenticatedRequest("PUT", base+"/messages/"+strconv.Itoa(int(first.Messages[0].ID)), api.ChatMessageRequest{Content: "Who spoke?"}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	assert.Len(suite.T(), conversations[3], 1)
+	edited := getSession()
+	assert.Equal(suite.T(), []string{"Who spoke?", "Reply 4"}, contents(edited))
+	assert.Nil(suite.T(), edited.Messages[0].ParentID)
+	assert.Len(suite.T(), edited.Messages[0].SiblingIDs, 2)
+
+	// Only user messages can be edited and assistant replies regenerated
+	resp = suite.makeAuthenticatedRequest("PUT", base+"/messages/"+strconv.Itoa(int(lastReply.ID)), api.ChatMessageRequest{Content: "x"}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+	resp = suite.makeAuthenticatedRequest("POST", base+"/messages/"+strconv.Itoa(int(first.Messages[0].ID))+"/regenerate", nil, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+
+	resp = suite.makeAuthenticatedRequest("GET", base+"/branches", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	var branches []api.ChatBranchResponse
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &branches))
+	require.Len(suite.T(), branches, 3)
+	assert.Equal(suite.T(), "Reply 2", branches[0].LastMessage.Content)
+	assert.Equal(suite.T(), first.Messages[0].ID, *branches[0].ForkID)
+	assert.Equal(suite.T(), 4, branches[1].MessageCount)
+	assert.True(suite.T(), branches[2].Active)
+	assert.Nil(suite.T(), branches[2].ForkID)
+
+	// Selecting the original question follows its most recent replies, and
+	// new messages continue that branch
+	resp = suite.makeAuthenticatedRequest("PUT", base+"/branch", api.ChatActiveBranchRequest{MessageID: first.Messages[0].ID}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(suite.T(), contents(regenerated), contents(getSession()))
+	resp = suite.makeAuthenticatedRequest("POST", base+"/messages", api.ChatMessageRequest{Content: "And in dollars?"}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	assert.Len(suite.T(), conversations[4], 5)
+	assert.Equal(suite.T(), "Reply 3", conversations[4][3].Content)
+
+	resp = suite.makeAuthenticatedRequest("PUT", base+"/branch", api.ChatActiveBranchRequest{MessageID: 999999}, true)
+	assert.Equal(suite.T(), http.StatusNotFound, resp.Code)
+}
+
+func (suite *APIHandlerTestSuite) TestChatBranchingLinksOlderSessions() {
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Legacy Chat Transcription")
+	session := suite.helper.CreateTestChatSession(suite.T(), job.ID)
+	for _, content := range []string{"First", "Second", "Third"} {
+		require.NoError(suite.T(), suite.helper.DB.Create(&models.ChatMessage{SessionID: session.ID, ChatSessionID: session.ID, Role: "user", Content: content}).Error)
+	}
+
+	resp := suite.makeAuthenticatedRequest("GET", "/api/v1/chat/sessions/"+session.ID, nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	var s api.ChatSessionWithMessages
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &s))
+	require.Len(suite.T(), s.Messages, 3)
+	assert.Nil(suite.T(), s.Messages[0].ParentID)
+	assert.Equal(suite.T(), s.Messages[1].ID, *s.Messages[2].ParentID)
+
+	var stored models.ChatSession
+	require.NoError(suite.T(), suite.helper.DB.First(&stored, "id = ?", session.ID).Error)
+	assert.Equal(suite.T(), s.Messages[2].ID, *stored.ActiveLeafID)
+}
+
+func (suite *APIHandlerTestSuite) TestChatRegenerateFailureKeepsReply() {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls > 1 {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Reply 1\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+	suite.helper.DB.Model(&models.LLMConfig{}).Where("is_active = ?", true).Updates(&models.LLMConfig{OpenAIBaseURL: &server.URL})
+
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Regenerate Failure Transcription")
+	session := suite.helper.CreateTestChatSession(suite.T(), job.ID)
+	base := "/api/v1/chat/sessions/" + session.ID
+	resp := suite.makeAuthenticatedRequest("POST", base+"/messages", api.ChatMessageRequest{Content: "What is the budget?"}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+
+	getSession := func() api.ChatSessionWithMessages {
+		resp := suite.makeAuthenticatedRequest("GET", base, nil, true)
+		require.Equal(suite.T(), http.StatusOK, resp.Code)
+		var s api.ChatSessionWithMessages
+		require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &s))
+		return s
+	}
+	before := getSession()
+	require.Len(suite.T(), before.Messages, 2)
+
+	// A failed regeneration keeps the old reply on the active branch
+	suite.makeAuthenticatedRequest("POST", base+"/messages/"+strconv.Itoa(int(before.Messages[1].ID))+"/regenerate", nil, true)
+	after := getSession()
+	require.Len(suite.T(), after.Messages, 2)
+	assert.Equal(suite.T(), "Reply 1", after.Messages[1].Content)
+}
@@ -151,3 +151,60 @@ func (suite *APIHandlerTestSuite) TestDeleteChatSession() {
 	suite.helper.DB.Model(&models.ChatSession{}).Where("id = ?", session.ID).Count(&count)
 	assert.Equal(suite.T(), int64(0), count)
 }
+
+func (suite *APIHandlerTestSuite) TestMultiJobChatSession() {
+	newJob := func(title, text string) *models.TranscriptionJob {
+		job := suite.helper.CreateTestTranscriptionJob(suite.T(), title)
+		job.Status = models.StatusCompleted
+		transcript := `{"segments": [{"start": 0.0, "end": 1.0, "text": "` + text + `", "speaker": "SPEAKER_00"}]}`
+		job.Transcript = &transcript
+		job.Tags = []string{"standup"}
+		job.UserID = &suite.helper.TestUser.ID
+		suite.helper.DB.Save(job)
+		return job
+	}
+	monday := newJob("Monday standup", "Monday notes")
+	tuesday := newJob("Tuesday standup", "Tuesday notes")
+
+	// Explicit job set; the first job becomes the anchor
+	req := api.ChatCreateRequest{
+		Model: "gpt-3.5-turbo",
+		Scope: &models.ChatScope{Type: models.ChatScopeJobs, JobIDs: []string{monday.ID, tuesday.ID, monday.ID}},
+	}
+	resp := suite.makeAuthenticatedRequest("POST", "/api/v1/chat/sessions", req, true)
+	assert.Equal(suite.T(), http.StatusCreated, resp.Code)
+
+	var sessionResp api.ChatSessionResponse
+	assert.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &sessionResp))
+	assert.Equal(suite.T(), monday.ID, sessionResp.TranscriptionID)
+	if assert.NotNil(suite.T(), sessionResp.Scope) {
+		assert.Equal(suite.T(), []string{monday.ID, tuesday.ID}, sessionResp.Scope.JobIDs)
+	}
+
+	msg := api.ChatMessageRequest{Content: "What changed between the standups?"}
+	resp = suite.makeAuthenticatedRequest("POST", "/api/v1/chat/sessions/"+sessionResp.ID+"/messages", msg, true)
+	assert.Equal(suite.T(), http.StatusOK, resp.Code)
+
+	// Tag scope
+	req = api.ChatCreateRequest{
+		TranscriptionID: tuesday.ID,
+		Model:           "gpt-3.5-turbo",
+		Scope:           &models.ChatScope{Type: models.ChatScopeTag, Tag: "standup"},
+	}
+	resp = suite.makeAuthenticatedRequest("POST", "/api/v1/chat/sessions", req, true)
+	assert.Equal(suite.T(), http.StatusCreated, resp.Code)
+
+	// Invalid scopes are rejected
+	req.Scope = &models.ChatScope{Type: models.ChatScopeTag}
+	resp = suite.makeAuthenticatedRequest("POST", "/api/v1/chat/sessions", req, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+
+	// Jobs of other users cannot be pulled into a session
+	otherUser := suite.helper.TestUser.ID + 1000
+	foreign := newJob("Someone else's call", "Private notes")
+	foreign.UserID = &otherUser
+	suite.helper.DB.Save(foreign)
+	req.Scope = &models.ChatScope{Type: models.ChatScopeJobs, JobIDs: []string{foreign.ID}}
+	resp = suite.makeAuthenticatedRequest("POST", "/api/v1/chat/sessions", req, true)
+	assert.Equal(suite.T(), http.StatusNotFound, resp.Code)
+}
@@ -13,14 +13,21 @@ import (
 	"testing"
 	"time"
 
+	"scriberr/internal/analytics"
 	"scriberr/internal/api"
+	"scriberr/internal/chapters"
+	"scriberr/internal/llm"
 	"scriberr/internal/models"
 	"scriberr/internal/processing"
 	"scriberr/internal/queue"
 	"scriberr/internal/repository"
+	"scriberr/internal/review"
 	"scriberr/internal/service"
+	"scriberr/internal/smartanalysis"
 	"scriberr/internal/sse"
+	"scriberr/internal/summarize"
 	"scriberr/internal/transcription"
+	"scriberr/internal/translate"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -80,6 +87,7 @@ func (suite *APIHandlerTestSuite) SetupSuite() {
 		profileRepo,
 		userRepo,
 		llmConfigRepo,
+		llm.NewRouter(llmConfigRepo),
 		summaryRepo,
 		chatRepo,
 		noteRepo,
@@ -91,6 +99,14 @@ func (suite *APIHandlerTestSuite) SetupSuite() {
 		multiTrackProcessor,
 		broadcaster,
 	)
+	suite.handler.SetSummaryService(summarize.NewService(jobRepo, summaryRepo, speakerMappingRepo, userRepo, llm.NewRouter(llmConfigRepo), broadcaster))
+	suite.handler.SetUsageRepository(repository.NewUsageRepository(suite.helper.DB))
+	suite.handler.SetActionItemRepository(repository.NewActionItemRepository(suite.helper.DB))
+	suite.handler.SetAnalyticsService(analytics.NewService(jobRepo, repository.NewAnalyticsRepository(suite.helper.DB), speakerMappingRepo))
+	suite.handler.SetTranslationService(translate.NewService(jobRepo, repository.NewTranslationRepository(suite.helper.DB), summaryRepo, llm.NewRouter(llmConfigRepo), "", ""))
+	suite.handler.SetReviewService(review.NewService(jobRepo, repository.NewReviewRepository(suite.helper.DB)))
+	suite.handler.SetSmartAnalysisService(smartanalysis.NewService(jobRepo, repository.NewSmartAnalysisRepository(suite.helper.DB), summaryRepo, llm.NewRouter(llmConfigRepo)))
+	suite.handler.SetChapterService(chapters.NewService(jobRepo, repository.NewChapterRepository(suite.helper.DB), speakerMappingRepo, summaryRepo, llm.NewRouter(llmConfigRepo)))
 
 	// Set up router
 	suite.router = api.SetupRoutes(suite.handler, suite.helper.AuthService)
@@ -112,6 +128,7 @@ func (suite *APIHandlerTestSuite) SetupTest() {
 		OpenAIBaseURL: &suite.mockOpenAI.URL,
 		APIKey:        stringPtr("test-api-key"),
 		IsActive:      true,
+		IsDefault:     true,
 	}
 	err := suite.helper.DB.Create(llmConfig).Error
 	assert.NoError(suite.T(), err)
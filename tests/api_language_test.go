@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+
+	"scriberr/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *APIHandlerTestSuite) TestSubmitLanguageDetection() {
+	submit := func(fields map[string]string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("audio", "meeting.mp3")
+		require.NoError(suite.T(), err)
+		part.Write([]byte("dummy audio data"))
+		for k, v := range fields {
+			writer.WriteField(k, v)
+		}
+		writer.Close()
+
+		req, _ := http.NewRequest("POST", "/api/v1/transcription/submit", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := submit(map[string]string{
+		"language_detection": "true",
+		"languages":          "VI, en",
+		"language_models":    "vi=fpt_ai,en=groq_whisper",
+	})
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.True(suite.T(), job.Parameters.LanguageDetection)
+	assert.Equal(suite.T(), "vi,en", *job.Parameters.Languages)
+	assert.Equal(suite.T(), "vi=fpt_ai,en=groq_whisper", *job.Parameters.LanguageModels)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, submit(map[string]string{"languages": "vi,not a language"}).Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, submit(map[string]string{"language_models": "vi:fpt_ai"}).Code)
+}
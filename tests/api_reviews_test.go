@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"scriberr/internal/auth"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/internal/review"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *APIHandlerTestSuite) TestReviewWorkflow() {
+	transcript := `{"language": "en", "text": "good morning the quarterly numbers thanks for watching", "segments": [
+		{"start": 0, "end": 2, "text": "Good morning.", "speaker": "SPEAKER_00"},
+		{"start": 2, "end": 5, "text": "the quarterly numbers", "speaker": "SPEAKER_00"},
+		{"start": 40, "end": 42, "text": "Thanks for watching!", "quality_flags": ["silence_hallucination"]}
+	], "word_segments": [
+		{"start": 0, "end": 1, "word": "Good", "score": 0.98},
+		{"start": 1, "end": 2, "word": "morning.", "score": 0.95},
+		{"start": 2, "end": 3, "word": "the", "score": 0.4},
+		{"start": 3, "end": 4, "word": "quarterly", "score": 0.3},
+		{"start": 4, "end": 5, "word": "numbers", "score": 0.5}
+	]}`
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Quarterly Call")
+	job.Status = models.StatusCompleted
+	job.Transcript = &transcript
+	job.UserID = &suite.helper.TestUser.ID
+	suite.helper.DB.Save(job)
+	base := "/api/v1/transcription/" + job.ID + "/review"
+
+	resp := suite.makeAuthenticatedRequest("GET", base, nil, true)
+	assert.Equal(suite.T(), http.StatusNotFound, resp.Code)
+
+	resp = suite.makeAuthenticatedRequest("POST", base, map[string]interface{}{"threshold": 0.7}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	var r models.Review
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &r))
+	assert.Equal(suite.T(), models.ReviewStatusPending, r.Status)
+	assert.Equal(suite.T(), 2, r.Total)
+	require.Len(suite.T(), r.Items, 2)
+	assert.Equal(suite.T(), 1, r.Items[0].Segment)
+	assert.InDelta(suite.T(), 0.4, *r.Items[0].Confidence, 0.001)
+	assert.Equal(suite.T(), 2, r.Items[1].Segment)
+	assert.Equal(suite.T(), []string{"silence_hallucination"}, r.Items[1].Flags)
+
+	// The job shows up in the listing filtered by review state
+	listed := func(query string) int {
+		resp := suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/list?"+query, nil, true)
+		require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+		var body struct {
+			Jobs []models.TranscriptionJob `json:"jobs"`
+		}
+		require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &body))
+		return len(body.Jobs)
+	}
+	assert.Equal(suite.T(), 1, listed("review_status=pending"))
+	assert.Equal(suite.T(), 0, listed("review_status=reviewed"))
+	assert.Equal(suite.T(), 0, listed("review_status=none"))
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/list?review_status=done", nil, true).Code)
+
+	// Another user can't see the review until it is assigned to them
+	hashed, err := auth.HashPassword("reviewerpassword")
+	require.NoError(suite.T(), err)
+	reviewer := models.User{Username: "reviewer", Password: hashed}
+	require.NoError(suite.T(), suite.helper.DB.Create(&reviewer).Error)
+	token, err := suite.helper.AuthService.GenerateToken(&reviewer)
+	require.NoError(suite.T(), err)
+	asReviewer := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(suite.T(), http.StatusNotFound, asReviewer("GET", base, nil).Code)
+	assert.Equal(suite.T(), http.StatusNotFound, asReviewer("POST", base+"/claim", nil).Code)
+
+	resp = suite.makeAuthenticatedRequest("PUT", base+"/assignee", map[string]interface{}{"user_id": 9999}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+	resp = suite.makeAuthenticatedRequest("PUT", base+"/assignee", map[string]interface{}{"user_id": reviewer.ID}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+
+	resp = asReviewer("GET", "/api/v1/reviews?assignee=me", nil)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	var queue []models.Review
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &queue))
+	require.Len(suite.T(), queue, 1)
+	assert.Equal(suite.T(), models.ReviewStatusInReview, queue[0].Status)
+	assert.Equal(suite.T(), 1, listed("review_status=in_review"))
+
+	// Only the assignee works the review
+	resp = suite.makeAuthenticatedRequest("POST", base+"/decisions", map[string]interface{}{"decisions": []map[string]interface{}{{"segment": 1}}}, true)
+	assert.Equal(suite.T(), http.StatusConflict, resp.Code)
+
+	resp = asReviewer("POST", base+"/decisions", map[string]interface{}{"decisions": []map[string]interface{}{{"segment": 0}}})
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+	resp = asReviewer("POST", base+"/decisions", map[string]interface{}{"decisions": []map[string]interface{}{{"segment": 1, "text": "The quarterly numbers."}}})
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &r))
+	assert.Equal(suite.T(), 1, r.Reviewed)
+	assert.Equal(suite.T(), models.ReviewItemCorrected, r.Items[0].Status)
+
+	resp = asReviewer("POST", base+"/complete", nil)
+	assert.Equal(suite.T(), http.StatusConflict, resp.Code)
+
+	resp = asReviewer("POST", base+"/decisions", map[string]interface{}{"decisions": []map[string]interface{}{{"segment": 2}}})
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	resp = asReviewer("POST", base+"/complete", nil)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &r))
+	assert.Equal(suite.T(), models.ReviewStatusReviewed, r.Status)
+	assert.Equal(suite.T(), reviewer.ID, *r.CompletedBy)
+
+	var stored models.TranscriptionJob
+	require.NoError(suite.T(), suite.helper.DB.First(&stored, "id = ?", job.ID).Error)
+	assert.Equal(suite.T(), models.ReviewStatusReviewed, stored.ReviewStatus)
+	assert.Contains(suite.T(), *stored.Transcript, "The quarterly numbers.")
+	assert.Contains(suite.T(), *stored.Transcript, "Thanks for watching!")
+	assert.Equal(suite.T(), 1, listed("review_status=reviewed"))
+	assert.Equal(suite.T(), 1, listed("status=completed&review_status=reviewed"))
+
+	resp = asReviewer("POST", base+"/decisions", map[string]interface{}{"decisions": []map[string]interface{}{{"segment": 2}}})
+	assert.Equal(suite.T(), http.StatusConflict, resp.Code)
+
+	resp = suite.makeAuthenticatedRequest("DELETE", base, nil, true)
+	assert.Equal(suite.T(), http.StatusNoContent, resp.Code)
+	assert.Equal(suite.T(), 1, listed("review_status=none"))
+}
+
+func (suite *APIHandlerTestSuite) TestReviewQueuedOnCompletion() {
+	transcript := `{"segments": [
+		{"start": 0, "end": 2, "text": "Good morning.", "avg_logprob": -1.5},
+		{"start": 40, "end": 42, "text": "Thanks for watching!", "quality_flags": ["silence_hallucination"]}
+	]}`
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Flagged Call")
+	job.Status = models.StatusCompleted
+	job.Transcript = &transcript
+	suite.helper.DB.Save(job)
+	service := review.NewService(repository.NewJobRepository(suite.helper.DB), repository.NewReviewRepository(suite.helper.DB))
+
+	// Flagged segments are queued without a threshold, unsure ones with one
+	service.Queue(job.ID)
+	r, err := service.Get(context.Background(), job.ID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), r)
+	require.Len(suite.T(), r.Items, 1)
+	assert.Equal(suite.T(), 1, r.Items[0].Segment)
+
+	threshold := 0.5
+	job.Parameters.ReviewThreshold = &threshold
+	suite.helper.DB.Save(job)
+	service.Queue(job.ID)
+	r, err = service.Get(context.Background(), job.ID)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), r.Items, 2)
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"scriberr/internal/api"
+	"scriberr/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *APIHandlerTestSuite) TestSmartAnalysisReview() {
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		prompts = append(prompts, string(body))
+		greeting := "Hello there, everyone."
+		if len(prompts) > 1 {
+			greeting = "Hello there, everyone!"
+		}
+		answer, _ := json.Marshal(`{"changes": [
+			{"n": 0, "text": "` + greeting + `", "speaker": ""},
+			{"n": 1, "text": "welcome to the weekly sink", "speaker": "SPEAKER_00"},
+			{"n": 2, "text": "Let's start with the roadmap.", "speaker": ""},
+			{"n": 9, "text": "Not a segment", "speaker": ""}
+		]}`)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%s}}]}`, answer)
+	}))
+	defer server.Close()
+	suite.helper.DB.Model(&models.LLMConfig{}).Where("is_active = ?", true).Updates(&models.LLMConfig{OpenAIBaseURL: &server.URL})
+	require.NoError(suite.T(), suite.helper.DB.Create(&models.SummarySetting{DefaultModel: "gpt-3.5-turbo"}).Error)
+
+	transcript := `{"language": "en", "text": "hello there everyone welcome to the weekly sink lets start with the roadmap", "segments": [
+		{"start": 0, "end": 2, "text": " hello there everyone", "speaker": "SPEAKER_00"},
+		{"start": 2, "end": 4, "text": "welcome to the weekly sink", "speaker": "SPEAKER_01"},
+		{"start": 4, "end": 6, "text": "lets start with the roadmap", "speaker": "SPEAKER_01"}
+	]}`
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Weekly Sync")
+	job.Status = models.StatusCompleted
+	job.Transcript = &transcript
+	suite.helper.DB.Save(job)
+	base := "/api/v1/transcription/" + job.ID + "/smart-analysis"
+
+	resp := suite.makeAuthenticatedRequest("GET", base, nil, true)
+	assert.Equal(suite.T(), http.StatusNotFound, resp.Code)
+
+	resp = suite.makeAuthenticatedRequest("POST", base, nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	var analysis models.SmartAnalysis
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &analysis))
+	assert.Equal(suite.T(), models.SmartAnalysisStatusCompleted, analysis.Status)
+	assert.Equal(suite.T(), "en", analysis.Language)
+	assert.Equal(suite.T(), "gpt-3.5-turbo", analysis.Model)
+	assert.Equal(suite.T(), 3, analysis.Segments)
+	require.Len(suite.T(), analysis.Changes, 3)
+	assert.Equal(suite.T(), "hello there everyone", analysis.Changes[0].OriginalText)
+	assert.Equal(suite.T(), "Hello there, everyone.", analysis.Changes[0].ProposedText)
+	assert.Nil(suite.T(), analysis.Changes[0].ProposedSpeaker)
+	assert.Equal(suite.T(), "welcome to the weekly sink", analysis.Changes[1].ProposedText)
+	assert.Equal(suite.T(), "SPEAKER_00", *analysis.Changes[1].ProposedSpeaker)
+	assert.Equal(suite.T(), models.ChangeStatusPending, analysis.Changes[2].Status)
+	require.Len(suite.T(), prompts, 1)
+	assert.Contains(suite.T(), prompts[0], "transcript in English")
+	assert.Contains(suite.T(), prompts[0], "[1] SPEAKER_01: welcome to the weekly sink")
+
+	// Proposals leave the transcript alone until they are accepted
+	var stored models.TranscriptionJob
+	suite.helper.DB.First(&stored, "id = ?", job.ID)
+	assert.Equal(suite.T(), transcript, *stored.Transcript)
+
+	resp = suite.makeAuthenticatedRequest("POST", base+"/review", api.SmartAnalysisReviewRequest{Accept: []int{5}}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+	resp = suite.makeAuthenticatedRequest("POST", base+"/review", api.SmartAnalysisReviewRequest{Accept: []int{0}, Reject: []int{0}}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+
+	resp = suite.makeAuthenticatedRequest("POST", base+"/review", api.SmartAnalysisReviewRequest{Accept: []int{0, 1}, Reject: []int{2}}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &analysis))
+	assert.Equal(suite.T(), models.ChangeStatusAccepted, analysis.Changes[0].Status)
+	assert.Equal(suite.T(), models.ChangeStatusRejected, analysis.Changes[2].Status)
+
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/"+job.ID+"/transcript", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	var transcriptResp struct {
+		Transcript struct {
+			Text     string `json:"text"`
+			Segments []struct {
+				Text    string `json:"text"`
+				Speaker string `json:"speaker"`
+			} `json:"segments"`
+		} `json:"transcript"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &transcriptResp))
+	assert.Equal(suite.T(), "Hello there, everyone.", transcriptResp.Transcript.Segments[0].Text)
+	assert.Equal(suite.T(), "SPEAKER_00", transcriptResp.Transcript.Segments[1].Speaker)
+	assert.Equal(suite.T(), "lets start with the roadmap", transcriptResp.Transcript.Segments[2].Text)
+	assert.True(suite.T(), strings.HasPrefix(transcriptResp.Transcript.Text, "Hello there, everyone. welcome"))
+
+	// Applied changes stay applied; rejected ones can still be accepted
+	resp = suite.makeAuthenticatedRequest("POST", base+"/review", api.SmartAnalysisReviewRequest{Reject: []int{0}}, true)
+	assert.Equal(suite.T(), http.StatusConflict, resp.Code)
+	resp = suite.makeAuthenticatedRequest("POST", base+"/review", api.SmartAnalysisReviewRequest{Accept: []int{2}}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+
+	// A fresh analysis of an edited transcript is not applied over the edit
+	resp = suite.makeAuthenticatedRequest("POST", base, nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &analysis))
+	require.Len(suite.T(), analysis.Changes, 1)
+	assert.Equal(suite.T(), "Hello there, everyone!", analysis.Changes[0].ProposedText)
+	suite.helper.DB.First(&stored, "id = ?", job.ID)
+	edited := strings.Replace(*stored.Transcript, "Hello there, everyone.", "Hi all.", -1)
+	suite.helper.DB.Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).Update("transcript", edited)
+	resp = suite.makeAuthenticatedRequest("POST", base+"/review", api.SmartAnalysisReviewRequest{All: "accept"}, true)
+	assert.Equal(suite.T(), http.StatusConflict, resp.Code)
+	resp = suite.makeAuthenticatedRequest("POST", base+"/review", api.SmartAnalysisReviewRequest{All: "reject"}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+
+	resp = suite.makeAuthenticatedRequest("DELETE", base, nil, true)
+	assert.Equal(suite.T(), http.StatusNoContent, resp.Code)
+	resp = suite.makeAuthenticatedRequest("GET", base, nil, true)
+	assert.Equal(suite.T(), http.StatusNotFound, resp.Code)
+}
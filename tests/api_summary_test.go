@@ -1,13 +1,24 @@
 package tests
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
 
 	"scriberr/internal/api"
+	"scriberr/internal/llm"
 	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/internal/summarize"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func (suite *APIHandlerTestSuite) TestSummarize() {
@@ -69,3 +80,353 @@ func (suite *APIHandlerTestSuite) TestGetSummaryForTranscription() {
 	assert.Equal(suite.T(), "Stored summary content", summaryResp.Content)
 	assert.Equal(suite.T(), "gpt-4", summaryResp.Model)
 }
+
+func (suite *APIHandlerTestSuite) TestSummarizeMapReduce() {
+	// Chunk summaries are plain completions, the final summary is streamed
+	var mu sync.Mutex
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []llm.ChatMessage `json:"messages"`
+			Stream   bool              `json:"stream"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		prompts = append(prompts, req.Messages[0].Content)
+		mu.Unlock()
+		if req.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Final summary\"}}]}\n\ndata: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"Chunk summary"}}]}`)
+	}))
+	defer server.Close()
+	suite.helper.DB.Model(&models.LLMConfig{}).Where("is_active = ?", true).Updates(&models.LLMConfig{OpenAIBaseURL: &server.URL})
+
+	// About 5000 tokens, more than the 4096-token window of unknown models
+	var segments []string
+	for i := 0; i < 200; i++ {
+		segments = append(segments, fmt.Sprintf(`{"start": %d, "end": %d, "text": "Line %d of a very long meeting about the quarterly budget and the hiring plan.", "speaker": "SPEAKER_00"}`, i, i+1, i))
+	}
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Long Transcription")
+	job.Status = models.StatusCompleted
+	transcript := `{"segments": [` + strings.Join(segments, ",") + `]}`
+	job.Transcript = &transcript
+	suite.helper.DB.Save(job)
+	suite.helper.DB.Create(&models.SpeakerMapping{TranscriptionJobID: job.ID, OriginalSpeaker: "SPEAKER_00", CustomName: "Alice"})
+	template := &models.SummaryTemplate{Name: "Minutes", Prompt: "Write meeting minutes."}
+	require.NoError(suite.T(), suite.helper.DB.Create(template).Error)
+
+	body, _ := json.Marshal(api.SummarizeRequest{Model: "local-model", TranscriptionID: job.ID, TemplateID: &template.ID})
+	req, _ := http.NewRequest("POST", "/api/v1/summarize/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(suite.T(), "text/event-stream", resp.Header().Get("Content-Type"))
+
+	var events []api.SummarizeEvent
+	for _, line := range strings.Split(resp.Body.String(), "\n") {
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			var event api.SummarizeEvent
+			require.NoError(suite.T(), json.Unmarshal([]byte(data), &event))
+			events = append(events, event)
+		}
+	}
+	var mapProgress []api.SummarizeEvent
+	text := ""
+	for _, e := range events {
+		switch {
+		case e.Type == "progress" && e.Stage == summarize.StageMap:
+			mapProgress = append(mapProgress, e)
+		case e.Type == "content":
+			text += e.Text
+		}
+	}
+	require.GreaterOrEqual(suite.T(), len(mapProgress), 2)
+	chunks := mapProgress[0].Total
+	assert.Equal(suite.T(), chunks, len(mapProgress))
+	assert.Equal(suite.T(), chunks, mapProgress[len(mapProgress)-1].Done)
+	assert.Equal(suite.T(), "Final summary", text)
+	assert.Equal(suite.T(), "done", events[len(events)-1].Type)
+
+	// Every chunk was summarized once, then the partials were combined
+	require.Len(suite.T(), prompts, chunks+1)
+	for _, p := range prompts[:chunks] {
+		assert.Contains(suite.T(), p, "Write meeting minutes.")
+		assert.Contains(suite.T(), p, "Alice: Line")
+	}
+	final := prompts[chunks]
+	assert.True(suite.T(), strings.HasPrefix(final, "Write meeting minutes."))
+	assert.Contains(suite.T(), final, "Part 1:\nChunk summary")
+	assert.NotContains(suite.T(), final, "Alice: Line")
+
+	var summary models.Summary
+	require.NoError(suite.T(), suite.helper.DB.Where("transcription_id = ?", job.ID).First(&summary).Error)
+	assert.Equal(suite.T(), "Final summary", summary.Content)
+	assert.Equal(suite.T(), template.ID, *summary.TemplateID)
+
+	// Short transcripts are summarized in one call
+	prompts = nil
+	short := suite.helper.CreateTestTranscriptionJob(suite.T(), "Short Transcription")
+	short.Status = models.StatusCompleted
+	shortTranscript := `{"segments": [{"start": 0.0, "end": 2.0, "text": "We agreed on the budget.", "speaker": "SPEAKER_00"}]}`
+	short.Transcript = &shortTranscript
+	suite.helper.DB.Save(short)
+	plain := suite.makeAuthenticatedRequest("POST", "/api/v1/summarize/", api.SummarizeRequest{Model: "local-model", TranscriptionID: short.ID}, true)
+	require.Equal(suite.T(), http.StatusOK, plain.Code)
+	assert.Equal(suite.T(), "Final summary", plain.Body.String())
+	require.Len(suite.T(), prompts, 1)
+	assert.Contains(suite.T(), prompts[0], "SPEAKER_00: We agreed on the budget.")
+}
+
+func (suite *APIHandlerTestSuite) TestSummaryHistory() {
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "History Transcription")
+	job.Status = models.StatusCompleted
+	transcript := `{"segments": [{"start": 0.0, "end": 2.0, "text": "We agreed on the budget.", "speaker": "SPEAKER_00"}]}`
+	job.Transcript = &transcript
+	suite.helper.DB.Save(job)
+	base := "/api/v1/transcription/" + job.ID + "/summaries"
+
+	waitFor := func(id string) models.Summary {
+		var summary models.Summary
+		require.Eventually(suite.T(), func() bool {
+			resp := suite.makeAuthenticatedRequest("GET", base+"/"+id, nil, true)
+			require.Equal(suite.T(), http.StatusOK, resp.Code)
+			require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &summary))
+			return summary.Status == models.SummaryStatusCompleted || summary.Status == models.SummaryStatusFailed
+		}, 5*time.Second, 20*time.Millisecond)
+		return summary
+	}
+
+	// Summaries are queued and generated in the background
+	resp := suite.makeAuthenticatedRequest("POST", base, api.QueueSummaryRequest{Model: "gpt-3.5-turbo"}, true)
+	require.Equal(suite.T(), http.StatusAccepted, resp.Code, resp.Body.String())
+	var first models.Summary
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &first))
+	assert.Equal(suite.T(), models.SummaryStatusPending, first.Status)
+	assert.Equal(suite.T(), "auto", first.Mode)
+	first = waitFor(first.ID)
+	assert.Equal(suite.T(), models.SummaryStatusCompleted, first.Status)
+	assert.NotEmpty(suite.T(), first.Content)
+	assert.NotNil(suite.T(), first.CompletedAt)
+
+	// Each run is kept, unlike the latest summary
+	resp = suite.makeAuthenticatedRequest("POST", base, api.QueueSummaryRequest{Model: "gpt-3.5-turbo", Mode: "single"}, true)
+	require.Equal(suite.T(), http.StatusAccepted, resp.Code)
+	var second models.Summary
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &second))
+	waitFor(second.ID)
+
+	resp = suite.makeAuthenticatedRequest("GET", base, nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	var history []models.Summary
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &history))
+	require.Len(suite.T(), history, 2)
+
+	resp = suite.makeAuthenticatedRequest("DELETE", base+"/"+second.ID, nil, true)
+	assert.Equal(suite.T(), http.StatusNoContent, resp.Code)
+	resp = suite.makeAuthenticatedRequest("GET", base+"/"+second.ID, nil, true)
+	assert.Equal(suite.T(), http.StatusNotFound, resp.Code)
+	resp = suite.makeAuthenticatedRequest("GET", base, nil, true)
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &history))
+	require.Len(suite.T(), history, 1)
+	assert.Equal(suite.T(), first.ID, history[0].ID)
+
+	// Unknown templates are rejected before queueing
+	missing := "missing"
+	resp = suite.makeAuthenticatedRequest("POST", base, api.QueueSummaryRequest{Model: "gpt-3.5-turbo", TemplateID: &missing}, true)
+	assert.Equal(suite.T(), http.StatusNotFound, resp.Code)
+
+	// Failures are kept in the history with their error
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"model overloaded"}}`, http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	suite.helper.DB.Model(&models.LLMConfig{}).Where("is_active = ?", true).Updates(&models.LLMConfig{OpenAIBaseURL: &failing.URL})
+	resp = suite.makeAuthenticatedRequest("POST", base, api.QueueSummaryRequest{Model: "gpt-3.5-turbo"}, true)
+	require.Equal(suite.T(), http.StatusAccepted, resp.Code)
+	var failed models.Summary
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &failed))
+	failed = waitFor(failed.ID)
+	assert.Equal(suite.T(), models.SummaryStatusFailed, failed.Status)
+	require.NotNil(suite.T(), failed.ErrorMessage)
+
+	// The latest summary is the newest completed one
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/"+job.ID+"/summary", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	var latest models.Summary
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &latest))
+	assert.Equal(suite.T(), first.ID, latest.ID)
+}
+
+func (suite *APIHandlerTestSuite) TestAutoSummaries() {
+	minutes := &models.SummaryTemplate{Name: "Minutes", Prompt: "Write meeting minutes."}
+	actions := &models.SummaryTemplate{Name: "Actions", Prompt: "List the action items.", Model: "gpt-4o"}
+	require.NoError(suite.T(), suite.helper.DB.Create(minutes).Error)
+	require.NoError(suite.T(), suite.helper.DB.Create(actions).Error)
+	// Templates without a model use the default summary model
+	require.NoError(suite.T(), suite.helper.DB.Create(&models.SummarySetting{DefaultModel: "gpt-3.5-turbo"}).Error)
+
+	// Users attach templates in their settings
+	resp := suite.makeAuthenticatedRequest("PUT", "/api/v1/user/settings", map[string]interface{}{"auto_summary_template_ids": []string{"missing"}}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+	resp = suite.makeAuthenticatedRequest("PUT", "/api/v1/user/settings", map[string]interface{}{"auto_summary_template_ids": []string{minutes.ID}}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	var settings api.UserSettingsResponse
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &settings))
+	assert.Equal(suite.T(), []string{minutes.ID}, settings.AutoSummaryTemplateIDs)
+
+	// Profiles attach them through the job parameters; duplicates run once
+	// and templates deleted since are recorded as failed
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Auto Summary Transcription")
+	job.Status = models.StatusCompleted
+	transcript := `{"segments": [{"start": 0.0, "end": 2.0, "text": "We agreed on the budget.", "speaker": "SPEAKER_00"}]}`
+	job.Transcript = &transcript
+	job.Parameters.SummaryTemplates = summarize.JoinTemplateIDs([]string{actions.ID, "deleted", minutes.ID})
+	suite.helper.DB.Save(job)
+
+	service := summarize.NewService(
+		repository.NewJobRepository(suite.helper.DB),
+		repository.NewSummaryRepository(suite.helper.DB),
+		repository.NewSpeakerMappingRepository(suite.helper.DB),
+		repository.NewUserRepository(suite.helper.DB),
+		llm.NewRouter(repository.NewLLMConfigRepository(suite.helper.DB)),
+		nil,
+	)
+	queued := service.QueueAutoSummaries(context.Background(), job)
+	require.Len(suite.T(), queued, 3)
+	finished := service.WaitForSummaries(context.Background(), queued)
+	require.Len(suite.T(), finished, 3)
+
+	byTemplate := make(map[string]models.Summary)
+	for _, s := range finished {
+		byTemplate[*s.TemplateID] = s
+	}
+	assert.Equal(suite.T(), models.SummaryStatusCompleted, byTemplate[actions.ID].Status)
+	assert.Equal(suite.T(), "gpt-4o", byTemplate[actions.ID].Model)
+	assert.NotEmpty(suite.T(), byTemplate[actions.ID].Content)
+	assert.Equal(suite.T(), models.SummaryStatusCompleted, byTemplate[minutes.ID].Status)
+	assert.Equal(suite.T(), "gpt-3.5-turbo", byTemplate[minutes.ID].Model)
+	assert.Equal(suite.T(), models.SummaryStatusFailed, byTemplate["deleted"].Status)
+	require.NotNil(suite.T(), byTemplate["deleted"].ErrorMessage)
+
+	// Finished summaries are watched from their stored outcome
+	updates, ok := service.Watch(context.Background(), byTemplate["deleted"].ID)
+	require.True(suite.T(), ok)
+	var outcome []summarize.Update
+	for u := range updates {
+		outcome = append(outcome, u)
+	}
+	require.Len(suite.T(), outcome, 1)
+	assert.Equal(suite.T(), models.SummaryStatusFailed, outcome[0].Status)
+	assert.Equal(suite.T(), *byTemplate["deleted"].ErrorMessage, outcome[0].Error)
+	updates, ok = service.Watch(context.Background(), byTemplate[actions.ID].ID)
+	require.True(suite.T(), ok)
+	outcome = nil
+	for u := range updates {
+		outcome = append(outcome, u)
+	}
+	require.Len(suite.T(), outcome, 2)
+	assert.Equal(suite.T(), byTemplate[actions.ID].Content, outcome[0].Text)
+	assert.Equal(suite.T(), models.SummaryStatusCompleted, outcome[1].Status)
+	_, ok = service.Watch(context.Background(), "missing")
+	assert.False(suite.T(), ok)
+
+	// Jobs without templates queue nothing
+	suite.helper.DB.Model(suite.helper.TestUser).Update("auto_summary_templates", nil)
+	other := suite.helper.CreateTestTranscriptionJob(suite.T(), "Plain Transcription")
+	assert.Empty(suite.T(), service.QueueAutoSummaries(context.Background(), other))
+}
+
+func (suite *APIHandlerTestSuite) TestStructuredSummaries() {
+	// The server rejects structured outputs but supports JSON mode, and its
+	// first answer misses a required field
+	var mu sync.Mutex
+	var formats []string
+	var repairs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages       []llm.ChatMessage `json:"messages"`
+			ResponseFormat *struct {
+				Type string `json:"type"`
+			} `json:"response_format"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		format := ""
+		if req.ResponseFormat != nil {
+			format = req.ResponseFormat.Type
+		}
+		mu.Lock()
+		formats = append(formats, format)
+		mu.Unlock()
+		if format == "json_schema" {
+			http.Error(w, `{"error":{"message":"response_format json_schema is not supported"}}`, http.StatusBadRequest)
+			return
+		}
+		answer := `{"overview": "Budget meeting"}`
+		if len(req.Messages) > 1 {
+			mu.Lock()
+			repairs = append(repairs, req.Messages[len(req.Messages)-1].Content)
+			mu.Unlock()
+			answer = "```json\n{\"overview\": \"Budget meeting\", \"action_items\": [{\"task\": \"Send the budget\", \"owner\": \"Alice\"}], \"decisions\": []}\n```"
+		}
+		body, _ := json.Marshal(answer)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%s}}]}`, body)
+	}))
+	defer server.Close()
+	suite.helper.DB.Model(&models.LLMConfig{}).Where("is_active = ?", true).Updates(&models.LLMConfig{OpenAIBaseURL: &server.URL})
+
+	// Templates with an invalid schema are rejected
+	resp := suite.makeAuthenticatedRequest("POST", "/api/v1/summaries/", map[string]interface{}{
+		"name": "Broken", "model": "gpt-4o", "prompt": "Summarize.", "schema": map[string]interface{}{"type": "table"},
+	}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+
+	schema := `{"type": "object", "properties": {
+		"overview": {"type": "string"},
+		"action_items": {"type": "array", "items": {"type": "object", "properties": {"task": {"type": "string"}, "owner": {"type": "string"}}, "required": ["task"]}},
+		"decisions": {"type": "array", "items": {"type": "string"}}
+	}, "required": ["overview", "action_items", "decisions"]}`
+	resp = suite.makeAuthenticatedRequest("POST", "/api/v1/summaries/", map[string]interface{}{
+		"name": "Meeting", "model": "gpt-4o", "prompt": "Summarize the meeting.", "schema": json.RawMessage(schema),
+	}, true)
+	require.Equal(suite.T(), http.StatusCreated, resp.Code, resp.Body.String())
+	var template models.SummaryTemplate
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &template))
+	assert.NotEmpty(suite.T(), template.Schema)
+
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Structured Transcription")
+	job.Status = models.StatusCompleted
+	transcript := `{"segments": [{"start": 0.0, "end": 2.0, "text": "Alice will send the budget.", "speaker": "SPEAKER_00"}]}`
+	job.Transcript = &transcript
+	suite.helper.DB.Save(job)
+	base := "/api/v1/transcription/" + job.ID + "/summaries"
+
+	resp = suite.makeAuthenticatedRequest("POST", base, api.QueueSummaryRequest{TemplateID: &template.ID}, true)
+	require.Equal(suite.T(), http.StatusAccepted, resp.Code, resp.Body.String())
+	var summary models.Summary
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &summary))
+	require.Eventually(suite.T(), func() bool {
+		resp := suite.makeAuthenticatedRequest("GET", base+"/"+summary.ID, nil, true)
+		require.Equal(suite.T(), http.StatusOK, resp.Code)
+		require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &summary))
+		return summary.Status == models.SummaryStatusCompleted || summary.Status == models.SummaryStatusFailed
+	}, 5*time.Second, 20*time.Millisecond)
+	require.Equal(suite.T(), models.SummaryStatusCompleted, summary.Status)
+
+	// The repaired result is stored as is, with a Markdown rendering
+	assert.JSONEq(suite.T(), `{"overview": "Budget meeting", "action_items": [{"task": "Send the budget", "owner": "Alice"}], "decisions": []}`, string(summary.Structured))
+	assert.Equal(suite.T(), "## Overview\n\nBudget meeting\n\n## Action items\n\n- Send the budget (owner: Alice)\n\n## Decisions\n\n_None_", summary.Content)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(suite.T(), []string{"json_schema", "json_object", "json_schema", "json_object"}, formats)
+	require.Len(suite.T(), repairs, 1)
+	assert.Contains(suite.T(), repairs[0], "$.action_items is required")
+}
@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"time"
+
+	"scriberr/internal/api"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/internal/translate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *APIHandlerTestSuite) TestTranslations() {
+	// The mock model answers every numbered line it is sent
+	numbered := regexp.MustCompile(`\[(\d+)\] `)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		type line struct {
+			N    int    `json:"n"`
+			Text string `json:"text"`
+		}
+		var lines []line
+		for _, m := range numbered.FindAllStringSubmatch(string(body), -1) {
+			n, _ := strconv.Atoi(m[1])
+			lines = append(lines, line{N: n, Text: fmt.Sprintf("Dòng %d", n)})
+		}
+		content, _ := json.Marshal(map[string]any{"lines": lines})
+		answer, _ := json.Marshal(string(content))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%s}}]}`, answer)
+	}))
+	defer server.Close()
+	suite.helper.DB.Model(&models.LLMConfig{}).Where("is_active = ?", true).Updates(&models.LLMConfig{OpenAIBaseURL: &server.URL})
+	require.NoError(suite.T(), suite.helper.DB.Create(&models.SummarySetting{DefaultModel: "gpt-3.5-turbo"}).Error)
+
+	transcript := `{"language": "en", "segments": [
+		{"start": 0, "end": 2.5, "text": "Hello there everyone", "speaker": "SPEAKER_00"},
+		{"start": 2.5, "end": 6, "text": "Welcome to the weekly sync", "speaker": "SPEAKER_01"}
+	], "word_segments": [{"start": 0, "end": 0.5, "word": "Hello"}]}`
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Weekly Sync")
+	job.Status = models.StatusCompleted
+	job.Transcript = &transcript
+	suite.helper.DB.Save(job)
+	base := "/api/v1/transcription/" + job.ID
+
+	resp := suite.makeAuthenticatedRequest("POST", base+"/translations", api.TranslationRequest{Language: "not a language"}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+	resp = suite.makeAuthenticatedRequest("POST", base+"/translations", api.TranslationRequest{Language: "vi", Provider: "service"}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+	resp = suite.makeAuthenticatedRequest("GET", base+"/translations/vi", nil, true)
+	assert.Equal(suite.T(), http.StatusNotFound, resp.Code)
+
+	resp = suite.makeAuthenticatedRequest("POST", base+"/translations", api.TranslationRequest{Language: "VI"}, true)
+	require.Equal(suite.T(), http.StatusAccepted, resp.Code, resp.Body.String())
+	var translation models.TranscriptTranslation
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &translation))
+	assert.Equal(suite.T(), "vi", translation.Language)
+	assert.Equal(suite.T(), models.TranslationProviderLLM, translation.Provider)
+
+	require.Eventually(suite.T(), func() bool {
+		resp := suite.makeAuthenticatedRequest("GET", base+"/translations/vi", nil, true)
+		translation = models.TranscriptTranslation{}
+		_ = json.Unmarshal(resp.Body.Bytes(), &translation)
+		return translation.Status == models.TranslationStatusCompleted || translation.Status == models.TranslationStatusFailed
+	}, 10*time.Second, 50*time.Millisecond)
+	require.Equal(suite.T(), models.TranslationStatusCompleted, translation.Status, translation.Error)
+	assert.Equal(suite.T(), "en", translation.SourceLanguage)
+	assert.Equal(suite.T(), "gpt-3.5-turbo", translation.Model)
+	assert.Equal(suite.T(), 2, translation.Translated)
+	require.Len(suite.T(), translation.Segments, 2)
+	assert.Equal(suite.T(), "Dòng 1", translation.Segments[1].Text)
+	assert.Equal(suite.T(), 2.5, translation.Segments[1].Start)
+	assert.Equal(suite.T(), "SPEAKER_01", *translation.Segments[1].Speaker)
+
+	resp = suite.makeAuthenticatedRequest("GET", base+"/translations", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	var list []models.TranscriptTranslation
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &list))
+	require.Len(suite.T(), list, 1)
+	assert.Empty(suite.T(), list[0].Segments)
+
+	resp = suite.makeAuthenticatedRequest("GET", base+"/transcript?language=vi", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	var transcriptResp struct {
+		Transcript map[string]any `json:"transcript"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &transcriptResp))
+	assert.Equal(suite.T(), "vi", transcriptResp.Transcript["language"])
+	assert.Equal(suite.T(), "Dòng 0 Dòng 1", transcriptResp.Transcript["text"])
+	assert.NotContains(suite.T(), transcriptResp.Transcript, "word_segments")
+	resp = suite.makeAuthenticatedRequest("GET", base+"/transcript?language=fr", nil, true)
+	assert.Equal(suite.T(), http.StatusNotFound, resp.Code)
+
+	resp = suite.makeAuthenticatedRequest("GET", base+"/export?format=srt&language=vi&bilingual=true", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	assert.Contains(suite.T(), resp.Body.String(), "00:00:02,500 --> 00:00:06,000\nSPEAKER_01: Welcome to the weekly sync\nDòng 1\n")
+	assert.Contains(suite.T(), resp.Header().Get("Content-Disposition"), ".vi.srt")
+	resp = suite.makeAuthenticatedRequest("GET", base+"/export?format=txt&language=vi", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	assert.Contains(suite.T(), resp.Body.String(), "Dòng 0")
+	assert.NotContains(suite.T(), resp.Body.String(), "Hello there")
+
+	resp = suite.makeAuthenticatedRequest("POST", "/api/v1/chat/sessions", api.ChatCreateRequest{TranscriptionID: job.ID, Model: "gpt-3.5-turbo", Language: "Vi"}, true)
+	require.Equal(suite.T(), http.StatusCreated, resp.Code, resp.Body.String())
+	var session api.ChatSessionResponse
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &session))
+	assert.Equal(suite.T(), "vi", session.Language)
+	resp = suite.makeAuthenticatedRequest("POST", "/api/v1/chat/sessions", api.ChatCreateRequest{TranscriptionID: job.ID, Model: "gpt-3.5-turbo", Language: "??"}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+
+	resp = suite.makeAuthenticatedRequest("DELETE", base+"/translations/vi", nil, true)
+	assert.Equal(suite.T(), http.StatusNoContent, resp.Code)
+	resp = suite.makeAuthenticatedRequest("GET", base+"/translations/vi", nil, true)
+	assert.Equal(suite.T(), http.StatusNotFound, resp.Code)
+
+	// Deleting the job removes its translations
+	resp = suite.makeAuthenticatedRequest("POST", base+"/translations", api.TranslationRequest{Language: "ja"}, true)
+	require.Equal(suite.T(), http.StatusAccepted, resp.Code)
+	resp = suite.makeAuthenticatedRequest("DELETE", base, nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	var count int64
+	suite.helper.DB.Model(&models.TranscriptTranslation{}).Where("transcription_id = ?", job.ID).Count(&count)
+	assert.Zero(suite.T(), count)
+}
+
+func (suite *APIHandlerTestSuite) TestTranslationsWithService() {
+	var requests int
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var req struct {
+			Q      []string `json:"q"`
+			Source string   `json:"source"`
+			Target string   `json:"target"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		out := make([]string, len(req.Q))
+		for i, q := range req.Q {
+			out[i] = req.Target + ": " + q
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"translatedText": out})
+	}))
+	defer service.Close()
+	translations := translate.NewService(repository.NewJobRepository(suite.helper.DB), repository.NewTranslationRepository(suite.helper.DB), repository.NewSummaryRepository(suite.helper.DB), nil, service.URL, "")
+
+	transcript := `{"segments": [{"start": 0, "end": 2, "text": "Bonjour"}, {"start": 2, "end": 4, "text": "Merci"}]}`
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Service")
+	job.Status = models.StatusCompleted
+	job.Transcript = &transcript
+	suite.helper.DB.Save(job)
+
+	translation, err := translations.Enqueue(suite.T().Context(), job, translate.Options{Language: "en"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), models.TranslationProviderService, translation.Provider)
+	require.Eventually(suite.T(), func() bool {
+		t, err := translations.Completed(suite.T().Context(), job.ID, "en")
+		return err == nil && t != nil
+	}, 10*time.Second, 50*time.Millisecond)
+	done, err := translations.Completed(suite.T().Context(), job.ID, "en")
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "en: Merci", done.Segments[1].Text)
+	assert.Equal(suite.T(), 1, requests)
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriberr/internal/archive"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	helper := NewTestHelper(t, "test_archive.db")
+	defer helper.Cleanup()
+
+	ctx := context.Background()
+	db := helper.DB
+	jobRepo := repository.NewJobRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	svc := archive.NewService(
+		jobRepo,
+		repository.NewSummaryRepository(db),
+		chatRepo,
+		repository.NewNoteRepository(db),
+		repository.NewSpeakerMappingRepository(db),
+		helper.Config.UploadDir,
+	)
+
+	audioPath := filepath.Join(helper.Config.UploadDir, "source.wav")
+	require.NoError(t, os.WriteFile(audioPath, []byte("RIFF fake audio"), 0644))
+
+	title := "Archived Meeting"
+	transcript := `{"segments":[{"start":0,"end":1,"text":"hello","speaker":"SPEAKER_00"}],"language":"en"}`
+	secret := "hf_secret"
+	job := &models.TranscriptionJob{
+		ID:         "archive-source-job",
+		Title:      &title,
+		Status:     models.StatusCompleted,
+		AudioPath:  audioPath,
+		Transcript: &transcript,
+		UserID:     &helper.TestUser.ID,
+		Parameters: models.WhisperXParams{Model: "base", HfToken: &secret},
+	}
+	require.NoError(t, db.Create(job).Error)
+	require.NoError(t, db.Create(&models.SpeakerMapping{TranscriptionJobID: job.ID, OriginalSpeaker: "SPEAKER_00", CustomName: "Alice"}).Error)
+	helper.CreateTestNote(t, job.ID)
+	require.NoError(t, db.Create(&models.Summary{TranscriptionID: job.ID, Model: "gpt-4", Mode: "single", Content: "Summary", Structured: json.RawMessage(`{"topics":["archives"]}`)}).Error)
+	session := helper.CreateTestChatSession(t, job.ID)
+	require.NoError(t, chatRepo.AddMessage(ctx, &models.ChatMessage{ChatSessionID: session.ID, Role: "user", Content: "hi"}))
+	require.NoError(t, jobRepo.CreateExecution(ctx, &models.TranscriptionJobExecution{TranscriptionJobID: job.ID, Status: models.StatusCompleted, Source: models.ExecutionSourceTranscribed}))
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.Export(ctx, &buf, []models.TranscriptionJob{*job}))
+	assert.NotContains(t, buf.String(), secret)
+
+	target := models.User{Username: "archive-target", Password: "x"}
+	require.NoError(t, db.Create(&target).Error)
+
+	result, err := svc.Import(ctx, bytes.NewReader(buf.Bytes()), int64(buf.Len()), target.ID)
+	require.NoError(t, err)
+	require.Len(t, result.Imported, 1)
+	newID := result.Imported[0].NewID
+	assert.NotEqual(t, job.ID, newID)
+
+	imported, err := jobRepo.FindByID(ctx, newID)
+	require.NoError(t, err)
+	require.NotNil(t, imported)
+	assert.Equal(t, target.ID, *imported.UserID)
+	assert.Equal(t, transcript, *imported.Transcript)
+	assert.Nil(t, imported.Parameters.HfToken)
+	data, err := os.ReadFile(imported.AudioPath)
+	require.NoError(t, err)
+	assert.Equal(t, "RIFF fake audio", string(data))
+
+	var count int64
+	db.Model(&models.SpeakerMapping{}).Where("transcription_job_id = ?", newID).Count(&count)
+	assert.Equal(t, int64(1), count)
+	db.Model(&models.Note{}).Where("transcription_id = ?", newID).Count(&count)
+	assert.Equal(t, int64(1), count)
+	var summary models.Summary
+	require.NoError(t, db.Where("transcription_id = ?", newID).First(&summary).Error)
+	assert.Equal(t, models.SummaryStatusCompleted, summary.Status)
+	assert.Equal(t, "single", summary.Mode)
+	assert.JSONEq(t, `{"topics":["archives"]}`, string(summary.Structured))
+	db.Model(&models.TranscriptionJobExecution{}).Where("transcription_job_id = ?", newID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	sessions, err := chatRepo.ListByJob(ctx, newID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	messages, err := chatRepo.GetMessages(ctx, sessions[0].ID, 0)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "hi", messages[0].Content)
+
+	// Importing the same archive again is deduplicated by audio hash
+	again, err := svc.Import(ctx, bytes.NewReader(buf.Bytes()), int64(buf.Len()), target.ID)
+	require.NoError(t, err)
+	assert.Empty(t, again.Imported)
+	require.Len(t, again.Skipped, 1)
+	assert.Equal(t, newID, again.Skipped[0].ExistingID)
+
+	// Jobs without a stored hash are hashed when their audio size matches
+	require.NoError(t, db.Model(job).Update("audio_hash", nil).Error)
+	own, err := svc.Import(ctx, bytes.NewReader(buf.Bytes()), int64(buf.Len()), helper.TestUser.ID)
+	require.NoError(t, err)
+	require.Len(t, own.Skipped, 1)
+	assert.Equal(t, job.ID, own.Skipped[0].ExistingID)
+	source, err := jobRepo.FindByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, source.AudioHash)
+}
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"scriberr/internal/api"
+	"scriberr/internal/llm"
 	"scriberr/internal/processing"
 	"scriberr/internal/queue"
 	"scriberr/internal/repository"
@@ -73,6 +74,7 @@ func (suite *CLIHandlerTestSuite) SetupSuite() {
 		profileRepo,
 		userRepo,
 		llmConfigRepo,
+		llm.NewRouter(llmConfigRepo),
 		summaryRepo,
 		chatRepo,
 		noteRepo,
@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"scriberr/internal/api"
+	"scriberr/internal/llm"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLLMRouterFallback(t *testing.T) {
+	helper := NewTestHelper(t, "test_llm_routing.db")
+	defer helper.Cleanup()
+
+	working := NewMockOpenAIServer()
+	defer working.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	ctx := context.Background()
+	repo := repository.NewLLMConfigRepository(helper.DB)
+	newConfig := func(name, url string, active bool) *models.LLMConfig {
+		cfg := &models.LLMConfig{Name: name, Provider: "openai", APIKey: stringPtr("key"), OpenAIBaseURL: stringPtr(url), IsActive: true}
+		require.NoError(t, repo.Create(ctx, cfg))
+		if !active {
+			cfg.IsActive = false
+			require.NoError(t, repo.Update(ctx, cfg))
+		}
+		return cfg
+	}
+	brokenCfg := newConfig("broken", broken.URL, true)
+	workingCfg := newConfig("working", working.URL, true)
+	disabledCfg := newConfig("disabled", working.URL, false)
+
+	require.NoError(t, repo.SaveRoute(ctx, &models.LLMRoute{
+		Feature: models.LLMFeatureChat,
+		Targets: []models.LLMRouteTarget{
+			{ConfigID: disabledCfg.ID, Model: "disabled-model"},
+			{ConfigID: brokenCfg.ID, Model: "primary-model"},
+			{ConfigID: workingCfg.ID},
+		},
+	}))
+	router := llm.NewRouter(repo)
+
+	// Inactive configs are skipped; the caller's model fills the gaps
+	route, err := router.Resolve(ctx, models.LLMFeatureChat, nil)
+	require.NoError(t, err)
+	require.Len(t, route.Targets, 2)
+	route = route.WithModel("session-model")
+	assert.Equal(t, "primary-model", route.Primary().Model)
+	assert.Equal(t, "session-model", route.Targets[1].Model)
+
+	resp, err := route.ChatCompletion(ctx, []llm.ChatMessage{{Role: "user", Content: "Hi"}}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "This is a test response from the mock OpenAI service.", resp.Choices[0].Message.Content)
+
+	contentChan, errorChan := route.ChatCompletionStream(ctx, []llm.ChatMessage{{Role: "user", Content: "Hi"}}, 0)
+	var streamed strings.Builder
+	for chunk := range contentChan {
+		streamed.WriteString(chunk)
+	}
+	assert.NoError(t, <-errorChan)
+	assert.Equal(t, "This is a test streaming response.", streamed.String())
+
+	// Chat titles follow the chat route unless routed themselves
+	titles, err := router.Resolve(ctx, models.LLMFeatureChatTitle, nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.LLMFeatureChatTitle, titles.Feature)
+	assert.Len(t, titles.Targets, 2)
+
+	// A user's route overrides the global one for them only
+	require.NoError(t, repo.SaveRoute(ctx, &models.LLMRoute{
+		UserID:  &helper.TestUser.ID,
+		Feature: models.LLMFeatureChat,
+		Targets: []models.LLMRouteTarget{{ConfigID: workingCfg.ID, Model: "user-model"}},
+	}))
+	route, err = router.Resolve(ctx, models.LLMFeatureChat, &helper.TestUser.ID)
+	require.NoError(t, err)
+	require.Len(t, route.Targets, 1)
+	assert.Equal(t, "user-model", route.Primary().Model)
+
+	// Unrouted features use the default config without a model
+	workingCfg.IsDefault = true
+	require.NoError(t, repo.Update(ctx, workingCfg))
+	route, err = router.Resolve(ctx, models.LLMFeatureSummarization, &helper.TestUser.ID)
+	require.NoError(t, err)
+	require.Len(t, route.Targets, 1)
+	assert.Equal(t, workingCfg.ID, route.Primary().Config.ID)
+	assert.Empty(t, route.Primary().Model)
+
+	_, err = route.ChatCompletion(ctx, []llm.ChatMessage{{Role: "user", Content: "Hi"}}, 0)
+	assert.Error(t, err, "a target without a model cannot be called")
+}
+
+func (suite *APIHandlerTestSuite) TestLLMRouteEndpoints() {
+	var config models.LLMConfig
+	require.NoError(suite.T(), suite.helper.DB.Where("is_active = ?", true).First(&config).Error)
+	route := api.LLMRouteRequest{Scope: "global", Targets: []models.LLMRouteTarget{{ConfigID: config.ID, Model: "gpt-4o-mini"}}}
+
+	// Global routes are for admins
+	resp := suite.makeAuthenticatedRequest("PUT", "/api/v1/llm/routes/summarization", route, true)
+	assert.Equal(suite.T(), http.StatusForbidden, resp.Code)
+	suite.helper.DB.Model(suite.helper.TestUser).Update("role", "admin")
+	token, err := suite.helper.AuthService.GenerateToken(suite.helper.TestUser)
+	require.NoError(suite.T(), err)
+	suite.helper.TestToken = token
+	resp = suite.makeAuthenticatedRequest("PUT", "/api/v1/llm/routes/summarization", route, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+
+	route.Scope = "user"
+	route.Targets[0].Model = "gpt-4o"
+	resp = suite.makeAuthenticatedRequest("PUT", "/api/v1/llm/routes/summarization", route, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+
+	resp = suite.makeAuthenticatedRequest("PUT", "/api/v1/llm/routes/dictation", route, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+	route.Targets[0].ConfigID = config.ID + 100
+	resp = suite.makeAuthenticatedRequest("PUT", "/api/v1/llm/routes/chat", route, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/llm/routes", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	var routes []api.LLMFeatureRoutes
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &routes))
+	require.Len(suite.T(), routes, len(models.LLMFeatures))
+	for _, r := range routes {
+		if r.Feature == models.LLMFeatureSummarization {
+			assert.Equal(suite.T(), "gpt-4o-mini", r.Global[0].Model)
+			assert.Equal(suite.T(), "gpt-4o", r.User[0].Model)
+		} else {
+			assert.Empty(suite.T(), r.Global, r.Feature)
+		}
+	}
+
+	resp = suite.makeAuthenticatedRequest("DELETE", "/api/v1/llm/routes/summarization", nil, true)
+	assert.Equal(suite.T(), http.StatusNoContent, resp.Code)
+	var remaining int64
+	suite.helper.DB.Model(&models.LLMRoute{}).Count(&remaining)
+	assert.Equal(suite.T(), int64(1), remaining)
+
+	// Several configs can be active at once
+	resp = suite.makeAuthenticatedRequest("POST", "/api/v1/llm/configs", api.LLMConfigRequest{Name: "Local", Provider: "ollama", BaseURL: stringPtr("http://localhost:11434"), IsActive: true}, true)
+	require.Equal(suite.T(), http.StatusCreated, resp.Code, resp.Body.String())
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/llm/configs", nil, true)
+	var configs []api.LLMConfigResponse
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &configs))
+	require.Len(suite.T(), configs, 2)
+	assert.True(suite.T(), configs[0].IsActive)
+	assert.True(suite.T(), configs[1].IsActive)
+}
+
+func (suite *APIHandlerTestSuite) TestGroqSmartAnalysisLeavesDefaultConfig() {
+	ctx := context.Background()
+	repo := repository.NewLLMConfigRepository(suite.helper.DB)
+	var defaultCfg models.LLMConfig
+	require.NoError(suite.T(), suite.helper.DB.Where("is_default = ?", true).First(&defaultCfg).Error)
+
+	require.NoError(suite.T(), llm.EnsureGroqSmartAnalysis(ctx, repo, "groq-key", "llama-3.3-70b-versatile"))
+	active, err := repo.GetActive(ctx)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), defaultCfg.ID, active.ID)
+
+	// The legacy endpoint saves the default config, never the Groq one
+	resp := suite.makeAuthenticatedRequest("POST", "/api/v1/llm/config", api.LLMConfigRequest{Provider: "ollama", BaseURL: stringPtr("http://localhost:11434"), IsActive: true}, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	var groq models.LLMConfig
+	require.NoError(suite.T(), suite.helper.DB.Where("provider = ?", "groq").First(&groq).Error)
+	assert.Equal(suite.T(), "groq-key", *groq.APIKey)
+	assert.False(suite.T(), groq.IsDefault)
+	var saved models.LLMConfig
+	require.NoError(suite.T(), suite.helper.DB.First(&saved, defaultCfg.ID).Error)
+	assert.Equal(suite.T(), "ollama", saved.Provider)
+
+	// Without a default config the Groq config still serves only its route
+	suite.helper.DB.Delete(&saved)
+	_, err = repo.GetActive(ctx)
+	assert.Error(suite.T(), err)
+}
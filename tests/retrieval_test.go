@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"scriberr/internal/llm"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/internal/retrieval"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// topicEmbeddingServer serves OpenAI-compatible embeddings with one dimension
+// per topic keyword, so similarity follows shared topics
+func topicEmbeddingServer(t *testing.T, calls *int32) *httptest.Server {
+	topics := []string{"budget", "hiring", "migration", "deadline"}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		atomic.AddInt32(calls, 1)
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "test-embed", req.Model)
+
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}
+		var resp struct {
+			Data []item `json:"data"`
+		}
+		// Return out of order to check the client sorts by index
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			vec := make([]float32, len(topics)+1)
+			vec[len(topics)] = 0.1
+			for j, topic := range topics {
+				vec[j] = float32(strings.Count(strings.ToLower(req.Input[i]), topic))
+			}
+			resp.Data = append(resp.Data, item{Index: i, Embedding: vec})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestRetrievalIndexAndSearch(t *testing.T) {
+	helper := NewTestHelper(t, "test_retrieval.db")
+	defer helper.Cleanup()
+
+	var calls int32
+	server := topicEmbeddingServer(t, &calls)
+	defer server.Close()
+
+	ctx := context.Background()
+	db := helper.DB
+	require.NoError(t, db.Create(&models.LLMConfig{
+		Provider:       "openai",
+		APIKey:         stringPtr("test-key"),
+		OpenAIBaseURL:  stringPtr(server.URL),
+		EmbeddingModel: stringPtr("test-embed"),
+		IsActive:       true,
+		IsDefault:      true,
+	}).Error)
+
+	long := strings.Repeat("filler ", 180)
+	transcript := `{"segments":[
+		{"start":0,"end":10,"text":"We reviewed the budget for next year. ` + long + `"},
+		{"start":10,"end":20,"text":"The budget is tight. ` + long + `"},
+		{"start":20,"end":30,"text":"Hiring plans for the team. ` + long + `"},
+		{"start":30,"end":40,"text":"The migration deadline is next Friday. ` + long + `"}]}`
+	job := &models.TranscriptionJob{ID: "retrieval-job", Status: models.StatusCompleted, AudioPath: "a.wav", Transcript: &transcript, UserID: &helper.TestUser.ID}
+	require.NoError(t, db.Create(job).Error)
+
+	jobRepo := repository.NewJobRepository(db)
+	chunkRepo := repository.NewTranscriptChunkRepository(db)
+	svc := retrieval.NewService(jobRepo, chunkRepo, llm.NewRouter(repository.NewLLMConfigRepository(db)))
+
+	hits, err := svc.Search(ctx, job, "When is the migration deadline?", 2)
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.Contains(t, hits[0].Chunk.Text, "migration deadline")
+	assert.Equal(t, 40.0, hits[0].Chunk.EndTime)
+	assert.Greater(t, hits[0].Score, hits[1].Score)
+
+	stored, err := chunkRepo.ListByJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, stored)
+	assert.Equal(t, "test-embed", stored[0].Model)
+	assert.Equal(t, 5, stored[0].Dimensions)
+
+	// The stored index is reused while the transcript is unchanged
+	before := atomic.LoadInt32(&calls)
+	_, err = svc.Search(ctx, job, "budget", 1)
+	require.NoError(t, err)
+	assert.Equal(t, before+1, atomic.LoadInt32(&calls), "only the query should be embedded")
+
+	// Edited transcripts are re-indexed
+	edited := `{"segments":[{"start":0,"end":5,"text":"Only hiring was discussed"}]}`
+	require.NoError(t, jobRepo.UpdateTranscript(ctx, job.ID, edited))
+	job.Transcript = &edited
+	hits, err = svc.Search(ctx, job, "hiring", 3)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "Only hiring was discussed", hits[0].Chunk.Text)
+
+	require.NoError(t, svc.Delete(ctx, job.ID))
+	stored, err = chunkRepo.ListByJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored)
+}
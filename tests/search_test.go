@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchTranscriptSegments(t *testing.T) {
+	helper := NewTestHelper(t, "test_search.db")
+	defer helper.Cleanup()
+
+	ctx := context.Background()
+	db := helper.DB
+	jobRepo := repository.NewJobRepository(db)
+
+	createJob := func(id string, userID uint, transcript string) *models.TranscriptionJob {
+		job := &models.TranscriptionJob{ID: id, Status: models.StatusCompleted, AudioPath: id + ".wav", Transcript: &transcript, UserID: &userID}
+		require.NoError(t, db.Create(job).Error)
+		return job
+	}
+
+	planning := createJob("search-planning", helper.TestUser.ID, `{"segments":[
+		{"start":0,"end":4.5,"text":"Welcome everyone to the planning call","speaker":"SPEAKER_00"},
+		{"start":4.5,"end":9,"text":"The migration deadline is next Friday","speaker":"SPEAKER_01"},
+		{"start":9,"end":12,"text":"We should migrate the billing service first","speaker":"SPEAKER_00"}]}`)
+	createJob("search-other-user", helper.TestUser.ID+1000, `{"segments":[{"start":0,"end":2,"text":"migration deadline for someone else"}]}`)
+	createJob("search-invalid", helper.TestUser.ID, `not json`)
+	require.NoError(t, db.Create(&models.SpeakerMapping{TranscriptionJobID: planning.ID, OriginalSpeaker: "SPEAKER_01", CustomName: "Alice"}).Error)
+
+	search := func(p repository.SegmentSearchParams) ([]repository.SegmentSearchHit, int64) {
+		p.UserID = helper.TestUser.ID
+		p.Limit = 10
+		hits, total, err := jobRepo.SearchSegments(ctx, p)
+		require.NoError(t, err)
+		return hits, total
+	}
+
+	// Phrase query, scoped to the user's jobs
+	hits, total := search(repository.SegmentSearchParams{Query: `"migration deadline"`})
+	require.Equal(t, int64(1), total)
+	assert.Equal(t, planning.ID, hits[0].JobID)
+	assert.Equal(t, 1, hits[0].SegmentIndex)
+	assert.Equal(t, 4.5, hits[0].Start)
+	assert.Equal(t, 9.0, hits[0].End)
+	require.NotNil(t, hits[0].SpeakerName)
+	assert.Equal(t, "Alice", *hits[0].SpeakerName)
+	assert.Contains(t, hits[0].Snippet, "The <mark>migration deadline</mark> is")
+
+	// Prefix query and speaker filter by mapped name or original label
+	_, total = search(repository.SegmentSearchParams{Query: "migr*"})
+	assert.Equal(t, int64(2), total)
+	_, total = search(repository.SegmentSearchParams{Query: "migr*", Speaker: "alice"})
+	assert.Equal(t, int64(1), total)
+	_, total = search(repository.SegmentSearchParams{Query: "migr*", Speaker: "SPEAKER_00"})
+	assert.Equal(t, int64(1), total)
+
+	// Status, date and tag filters
+	_, total = search(repository.SegmentSearchParams{Query: "migr*", Status: models.StatusFailed})
+	assert.Equal(t, int64(0), total)
+	future := time.Now().Add(time.Hour)
+	_, total = search(repository.SegmentSearchParams{Query: "migr*", From: &future})
+	assert.Equal(t, int64(0), total)
+	_, total = search(repository.SegmentSearchParams{Query: "migr*", Tag: "client"})
+	assert.Equal(t, int64(0), total)
+	require.NoError(t, jobRepo.UpdateTags(ctx, planning.ID, []string{"client", "q3"}))
+	_, total = search(repository.SegmentSearchParams{Query: "migr*", Tag: "client"})
+	assert.Equal(t, int64(2), total)
+
+	// Transcript edits re-index the job
+	require.NoError(t, jobRepo.UpdateTranscript(ctx, planning.ID, `{"segments":[{"start":0,"end":3,"text":"The launch was postponed"}]}`))
+	_, total = search(repository.SegmentSearchParams{Query: `"migration deadline"`})
+	assert.Equal(t, int64(0), total)
+	_, total = search(repository.SegmentSearchParams{Query: "postponed"})
+	assert.Equal(t, int64(1), total)
+
+	// Deleted jobs drop out of results
+	require.NoError(t, jobRepo.Delete(ctx, planning.ID))
+	_, total = search(repository.SegmentSearchParams{Query: "postponed"})
+	assert.Equal(t, int64(0), total)
+
+	// Malformed FTS expressions are reported as errors
+	_, _, err := jobRepo.SearchSegments(ctx, repository.SegmentSearchParams{UserID: helper.TestUser.ID, Query: `"unterminated`, Limit: 10})
+	assert.Error(t, err)
+}
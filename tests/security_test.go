@@ -16,6 +16,7 @@ import (
 	"scriberr/internal/auth"
 	"scriberr/internal/config"
 	"scriberr/internal/database"
+	"scriberr/internal/llm"
 	"scriberr/internal/processing"
 	"scriberr/internal/queue"
 	"scriberr/internal/repository"
@@ -100,6 +101,7 @@ func (suite *SecurityTestSuite) SetupSuite() {
 		profileRepo,
 		userRepo,
 		llmConfigRepo,
+		llm.NewRouter(llmConfigRepo),
 		summaryRepo,
 		chatRepo,
 		noteRepo,
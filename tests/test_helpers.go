@@ -93,11 +93,22 @@ func (h *TestHelper) ResetDB(t *testing.T) {
 	// List of models to clean
 	modelsToClean := []interface{}{
 		&models.Note{},
+		&models.ActionItem{},
+		&models.Chapter{},
+		&models.ConversationAnalytics{},
+		&models.TranscriptTranslation{},
+		&models.SmartAnalysis{},
+		&models.Review{},
 		&models.ChatSession{},
 		&models.TranscriptionJobExecution{}, // Assuming this exists based on MockJobRepository
 		&models.TranscriptionJob{},
 		&models.TranscriptionProfile{},
+		&models.Summary{},
 		&models.SummaryTemplate{},
+		&models.SummarySetting{},
+		&models.LLMRoute{},
+		&models.UsageEntry{},
+		&models.ModelPrice{},
 		&models.LLMConfig{},
 		&models.APIKey{},
 		&models.User{},
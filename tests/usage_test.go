@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"scriberr/internal/api"
+	"scriberr/internal/llm"
+	"scriberr/internal/models"
+	"scriberr/internal/repository"
+	"scriberr/internal/usage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageLedgerRecordsRoutedCalls(t *testing.T) {
+	helper := NewTestHelper(t, "test_usage_ledger.db")
+	defer helper.Cleanup()
+
+	// A provider that reports token usage
+	metered := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Metered."},"finish_reason":"stop"}],"usage":{"prompt_tokens":1000,"completion_tokens":500,"total_tokens":1500}}`))
+	}))
+	defer metered.Close()
+	// The mock reports none, so its usage is estimated
+	unmetered := NewMockOpenAIServer()
+	defer unmetered.Close()
+
+	ctx := context.Background()
+	configs := repository.NewLLMConfigRepository(helper.DB)
+	usageRepo := repository.NewUsageRepository(helper.DB)
+	meteredCfg := &models.LLMConfig{Name: "metered", Provider: "openai", APIKey: stringPtr("key"), OpenAIBaseURL: stringPtr(metered.URL), IsActive: true}
+	require.NoError(t, configs.Create(ctx, meteredCfg))
+	unmeteredCfg := &models.LLMConfig{Name: "unmetered", Provider: "openai", APIKey: stringPtr("key"), OpenAIBaseURL: stringPtr(unmetered.URL), IsActive: true}
+	require.NoError(t, configs.Create(ctx, unmeteredCfg))
+	require.NoError(t, configs.SaveRoute(ctx, &models.LLMRoute{Feature: models.LLMFeatureSummarization, Targets: []models.LLMRouteTarget{{ConfigID: meteredCfg.ID, Model: "gpt-4o"}}}))
+	require.NoError(t, configs.SaveRoute(ctx, &models.LLMRoute{Feature: models.LLMFeatureChat, Targets: []models.LLMRouteTarget{{ConfigID: unmeteredCfg.ID, Model: "small"}}}))
+
+	// A model price wins over the provider's default price
+	require.NoError(t, usageRepo.SavePrice(ctx, &models.ModelPrice{Provider: "openai", InputPerMillion: 1, OutputPerMillion: 1}))
+	require.NoError(t, usageRepo.SavePrice(ctx, &models.ModelPrice{Provider: "openai", Model: "gpt-4o", InputPerMillion: 2.5, OutputPerMillion: 10}))
+
+	router := llm.NewRouter(configs)
+	router.SetUsageRecorder(usage.NewLedger(usageRepo))
+
+	route, err := router.Resolve(ctx, models.LLMFeatureSummarization, &helper.TestUser.ID)
+	require.NoError(t, err)
+	reported := 0
+	callCtx := llm.WithUsageReporter(ctx, func(u llm.Usage) { reported += u.PromptTokens + u.CompletionTokens })
+	_, err = route.ForJob("job-1").ChatCompletion(callCtx, []llm.ChatMessage{{Role: "user", Content: "Summarize"}}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1500, reported)
+
+	route, err = router.Resolve(ctx, models.LLMFeatureChat, nil)
+	require.NoError(t, err)
+	contentChan, errorChan := route.ChatCompletionStream(ctx, []llm.ChatMessage{{Role: "user", Content: strings.Repeat("word ", 40)}}, 0)
+	for range contentChan {
+	}
+	require.NoError(t, <-errorChan)
+
+	entries, total, err := usageRepo.ListEntries(ctx, repository.UsageFilter{}, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), total)
+	byFeature := map[string]models.UsageEntry{}
+	for _, e := range entries {
+		byFeature[e.Feature] = e
+	}
+
+	summary := byFeature[models.LLMFeatureSummarization]
+	assert.Equal(t, helper.TestUser.ID, *summary.UserID)
+	assert.Equal(t, "job-1", *summary.JobID)
+	assert.Equal(t, "gpt-4o", summary.Model)
+	assert.Equal(t, 1000, summary.InputTokens)
+	assert.Equal(t, 500, summary.OutputTokens)
+	assert.False(t, summary.Estimated)
+	assert.InDelta(t, 0.0075, summary.Cost, 1e-9)
+
+	chat := byFeature[models.LLMFeatureChat]
+	assert.Nil(t, chat.UserID)
+	assert.True(t, chat.Estimated)
+	assert.Equal(t, 50, chat.InputTokens)
+	assert.Equal(t, 9, chat.OutputTokens)
+	assert.Equal(t, 1.0, chat.InputPrice, "the provider's default price applies")
+}
+
+func (suite *APIHandlerTestSuite) TestUsageReportEndpoints() {
+	ctx := context.Background()
+	repo := repository.NewUsageRepository(suite.helper.DB)
+	userID := suite.helper.TestUser.ID
+	jobID := "job-1"
+	entries := []models.UsageEntry{
+		{UserID: &userID, JobID: &jobID, Feature: models.LLMFeatureChat, Provider: "openai", Model: "gpt-4o", InputTokens: 100, OutputTokens: 10, Cost: 0.5, CreatedAt: time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)},
+		{UserID: &userID, Feature: models.LLMFeatureChat, Provider: "openai", Model: "gpt-4o", InputTokens: 200, OutputTokens: 20, Cost: 1, CreatedAt: time.Date(2026, 2, 3, 10, 0, 0, 0, time.UTC)},
+		{UserID: &userID, JobID: &jobID, Feature: models.UsageFeatureTranscription, Provider: "groq", Model: "whisper-large-v3", AudioSeconds: 120, Cost: 0.25, CreatedAt: time.Date(2026, 2, 4, 10, 0, 0, 0, time.UTC)},
+	}
+	for i := range entries {
+		require.NoError(suite.T(), repo.Record(ctx, &entries[i]))
+	}
+
+	// Usage is for admins
+	resp := suite.makeAuthenticatedRequest("GET", "/api/v1/admin/usage/report", nil, true)
+	assert.Equal(suite.T(), http.StatusForbidden, resp.Code)
+	suite.helper.DB.Model(suite.helper.TestUser).Update("role", "admin")
+	token, err := suite.helper.AuthService.GenerateToken(suite.helper.TestUser)
+	require.NoError(suite.T(), err)
+	suite.helper.TestToken = token
+
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/admin/usage/report?group_by=feature&period=month", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	var report api.UsageReportResponse
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &report))
+	require.Len(suite.T(), report.Rows, 3)
+	assert.Equal(suite.T(), models.LLMFeatureChat, report.Rows[0].Feature)
+	assert.Equal(suite.T(), "2026-01", report.Rows[0].Period)
+	assert.Equal(suite.T(), "2026-02", report.Rows[1].Period)
+	assert.Equal(suite.T(), int64(200), report.Rows[1].InputTokens)
+	assert.Equal(suite.T(), 120.0, report.Rows[2].AudioSeconds)
+	assert.Equal(suite.T(), int64(3), report.Total.Calls)
+	assert.InDelta(suite.T(), 1.75, report.Total.Cost, 1e-9)
+
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/admin/usage/report?group_by=user&from=2026-02-01&to=2026-02-03", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &report))
+	require.Len(suite.T(), report.Rows, 1)
+	assert.Equal(suite.T(), userID, *report.Rows[0].UserID)
+	assert.Equal(suite.T(), int64(1), report.Rows[0].Calls)
+
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/admin/usage/report?group_by=colour", nil, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.Code)
+
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/admin/usage/entries?job_id=job-1", nil, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	var listed struct {
+		Entries []models.UsageEntry `json:"entries"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &listed))
+	require.Len(suite.T(), listed.Entries, 2)
+	assert.Equal(suite.T(), models.UsageFeatureTranscription, listed.Entries[0].Feature)
+
+	// Saving a price twice replaces it
+	price := api.ModelPriceRequest{Provider: "groq", Model: "whisper-large-v3", AudioPerMinute: 0.111}
+	resp = suite.makeAuthenticatedRequest("PUT", "/api/v1/admin/usage/prices", price, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code, resp.Body.String())
+	price.AudioPerMinute = 0.05
+	resp = suite.makeAuthenticatedRequest("PUT", "/api/v1/admin/usage/prices", price, true)
+	require.Equal(suite.T(), http.StatusOK, resp.Code)
+	var saved models.ModelPrice
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &saved))
+
+	resp = suite.makeAuthenticatedRequest("GET", "/api/v1/admin/usage/prices", nil, true)
+	var prices []models.ModelPrice
+	require.NoError(suite.T(), json.Unmarshal(resp.Body.Bytes(), &prices))
+	require.Len(suite.T(), prices, 1)
+	assert.Equal(suite.T(), 0.05, prices[0].AudioPerMinute)
+
+	resp = suite.makeAuthenticatedRequest("DELETE", "/api/v1/admin/usage/prices/"+strconv.Itoa(int(saved.ID)), nil, true)
+	assert.Equal(suite.T(), http.StatusNoContent, resp.Code)
+}